@@ -0,0 +1,255 @@
+package book
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// AuditEntry records a change to a book.
+	// Entries are only added to the audit log, never changed.
+	AuditEntry struct {
+		ID     string
+		BookID string
+		Time   time.Time
+		// Actor is who made the change.
+		Actor string
+		// Action is one of the AuditActions.
+		Action  string
+		Changes []FieldChange
+	}
+	// Audit is who is making a change and how.
+	// Databases use it to add an entry to the audit log for each book they change, with the book from before the change read in the same transaction.
+	Audit struct {
+		Actor string
+		// Action is one of the AuditActions.
+		Action string
+	}
+	// FieldChange is the text of a field of a book before and after a change.
+	// Fields are named like the columns of the csv dump, and custom fields have a "custom:" prefix.
+	FieldChange struct {
+		Field  string
+		Before string
+		After  string
+	}
+)
+
+// Actions of audit entries.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRevert  = "revert"
+	ActionRestore = "restore"
+	ActionMerge   = "merge"
+	ActionImport  = "import"
+)
+
+// CustomFieldPrefix is the prefix of the names of custom fields in field changes.
+const CustomFieldPrefix = "custom:"
+
+// auditDateLayout is how dates are written in field changes.
+const auditDateLayout = HyphenatedYYYYMMDD
+
+// auditFields are the names of the fields that are compared, in order.
+// Ids are not compared, and images are left out because they are large.
+var auditFields = []string{
+	"title",
+	"author",
+	"contributors",
+	"description",
+	"subject",
+	"tags",
+	"series",
+	"volume",
+	"language",
+	"edition",
+	"format",
+	"reading-level",
+	"dewey-dec-class",
+	"pages",
+	"publisher",
+	"publish-date",
+	"added-date",
+	"ean-isbn13",
+	"upc-isbn10",
+}
+
+// NewAuditEntry creates an entry for the change from the book before to the book after it.
+// The id and time of the entry are set.
+func NewAuditEntry(actor, action string, before, after Book) AuditEntry {
+	bookID := after.ID
+	if len(bookID) == 0 {
+		bookID = before.ID
+	}
+	e := AuditEntry{
+		ID:      NewID(),
+		BookID:  bookID,
+		Time:    time.Now().UTC(),
+		Actor:   actor,
+		Action:  action,
+		Changes: Diff(before, after),
+	}
+	return e
+}
+
+// Entry creates the entry for the change from the book before to the book after it.
+func (a Audit) Entry(before, after Book) AuditEntry {
+	return NewAuditEntry(a.Actor, a.Action, before, after)
+}
+
+// Entries creates an entry for each audit of the change from the book before to the book after it.
+func Entries(audits []Audit, before, after Book) []AuditEntry {
+	entries := make([]AuditEntry, len(audits))
+	for i, a := range audits {
+		entries[i] = a.Entry(before, after)
+	}
+	return entries
+}
+
+// AppendAuditEntries adds the entries to the end of the log, skipping entries with ids that are already in it.
+func AppendAuditEntries(log []AuditEntry, entries ...AuditEntry) []AuditEntry {
+	ids := make(map[string]struct{}, len(log)+len(entries))
+	for _, e := range log {
+		ids[e.ID] = struct{}{}
+	}
+	for _, e := range entries {
+		if _, ok := ids[e.ID]; ok {
+			continue
+		}
+		ids[e.ID] = struct{}{}
+		log = append(log, e)
+	}
+	return log
+}
+
+// Diff compares the text of the fields of the books, returning the fields that are different.
+// Custom fields are compared after the other fields, sorted by name.
+func Diff(before, after Book) []FieldChange {
+	sb1, sb2 := before.StringBook(auditDateLayout), after.StringBook(auditDateLayout)
+	var changes []FieldChange
+	for _, name := range auditFields {
+		v1, v2 := *sb1.field(name), *sb2.field(name)
+		if v1 != v2 {
+			changes = append(changes, FieldChange{name, v1, v2})
+		}
+	}
+	for _, name := range CustomNames(nil, before.Custom, after.Custom) {
+		v1, v2 := before.Custom[name], after.Custom[name]
+		if v1 != v2 {
+			changes = append(changes, FieldChange{CustomFieldPrefix + name, v1, v2})
+		}
+	}
+	return changes
+}
+
+// Revert sets the fields of the book that the entry changed to what they were before the change.
+func (e AuditEntry) Revert(b Book) (*Book, error) {
+	sb := b.StringBook(auditDateLayout)
+	custom := make(map[string]string, len(sb.Custom))
+	for k, v := range sb.Custom {
+		custom[k] = v
+	}
+	sb.Custom = custom
+	for _, c := range e.Changes {
+		if name := strings.TrimPrefix(c.Field, CustomFieldPrefix); name != c.Field {
+			sb.Custom[name] = c.Before
+			continue
+		}
+		f := sb.field(c.Field)
+		if f == nil {
+			return nil, fmt.Errorf("unknown field: %q", c.Field)
+		}
+		*f = c.Before
+	}
+	b2, err := sb.Book(auditDateLayout)
+	if err != nil {
+		return nil, fmt.Errorf("reverting %v: %w", e.ID, err)
+	}
+	return b2, nil
+}
+
+// StringBook is the text of the fields of the book, which can be parsed again with the date layout.
+// Zero numbers and dates are empty.
+func (b Book) StringBook(dateLayout DateLayout) StringBook {
+	sb := StringBook{
+		ID:            b.ID,
+		Title:         b.Title,
+		Author:        b.Author,
+		Subject:       b.Subject,
+		Tags:          FormatTags(b.Tags),
+		Contributors:  FormatContributors(b.Contributors),
+		Series:        b.Series,
+		Language:      b.Language,
+		Edition:       b.Edition,
+		Format:        b.Format,
+		ReadingLevel:  b.ReadingLevel,
+		Custom:        b.Custom,
+		Description:   b.Description,
+		DeweyDecClass: b.DeweyDecClass,
+		Publisher:     b.Publisher,
+		EanIsbn13:     b.EanIsbn13,
+		UpcIsbn10:     b.UpcIsbn10,
+		ImageBase64:   b.ImageBase64,
+	}
+	if b.Volume != 0 {
+		sb.Volume = strconv.Itoa(b.Volume)
+	}
+	if b.Pages != 0 {
+		sb.Pages = strconv.Itoa(b.Pages)
+	}
+	if !b.PublishDate.IsZero() {
+		sb.PublishDate = b.PublishDate.Format(string(dateLayout))
+	}
+	if !b.AddedDate.IsZero() {
+		sb.AddedDate = b.AddedDate.Format(string(dateLayout))
+	}
+	return sb
+}
+
+// field is the text of the field with the name, or nil if the name is not one of the auditFields.
+func (sb *StringBook) field(name string) *string {
+	switch name {
+	case "title":
+		return &sb.Title
+	case "author":
+		return &sb.Author
+	case "contributors":
+		return &sb.Contributors
+	case "description":
+		return &sb.Description
+	case "subject":
+		return &sb.Subject
+	case "tags":
+		return &sb.Tags
+	case "series":
+		return &sb.Series
+	case "volume":
+		return &sb.Volume
+	case "language":
+		return &sb.Language
+	case "edition":
+		return &sb.Edition
+	case "format":
+		return &sb.Format
+	case "reading-level":
+		return &sb.ReadingLevel
+	case "dewey-dec-class":
+		return &sb.DeweyDecClass
+	case "pages":
+		return &sb.Pages
+	case "publisher":
+		return &sb.Publisher
+	case "publish-date":
+		return &sb.PublishDate
+	case "added-date":
+		return &sb.AddedDate
+	case "ean-isbn13":
+		return &sb.EanIsbn13
+	case "upc-isbn10":
+		return &sb.UpcIsbn10
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	before := Book{
+		Header:    Header{ID: "1", Title: "Old", Author: "a", Subject: "s"},
+		Tags:      []string{"t1"},
+		Volume:    2,
+		Format:    "print",
+		Custom:    map[string]string{"Box": "3", "Donor": "Ann"},
+		Pages:     10,
+		AddedDate: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	after := before
+	after.Title = "New"
+	after.Tags = []string{"t1", "t2"}
+	after.Volume = 0
+	after.Custom = map[string]string{"Box": "4", "Shelf": "Top"}
+	after.PublishDate = time.Date(2001, 12, 31, 0, 0, 0, 0, time.UTC)
+	after.ImageBase64 = "ignored"
+	want := []FieldChange{
+		{"title", "Old", "New"},
+		{"tags", "t1", "t1; t2"},
+		{"volume", "2", ""},
+		{"publish-date", "", "2001-12-31"},
+		{CustomFieldPrefix + "Box", "3", "4"},
+		{CustomFieldPrefix + "Donor", "Ann", ""},
+		{CustomFieldPrefix + "Shelf", "", "Top"},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+	if got := Diff(before, before); len(got) != 0 {
+		t.Errorf("wanted no changes comparing book to itself, got %q", got)
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	before := Book{Header: Header{ID: "7", Title: "a"}}
+	e := NewAuditEntry("Ann", ActionDelete, before, Book{})
+	switch {
+	case len(e.ID) == 0:
+		t.Errorf("wanted id")
+	case e.BookID != "7":
+		t.Errorf("wanted book id of deleted book, got %q", e.BookID)
+	case e.Time.IsZero():
+		t.Errorf("wanted time")
+	case e.Actor != "Ann", e.Action != ActionDelete:
+		t.Errorf("unwanted actor or action: %v", e)
+	case !reflect.DeepEqual([]FieldChange{{"title", "a", ""}}, e.Changes):
+		t.Errorf("unwanted changes: %q", e.Changes)
+	}
+}
+
+func TestAppendAuditEntries(t *testing.T) {
+	log := []AuditEntry{{ID: "1"}, {ID: "2"}}
+	got := AppendAuditEntries(log, AuditEntry{ID: "2", Actor: "dup"}, AuditEntry{ID: "3"}, AuditEntry{ID: "3", Actor: "dup"})
+	want := []AuditEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestAuditEntryRevert(t *testing.T) {
+	before := Book{
+		Header:      Header{ID: "1", Title: "Old", Author: "a", Subject: "s"},
+		Language:    "en",
+		Format:      "print",
+		Custom:      map[string]string{"Box": "3"},
+		Pages:       10,
+		PublishDate: time.Date(2001, 12, 31, 0, 0, 0, 0, time.UTC),
+		AddedDate:   time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC),
+		ImageBase64: "img",
+	}
+	after := before
+	after.Title = "New"
+	after.Pages = 11
+	after.PublishDate = time.Time{}
+	after.Custom = map[string]string{"Box": "4", "Shelf": "Top"}
+	e := AuditEntry{Changes: Diff(before, after)}
+	later := after
+	later.Author = "b" // changes after the entry are kept
+	want := before
+	want.Author = "b"
+	tests := []struct {
+		name   string
+		e      AuditEntry
+		b      Book
+		want   *Book
+		wantOk bool
+	}{
+		{"unknown field", AuditEntry{Changes: []FieldChange{{Field: "image"}}}, after, nil, false},
+		{"bad value", AuditEntry{Changes: []FieldChange{{Field: "pages", Before: "many"}}}, after, nil, false},
+		{"happy path", e, later, &want, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.e.Revert(test.b)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", test.want, got)
+			}
+		})
+	}
+	if len(later.Custom) != 2 {
+		t.Errorf("reverting changed custom values of the book")
+	}
+}
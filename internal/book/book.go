@@ -4,6 +4,7 @@ package book
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -22,6 +23,21 @@ type (
 	// Book contains common book fields
 	Book struct {
 		Header
+		// Tags are other subjects of the book.
+		Tags []string
+		// Contributors are the people who worked on the book, in addition to or including the author.
+		Contributors []Contributor
+		// Series is the name of the series the book is in, and Volume is its number in the series, or zero if it is not numbered.
+		Series string
+		Volume int
+		// Language is the language of the book, such as "en", and Edition is the edition, such as "2nd".
+		Language string
+		Edition  string
+		// Format is one of the Formats, and ReadingLevel is one of the ReadingLevels or empty.
+		Format       string
+		ReadingLevel string
+		// Custom are the values of the custom fields of the book, by field name.
+		Custom        map[string]string
 		Description   string
 		DeweyDecClass string
 		Pages         int
@@ -37,6 +53,15 @@ type (
 		Title         string
 		Author        string
 		Subject       string
+		Tags          string
+		Contributors  string
+		Series        string
+		Volume        string
+		Language      string
+		Edition       string
+		Format        string
+		ReadingLevel  string
+		Custom        map[string]string
 		Description   string
 		DeweyDecClass string
 		Pages         string
@@ -47,6 +72,11 @@ type (
 		UpcIsbn10     string
 		ImageBase64   string
 	}
+	// ShelvedBook is the header and shelf location of a book, which is enough to browse books in shelf order.
+	ShelvedBook struct {
+		Header
+		DeweyDecClass string
+	}
 	DateLayout string
 	Subject    struct {
 		Name  string
@@ -54,10 +84,16 @@ type (
 	}
 	Books    []Book
 	Subjects []Subject
-	// Filter is used to match books weth the exact subject (if set) or a whole word match to any of the header parts.
+	// Filter is used to match books weth the subject (if set) as their subject or one of their tags and the author (if set) as their author or one of their contributors, ignoring case and surrounding whitespace.
+	// The language, format, and reading level (if set) are matched the same way.
+	// The header part (if set) is matched to any part of the header, tags, or contributor names.
 	Filter struct {
-		Subject    string
-		HeaderPart string
+		Subject      string
+		Author       string
+		Language     string
+		Format       string
+		ReadingLevel string
+		HeaderPart   string
 	}
 )
 
@@ -66,6 +102,12 @@ const (
 	SlashMMDDYYYY      DateLayout = "01/02/2006"
 )
 
+// ErrIDExists is wrapped by errors from importing books that have ids of books that already exist.
+var ErrIDExists = errors.New("book id already exists")
+
+// ErrNotFound is wrapped by errors from looking up books that do not exist.
+var ErrNotFound = errors.New("book not found")
+
 // NewID creates a random, url-safe, base64 string.
 func NewID() string {
 	var src [24]byte
@@ -81,11 +123,54 @@ func (books Books) Sort() {
 	})
 }
 
+// CheckIDs ensures each book has an id that no other book has.
+// Books that are imported must keep their ids.
+func (books Books) CheckIDs() error {
+	ids := make(map[string]struct{}, len(books))
+	for i, b := range books {
+		if len(b.ID) == 0 {
+			return fmt.Errorf("book %v does not have an id", i)
+		}
+		if _, ok := ids[b.ID]; ok {
+			return fmt.Errorf("id of %q is used by more than one book", b.ID)
+		}
+		ids[b.ID] = struct{}{}
+	}
+	return nil
+}
+
+// ExistingIDsError reports the ids of imported books that collide with books that already exist.
+func ExistingIDsError(ids []string) error {
+	return fmt.Errorf("%w: %q", ErrIDExists, ids)
+}
+
+// IDNotFoundError reports that no book has the id.
+func IDNotFoundError(id string) error {
+	return fmt.Errorf("%w: no book with id of %q", ErrNotFound, id)
+}
+
+// ISBNNotFoundError reports that no book has the isbn.
+func ISBNNotFoundError(isbn string) error {
+	return fmt.Errorf("%w: no book with isbn of %q", ErrNotFound, isbn)
+}
+
+// FindISBN returns the first book with the isbn as its isbn-13 or isbn-10.
+func (books Books) FindISBN(isbn string) (*Book, error) {
+	if len(isbn) != 0 {
+		for _, b := range books {
+			if b.EanIsbn13 == isbn || b.UpcIsbn10 == isbn {
+				return &b, nil
+			}
+		}
+	}
+	return nil, ISBNNotFoundError(isbn)
+}
+
 func (h Header) less(other Header) bool {
 	if h.Subject != other.Subject {
 		return h.Subject < other.Subject
 	}
-	return h.Title != other.Title
+	return h.Title < other.Title
 }
 
 func (subjects Subjects) Sort() {
@@ -101,15 +186,107 @@ func (s Subject) less(other Subject) bool {
 	return s.Count > other.Count // max first
 }
 
+// Subjects counts the books for each subject or tag, returning the subjects on the page, sorted by name.
+// Subjects with the same key are grouped under the first of their names.
+// Books are counted once for each of their subjects.
+func (books Books) Subjects(limit, offset int) Subjects {
+	if limit < 0 {
+		return Subjects{}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	m := make(map[string]Subject)
+	for _, b := range books {
+		counted := make(map[string]struct{}, len(b.Tags)+1)
+		for _, subject := range b.Subjects() {
+			k := SubjectKey(subject)
+			if _, ok := counted[k]; ok {
+				continue
+			}
+			counted[k] = struct{}{}
+			s, ok := m[k]
+			if name := strings.TrimSpace(subject); !ok || name < s.Name {
+				s.Name = name
+			}
+			s.Count++
+			m[k] = s
+		}
+	}
+	if offset > len(m) {
+		return Subjects{}
+	}
+	subjects := make(Subjects, 0, len(m))
+	for _, s := range m {
+		subjects = append(subjects, s)
+	}
+	subjects.Sort()
+	subjects = subjects[offset:]
+	if len(subjects) > limit {
+		subjects = subjects[:limit]
+	}
+	return subjects
+}
+
+// Headers returns the headers of the books that match the filter on the page.
+// The books should be sorted.
+func (books Books) Headers(filter Filter, limit, offset int) []Header {
+	if limit < 0 || offset > len(books) {
+		return []Header{}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	headers := make([]Header, 0, limit+offset)
+	for _, b := range books {
+		if !filter.Matches(b) {
+			continue
+		}
+		headers = append(headers, b.Header)
+		if len(headers) == cap(headers) {
+			break
+		}
+	}
+	if offset > len(headers) { // fewer books match the filter than are on the pages before
+		return []Header{}
+	}
+	headers = headers[offset:]
+	if len(headers) > limit {
+		headers = headers[:limit]
+	}
+	return headers
+}
+
+// Shelved returns the headers and shelf locations of the books.
+func (books Books) Shelved() []ShelvedBook {
+	shelved := make([]ShelvedBook, len(books))
+	for i, b := range books {
+		shelved[i] = ShelvedBook{
+			Header:        b.Header,
+			DeweyDecClass: b.DeweyDecClass,
+		}
+	}
+	return shelved
+}
+
 func (f Filter) Matches(b Book) bool {
-	if len(f.Subject) != 0 && !strings.EqualFold(f.Subject, b.Subject) {
+	if len(f.Subject) != 0 && !b.HasSubject(f.Subject) {
+		return false
+	}
+	if len(f.Author) != 0 && !b.HasAuthor(f.Author) {
 		return false
 	}
+	for _, p := range [][2]string{{f.Language, b.Language}, {f.Format, b.Format}, {f.ReadingLevel, b.ReadingLevel}} {
+		if want, got := p[0], p[1]; len(want) != 0 && SubjectKey(want) != SubjectKey(got) {
+			return false
+		}
+	}
 	if len(f.HeaderPart) == 0 {
 		return true
 	}
 	headerPart := strings.ToLower(f.HeaderPart)
-	for _, part := range []string{b.Title, b.Author, b.Subject} {
+	parts := append([]string{b.Title, b.Subject}, b.Tags...)
+	for _, part := range append(parts, b.ContributorNames()...) {
 		part = strings.ToLower(part)
 		if strings.Contains(part, headerPart) {
 			return true
@@ -126,6 +303,12 @@ func (sb StringBook) Book(dateLayout DateLayout) (*Book, error) {
 			Author:  sb.Author,
 			Subject: sb.Subject,
 		},
+		Tags:          NormalizeTags(sb.Subject, ParseTags(sb.Tags)),
+		Contributors:  ParseContributors(sb.Contributors),
+		Series:        NormalizeSubject(sb.Series),
+		Language:      NormalizeLanguage(sb.Language),
+		Edition:       NormalizeSubject(sb.Edition),
+		Custom:        NormalizeCustom(sb.Custom),
 		Description:   sb.Description,
 		DeweyDecClass: sb.DeweyDecClass,
 		Publisher:     sb.Publisher,
@@ -134,6 +317,17 @@ func (sb StringBook) Book(dateLayout DateLayout) (*Book, error) {
 		ImageBase64:   sb.ImageBase64,
 	}
 	var err error
+	if b.Format, err = ParseFormat(sb.Format); err != nil {
+		return nil, err
+	}
+	if b.ReadingLevel, err = ParseReadingLevel(sb.ReadingLevel); err != nil {
+		return nil, err
+	}
+	if len(sb.Volume) != 0 {
+		if b.Volume, err = strconv.Atoi(sb.Volume); err != nil {
+			return nil, fmt.Errorf("volume: %w", err)
+		}
+	}
 	if len(sb.Pages) != 0 {
 		if b.Pages, err = strconv.Atoi(sb.Pages); err != nil {
 			return nil, fmt.Errorf("pages: %w", err)
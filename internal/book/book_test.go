@@ -1,6 +1,7 @@
 package book
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -40,6 +41,21 @@ func TestBooksSort(t *testing.T) {
 				{Header: Header{ID: "9", Title: "Secrets", Author: "Everyone", Subject: "Behind others"}, Pages: 5},
 			},
 		},
+		{
+			name: "same subject, by title",
+			s: Books{
+				{Header: Header{Title: "C", Subject: "s"}},
+				{Header: Header{Title: "A", Subject: "s"}},
+				{Header: Header{Title: "D", Subject: "s"}},
+				{Header: Header{Title: "B", Subject: "s"}},
+			},
+			want: Books{
+				{Header: Header{Title: "A", Subject: "s"}},
+				{Header: Header{Title: "B", Subject: "s"}},
+				{Header: Header{Title: "C", Subject: "s"}},
+				{Header: Header{Title: "D", Subject: "s"}},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -51,6 +67,122 @@ func TestBooksSort(t *testing.T) {
 	}
 }
 
+func TestBooksCheckIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		books  Books
+		wantOk bool
+	}{
+		{"no books", nil, true},
+		{"missing id", Books{{Header: Header{ID: "a"}}, {}}, false},
+		{"duplicate id", Books{{Header: Header{ID: "a"}}, {Header: Header{ID: "a"}}}, false},
+		{"happy path", Books{{Header: Header{ID: "a"}}, {Header: Header{ID: "b"}}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.books.CheckIDs()
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExistingIDsError(t *testing.T) {
+	err := ExistingIDsError([]string{"a", "b"})
+	if !errors.Is(err, ErrIDExists) {
+		t.Errorf("wanted error to wrap ErrIDExists: %v", err)
+	}
+	if want, got := `book id already exists: ["a" "b"]`, err.Error(); want != got {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
+
+func TestFindISBN(t *testing.T) {
+	books := Books{
+		{Header: Header{ID: "a"}},
+		{Header: Header{ID: "b"}, EanIsbn13: "9780306406157", UpcIsbn10: "0306406152"},
+		{Header: Header{ID: "c"}, EanIsbn13: "9780804429573"},
+	}
+	tests := []struct {
+		name   string
+		isbn   string
+		wantID string
+		wantOk bool
+	}{
+		{"empty", "", "", false},
+		{"missing", "9791234567896", "", false},
+		{"isbn-13", "9780306406157", "b", true},
+		{"isbn-10", "0306406152", "b", true},
+		{"only isbn-13", "9780804429573", "c", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := books.FindISBN(test.isbn)
+			switch {
+			case !test.wantOk:
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("wanted ErrNotFound, got %v", err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.wantID != got.ID:
+				t.Errorf("wanted book %q, got %q", test.wantID, got.ID)
+			}
+		})
+	}
+}
+
+func TestBooksHeaders(t *testing.T) {
+	books := Books{
+		{Header: Header{ID: "1", Title: "a", Subject: "s"}},
+		{Header: Header{ID: "2", Title: "b", Subject: "t"}},
+		{Header: Header{ID: "3", Title: "c", Subject: "t"}},
+		{Header: Header{ID: "4", Title: "d", Subject: "t"}},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		limit  int
+		offset int
+		want   []Header
+	}{
+		{"negative limit", Filter{}, -1, 0, []Header{}},
+		{"negative offset", Filter{}, 1, -2, []Header{books[0].Header}},
+		{"offset past books", Filter{}, 2, 5, []Header{}},
+		{"page", Filter{}, 2, 1, []Header{books[1].Header, books[2].Header}},
+		{"filtered page", Filter{Subject: "t"}, 2, 2, []Header{books[3].Header}},
+		{"offset past matches", Filter{Subject: "s"}, 2, 3, []Header{}},
+		{"offset at matches", Filter{Subject: "s"}, 2, 1, []Header{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := books.Headers(test.filter, test.limit, test.offset); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestBooksShelved(t *testing.T) {
+	books := Books{
+		{Header: Header{ID: "a", Title: "Lemurs"}, DeweyDecClass: "599.8", Description: "d", Pages: 9},
+		{Header: Header{ID: "b", Subject: "Fiction"}},
+	}
+	want := []ShelvedBook{
+		{Header: Header{ID: "a", Title: "Lemurs"}, DeweyDecClass: "599.8"},
+		{Header: Header{ID: "b", Subject: "Fiction"}},
+	}
+	if got := books.Shelved(); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
+
 func TestSubjectsSort(t *testing.T) {
 	tests := []struct {
 		name string
@@ -133,6 +265,60 @@ func TestFilterMatches(t *testing.T) {
 			filter: Filter{Subject: "fruits"},
 			want:   true,
 		},
+		{
+			name:   "subject surrounding whitespace",
+			book:   Book{Header: Header{Subject: "Fruits "}},
+			filter: Filter{Subject: " fruits"},
+			want:   true,
+		},
+		{
+			name:   "tag match",
+			book:   Book{Header: Header{Subject: "History"}, Tags: []string{"Biography"}},
+			filter: Filter{Subject: "biography "},
+			want:   true,
+		},
+		{
+			name:   "tag header part match",
+			book:   Book{Header: Header{Subject: "History"}, Tags: []string{"Biography"}},
+			filter: Filter{HeaderPart: "graph"},
+			want:   true,
+		},
+		{
+			name:   "author match",
+			book:   Book{Header: Header{Author: "Ann Lee"}},
+			filter: Filter{Author: " ann lee"},
+			want:   true,
+		},
+		{
+			name:   "contributor match",
+			book:   Book{Header: Header{Author: "Ann Lee"}, Contributors: []Contributor{{Name: "Bob Smith", Role: "editor"}}},
+			filter: Filter{Author: "Bob Smith"},
+			want:   true,
+		},
+		{
+			name:   "author no match",
+			book:   Book{Header: Header{Author: "Ann Lee"}},
+			filter: Filter{Author: "Ann"},
+			want:   false,
+		},
+		{
+			name:   "contributor header part match",
+			book:   Book{Header: Header{Author: "Ann Lee"}, Contributors: []Contributor{{Name: "Bob Smith", Role: "editor"}}},
+			filter: Filter{HeaderPart: "smith"},
+			want:   true,
+		},
+		{
+			name:   "language, format, and reading level match",
+			book:   Book{Language: "es", Format: "dvd", ReadingLevel: "children"},
+			filter: Filter{Language: "ES ", Format: "dvd", ReadingLevel: "Children"},
+			want:   true,
+		},
+		{
+			name:   "format no match",
+			book:   Book{Language: "es", Format: "dvd"},
+			filter: Filter{Language: "es", Format: "ebook"},
+			want:   false,
+		},
 		{
 			name:   "header match 2",
 			book:   Book{Header: Header{Title: "Fruit Trees", Subject: "Fruits"}},
@@ -163,8 +349,11 @@ func TestStringBookBook(t *testing.T) {
 		want       *Book
 		wantOk     bool
 	}{
-		{"empty, no layout", "", StringBook{}, &Book{}, true},
+		{"empty, no layout", "", StringBook{}, &Book{Language: "en", Format: "print"}, true},
 		{"bad pages", HyphenatedYYYYMMDD, StringBook{Pages: "a"}, nil, false},
+		{"bad volume", HyphenatedYYYYMMDD, StringBook{Volume: "II"}, nil, false},
+		{"bad format", HyphenatedYYYYMMDD, StringBook{Format: "scroll"}, nil, false},
+		{"bad reading level", HyphenatedYYYYMMDD, StringBook{ReadingLevel: "expert"}, nil, false},
 		{"bad publish date", HyphenatedYYYYMMDD, StringBook{PublishDate: "monday"}, nil, false},
 		{"bad added date", HyphenatedYYYYMMDD, StringBook{PublishDate: "2012-12-31", AddedDate: "12/31/2012"}, nil, false},
 		{"minimal", HyphenatedYYYYMMDD, StringBook{
@@ -179,6 +368,8 @@ func TestStringBookBook(t *testing.T) {
 				Author:  "b",
 				Subject: "c",
 			},
+			Language:  "en",
+			Format:    "print",
 			Pages:     1,
 			AddedDate: time.Date(2008, 7, 4, 0, 0, 0, 0, time.UTC),
 		}, true},
@@ -187,6 +378,15 @@ func TestStringBookBook(t *testing.T) {
 			Title:         "Readings",
 			Author:        "people",
 			Subject:       "stuff",
+			Tags:          "things; Stuff;  other  things ;",
+			Contributors:  "Ann Lee | Editor",
+			Series:        " The  Readers ",
+			Volume:        "3",
+			Language:      " ES ",
+			Edition:       " 2nd  revised ",
+			Format:        "Audiobook",
+			ReadingLevel:  "Young Adult",
+			Custom:        map[string]string{" Box  Number ": " 12 ", "Donor": " "},
 			DeweyDecClass: "¿unknown?",
 			Pages:         "42",
 			Publisher:     "Nobody",
@@ -202,6 +402,15 @@ func TestStringBookBook(t *testing.T) {
 				Author:  "people",
 				Subject: "stuff",
 			},
+			Tags:          []string{"other things", "things"},
+			Contributors:  []Contributor{{Name: "Ann Lee", Role: "editor", SortName: "Lee, Ann"}},
+			Series:        "The Readers",
+			Volume:        3,
+			Language:      "es",
+			Edition:       "2nd revised",
+			Format:        "audiobook",
+			ReadingLevel:  "young adult",
+			Custom:        map[string]string{"Box Number": "12"},
 			DeweyDecClass: "¿unknown?",
 			Pages:         42,
 			Publisher:     "Nobody",
@@ -222,7 +431,7 @@ func TestStringBookBook(t *testing.T) {
 				}
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
-			case *test.want != *got:
+			case !reflect.DeepEqual(test.want, got):
 				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", test.want, got)
 			}
 		})
@@ -0,0 +1,102 @@
+package citation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+var bibTeXEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// writeBibTeX writes a @book entry for each book.
+// Each entry has a key made from the author, year, and title, such as "boas1999zoology".
+func writeBibTeX(w io.Writer, books []book.Book) error {
+	bw := bufio.NewWriter(w)
+	usedKeys := make(map[string]struct{}, len(books))
+	suffixCounts := make(map[string]int, len(books))
+	for i, b := range books {
+		if i != 0 {
+			bw.WriteString("\n")
+		}
+		base := bibTeXKey(b)
+		key := base
+		for _, used := usedKeys[key]; used; _, used = usedKeys[key] {
+			suffixCounts[base]++
+			key = base + bibTeXKeySuffix(suffixCounts[base]) // boas1999zoology, boas1999zoologya, ..., boas1999zoologyz, boas1999zoologyaa, ...
+		}
+		usedKeys[key] = struct{}{}
+		fmt.Fprintf(bw, "@book{%s,\n", key)
+		fields := []struct {
+			name, value string
+		}{
+			{"title", b.Title},
+			{"author", b.Author},
+			{"publisher", b.Publisher},
+			{"year", publishYear(b)},
+			{"isbn", isbn(b)},
+			{"pagetotal", pages(b)},
+			{"keywords", b.Subject},
+			{"abstract", b.Description},
+		}
+		for _, f := range fields {
+			if len(f.value) != 0 {
+				fmt.Fprintf(bw, "  %s = {%s},\n", f.name, bibTeXEscaper.Replace(f.value))
+			}
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
+
+// bibTeXKeySuffix is the nth lowercase letter sequence: a, b, ..., z, aa, ab, ...
+func bibTeXKeySuffix(n int) string {
+	var suffix []byte
+	for ; n > 0; n = (n - 1) / 26 {
+		suffix = append([]byte{byte('a' + (n-1)%26)}, suffix...)
+	}
+	return string(suffix)
+}
+
+// bibTeXKey is the lowercase letters and digits of the author's family name, the publish year, and the first word of the title.
+func bibTeXKey(b book.Book) string {
+	family := b.Author
+	if f, _, ok := splitName(b.Author); ok {
+		family = f
+	} else if words := strings.Fields(b.Author); len(words) != 0 {
+		family = words[len(words)-1]
+	}
+	var titleWord string
+	if words := strings.Fields(b.Title); len(words) != 0 {
+		titleWord = words[0]
+	}
+	key := keyPart(family) + publishYear(b) + keyPart(titleWord)
+	if len(key) == 0 {
+		return "book"
+	}
+	return key
+}
+
+func keyPart(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
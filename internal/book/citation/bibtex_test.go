@@ -0,0 +1,117 @@
+package citation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestWriteBibTeX(t *testing.T) {
+	books := []book.Book{
+		testBook,
+		testBook,
+		{Header: book.Header{Title: "100% {Secrets}_"}},
+		{},
+	}
+	want := `@book{boas1999zoology,
+  title = {Zoology \& Botany},
+  author = {Boas, Franz},
+  publisher = {Lemur Press},
+  year = {1999},
+  isbn = {0306406152},
+  pagetotal = {100},
+  keywords = {Animals},
+  abstract = {About animals.},
+}
+
+@book{boas1999zoologya,
+  title = {Zoology \& Botany},
+  author = {Boas, Franz},
+  publisher = {Lemur Press},
+  year = {1999},
+  isbn = {0306406152},
+  pagetotal = {100},
+  keywords = {Animals},
+  abstract = {About animals.},
+}
+
+@book{100,
+  title = {100\% \{Secrets\}\_},
+}
+
+@book{book,
+}
+`
+	var sb strings.Builder
+	if err := BibTeX.Write(&sb, books...); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if got := sb.String(); want != got {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestBibTeXKey(t *testing.T) {
+	tests := []struct {
+		author string
+		title  string
+		want   string
+	}{
+		{"", "", "book"},
+		{"Franz Boas", "The Mind", "boasthe"},
+		{"Boas, Franz", "Mind", "boasmind"},
+		{"Émile Zola", "Germinal", "zolagerminal"},
+	}
+	for _, test := range tests {
+		b := book.Book{Header: book.Header{Author: test.author, Title: test.title}}
+		if got := bibTeXKey(b); test.want != got {
+			t.Errorf("bibTeXKey(%q, %q): wanted %q, got %q", test.author, test.title, test.want, got)
+		}
+	}
+}
+
+func TestBibTeXKeySuffix(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "a"},
+		{2, "b"},
+		{26, "z"},
+		{27, "aa"},
+		{28, "ab"},
+		{52, "az"},
+		{53, "ba"},
+		{702, "zz"},
+		{703, "aaa"},
+	}
+	for _, test := range tests {
+		if got := bibTeXKeySuffix(test.n); test.want != got {
+			t.Errorf("bibTeXKeySuffix(%v): wanted %q, got %q", test.n, test.want, got)
+		}
+	}
+}
+
+func TestWriteBibTeXUniqueKeys(t *testing.T) {
+	books := make([]book.Book, 30)
+	books[0].Title = "Booka" // same key as the second untitled book
+	var sb strings.Builder
+	if err := BibTeX.Write(&sb, books...); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	keys := make(map[string]struct{}, len(books))
+	for _, line := range strings.Split(sb.String(), "\n") {
+		key, ok := strings.CutPrefix(line, "@book{")
+		if !ok {
+			continue
+		}
+		if _, ok := keys[key]; ok {
+			t.Errorf("duplicate key: %q", key)
+		}
+		keys[key] = struct{}{}
+	}
+	if want, got := len(books), len(keys); want != got {
+		t.Errorf("wanted %v keys, got %v", want, got)
+	}
+}
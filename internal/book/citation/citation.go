@@ -0,0 +1,89 @@
+// Package citation writes bibliographic citations of books for reference managers.
+package citation
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// Format is a kind of citation file.
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	write       func(w io.Writer, books []book.Book) error
+}
+
+var (
+	BibTeX = Format{
+		Name:        "bibtex",
+		ContentType: "application/x-bibtex; charset=utf-8",
+		Extension:   ".bib",
+		write:       writeBibTeX,
+	}
+	RIS = Format{
+		Name:        "ris",
+		ContentType: "application/x-research-info-systems; charset=utf-8",
+		Extension:   ".ris",
+		write:       writeRIS,
+	}
+	CSLJSON = Format{
+		Name:        "csl-json",
+		ContentType: "application/vnd.citationstyles.csl+json; charset=utf-8",
+		Extension:   ".json",
+		write:       writeCSLJSON,
+	}
+	// Formats are the citation formats, by name.
+	Formats = map[string]Format{
+		BibTeX.Name:  BibTeX,
+		RIS.Name:     RIS,
+		CSLJSON.Name: CSLJSON,
+	}
+)
+
+// Write writes the citations of the books.
+func (f Format) Write(w io.Writer, books ...book.Book) error {
+	return f.write(w, books)
+}
+
+// publishYear is the year the book was published, or an empty string if it is not known.
+func publishYear(b book.Book) string {
+	if b.PublishDate.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(b.PublishDate.Year())
+}
+
+// isbn is the ISBN-13 of the book, or the ISBN-10 if the book does not have an ISBN-13.
+// UPC-A codes in the ISBN-10 field are not ISBNs, so they are not cited.
+func isbn(b book.Book) string {
+	switch {
+	case len(b.EanIsbn13) != 0:
+		return b.EanIsbn13
+	case len(b.UpcIsbn10) == 10:
+		return b.UpcIsbn10
+	}
+	return ""
+}
+
+// pages is the number of pages in the book, or an empty string if it is not known.
+func pages(b book.Book) string {
+	if b.Pages <= 0 {
+		return ""
+	}
+	return strconv.Itoa(b.Pages)
+}
+
+// splitName splits an author that is written as "Family, Given".
+// Authors that are not written that way are not split.
+func splitName(author string) (family, given string, ok bool) {
+	family, given, ok = strings.Cut(author, ",")
+	family, given = strings.TrimSpace(family), strings.TrimSpace(given)
+	if !ok || len(family) == 0 || len(given) == 0 || strings.Contains(given, ",") {
+		return "", "", false
+	}
+	return family, given, true
+}
@@ -0,0 +1,75 @@
+package citation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+var testBook = book.Book{
+	Header: book.Header{
+		ID:      "5",
+		Title:   "Zoology & Botany",
+		Author:  "Boas, Franz",
+		Subject: "Animals",
+	},
+	Description:   "About animals.",
+	DeweyDecClass: "590",
+	Pages:         100,
+	Publisher:     "Lemur Press",
+	PublishDate:   time.Date(1999, 5, 6, 0, 0, 0, 0, time.UTC),
+	UpcIsbn10:     "0306406152",
+}
+
+func TestFormats(t *testing.T) {
+	for name, f := range Formats {
+		if name != f.Name {
+			t.Errorf("format %q has name %q", name, f.Name)
+		}
+		if f.write == nil || len(f.ContentType) == 0 || len(f.Extension) == 0 {
+			t.Errorf("format %q is not complete", name)
+		}
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		author     string
+		wantFamily string
+		wantGiven  string
+		wantOk     bool
+	}{
+		{"", "", "", false},
+		{"Anonymous", "", "", false},
+		{"Franz Boas", "", "", false},
+		{"Boas, Franz", "Boas", "Franz", true},
+		{" Tolkien ,  J. R. R. ", "Tolkien", "J. R. R.", true},
+		{"Boas, Franz, Jr., Sr.", "", "", false},
+		{"Boas,", "", "", false},
+	}
+	for _, test := range tests {
+		family, given, ok := splitName(test.author)
+		if test.wantFamily != family || test.wantGiven != given || test.wantOk != ok {
+			t.Errorf("splitName(%q): wanted %q, %q, %v, got %q, %q, %v", test.author, test.wantFamily, test.wantGiven, test.wantOk, family, given, ok)
+		}
+	}
+}
+
+func TestISBN(t *testing.T) {
+	tests := []struct {
+		name string
+		b    book.Book
+		want string
+	}{
+		{"none", book.Book{}, ""},
+		{"isbn-13", book.Book{EanIsbn13: "9780306406157", UpcIsbn10: "0306406152"}, "9780306406157"},
+		{"isbn-10", book.Book{UpcIsbn10: "0306406152"}, "0306406152"},
+		{"upc-a", book.Book{UpcIsbn10: "036000291452"}, ""},
+	}
+	for _, test := range tests {
+		if got := isbn(test.b); test.want != got {
+			t.Errorf("%v: wanted %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package citation
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// cslItem is a Citation Style Language item.
+	cslItem struct {
+		ID            string    `json:"id"`
+		Type          string    `json:"type"`
+		Title         string    `json:"title,omitempty"`
+		Author        []cslName `json:"author,omitempty"`
+		Issued        *cslDate  `json:"issued,omitempty"`
+		Publisher     string    `json:"publisher,omitempty"`
+		ISBN          string    `json:"ISBN,omitempty"`
+		NumberOfPages string    `json:"number-of-pages,omitempty"`
+		CallNumber    string    `json:"call-number,omitempty"`
+		Keyword       string    `json:"keyword,omitempty"`
+		Abstract      string    `json:"abstract,omitempty"`
+	}
+	cslName struct {
+		Family  string `json:"family,omitempty"`
+		Given   string `json:"given,omitempty"`
+		Literal string `json:"literal,omitempty"`
+	}
+	cslDate struct {
+		DateParts [][]int `json:"date-parts"`
+	}
+)
+
+// writeCSLJSON writes an array with a CSL-JSON item for each book.
+func writeCSLJSON(w io.Writer, books []book.Book) error {
+	items := make([]cslItem, len(books))
+	for i, b := range books {
+		items[i] = newCSLItem(b)
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(items)
+}
+
+func newCSLItem(b book.Book) cslItem {
+	item := cslItem{
+		ID:            b.ID,
+		Type:          "book",
+		Title:         b.Title,
+		Publisher:     b.Publisher,
+		ISBN:          isbn(b),
+		NumberOfPages: pages(b),
+		CallNumber:    b.DeweyDecClass,
+		Keyword:       b.Subject,
+		Abstract:      b.Description,
+	}
+	if len(b.Author) != 0 {
+		name := cslName{
+			Literal: b.Author,
+		}
+		if family, given, ok := splitName(b.Author); ok {
+			name = cslName{
+				Family: family,
+				Given:  given,
+			}
+		}
+		item.Author = []cslName{name}
+	}
+	if !b.PublishDate.IsZero() {
+		item.Issued = &cslDate{
+			DateParts: [][]int{{b.PublishDate.Year()}},
+		}
+	}
+	return item
+}
@@ -0,0 +1,79 @@
+package citation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestWriteCSLJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		books []book.Book
+		want  string
+	}{
+		{
+			name: "empty",
+			want: "[]\n",
+		},
+		{
+			name:  "all fields",
+			books: []book.Book{testBook},
+			want: `[
+  {
+    "id": "5",
+    "type": "book",
+    "title": "Zoology & Botany",
+    "author": [
+      {
+        "family": "Boas",
+        "given": "Franz"
+      }
+    ],
+    "issued": {
+      "date-parts": [
+        [
+          1999
+        ]
+      ]
+    },
+    "publisher": "Lemur Press",
+    "ISBN": "0306406152",
+    "number-of-pages": "100",
+    "call-number": "590",
+    "keyword": "Animals",
+    "abstract": "About animals."
+  }
+]
+`,
+		},
+		{
+			name:  "literal author",
+			books: []book.Book{{Header: book.Header{ID: "7", Author: "Anonymous"}}},
+			want: `[
+  {
+    "id": "7",
+    "type": "book",
+    "author": [
+      {
+        "literal": "Anonymous"
+      }
+    ]
+  }
+]
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := CSLJSON.Write(&sb, test.books...); err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			if got := sb.String(); test.want != got {
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
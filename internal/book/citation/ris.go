@@ -0,0 +1,43 @@
+package citation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// writeRIS writes a BOOK reference for each book.
+// Lines end with carriage returns and line feeds, as the RIS specification requires.
+func writeRIS(w io.Writer, books []book.Book) error {
+	bw := bufio.NewWriter(w)
+	for _, b := range books {
+		tags := []struct {
+			tag, value string
+		}{
+			{"TY", "BOOK"},
+			{"TI", b.Title},
+			{"AU", b.Author},
+			{"PY", publishYear(b)},
+			{"PB", b.Publisher},
+			{"SN", isbn(b)},
+			{"CN", b.DeweyDecClass},
+			{"KW", b.Subject},
+			{"AB", b.Description},
+		}
+		for _, t := range tags {
+			if len(t.value) != 0 {
+				fmt.Fprintf(bw, "%s  - %s\r\n", t.tag, risValue(t.value))
+			}
+		}
+		bw.WriteString("ER  - \r\n")
+	}
+	return bw.Flush()
+}
+
+// risValue is the value on a single line.
+func risValue(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,35 @@
+package citation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestWriteRIS(t *testing.T) {
+	books := []book.Book{
+		testBook,
+		{Header: book.Header{Title: "Multi\nline"}},
+	}
+	want := "TY  - BOOK\r\n" +
+		"TI  - Zoology & Botany\r\n" +
+		"AU  - Boas, Franz\r\n" +
+		"PY  - 1999\r\n" +
+		"PB  - Lemur Press\r\n" +
+		"SN  - 0306406152\r\n" +
+		"CN  - 590\r\n" +
+		"KW  - Animals\r\n" +
+		"AB  - About animals.\r\n" +
+		"ER  - \r\n" +
+		"TY  - BOOK\r\n" +
+		"TI  - Multi line\r\n" +
+		"ER  - \r\n"
+	var sb strings.Builder
+	if err := RIS.Write(&sb, books...); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if got := sb.String(); want != got {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
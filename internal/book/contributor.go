@@ -0,0 +1,215 @@
+package book
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// Contributor is a person who worked on a book, such as a co-author, editor, translator, or illustrator.
+	Contributor struct {
+		Name string
+		// Role is what the contributor did for the book, such as "editor".
+		Role string
+		// SortName is the name the contributor is sorted by, such as "Tolkien, J. R. R.".
+		SortName string
+	}
+	// Author is a name that books are credited to, with the number of books.
+	Author struct {
+		Name     string
+		SortName string
+		Count    int
+	}
+	Authors []Author
+)
+
+// Common contributor roles.
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+)
+
+// ContributorSeparator separates contributors when they are written as text, such as in csv files and forms.
+// The name, role, and sort name of each contributor are separated by the ContributorFieldSeparator.
+const (
+	ContributorSeparator      = ";"
+	ContributorFieldSeparator = "|"
+)
+
+// AuthorKey is what authors are grouped by: the name without surrounding whitespace, in lowercase.
+func AuthorKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SortName is the name with the last word first, such as "Tolkien, J. R. R." for "J. R. R. Tolkien".
+// Names that already have commas and names that are one word are not changed.
+func SortName(name string) string {
+	name = NormalizeSubject(name)
+	i := strings.LastIndex(name, " ")
+	if i < 0 || strings.Contains(name, ",") {
+		return name
+	}
+	return name[i+1:] + ", " + name[:i]
+}
+
+// ParseContributors splits the text into contributors, like "Alan Lee | illustrator; Christopher Tolkien | editor | Tolkien, Christopher".
+// The contributors are normalized.
+func ParseContributors(text string) []Contributor {
+	var contributors []Contributor
+	for _, s := range strings.Split(text, ContributorSeparator) {
+		fields := strings.SplitN(s, ContributorFieldSeparator, 3)
+		var c Contributor
+		c.Name = fields[0]
+		if len(fields) > 1 {
+			c.Role = fields[1]
+		}
+		if len(fields) > 2 {
+			c.SortName = fields[2]
+		}
+		contributors = append(contributors, c)
+	}
+	return NormalizeContributors(contributors)
+}
+
+// FormatContributors joins the contributors so they can be parsed again.
+func FormatContributors(contributors []Contributor) string {
+	parts := make([]string, len(contributors))
+	for i, c := range contributors {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ContributorSeparator+" ")
+}
+
+func (c Contributor) String() string {
+	s := c.Name + " " + ContributorFieldSeparator + " " + c.Role
+	if len(c.SortName) != 0 {
+		s += " " + ContributorFieldSeparator + " " + c.SortName
+	}
+	return s
+}
+
+// NormalizeContributors normalizes the whitespace of the contributors, removing contributors without names and contributors with the same name and role as earlier ones.
+// Roles are lowercase, and contributors without roles are authors.
+// Contributors without sort names are given the sort names of their names.
+// The order of the contributors is kept.
+func NormalizeContributors(contributors []Contributor) []Contributor {
+	normalize := func(s string) string {
+		s = strings.ReplaceAll(s, ContributorSeparator, " ")
+		s = strings.ReplaceAll(s, ContributorFieldSeparator, " ")
+		return NormalizeSubject(s)
+	}
+	type nameRole struct {
+		key, role string
+	}
+	seen := make(map[nameRole]struct{}, len(contributors))
+	var normalized []Contributor
+	for _, c := range contributors {
+		c.Name = normalize(c.Name)
+		c.Role = strings.ToLower(normalize(c.Role))
+		c.SortName = normalize(c.SortName)
+		if len(c.Name) == 0 {
+			continue
+		}
+		if len(c.Role) == 0 {
+			c.Role = RoleAuthor
+		}
+		if len(c.SortName) == 0 {
+			c.SortName = SortName(c.Name)
+		}
+		k := nameRole{AuthorKey(c.Name), c.Role}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		normalized = append(normalized, c)
+	}
+	return normalized
+}
+
+// ContributorNames are the names of the author and the contributors of the book.
+func (b Book) ContributorNames() []string {
+	names := make([]string, 0, len(b.Contributors)+1)
+	names = append(names, b.Author)
+	for _, c := range b.Contributors {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// HasAuthor determines if the name is the author or the name of a contributor of the book, ignoring case and surrounding whitespace.
+func (b Book) HasAuthor(name string) bool {
+	k := AuthorKey(name)
+	for _, n := range b.ContributorNames() {
+		if AuthorKey(n) == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (authors Authors) Sort() {
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].less(authors[j])
+	})
+}
+
+func (a Author) less(other Author) bool {
+	if k1, k2 := strings.ToLower(a.SortName), strings.ToLower(other.SortName); k1 != k2 {
+		return k1 < k2
+	}
+	return a.Name < other.Name
+}
+
+// Authors counts the books for the author and each contributor, returning the authors on the page, sorted by sort name.
+// Authors with the same key are grouped under the first of their names and the last of their sort names.
+// Authors without sort names, such as authors of books without contributors, are given sort names from their names like contributors are.
+// Books are counted once for each of their authors, and books without authors are not counted.
+func (books Books) Authors(limit, offset int) Authors {
+	if limit < 0 {
+		return Authors{}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	m := make(map[string]Author)
+	for _, b := range books {
+		counted := make(map[string]struct{}, len(b.Contributors)+1)
+		contributors := append([]Contributor{{Name: b.Author}}, b.Contributors...)
+		for _, c := range contributors {
+			k := AuthorKey(c.Name)
+			if len(k) == 0 {
+				continue
+			}
+			a, ok := m[k]
+			if name := strings.TrimSpace(c.Name); !ok || name < a.Name {
+				a.Name = name
+			}
+			if c.SortName > a.SortName {
+				a.SortName = c.SortName
+			}
+			if _, ok := counted[k]; !ok {
+				counted[k] = struct{}{}
+				a.Count++
+			}
+			m[k] = a
+		}
+	}
+	if offset > len(m) {
+		return Authors{}
+	}
+	authors := make(Authors, 0, len(m))
+	for _, a := range m {
+		if len(a.SortName) == 0 {
+			a.SortName = SortName(a.Name)
+		}
+		authors = append(authors, a)
+	}
+	authors.Sort()
+	authors = authors[offset:]
+	if len(authors) > limit {
+		authors = authors[:limit]
+	}
+	return authors
+}
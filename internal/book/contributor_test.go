@@ -0,0 +1,124 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", ""},
+		{"Homer", "Homer"},
+		{" J. R. R.  Tolkien ", "Tolkien, J. R. R."},
+		{"Le Guin, Ursula K.", "Le Guin, Ursula K."},
+	}
+	for _, test := range tests {
+		if want, got := test.want, SortName(test.name); want != got {
+			t.Errorf("sort name of %q: wanted %q, got %q", test.name, want, got)
+		}
+	}
+}
+
+func TestParseContributors(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Contributor
+	}{
+		{"", nil},
+		{" ; | editor", nil},
+		{"Alan Lee", []Contributor{{Name: "Alan Lee", Role: "author", SortName: "Lee, Alan"}}},
+		{
+			" Alan  Lee | Illustrator;Christopher Tolkien|editor|Tolkien, C.;",
+			[]Contributor{
+				{Name: "Alan Lee", Role: "illustrator", SortName: "Lee, Alan"},
+				{Name: "Christopher Tolkien", Role: "editor", SortName: "Tolkien, C."},
+			},
+		},
+	}
+	for _, test := range tests {
+		if want, got := test.want, ParseContributors(test.text); !reflect.DeepEqual(want, got) {
+			t.Errorf("parsing %q: \n wanted: %q \n got:    %q", test.text, want, got)
+		}
+	}
+}
+
+func TestFormatContributors(t *testing.T) {
+	contributors := []Contributor{
+		{Name: "Alan Lee", Role: "illustrator"},
+		{Name: "Christopher Tolkien", Role: "editor", SortName: "Tolkien, Christopher"},
+	}
+	want := "Alan Lee | illustrator; Christopher Tolkien | editor | Tolkien, Christopher"
+	got := FormatContributors(contributors)
+	if want != got {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+	if want, got := NormalizeContributors(contributors), ParseContributors(got); !reflect.DeepEqual(want, got) {
+		t.Errorf("parsing formatted contributors: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestNormalizeContributors(t *testing.T) {
+	contributors := []Contributor{
+		{Name: "Zed | Alpha", Role: " ", SortName: "Alpha;Zed"},
+		{Name: "  "},
+		{Name: "Bea Jones", Role: "Editor"},
+		{Name: "zed alpha", Role: "author"},
+		{Name: "Zed Alpha", Role: "translator"},
+	}
+	want := []Contributor{
+		{Name: "Zed Alpha", Role: "author", SortName: "Alpha Zed"},
+		{Name: "Bea Jones", Role: "editor", SortName: "Jones, Bea"},
+		{Name: "Zed Alpha", Role: "translator", SortName: "Alpha, Zed"},
+	}
+	if got := NormalizeContributors(contributors); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestBookHasAuthor(t *testing.T) {
+	b := Book{
+		Header:       Header{Author: "Ann"},
+		Contributors: []Contributor{{Name: "Bob Smith", Role: "editor"}},
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"ann ", true},
+		{"BOB SMITH", true},
+		{"Bob", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if want, got := test.want, b.HasAuthor(test.name); want != got {
+			t.Errorf("wanted HasAuthor(%q) to be %v", test.name, want)
+		}
+	}
+}
+
+func TestBooksAuthors(t *testing.T) {
+	books := Books{
+		{Header: Header{Author: "Zed Alpha"}},
+		{Header: Header{Author: "zed alpha "}, Contributors: []Contributor{{Name: "Zed Alpha", Role: "illustrator", SortName: "Alpha, Zed"}}},
+		{Header: Header{Author: "Bea Jones"}, Contributors: []Contributor{{Name: "Carl", Role: "editor", SortName: "Carl"}}},
+		{Header: Header{Author: "Carl"}},
+		{Header: Header{Author: " "}},
+	}
+	want := Authors{
+		{Name: "Zed Alpha", SortName: "Alpha, Zed", Count: 2},
+		{Name: "Carl", SortName: "Carl", Count: 2},
+		{Name: "Bea Jones", SortName: "Jones, Bea", Count: 1},
+	}
+	if got := books.Authors(10, 0); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+	if got := books.Authors(1, 2); !reflect.DeepEqual(want[2:], got) {
+		t.Errorf("page not equal: \n wanted: %+v \n got:    %+v", want[2:], got)
+	}
+	if got := books.Authors(1, 4); len(got) != 0 {
+		t.Errorf("wanted no authors after the last page, got %+v", got)
+	}
+}
@@ -0,0 +1,182 @@
+package book
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CustomField is a field that admins add to books, such as "Donor Name" or "Box Number".
+// Books store the values of custom fields by name.
+type CustomField struct {
+	Name string
+	Type string
+	// Options are the values that enum fields can have, in the order they are shown.
+	Options []string
+}
+
+// Types of custom fields.
+const (
+	FieldText   = "text"
+	FieldNumber = "number"
+	FieldDate   = "date"
+	FieldEnum   = "enum"
+)
+
+// OptionSeparator separates the options of enum fields when they are written as text, such as in forms.
+const OptionSeparator = ";"
+
+// FieldTypes are the types that custom fields can have, in the order they are shown.
+var FieldTypes = []string{FieldText, FieldNumber, FieldDate, FieldEnum}
+
+// ParseCustomField normalizes the name and options of the field and checks its type.
+// Enum fields must have options, and other fields have none.
+func ParseCustomField(name, fieldType, options string) (*CustomField, error) {
+	f := CustomField{
+		Name: NormalizeSubject(name),
+		Type: strings.ToLower(strings.TrimSpace(fieldType)),
+	}
+	if len(f.Name) == 0 {
+		return nil, fmt.Errorf("custom field name required")
+	}
+	if !contains(FieldTypes, f.Type) {
+		return nil, fmt.Errorf("custom field %q: unknown type: %q", f.Name, f.Type)
+	}
+	if f.Type == FieldEnum {
+		f.Options = ParseOptions(options)
+		if len(f.Options) == 0 {
+			return nil, fmt.Errorf("custom field %q: options required", f.Name)
+		}
+	}
+	return &f, nil
+}
+
+// ParseOptions splits the text into normalized options, removing empty options and options that have the same key as earlier ones.
+// The order of the options is kept.
+func ParseOptions(text string) []string {
+	seen := make(map[string]struct{})
+	var options []string
+	for _, o := range strings.Split(text, OptionSeparator) {
+		o = NormalizeSubject(o)
+		k := SubjectKey(o)
+		if _, ok := seen[k]; ok || len(k) == 0 {
+			continue
+		}
+		seen[k] = struct{}{}
+		options = append(options, o)
+	}
+	return options
+}
+
+// FormatOptions joins the options so they can be parsed again.
+func FormatOptions(options []string) string {
+	return strings.Join(options, OptionSeparator+" ")
+}
+
+// CheckCustomFields ensures the fields have different names.
+func CheckCustomFields(fields []CustomField) error {
+	seen := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		k := SubjectKey(f.Name)
+		if _, ok := seen[k]; ok {
+			return fmt.Errorf("duplicate custom field: %q", f.Name)
+		}
+		seen[k] = struct{}{}
+	}
+	return nil
+}
+
+// ParseValue normalizes the value, which must be empty or valid for the type of the field.
+// Numbers are decimal, dates are hyphenated, and enum values are one of the options, ignoring case.
+func (f CustomField) ParseValue(value string) (string, error) {
+	value = NormalizeSubject(value)
+	if len(value) == 0 {
+		return "", nil
+	}
+	switch f.Type {
+	case FieldNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("%v: not a number: %q", f.Name, value)
+		}
+	case FieldDate:
+		if _, err := time.Parse(string(HyphenatedYYYYMMDD), value); err != nil {
+			return "", fmt.Errorf("%v: not a date: %q", f.Name, value)
+		}
+	case FieldEnum:
+		for _, o := range f.Options {
+			if SubjectKey(o) == SubjectKey(value) {
+				return o, nil
+			}
+		}
+		return "", fmt.Errorf("%v: unknown option: %q", f.Name, value)
+	}
+	return value, nil
+}
+
+// ParseCustomValues normalizes the values of the custom fields by the fields' names.
+// Values must be for one of the fields, and empty values are removed.
+func ParseCustomValues(fields []CustomField, values map[string]string) (map[string]string, error) {
+	m := make(map[string]CustomField, len(fields))
+	for _, f := range fields {
+		m[f.Name] = f
+	}
+	var custom map[string]string
+	for name, value := range values {
+		f, ok := m[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field: %q", name)
+		}
+		v, err := f.ParseValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if custom == nil {
+			custom = make(map[string]string, len(values))
+		}
+		custom[name] = v
+	}
+	return custom, nil
+}
+
+// NormalizeCustom normalizes the whitespace of the custom values, removing empty values.
+// Books without custom values have nil values.
+func NormalizeCustom(values map[string]string) map[string]string {
+	var custom map[string]string
+	for name, value := range values {
+		name, value = NormalizeSubject(name), NormalizeSubject(value)
+		if len(name) == 0 || len(value) == 0 {
+			continue
+		}
+		if custom == nil {
+			custom = make(map[string]string, len(values))
+		}
+		custom[name] = value
+	}
+	return custom
+}
+
+// CustomNames are the names of the fields followed by the other names of the values, sorted.
+func CustomNames(fields []CustomField, values ...map[string]string) []string {
+	seen := make(map[string]struct{}, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		seen[f.Name] = struct{}{}
+		names = append(names, f.Name)
+	}
+	var others []string
+	for _, m := range values {
+		for name := range m {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				others = append(others, name)
+			}
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
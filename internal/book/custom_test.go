@@ -0,0 +1,132 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCustomField(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldName string
+		fieldType string
+		options   string
+		want      *CustomField
+		wantOk    bool
+	}{
+		{"no name", " ", "text", "", nil, false},
+		{"bad type", "Box", "color", "", nil, false},
+		{"enum without options", "Shelf", "enum", " ; ", nil, false},
+		{"text", "  Donor  Name ", " Text ", "ignored", &CustomField{Name: "Donor Name", Type: "text"}, true},
+		{"number", "Box", "number", "", &CustomField{Name: "Box", Type: "number"}, true},
+		{"enum", "Shelf", "enum", "Top; bottom ;top;", &CustomField{Name: "Shelf", Type: "enum", Options: []string{"Top", "bottom"}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCustomField(test.fieldName, test.fieldType, test.options)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	options := []string{"Top", "Bottom shelf"}
+	want := "Top; Bottom shelf"
+	got := FormatOptions(options)
+	if want != got {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+	if got := ParseOptions(got); !reflect.DeepEqual(options, got) {
+		t.Errorf("parsing formatted options: \n wanted: %q \n got:    %q", options, got)
+	}
+}
+
+func TestCheckCustomFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []CustomField
+		wantOk bool
+	}{
+		{"none", nil, true},
+		{"different", []CustomField{{Name: "Box"}, {Name: "Donor"}}, true},
+		{"duplicate", []CustomField{{Name: "Box"}, {Name: "box"}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckCustomFields(test.fields)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseCustomValues(t *testing.T) {
+	fields := []CustomField{
+		{Name: "Donor", Type: FieldText},
+		{Name: "Box", Type: FieldNumber},
+		{Name: "Received", Type: FieldDate},
+		{Name: "Shelf", Type: FieldEnum, Options: []string{"Top", "Bottom"}},
+	}
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   map[string]string
+		wantOk bool
+	}{
+		{"none", nil, nil, true},
+		{"empty", map[string]string{"Donor": " ", "Box": ""}, nil, true},
+		{"unknown field", map[string]string{"Color": "red"}, nil, false},
+		{"bad number", map[string]string{"Box": "twelve"}, nil, false},
+		{"bad date", map[string]string{"Received": "12/31/2012"}, nil, false},
+		{"bad option", map[string]string{"Shelf": "middle"}, nil, false},
+		{
+			"all",
+			map[string]string{"Donor": " Ann  Lee ", "Box": "1.5", "Received": "2012-12-31", "Shelf": "top"},
+			map[string]string{"Donor": "Ann Lee", "Box": "1.5", "Received": "2012-12-31", "Shelf": "Top"},
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCustomValues(fields, test.values)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomNames(t *testing.T) {
+	fields := []CustomField{{Name: "Donor"}, {Name: "Box"}}
+	values := []map[string]string{
+		{"Donor": "Ann", "Old": "x"},
+		nil,
+		{"Box": "1", "Aisle": "2", "Old": "y"},
+	}
+	want := []string{"Donor", "Box", "Aisle", "Old"}
+	if got := CustomNames(fields, values...); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
@@ -0,0 +1,149 @@
+// Package dewey parses Dewey Decimal Classification call numbers into the hierarchy of classes, divisions, and sections, so books can be browsed and sorted in shelf order.
+package dewey
+
+import (
+	_ "embed"
+	"regexp"
+	"strings"
+)
+
+type (
+	// CallNumber is a shelf location with a Dewey Decimal number.
+	CallNumber struct {
+		// Prefix is text before the number, such as "REF" or "J" for reference or juvenile collections.
+		Prefix string
+		// Number is the classification number, such as "599.8".
+		Number string
+		// Cutter is text after the number, which is usually the start of the surname of the author.
+		Cutter string
+	}
+	// Node is a class, division, or section of the hierarchy, which is the first one, two, or three digits of numbers.
+	// The empty node is the root of the hierarchy.
+	Node string
+)
+
+var (
+	//go:embed names.txt
+	namesTxt string
+	// names are the names of nodes, which are the lines of the embedded names file.
+	// Each line has the digits of a node and its name, separated by a tab.
+	names = parseNames(namesTxt)
+	// numberRE matches the field of shelf locations with the number, which may be followed by the cutter without a space.
+	numberRE = regexp.MustCompile(`^(\d{3})(\.\d*)?([^\d.].*)?$`)
+)
+
+// Parse reads the call number in the shelf location.
+// The number is the first whitespace-separated field that starts with three digits; shelf locations without one, such as "F DOE" for fiction, are not call numbers.
+func Parse(shelfLocation string) (*CallNumber, bool) {
+	fields := strings.Fields(strings.ToUpper(shelfLocation))
+	for i, field := range fields {
+		m := numberRE.FindStringSubmatch(field)
+		if m == nil {
+			continue
+		}
+		number := m[1]
+		if len(m[2]) > 1 {
+			number += m[2]
+		}
+		cutter := append([]string{m[3]}, fields[i+1:]...)
+		c := CallNumber{
+			Prefix: strings.Join(fields[:i], " "),
+			Number: number,
+			Cutter: strings.TrimSpace(strings.Join(cutter, " ")),
+		}
+		return &c, true
+	}
+	return nil, false
+}
+
+// Less reports whether the book with shelf location a is shelved before the book with shelf location b.
+// Books are grouped by prefix, then ordered by number and cutter.  Shelf locations without call numbers are last, ordered by their text.
+func Less(a, b string) bool {
+	ca, okA := Parse(a)
+	cb, okB := Parse(b)
+	switch {
+	case okA != okB:
+		return okA
+	case !okA:
+		return strings.ToUpper(strings.TrimSpace(a)) < strings.ToUpper(strings.TrimSpace(b))
+	case ca.Prefix != cb.Prefix:
+		return ca.Prefix < cb.Prefix
+	case ca.Number != cb.Number:
+		return ca.Number < cb.Number // the whole number is always three digits, so decimals are compared digit by digit
+	}
+	return ca.Cutter < cb.Cutter
+}
+
+// Section is the node of the section of the call number.
+func (c CallNumber) Section() Node {
+	return Node(c.Number[:3])
+}
+
+// ParseNode reads the digits of the node, which must be at most three digits.
+func ParseNode(digits string) (Node, bool) {
+	if len(digits) > 3 {
+		return "", false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return Node(digits), true
+}
+
+// Contains reports whether the call number is in the node or one of its descendants.
+func (n Node) Contains(c CallNumber) bool {
+	return strings.HasPrefix(c.Number, string(n))
+}
+
+// IsSection reports whether the node is a section, which has no children.
+func (n Node) IsSection() bool {
+	return len(n) == 3
+}
+
+// Children are the ten nodes in the node, or none if it is a section.
+func (n Node) Children() []Node {
+	if n.IsSection() {
+		return nil
+	}
+	children := make([]Node, 10)
+	for i := range children {
+		children[i] = n + Node('0'+rune(i))
+	}
+	return children
+}
+
+// Path is the ancestors of the node, from the class to the node itself, without the root.
+func (n Node) Path() []Node {
+	path := make([]Node, len(n))
+	for i := range path {
+		path[i] = n[:i+1]
+	}
+	return path
+}
+
+// Number is the node as it is usually written, with zeros to make it three digits, such as "590" for the animals division.
+func (n Node) Number() string {
+	if len(n) == 0 {
+		return ""
+	}
+	return string(n) + strings.Repeat("0", 3-len(n))
+}
+
+// Name is the name of the node from the embedded table, or empty if the table does not have it.
+func (n Node) Name() string {
+	return names[n]
+}
+
+func parseNames(text string) map[Node]string {
+	m := make(map[Node]string)
+	for _, line := range strings.Split(text, "\n") {
+		digits, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		m[Node(digits)] = strings.TrimSpace(name)
+	}
+	return m
+}
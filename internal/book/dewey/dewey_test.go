@@ -0,0 +1,150 @@
+package dewey
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		shelfLocation string
+		wantOk        bool
+		want          CallNumber
+	}{
+		{"", false, CallNumber{}},
+		{"F DOE", false, CallNumber{}},
+		{"1999", false, CallNumber{}},
+		{"59", false, CallNumber{}},
+		{"599.8.2", false, CallNumber{}},
+		{"599", true, CallNumber{Number: "599"}},
+		{"599.", true, CallNumber{Number: "599"}},
+		{"599.8 doe", true, CallNumber{Number: "599.8", Cutter: "DOE"}},
+		{" 599.8DOE ", true, CallNumber{Number: "599.8", Cutter: "DOE"}},
+		{"REF 030 WOR 2001", true, CallNumber{Prefix: "REF", Number: "030", Cutter: "WOR 2001"}},
+		{"j  599.12 D64", true, CallNumber{Prefix: "J", Number: "599.12", Cutter: "D64"}},
+	}
+	for _, test := range tests {
+		got, ok := Parse(test.shelfLocation)
+		switch {
+		case !test.wantOk:
+			if ok {
+				t.Errorf("Parse(%q): wanted no call number, got %+v", test.shelfLocation, got)
+			}
+		case !ok:
+			t.Errorf("Parse(%q): wanted call number", test.shelfLocation)
+		case test.want != *got:
+			t.Errorf("Parse(%q): not equal: \n wanted: %+v \n got:    %+v", test.shelfLocation, test.want, *got)
+		}
+	}
+}
+
+func TestLess(t *testing.T) {
+	want := []string{
+		"005.133 GO",
+		"500",
+		"599 ZOO",
+		"599.12 D64",
+		"599.8 BAR",
+		"599.8 DOE",
+		"599.82",
+		"600",
+		"J 599.8 ABE",
+		"REF 030 WOR",
+		"",
+		"B LINCOLN",
+		"f doe",
+	}
+	got := make([]string, len(want))
+	for i, j := range []int{12, 3, 10, 7, 0, 5, 11, 9, 1, 4, 8, 2, 6} {
+		got[i] = want[j]
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return Less(got[i], got[j])
+	})
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not in shelf order: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		digits string
+		wantOk bool
+	}{
+		{"", true},
+		{"5", true},
+		{"59", true},
+		{"599", true},
+		{"5991", false},
+		{"5a", false},
+		{"-1", false},
+	}
+	for _, test := range tests {
+		got, ok := ParseNode(test.digits)
+		switch {
+		case test.wantOk != ok:
+			t.Errorf("ParseNode(%q): wanted ok of %v", test.digits, test.wantOk)
+		case ok && string(got) != test.digits:
+			t.Errorf("ParseNode(%q): got %q", test.digits, got)
+		}
+	}
+}
+
+func TestNode(t *testing.T) {
+	c := CallNumber{Number: "599.8"}
+	tests := []struct {
+		node         Node
+		wantNumber   string
+		wantName     string
+		wantPath     []Node
+		wantChildren int
+		wantContains bool
+	}{
+		{"", "", "", []Node{}, 10, true},
+		{"5", "500", "Science", []Node{"5"}, 10, true},
+		{"59", "590", "Animals (Zoology)", []Node{"5", "59"}, 10, true},
+		{"599", "599", "", []Node{"5", "59", "599"}, 0, true},
+		{"58", "580", "Plants (Botany)", []Node{"5", "58"}, 10, false},
+		{"0", "000", "Computer science, information & general works", []Node{"0"}, 10, false},
+	}
+	for _, test := range tests {
+		n := test.node
+		switch {
+		case test.wantNumber != n.Number():
+			t.Errorf("%q: wanted number %q, got %q", n, test.wantNumber, n.Number())
+		case test.wantName != n.Name():
+			t.Errorf("%q: wanted name %q, got %q", n, test.wantName, n.Name())
+		case !reflect.DeepEqual(test.wantPath, n.Path()):
+			t.Errorf("%q: wanted path %q, got %q", n, test.wantPath, n.Path())
+		case test.wantChildren != len(n.Children()):
+			t.Errorf("%q: wanted %v children, got %q", n, test.wantChildren, n.Children())
+		case test.wantChildren != 0 && n.Children()[9] != n+"9":
+			t.Errorf("%q: unwanted last child: %q", n, n.Children()[9])
+		case test.wantContains != n.Contains(c):
+			t.Errorf("%q: wanted contains %v for %v", n, test.wantContains, c.Number)
+		case n.IsSection() != (len(n) == 3):
+			t.Errorf("%q: wrong is section", n)
+		}
+	}
+	if want, got := Node("599"), c.Section(); want != got {
+		t.Errorf("wanted section %q, got %q", want, got)
+	}
+}
+
+func TestNames(t *testing.T) {
+	var root Node
+	for _, class := range root.Children() {
+		if len(class.Name()) == 0 {
+			t.Errorf("missing name of class %v", class.Number())
+		}
+		for _, division := range class.Children() {
+			if len(division.Name()) == 0 {
+				t.Errorf("missing name of division %v", division.Number())
+			}
+		}
+	}
+	if want, got := 110, len(names); want != got {
+		t.Errorf("wanted %v names, got %v", want, got)
+	}
+}
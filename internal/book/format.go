@@ -0,0 +1,71 @@
+package book
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formats of books.
+const (
+	// FormatPrint is the format of printed books whose bindings are not known.
+	FormatPrint     = "print"
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatAudiobook = "audiobook"
+	FormatEbook     = "ebook"
+	FormatDVD       = "dvd"
+)
+
+// DefaultLanguage is the language of books without languages, as an ISO 639-1 code.
+// DefaultFormat is the format of books without formats.
+const (
+	DefaultLanguage = "en"
+	DefaultFormat   = FormatPrint
+)
+
+// Formats are the formats that books can have, in the order they are shown.
+var Formats = []string{FormatPrint, FormatHardcover, FormatPaperback, FormatAudiobook, FormatEbook, FormatDVD}
+
+// ReadingLevels are the reading levels that books can have, from youngest to oldest readers.
+var ReadingLevels = []string{"early reader", "children", "middle grade", "young adult", "adult"}
+
+// NormalizeLanguage lowercases the language and normalizes its whitespace.
+// Books without languages have the default language.
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(NormalizeSubject(language))
+	if len(language) == 0 {
+		return DefaultLanguage
+	}
+	return language
+}
+
+// ParseFormat normalizes the format, which must be one of the Formats.
+// Books without formats have the default format.
+func ParseFormat(format string) (string, error) {
+	format = strings.ToLower(NormalizeSubject(format))
+	if len(format) == 0 {
+		return DefaultFormat, nil
+	}
+	if !contains(Formats, format) {
+		return "", fmt.Errorf("unknown format: %q", format)
+	}
+	return format, nil
+}
+
+// ParseReadingLevel normalizes the reading level, which must be empty or one of the ReadingLevels.
+func ParseReadingLevel(level string) (string, error) {
+	level = strings.ToLower(NormalizeSubject(level))
+	if len(level) != 0 && !contains(ReadingLevels, level) {
+		return "", fmt.Errorf("unknown reading level: %q", level)
+	}
+	return level, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
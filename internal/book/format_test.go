@@ -0,0 +1,72 @@
+package book
+
+import "testing"
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"", "en"},
+		{"  ", "en"},
+		{" FR ", "fr"},
+		{"pt  BR", "pt br"},
+	}
+	for _, test := range tests {
+		if want, got := test.want, NormalizeLanguage(test.language); want != got {
+			t.Errorf("language %q: wanted %q, got %q", test.language, want, got)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		wantOk bool
+	}{
+		{"", "print", true},
+		{" Hardcover ", "hardcover", true},
+		{"DVD", "dvd", true},
+		{"scroll", "", false},
+	}
+	for _, test := range tests {
+		got, err := ParseFormat(test.format)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("format %q: wanted error", test.format)
+			}
+		case err != nil:
+			t.Errorf("format %q: unwanted error: %v", test.format, err)
+		case test.want != got:
+			t.Errorf("format %q: wanted %q, got %q", test.format, test.want, got)
+		}
+	}
+}
+
+func TestParseReadingLevel(t *testing.T) {
+	tests := []struct {
+		level  string
+		want   string
+		wantOk bool
+	}{
+		{"", "", true},
+		{" Middle  Grade", "middle grade", true},
+		{"adult", "adult", true},
+		{"expert", "", false},
+	}
+	for _, test := range tests {
+		got, err := ParseReadingLevel(test.level)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("reading level %q: wanted error", test.level)
+			}
+		case err != nil:
+			t.Errorf("reading level %q: unwanted error: %v", test.level, err)
+		case test.want != got:
+			t.Errorf("reading level %q: wanted %q, got %q", test.level, test.want, got)
+		}
+	}
+}
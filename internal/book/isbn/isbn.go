@@ -0,0 +1,167 @@
+// Package isbn validates, normalizes, and converts International Standard Book Numbers.
+package isbn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalid is wrapped by errors for isbns that are malformed or have incorrect check digits.
+var ErrInvalid = errors.New("invalid isbn")
+
+// bookland is the prefix of isbn-13s that have isbn-10 equivalents.
+const bookland = "978"
+
+// Normalize removes hyphens and spaces from the isbn and makes a trailing "x" check digit uppercase.
+// It does not validate the isbn.
+func Normalize(isbn string) string {
+	isbn = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ', '\t':
+			return -1
+		}
+		return r
+	}, isbn)
+	return strings.ToUpper(isbn)
+}
+
+// Validate10 checks the digits and check digit of the normalized isbn-10.
+func Validate10(isbn string) error {
+	if len(isbn) != 10 {
+		return fmt.Errorf("%w: %q: isbn-10 must have 10 characters", ErrInvalid, isbn)
+	}
+	if !isDigits(isbn[:9]) {
+		return fmt.Errorf("%w: %q: isbn-10 must start with 9 digits", ErrInvalid, isbn)
+	}
+	if want, got := checkDigit10(isbn[:9]), isbn[9]; want != got {
+		return fmt.Errorf("%w: %q: wanted check digit %c", ErrInvalid, isbn, want)
+	}
+	return nil
+}
+
+// Validate13 checks the digits and check digit of the normalized isbn-13.
+func Validate13(isbn string) error {
+	if len(isbn) != 13 || !isDigits(isbn) {
+		return fmt.Errorf("%w: %q: isbn-13 must have 13 digits", ErrInvalid, isbn)
+	}
+	if want, got := checkDigit13(isbn[:12]), isbn[12]; want != got {
+		return fmt.Errorf("%w: %q: wanted check digit %c", ErrInvalid, isbn, want)
+	}
+	return nil
+}
+
+// ValidateUPC checks the digits and check digit of the normalized 12 digit UPC-A code.
+// Some books have UPC-A codes instead of isbn-10s.
+func ValidateUPC(upc string) error {
+	if len(upc) != 12 || !isDigits(upc) {
+		return fmt.Errorf("%w: %q: upc-a must have 12 digits", ErrInvalid, upc)
+	}
+	if want, got := checkDigit13("0"+upc[:11]), upc[11]; want != got { // a upc-a is an ean-13 without the leading zero
+		return fmt.Errorf("%w: %q: wanted check digit %c", ErrInvalid, upc, want)
+	}
+	return nil
+}
+
+// To13 converts the valid, normalized isbn-10 to an isbn-13.
+func To13(isbn10 string) (string, error) {
+	if err := Validate10(isbn10); err != nil {
+		return "", err
+	}
+	isbn := bookland + isbn10[:9]
+	return isbn + string(checkDigit13(isbn)), nil
+}
+
+// To10 converts the valid, normalized isbn-13 to an isbn-10.
+// Only isbn-13s that start with 978 can be converted.
+func To10(isbn13 string) (string, error) {
+	if err := Validate13(isbn13); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(isbn13, bookland) {
+		return "", fmt.Errorf("%w: %q: only isbn-13s that start with %v have isbn-10s", ErrInvalid, isbn13, bookland)
+	}
+	isbn := isbn13[3:12]
+	return isbn + string(checkDigit10(isbn)), nil
+}
+
+// Pair normalizes and validates the isbn-13 and isbn-10 of a book.
+// The isbn-10 field may also hold a 12 digit UPC-A code, which is not converted or compared to the isbn-13.
+// Values that are in the wrong field because of their length are swapped.
+// A missing isbn is filled in from the other one if it can be converted.
+// It is an error for both isbns to be set to different books.
+func Pair(isbn13, isbn10 string) (string, string, error) {
+	isbn13, isbn10 = Normalize(isbn13), Normalize(isbn10)
+	if is10Field(isbn13) && !is10Field(isbn10) || len(isbn10) == 13 && len(isbn13) != 13 {
+		isbn13, isbn10 = isbn10, isbn13
+	}
+	if len(isbn13) != 0 {
+		if err := Validate13(isbn13); err != nil {
+			return "", "", err
+		}
+	}
+	isUPC := len(isbn10) == 12
+	switch {
+	case isUPC:
+		if err := ValidateUPC(isbn10); err != nil {
+			return "", "", err
+		}
+	case len(isbn10) != 0:
+		if err := Validate10(isbn10); err != nil {
+			return "", "", err
+		}
+	}
+	switch {
+	case isUPC:
+	case len(isbn13) == 0 && len(isbn10) != 0:
+		isbn13, _ = To13(isbn10) // already validated
+	case len(isbn10) == 0 && strings.HasPrefix(isbn13, bookland):
+		isbn10, _ = To10(isbn13) // already validated
+	case len(isbn13) != 0 && len(isbn10) != 0:
+		if want, _ := To13(isbn10); want != isbn13 {
+			return "", "", fmt.Errorf("%w: isbn-13 %q and isbn-10 %q are for different books", ErrInvalid, isbn13, isbn10)
+		}
+	}
+	return isbn13, isbn10, nil
+}
+
+// is10Field reports whether the normalized value has the length of an isbn-10 or a UPC-A code.
+func is10Field(value string) bool {
+	return len(value) == 10 || len(value) == 12
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDigit10 is the check digit for the first 9 digits of an isbn-10, which might be X for 10.
+func checkDigit10(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(digits[i]-'0')
+	}
+	c := (11 - sum%11) % 11
+	if c == 10 {
+		return 'X'
+	}
+	return byte('0' + c)
+}
+
+// checkDigit13 is the check digit for the first 12 digits of an isbn-13.
+func checkDigit13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(digits[i]-'0')
+	}
+	c := (10 - sum%10) % 10
+	return byte('0' + c)
+}
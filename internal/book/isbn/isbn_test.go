@@ -0,0 +1,268 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want string
+	}{
+		{"", ""},
+		{"978-0-306-40615-7", "9780306406157"},
+		{"0 8044 2957 x", "080442957X"},
+		{"\t0306406152 ", "0306406152"},
+	}
+	for _, test := range tests {
+		if got := Normalize(test.isbn); test.want != got {
+			t.Errorf("Normalize(%q): wanted %q, got %q", test.isbn, test.want, got)
+		}
+	}
+}
+
+func TestValidate10(t *testing.T) {
+	tests := []struct {
+		isbn   string
+		wantOk bool
+	}{
+		{"", false},
+		{"030640615", false},
+		{"03064061522", false},
+		{"0306406152", true},
+		{"0306406153", false},
+		{"080442957X", true},
+		{"0804429579", false},
+		{"X306406152", false},
+		{"030640615x", false}, // not normalized
+	}
+	for _, test := range tests {
+		err := Validate10(test.isbn)
+		switch {
+		case !test.wantOk:
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate10(%q): wanted ErrInvalid, got %v", test.isbn, err)
+			}
+		case err != nil:
+			t.Errorf("Validate10(%q): unwanted error: %v", test.isbn, err)
+		}
+	}
+}
+
+func TestValidate13(t *testing.T) {
+	tests := []struct {
+		isbn   string
+		wantOk bool
+	}{
+		{"", false},
+		{"978030640615", false},
+		{"9780306406157", true},
+		{"9780306406158", false},
+		{"978030640615X", false},
+		{"9791234567896", true},
+		{"0000000000000", true},
+	}
+	for _, test := range tests {
+		err := Validate13(test.isbn)
+		switch {
+		case !test.wantOk:
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate13(%q): wanted ErrInvalid, got %v", test.isbn, err)
+			}
+		case err != nil:
+			t.Errorf("Validate13(%q): unwanted error: %v", test.isbn, err)
+		}
+	}
+}
+
+func TestValidateUPC(t *testing.T) {
+	tests := []struct {
+		upc    string
+		wantOk bool
+	}{
+		{"036000291452", true},
+		{"012345678905", true},
+		{"036000291453", false},
+		{"03600029145", false},
+		{"03600029145X", false},
+		{"0306406152", false},
+	}
+	for _, test := range tests {
+		err := ValidateUPC(test.upc)
+		switch {
+		case !test.wantOk:
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("ValidateUPC(%q): wanted ErrInvalid, got %v", test.upc, err)
+			}
+		case err != nil:
+			t.Errorf("ValidateUPC(%q): unwanted error: %v", test.upc, err)
+		}
+	}
+}
+
+func TestTo13(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		want   string
+		wantOk bool
+	}{
+		{"0306406152", "9780306406157", true},
+		{"080442957X", "9780804429573", true},
+		{"0306406153", "", false},
+	}
+	for _, test := range tests {
+		got, err := To13(test.isbn10)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("To13(%q): wanted error", test.isbn10)
+			}
+		case err != nil:
+			t.Errorf("To13(%q): unwanted error: %v", test.isbn10, err)
+		case test.want != got:
+			t.Errorf("To13(%q): wanted %q, got %q", test.isbn10, test.want, got)
+		}
+	}
+}
+
+func TestTo10(t *testing.T) {
+	tests := []struct {
+		isbn13 string
+		want   string
+		wantOk bool
+	}{
+		{"9780306406157", "0306406152", true},
+		{"9780804429573", "080442957X", true},
+		{"9780306406158", "", false},
+		{"9791234567896", "", false},
+	}
+	for _, test := range tests {
+		got, err := To10(test.isbn13)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("To10(%q): wanted error", test.isbn13)
+			}
+		case err != nil:
+			t.Errorf("To10(%q): unwanted error: %v", test.isbn13, err)
+		case test.want != got:
+			t.Errorf("To10(%q): wanted %q, got %q", test.isbn13, test.want, got)
+		}
+	}
+}
+
+func TestPair(t *testing.T) {
+	tests := []struct {
+		name       string
+		isbn13     string
+		isbn10     string
+		wantIsbn13 string
+		wantIsbn10 string
+		wantOk     bool
+	}{
+		{
+			name:   "empty",
+			wantOk: true,
+		},
+		{
+			name:       "both",
+			isbn13:     "978-0-306-40615-7",
+			isbn10:     "0-306-40615-2",
+			wantIsbn13: "9780306406157",
+			wantIsbn10: "0306406152",
+			wantOk:     true,
+		},
+		{
+			name:       "only isbn-10",
+			isbn10:     "0-8044-2957-x",
+			wantIsbn13: "9780804429573",
+			wantIsbn10: "080442957X",
+			wantOk:     true,
+		},
+		{
+			name:       "only isbn-13",
+			isbn13:     "9780306406157",
+			wantIsbn13: "9780306406157",
+			wantIsbn10: "0306406152",
+			wantOk:     true,
+		},
+		{
+			name:       "only 979 isbn-13",
+			isbn13:     "9791234567896",
+			wantIsbn13: "9791234567896",
+			wantOk:     true,
+		},
+		{
+			name:       "swapped",
+			isbn13:     "0306406152",
+			isbn10:     "9780306406157",
+			wantIsbn13: "9780306406157",
+			wantIsbn10: "0306406152",
+			wantOk:     true,
+		},
+		{
+			name:       "isbn-10 in isbn-13 field",
+			isbn13:     "0306406152",
+			wantIsbn13: "9780306406157",
+			wantIsbn10: "0306406152",
+			wantOk:     true,
+		},
+		{
+			name:       "upc-a",
+			isbn13:     "9780306406157",
+			isbn10:     "0-36000-29145-2",
+			wantIsbn13: "9780306406157",
+			wantIsbn10: "036000291452",
+			wantOk:     true,
+		},
+		{
+			name:       "only upc-a",
+			isbn10:     "036000291452",
+			wantIsbn10: "036000291452",
+			wantOk:     true,
+		},
+		{
+			name:       "upc-a in isbn-13 field",
+			isbn13:     "036000291452",
+			wantIsbn10: "036000291452",
+			wantOk:     true,
+		},
+		{
+			name:   "invalid upc-a",
+			isbn10: "036000291453",
+		},
+		{
+			name:   "invalid isbn-13",
+			isbn13: "9780306406158",
+		},
+		{
+			name:   "invalid isbn-10",
+			isbn10: "0306406153",
+		},
+		{
+			name:   "bad length",
+			isbn13: "12345",
+		},
+		{
+			name:   "different books",
+			isbn13: "9780306406157",
+			isbn10: "080442957X",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isbn13, isbn10, err := Pair(test.isbn13, test.isbn10)
+			switch {
+			case !test.wantOk:
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("wanted ErrInvalid, got %v", err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.wantIsbn13 != isbn13, test.wantIsbn10 != isbn10:
+				t.Errorf("wanted %q, %q, got %q, %q", test.wantIsbn13, test.wantIsbn10, isbn13, isbn10)
+			}
+		})
+	}
+}
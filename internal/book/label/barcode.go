@@ -0,0 +1,148 @@
+package label
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
+)
+
+// Barcode is a one-dimensional barcode.
+type Barcode struct {
+	// Modules are the narrowest bars of the barcode, which are black if true.
+	Modules []bool
+	// Text is printed under the barcode so people can read it.
+	Text string
+}
+
+// quietZone is the number of blank modules needed on each side of barcodes.
+const quietZone = 10
+
+// code128Patterns are the widths of the bars and spaces of each code 128 symbol value.
+// The last pattern is the stop symbol.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// Code128 encodes the text with code set B, which has the printable ascii characters.
+func Code128(text string) (*Barcode, error) {
+	if len(text) == 0 {
+		return nil, fmt.Errorf("code 128 text required")
+	}
+	values := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range text {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("code 128 cannot encode %q", r)
+		}
+		v := int(r - ' ')
+		values = append(values, v)
+		checksum += (i + 1) * v
+	}
+	values = append(values, checksum%103, code128Stop)
+	var modules []bool
+	for _, v := range values {
+		black := true
+		for _, width := range code128Patterns[v] {
+			for j := '0'; j < width; j++ {
+				modules = append(modules, black)
+			}
+			black = !black
+		}
+	}
+	b := Barcode{
+		Modules: modules,
+		Text:    text,
+	}
+	return &b, nil
+}
+
+// ean13 encodings of digits are the left-hand odd (L) codes; even (G) codes are reversed right-hand codes and right-hand (R) codes are complements of L codes.
+var ean13LCodes = [...]string{
+	"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// ean13Parities are which of the left-hand digits use G codes, for each first digit.
+var ean13Parities = [...]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLG", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// EAN13 encodes the isbn-13.
+// The first digit is encoded by the parities of the next six digits.
+func EAN13(isbn13 string) (*Barcode, error) {
+	if err := isbn.Validate13(isbn13); err != nil {
+		return nil, fmt.Errorf("encoding ean-13: %w", err)
+	}
+	var sb strings.Builder
+	sb.WriteString("101")
+	parities := ean13Parities[isbn13[0]-'0']
+	for i, d := range isbn13[1:7] {
+		code := ean13LCodes[d-'0']
+		if parities[i] == 'G' {
+			code = reverse(complement(code))
+		}
+		sb.WriteString(code)
+	}
+	sb.WriteString("01010")
+	for _, d := range isbn13[7:] {
+		sb.WriteString(complement(ean13LCodes[d-'0']))
+	}
+	sb.WriteString("101")
+	modules := make([]bool, sb.Len())
+	for i, c := range sb.String() {
+		modules[i] = c == '1'
+	}
+	b := Barcode{
+		Modules: modules,
+		Text:    isbn13,
+	}
+	return &b, nil
+}
+
+func complement(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '0' {
+			return '1'
+		}
+		return '0'
+	}, code)
+}
+
+func reverse(code string) string {
+	b := []byte(code)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// bars are the runs of black modules as their starting module and width.
+func (b Barcode) bars() [][2]int {
+	var bars [][2]int
+	for i := 0; i < len(b.Modules); i++ {
+		if !b.Modules[i] {
+			continue
+		}
+		start := i
+		for i < len(b.Modules) && b.Modules[i] {
+			i++
+		}
+		bars = append(bars, [2]int{start, i - start})
+	}
+	return bars
+}
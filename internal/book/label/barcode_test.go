@@ -0,0 +1,205 @@
+package label
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCode128Patterns(t *testing.T) {
+	seen := make(map[string]bool)
+	for i, p := range code128Patterns {
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		want := 11
+		if i == code128Stop {
+			want = 13
+		}
+		if sum != want {
+			t.Errorf("pattern %v (%v) has %v modules, wanted %v", i, p, sum, want)
+		}
+		if seen[p] {
+			t.Errorf("pattern %v (%v) is not unique", i, p)
+		}
+		seen[p] = true
+	}
+}
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		text       string
+		wantOk     bool
+		wantValues []int
+	}{
+		{"", false, nil},
+		{"tab\t", false, nil},
+		{"é", false, nil},
+		{"PJJ123C", true, []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		{"a_-=", true, []int{104, 65, 63, 13, 29, 38, 106}},
+	}
+	for _, test := range tests {
+		got, err := Code128(test.text)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("Code128(%q): wanted error", test.text)
+			}
+		case err != nil:
+			t.Errorf("Code128(%q): unwanted error: %v", test.text, err)
+		case got.Text != test.text:
+			t.Errorf("Code128(%q): wanted text %q, got %q", test.text, test.text, got.Text)
+		default:
+			if values := decodeCode128(t, got.Modules); !equalInts(test.wantValues, values) {
+				t.Errorf("Code128(%q): values not equal: \n wanted: %v \n got:    %v", test.text, test.wantValues, values)
+			}
+		}
+	}
+}
+
+// decodeCode128 reads the symbol values from the modules.
+func decodeCode128(t *testing.T, modules []bool) []int {
+	t.Helper()
+	var widths strings.Builder
+	for i := 0; i < len(modules); {
+		j := i
+		for j < len(modules) && modules[j] == modules[i] {
+			j++
+		}
+		widths.WriteByte(byte('0' + j - i))
+		i = j
+	}
+	s := widths.String()
+	var values []int
+	for len(s) != 0 {
+		n := 6
+		if len(s) == 7 {
+			n = 7
+		}
+		found := false
+		for v, p := range code128Patterns {
+			if len(s) >= n && s[:n] == p {
+				values = append(values, v)
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("unknown pattern at %q", s)
+		}
+		s = s[n:]
+	}
+	return values
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEAN13(t *testing.T) {
+	tests := []struct {
+		isbn13 string
+		wantOk bool
+	}{
+		{"", false},
+		{"9780306406158", false},
+		{"9780306406157", true},
+		{"4006381333931", true},
+		{"0000000000000", true},
+	}
+	for _, test := range tests {
+		got, err := EAN13(test.isbn13)
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("EAN13(%q): wanted error", test.isbn13)
+			}
+		case err != nil:
+			t.Errorf("EAN13(%q): unwanted error: %v", test.isbn13, err)
+		case len(got.Modules) != 95:
+			t.Errorf("EAN13(%q): wanted 95 modules, got %v", test.isbn13, len(got.Modules))
+		default:
+			if want, got := test.isbn13, decodeEAN13(t, got.Modules); want != got {
+				t.Errorf("EAN13: decoded %q as %q", want, got)
+			}
+		}
+	}
+}
+
+// decodeEAN13 reads the digits from the modules, checking the guards.
+func decodeEAN13(t *testing.T, modules []bool) string {
+	t.Helper()
+	var sb strings.Builder
+	for _, m := range modules {
+		if m {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	s := sb.String()
+	if s[:3] != "101" || s[45:50] != "01010" || s[92:] != "101" {
+		t.Fatalf("bad guards: %v", s)
+	}
+	digit := func(code string, codes func(d int) string) (int, bool) {
+		for d := 0; d < 10; d++ {
+			if codes(d) == code {
+				return d, true
+			}
+		}
+		return 0, false
+	}
+	lCode := func(d int) string { return ean13LCodes[d] }
+	gCode := func(d int) string { return reverse(complement(ean13LCodes[d])) }
+	rCode := func(d int) string { return complement(ean13LCodes[d]) }
+	var digits, parities strings.Builder
+	for i := 0; i < 6; i++ {
+		code := s[3+7*i : 10+7*i]
+		if d, ok := digit(code, lCode); ok {
+			digits.WriteByte(byte('0' + d))
+			parities.WriteByte('L')
+		} else if d, ok := digit(code, gCode); ok {
+			digits.WriteByte(byte('0' + d))
+			parities.WriteByte('G')
+		} else {
+			t.Fatalf("unknown left code %v", code)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		code := s[50+7*i : 57+7*i]
+		d, ok := digit(code, rCode)
+		if !ok {
+			t.Fatalf("unknown right code %v", code)
+		}
+		digits.WriteByte(byte('0' + d))
+	}
+	for first, p := range ean13Parities {
+		if p == parities.String() {
+			return string(rune('0'+first)) + digits.String()
+		}
+	}
+	t.Fatalf("unknown parities: %v", parities.String())
+	return ""
+}
+
+func TestBars(t *testing.T) {
+	b := Barcode{Modules: []bool{true, true, false, true, false, false, true}}
+	want := [][2]int{{0, 2}, {3, 1}, {6, 1}}
+	got := b.bars()
+	if len(want) != len(got) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("wanted %v, got %v", want, got)
+		}
+	}
+}
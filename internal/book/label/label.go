@@ -0,0 +1,292 @@
+// Package label draws spine and barcode labels for books onto sheets of labels.
+package label
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
+)
+
+type (
+	// Label is what is printed for a book.
+	Label struct {
+		ShelfLocation string
+		Author        string
+		Title         string
+		Barcode       Barcode
+		// QR is drawn instead of the barcode if it is set.
+		QR *QRCode
+	}
+	// Layout is a sheet of labels in a grid.
+	// Lengths are in points, which are 1/72 of an inch.
+	Layout struct {
+		Name        string
+		PageWidth   float64
+		PageHeight  float64
+		Columns     int
+		Rows        int
+		LabelWidth  float64
+		LabelHeight float64
+		TopMargin   float64
+		LeftMargin  float64
+		// ColumnPitch is the distance between the left sides of labels next to each other.
+		ColumnPitch float64
+		// RowPitch is the distance between the tops of labels above each other.
+		RowPitch float64
+	}
+	// BarcodeSource is what the barcode of a label encodes.
+	BarcodeSource string
+	// canvas is a page that labels are drawn on.
+	// Positions are from the top left corner of the page; text is positioned by the left of its baseline.
+	canvas interface {
+		rect(x, y, w, h float64)
+		text(x, y, size float64, s string)
+	}
+	// placedLabel is a label on a page.
+	placedLabel struct {
+		Label
+		x, y float64
+	}
+)
+
+const (
+	// ISBNBarcode encodes the isbn-13 of the book as an EAN-13, or the id of the book if it does not have one.
+	ISBNBarcode BarcodeSource = "isbn"
+	// IDBarcode encodes the id of the book as a code 128 barcode.
+	IDBarcode BarcodeSource = "id"
+)
+
+const (
+	inch = 72.0
+	mm   = inch / 25.4
+	// charWidth is about how wide characters are in Helvetica, relative to the font size.
+	charWidth = 0.55
+	// maxModuleWidth keeps short barcodes from being stretched too wide to scan.
+	maxModuleWidth = 1.0
+)
+
+var (
+	letterWidth, letterHeight = 8.5 * inch, 11 * inch
+	a4Width, a4Height         = 210 * mm, 297 * mm
+	// Layouts are common label sheets.
+	Layouts = map[string]Layout{
+		"avery-5160": {
+			Name:      "Avery 5160 (30 address labels, letter)",
+			PageWidth: letterWidth, PageHeight: letterHeight,
+			Columns: 3, Rows: 10,
+			LabelWidth: 2.625 * inch, LabelHeight: 1 * inch,
+			TopMargin: 0.5 * inch, LeftMargin: 0.1875 * inch,
+			ColumnPitch: 2.75 * inch, RowPitch: 1 * inch,
+		},
+		"avery-5167": {
+			Name:      "Avery 5167 (80 return address labels, letter)",
+			PageWidth: letterWidth, PageHeight: letterHeight,
+			Columns: 4, Rows: 20,
+			LabelWidth: 1.75 * inch, LabelHeight: 0.5 * inch,
+			TopMargin: 0.5 * inch, LeftMargin: 0.3 * inch,
+			ColumnPitch: 2.05 * inch, RowPitch: 0.5 * inch,
+		},
+		"avery-l7651": {
+			Name:      "Avery L7651 (65 mini labels, A4)",
+			PageWidth: a4Width, PageHeight: a4Height,
+			Columns: 5, Rows: 13,
+			LabelWidth: 38.1 * mm, LabelHeight: 21.2 * mm,
+			TopMargin: 10.7 * mm, LeftMargin: 4.7 * mm,
+			ColumnPitch: 40.6 * mm, RowPitch: 21.2 * mm,
+		},
+	}
+)
+
+// LayoutNames are the keys of the Layouts, sorted.
+func LayoutNames() []string {
+	names := make([]string, 0, len(Layouts))
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewLabel creates the label for the book.
+func NewLabel(b book.Book, source BarcodeSource) (*Label, error) {
+	var barcode *Barcode
+	var err error
+	switch {
+	case source == ISBNBarcode && isbn.Validate13(b.EanIsbn13) == nil:
+		barcode, err = EAN13(b.EanIsbn13)
+	case source == ISBNBarcode, source == IDBarcode:
+		barcode, err = Code128(b.ID)
+	default:
+		return nil, fmt.Errorf("unknown barcode source: %q", source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating barcode for book %q: %w", b.ID, err)
+	}
+	l := Label{
+		ShelfLocation: b.DeweyDecClass,
+		Author:        AbbreviateAuthor(b.Author),
+		Title:         b.Title,
+		Barcode:       *barcode,
+	}
+	return &l, nil
+}
+
+// NewQRLabel creates the label for the book with a QR code of the url, which is usually the page of the book.
+func NewQRLabel(b book.Book, url string) (*Label, error) {
+	qr, err := QR(url)
+	if err != nil {
+		return nil, fmt.Errorf("creating qr code for book %q: %w", b.ID, err)
+	}
+	l := Label{
+		ShelfLocation: b.DeweyDecClass,
+		Author:        AbbreviateAuthor(b.Author),
+		Title:         b.Title,
+		QR:            qr,
+	}
+	return &l, nil
+}
+
+// AbbreviateAuthor is the first three letters of the surname of the author, in uppercase, as is usual on spine labels.
+// The surname is before the comma of names like "Doe, Jane" and the last word of other names.
+func AbbreviateAuthor(author string) string {
+	surname, _, ok := strings.Cut(author, ",")
+	if !ok {
+		fields := strings.Fields(author)
+		if len(fields) == 0 {
+			return ""
+		}
+		surname = fields[len(fields)-1]
+	}
+	var letters []rune
+	for _, r := range surname {
+		if unicode.IsLetter(r) {
+			letters = append(letters, unicode.ToUpper(r))
+		}
+		if len(letters) == 3 {
+			break
+		}
+	}
+	return string(letters)
+}
+
+// Validate checks that the labels are on the page.
+func (l Layout) Validate() error {
+	switch {
+	case l.PageWidth <= 0, l.PageHeight <= 0:
+		return fmt.Errorf("page size must be positive")
+	case l.Columns <= 0, l.Rows <= 0:
+		return fmt.Errorf("columns and rows must be positive")
+	case l.LabelWidth <= 0, l.LabelHeight <= 0:
+		return fmt.Errorf("label size must be positive")
+	case l.TopMargin < 0, l.LeftMargin < 0:
+		return fmt.Errorf("margins must not be negative")
+	case l.Columns > 1 && l.ColumnPitch < l.LabelWidth,
+		l.Rows > 1 && l.RowPitch < l.LabelHeight:
+		return fmt.Errorf("labels must not overlap")
+	case l.LeftMargin+float64(l.Columns-1)*l.ColumnPitch+l.LabelWidth > l.PageWidth+0.01,
+		l.TopMargin+float64(l.Rows-1)*l.RowPitch+l.LabelHeight > l.PageHeight+0.01:
+		return fmt.Errorf("labels must fit on the page")
+	}
+	return nil
+}
+
+// pages places the labels on pages, left to right and then top to bottom.
+// The first skip labels of the first page are left blank so partly used sheets can be printed on.
+func (l Layout) pages(skip int, labels []Label) [][]placedLabel {
+	perPage := l.Columns * l.Rows
+	if skip < 0 {
+		skip = 0
+	}
+	skip %= perPage
+	var pages [][]placedLabel
+	for i, label := range labels {
+		n := skip + i
+		if n%perPage == 0 || len(pages) == 0 {
+			pages = append(pages, nil)
+		}
+		n %= perPage
+		col, row := n%l.Columns, n/l.Columns
+		p := placedLabel{
+			Label: label,
+			x:     l.LeftMargin + float64(col)*l.ColumnPitch,
+			y:     l.TopMargin + float64(row)*l.RowPitch,
+		}
+		pages[len(pages)-1] = append(pages[len(pages)-1], p)
+	}
+	return pages
+}
+
+// draw puts the label in the box on the canvas.
+// The shelf location is on top, then the abbreviated author and title, then the barcode with its text.
+func (l Label) draw(c canvas, x, y, w, h float64) {
+	pad := 0.06 * h
+	x, y, w, h = x+pad, y+pad, w-2*pad, h-2*pad
+	if l.QR != nil {
+		l.drawQR(c, x, y, w, h)
+		return
+	}
+	size1, size2, size3 := 0.22*h, 0.13*h, 0.1*h
+	y += size1
+	c.text(x, y, size1, fit(l.ShelfLocation, w, size1))
+	y += 1.2 * size2
+	line2 := l.Author
+	if len(l.Title) != 0 {
+		if len(line2) != 0 {
+			line2 += " "
+		}
+		line2 += l.Title
+	}
+	c.text(x, y, size2, fit(line2, w, size2))
+	y += 0.4 * size2
+	barHeight := h - (1.2*size1 + 1.6*size2) - 1.2*size3
+	modules := len(l.Barcode.Modules) + 2*quietZone
+	moduleWidth := w / float64(modules)
+	if moduleWidth > maxModuleWidth {
+		moduleWidth = maxModuleWidth
+	}
+	barX := x + (w-moduleWidth*float64(modules))/2 + quietZone*moduleWidth
+	for _, bar := range l.Barcode.bars() {
+		c.rect(barX+float64(bar[0])*moduleWidth, y, float64(bar[1])*moduleWidth, barHeight)
+	}
+	text := fit(l.Barcode.Text, w, size3)
+	textX := x + (w-textWidth(text, size3))/2
+	c.text(textX, y+barHeight+size3, size3, text)
+}
+
+// drawQR puts the qr code on the left of the label with its quiet zone and the text on the right.
+// The author and title are on separate lines because there is less room for them.
+func (l Label) drawQR(c canvas, x, y, w, h float64) {
+	side := min(h, w/2)
+	moduleSize := side / float64(l.QR.Size+2*qrQuietZone)
+	l.QR.draw(c, x+qrQuietZone*moduleSize, y+(h-side)/2+qrQuietZone*moduleSize, moduleSize)
+	x += side
+	w -= side
+	size1, size2 := 0.22*h, 0.13*h
+	y += size1
+	c.text(x, y, size1, fit(l.ShelfLocation, w, size1))
+	y += 1.4 * size2
+	c.text(x, y, size2, fit(l.Author, w, size2))
+	y += 1.2 * size2
+	c.text(x, y, size2, fit(l.Title, w, size2))
+}
+
+// fit shortens the text so it is not wider than the width.
+func fit(s string, width, size float64) string {
+	runes := []rune(s)
+	max := int(width / (charWidth * size))
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 1 {
+		return ""
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func textWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * charWidth * size
+}
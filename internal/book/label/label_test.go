@@ -0,0 +1,182 @@
+package label
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestNewLabel(t *testing.T) {
+	b := book.Book{
+		Header:        book.Header{ID: "abc", Title: "Lemurs", Author: "Jane Doe"},
+		DeweyDecClass: "599.8 DOE",
+		EanIsbn13:     "9780306406157",
+	}
+	noISBN := b
+	noISBN.EanIsbn13 = ""
+	tests := []struct {
+		name     string
+		b        book.Book
+		source   BarcodeSource
+		wantOk   bool
+		wantText string
+	}{
+		{"unknown source", b, "qr", false, ""},
+		{"isbn", b, ISBNBarcode, true, "9780306406157"},
+		{"isbn missing", noISBN, ISBNBarcode, true, "abc"},
+		{"id", b, IDBarcode, true, "abc"},
+		{"no id", book.Book{}, IDBarcode, false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewLabel(test.b, test.source)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case got.ShelfLocation != "599.8 DOE", got.Author != "DOE", got.Title != "Lemurs":
+				t.Errorf("unwanted label text: %+v", got)
+			case test.wantText != got.Barcode.Text:
+				t.Errorf("wanted barcode of %q, got %q", test.wantText, got.Barcode.Text)
+			}
+		})
+	}
+}
+
+func TestNewQRLabel(t *testing.T) {
+	b := book.Book{
+		Header:        book.Header{ID: "abc", Title: "Lemurs", Author: "Jane Doe"},
+		DeweyDecClass: "599.8 DOE",
+	}
+	t.Run("too long", func(t *testing.T) {
+		if _, err := NewQRLabel(b, strings.Repeat("x", 3000)); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	got, err := NewQRLabel(b, "https://example.com/book?id=abc")
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case got.ShelfLocation != "599.8 DOE", got.Author != "DOE", got.Title != "Lemurs":
+		t.Errorf("unwanted label text: %+v", got)
+	case got.QR == nil, len(got.Barcode.Modules) != 0:
+		t.Errorf("wanted only qr code: %+v", got)
+	}
+	var sb strings.Builder
+	if err := WriteSVG(&sb, Layouts["avery-5160"], 0, *got); err != nil {
+		t.Fatalf("writing svg: %v", err)
+	}
+	for _, want := range []string{">599.8 DOE</text>", ">DOE</text>", ">Lemurs</text>"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("wanted %q in svg, got: \n %v", want, sb.String())
+		}
+	}
+}
+
+func TestAbbreviateAuthor(t *testing.T) {
+	tests := []struct {
+		author string
+		want   string
+	}{
+		{"", ""},
+		{"Plato", "PLA"},
+		{"Jane Doe", "DOE"},
+		{"Doe, Jane", "DOE"},
+		{"Flannery O'Connor", "OCO"},
+		{"Émile Zola", "ZOL"},
+		{"Li", "LI"},
+	}
+	for _, test := range tests {
+		if got := AbbreviateAuthor(test.author); test.want != got {
+			t.Errorf("AbbreviateAuthor(%q): wanted %q, got %q", test.author, test.want, got)
+		}
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	for name, l := range Layouts {
+		if err := l.Validate(); err != nil {
+			t.Errorf("layout %v: %v", name, err)
+		}
+	}
+	ok := Layouts["avery-5160"]
+	tests := []struct {
+		name string
+		edit func(l *Layout)
+	}{
+		{"no page", func(l *Layout) { l.PageWidth = 0 }},
+		{"no columns", func(l *Layout) { l.Columns = 0 }},
+		{"no label height", func(l *Layout) { l.LabelHeight = 0 }},
+		{"negative margin", func(l *Layout) { l.TopMargin = -1 }},
+		{"overlap", func(l *Layout) { l.ColumnPitch = l.LabelWidth - 1 }},
+		{"too many rows", func(l *Layout) { l.Rows++ }},
+	}
+	for _, test := range tests {
+		l := ok
+		test.edit(&l)
+		if err := l.Validate(); err == nil {
+			t.Errorf("%v: wanted error", test.name)
+		}
+	}
+}
+
+func TestLayoutPages(t *testing.T) {
+	l := Layout{Columns: 2, Rows: 2, TopMargin: 10, LeftMargin: 5, ColumnPitch: 100, RowPitch: 50}
+	labels := []Label{{Title: "a"}, {Title: "b"}, {Title: "c"}, {Title: "d"}}
+	tests := []struct {
+		name      string
+		skip      int
+		wantPages []int
+		wantFirst placedLabel
+	}{
+		{"no labels", 0, nil, placedLabel{}},
+		{"one page", 0, []int{4}, placedLabel{Label{Title: "a"}, 5, 10}},
+		{"skip", 3, []int{1, 3}, placedLabel{Label{Title: "a"}, 105, 60}},
+		{"skip whole pages", 5, []int{3, 1}, placedLabel{Label{Title: "a"}, 105, 10}},
+		{"negative skip", -1, []int{4}, placedLabel{Label{Title: "a"}, 5, 10}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := labels
+			if test.wantPages == nil {
+				in = nil
+			}
+			pages := l.pages(test.skip, in)
+			if want, got := len(test.wantPages), len(pages); want != got {
+				t.Fatalf("wanted %v pages, got %v", want, got)
+			}
+			for i, n := range test.wantPages {
+				if len(pages[i]) != n {
+					t.Errorf("wanted %v labels on page %v, got %v", n, i, len(pages[i]))
+				}
+			}
+			if len(pages) != 0 {
+				if got := pages[0][0]; got.Title != test.wantFirst.Title || got.x != test.wantFirst.x || got.y != test.wantFirst.y {
+					t.Errorf("first labels not equal: \n wanted: %+v \n got:    %+v", test.wantFirst, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s     string
+		width float64
+		want  string
+	}{
+		{"", 100, ""},
+		{"short", 100, "short"},
+		{"a long title that does not fit", 55, "a long ti…"},
+		{"ab", 5, ""},
+	}
+	for _, test := range tests {
+		if got := fit(test.s, test.width, 10); test.want != got {
+			t.Errorf("fit(%q, %v): wanted %q, got %q", test.s, test.width, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type (
+	// pdfCanvas writes the content stream of a pdf page.
+	pdfCanvas struct {
+		buf        *bytes.Buffer
+		pageHeight float64
+	}
+	// pdfWriter writes numbered objects, remembering where they are for the cross-reference table.
+	pdfWriter struct {
+		w       io.Writer
+		n       int64
+		offsets []int64
+		err     error
+	}
+)
+
+// WritePDF draws the labels on the pages of the layout as a pdf document.
+// The text uses the Helvetica font that pdf readers have, so no fonts are embedded.
+func WritePDF(w io.Writer, layout Layout, skip int, labels ...Label) error {
+	if err := layout.Validate(); err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	}
+	pages := layout.pages(skip, labels)
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+	pw := pdfWriter{w: w}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	// objects 1-3 are the catalog, page tree, and font; each page then has a page object and a content stream
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%v 0 R", 4+2*i))
+	}
+	pw.object("<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(fmt.Sprintf("<< /Type /Pages /Kids [%v] /Count %v >>", strings.Join(kids, " "), len(pages)))
+	pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		c := pdfCanvas{
+			buf:        new(bytes.Buffer),
+			pageHeight: layout.PageHeight,
+		}
+		for _, p := range page {
+			p.draw(c, p.x, p.y, layout.LabelWidth, layout.LabelHeight)
+		}
+		pw.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] /Resources << /Font << /F1 3 0 R >> >> /Contents %v 0 R >>",
+			num(layout.PageWidth), num(layout.PageHeight), 5+2*i))
+		pw.object(fmt.Sprintf("<< /Length %v >>\nstream\n%vendstream", c.buf.Len(), c.buf.String()))
+	}
+	xref := pw.n
+	pw.printf("xref\n0 %v\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %v /Root 1 0 R >>\nstartxref\n%v\n%%%%EOF\n", len(pw.offsets)+1, xref)
+	if pw.err != nil {
+		return fmt.Errorf("writing pdf: %w", pw.err)
+	}
+	return nil
+}
+
+func (pw *pdfWriter) printf(format string, a ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, a...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *pdfWriter) object(value string) {
+	pw.offsets = append(pw.offsets, pw.n)
+	pw.printf("%v 0 obj\n%v\nendobj\n", len(pw.offsets), value)
+}
+
+func (c pdfCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(c.buf, "%v %v %v %v re f\n", num(x), num(c.pageHeight-y-h), num(w), num(h))
+}
+
+func (c pdfCanvas) text(x, y, size float64, s string) {
+	if len(s) == 0 {
+		return
+	}
+	fmt.Fprintf(c.buf, "BT /F1 %v Tf %v %v Td (%v) Tj ET\n", num(size), num(x), num(c.pageHeight-y), pdfString(s))
+}
+
+// pdfString escapes the text for a literal string in the WinAnsiEncoding.
+// Characters that the encoding does not have are replaced with question marks.
+func pdfString(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(', r == ')', r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case ' ' <= r && r <= '~':
+			sb.WriteRune(r)
+		case r == '…':
+			sb.WriteString(`\205`)
+		case 0xA0 <= r && r <= 0xFF: // the same as latin-1
+			fmt.Fprintf(&sb, `\%03o`, r)
+		default:
+			sb.WriteRune('?')
+		}
+	}
+	return sb.String()
+}
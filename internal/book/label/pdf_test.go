@@ -0,0 +1,94 @@
+package label
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDF(t *testing.T) {
+	barcode, err := Code128("abc")
+	if err != nil {
+		t.Fatalf("creating barcode: %v", err)
+	}
+	l := Label{ShelfLocation: "599.8", Author: "DOE", Title: "Lemurs (2nd ed.)", Barcode: *barcode}
+	layout := Layouts["avery-5167"]
+	t.Run("bad layout", func(t *testing.T) {
+		var sb strings.Builder
+		if err := WritePDF(&sb, Layout{}, 0, l); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	var sb strings.Builder
+	if err := WritePDF(&sb, layout, 79, l, l); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	got := sb.String()
+	wantParts := []string{
+		"%PDF-1.4\n",
+		"/Kids [4 0 R 6 0 R] /Count 2",
+		"/MediaBox [0 0 612 792]",
+		"(599.8) Tj",
+		`(DOE Lemurs \(2nd ed.\)) Tj`,
+		"(abc) Tj",
+		" re f\n",
+		"trailer\n<< /Size 8 /Root 1 0 R >>",
+	}
+	for _, want := range wantParts {
+		if !strings.Contains(got, want) {
+			t.Errorf("wanted %q in pdf, got: \n %v", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "%%EOF\n") {
+		t.Errorf("wanted pdf to end with %%%%EOF")
+	}
+	// the cross-reference table must point to the objects
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(got)
+	if startxref == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(startxref[1])
+	if !strings.HasPrefix(got[xref:], "xref\n0 8\n") {
+		t.Fatalf("startxref does not point to xref table: %q", got[xref:xref+10])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(got[xref:], -1)
+	if len(entries) != 7 {
+		t.Fatalf("wanted 7 xref entries, got %v", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%v 0 obj\n", i+1); !strings.HasPrefix(got[offset:], want) {
+			t.Errorf("xref entry %v does not point to %q", i+1, want)
+		}
+	}
+	// stream lengths must be exact
+	streams := regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllStringSubmatch(got, -1)
+	if len(streams) != 2 {
+		t.Fatalf("wanted 2 streams, got %v", len(streams))
+	}
+	for i, s := range streams {
+		if n, _ := strconv.Atoi(s[1]); n != len(s[2]) {
+			t.Errorf("stream %v has length %v, but is %v bytes", i, n, len(s[2]))
+		}
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{`a(b)c\`, `a\(b\)c\\`},
+		{"café…", `caf\351\205`},
+		{"日本", "??"},
+	}
+	for _, test := range tests {
+		if got := pdfString(test.s); test.want != got {
+			t.Errorf("pdfString(%q): wanted %q, got %q", test.s, test.want, got)
+		}
+	}
+}
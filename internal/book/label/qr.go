@@ -0,0 +1,495 @@
+package label
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// QRCode is a two-dimensional QR code symbol.
+// Text is encoded as bytes with the medium (M) error correction level, which can recover from about 15% of the symbol being damaged.
+type QRCode struct {
+	// Size is the number of modules on each side of the symbol.
+	Size    int
+	modules []bool
+	// function modules are finder, timing, alignment, format, and version patterns, which are not masked.
+	function []bool
+}
+
+const (
+	qrMinVersion = 1
+	qrMaxVersion = 40
+	// qrQuietZone is the number of light modules needed around QR codes.
+	qrQuietZone = 4
+	// qrFormatM is the format indicator of the M error correction level.
+	qrFormatM = 0
+	// qrByteMode is the mode indicator of byte data.
+	qrByteMode = 0b0100
+)
+
+// qrECCodewords are the error correction codewords of each block for each version at the M level.
+var qrECCodewords = [...]int{
+	-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+	26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+}
+
+// qrBlocks are the number of error correction blocks for each version at the M level.
+var qrBlocks = [...]int{
+	-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+	17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+}
+
+// QR encodes the text in the smallest QR code that fits it.
+func QR(text string) (*QRCode, error) {
+	data := []byte(text)
+	version := qrMinVersion
+	for ; ; version++ {
+		if version > qrMaxVersion {
+			return nil, fmt.Errorf("text too long for qr code: %v bytes", len(data))
+		}
+		if 4+qrCountBits(version)+8*len(data) <= 8*qrDataCodewords(version) {
+			break
+		}
+	}
+	size := 4*version + 17
+	q := QRCode{
+		Size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+	q.drawFunctionPatterns(version)
+	codewords := qrCodewords(version, data)
+	q.drawCodewords(codewords)
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // undo the mask, which is its own inverse
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return &q, nil
+}
+
+// Dark reports whether the module in the column and row is dark.
+func (q QRCode) Dark(x, y int) bool {
+	return q.modules[y*q.Size+x]
+}
+
+func (q QRCode) set(x, y int, dark bool) {
+	q.modules[y*q.Size+x] = dark
+	q.function[y*q.Size+x] = true
+}
+
+// qrCountBits is the length of the byte count of byte data.
+func qrCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// qrRawCodewords is the number of codewords of the version, including error correction codewords.
+// It is the number of modules that are not function modules, divided by eight.
+func qrRawCodewords(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		n -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n / 8
+}
+
+func qrDataCodewords(version int) int {
+	return qrRawCodewords(version) - qrECCodewords[version]*qrBlocks[version]
+}
+
+// qrAlignmentPositions are the rows and columns of the centers of alignment patterns.
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, 4*version+10; i > 0; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (q QRCode) drawFunctionPatterns(version int) {
+	for i := 0; i < q.Size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(q.Size-4, 3)
+	q.drawFinder(3, q.Size-4)
+	positions := qrAlignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // finder patterns are in these corners
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	q.drawFormat(0) // reserve the format modules
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := q.Size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// drawFinder draws a finder pattern and its separator around the center.
+func (q QRCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.Size || yy < 0 || yy >= q.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawFormat draws both copies of the error correction level and mask, which are protected by a BCH code.
+func (q QRCode) drawFormat(mask int) {
+	data := qrFormatM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool {
+		return (bits>>i)&1 == 1
+	}
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.Size-15+i, bit(i))
+	}
+	q.set(8, q.Size-8, true) // the dark module
+}
+
+// qrCodewords encodes the data and adds error correction codewords to each block, interleaving the blocks.
+func qrCodewords(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(qrByteMode, 4)
+	bits.append(len(data), qrCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * qrDataCodewords(version)
+	bits.append(0, min(4, capacity-len(bits))) // terminator
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	dataCodewords := bits.bytes()
+	numBlocks := qrBlocks[version]
+	ecLen := qrECCodewords[version]
+	raw := qrRawCodewords(version)
+	numShortBlocks := numBlocks - raw%numBlocks
+	shortBlockLen := raw / numBlocks
+	divisor := reedSolomonDivisor(ecLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortBlockLen - ecLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := append([]byte{}, dataCodewords[k:k+n]...)
+		k += n
+		block = append(block, reedSolomonRemainder(block, divisor)...)
+		blocks[i] = block
+	}
+	codewords := make([]byte, 0, raw)
+	for i := 0; i <= shortBlockLen; i++ {
+		for j, block := range blocks {
+			switch {
+			case i == shortBlockLen-ecLen && j < numShortBlocks:
+				continue // short blocks have one less data codeword
+			case j < numShortBlocks && i > shortBlockLen-ecLen:
+				codewords = append(codewords, block[i-1])
+			case i < len(block):
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+	return codewords
+}
+
+// drawCodewords fills the modules that are not function modules with the codewords.
+// Pairs of columns are filled from the bottom right corner, zigzagging up and down.
+func (q QRCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert // upward
+				}
+				if q.function[y*q.Size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y*q.Size+x] = (codewords[i>>3]>>(7-i&7))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the modules that are not function modules where the mask pattern is true.
+func (q QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !q.function[y*q.Size+x] {
+				q.modules[y*q.Size+x] = !q.modules[y*q.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, using the rules of the QR code specification.
+// Long runs, blocks, and finder-like patterns of the same color and unbalanced colors are penalized.
+func (q QRCode) penalty() int {
+	p := 0
+	dark := 0
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, horizontal := range []bool{true, false} {
+		at := func(i, j int) bool {
+			if horizontal {
+				return q.Dark(j, i)
+			}
+			return q.Dark(i, j)
+		}
+		for i := 0; i < q.Size; i++ {
+			run := 1
+			for j := 1; j <= q.Size; j++ {
+				if j < q.Size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += 3 + run - 5
+				}
+				run = 1
+			}
+			for j := 0; j+11 <= q.Size; j++ {
+				for _, pattern := range finderLike {
+					matches := true
+					for k, d := range pattern {
+						if at(i, j+k) != d {
+							matches = false
+							break
+						}
+					}
+					if matches {
+						p += 40
+					}
+				}
+			}
+		}
+	}
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			d := q.Dark(x, y)
+			if d {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size && d == q.Dark(x+1, y) && d == q.Dark(x, y+1) && d == q.Dark(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+	total := q.Size * q.Size
+	p += abs(dark*20-total*10) / total * 10
+	return p
+}
+
+// Image draws the symbol with the quiet zone around it, with each module as a square of pixels.
+func (q QRCode) Image(scale int) *image.Gray {
+	n := (q.Size + 2*qrQuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, n, n))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if !q.Dark(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+qrQuietZone)*scale+dx, (y+qrQuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the image of the symbol as a png.
+func (q QRCode) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, q.Image(scale))
+}
+
+// WriteSVG writes the symbol as a svg image with a module as each unit.
+func (q QRCode) WriteSVG(w io.Writer) error {
+	n := q.Size + 2*qrQuietZone
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]v %[1]v" shape-rendering="crispEdges">`+"\n", n)
+	fmt.Fprintf(bw, `<rect width="%[1]v" height="%[1]v" fill="white"/>`+"\n", n)
+	q.draw(svgCanvas{bw}, qrQuietZone, qrQuietZone, 1)
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// draw puts the dark modules on the canvas, combining runs of modules in each row into single rectangles.
+func (q QRCode) draw(c canvas, x, y, moduleSize float64) {
+	for row := 0; row < q.Size; row++ {
+		for col := 0; col < q.Size; col++ {
+			if !q.Dark(col, row) {
+				continue
+			}
+			start := col
+			for col < q.Size && q.Dark(col, row) {
+				col++
+			}
+			c.rect(x+float64(start)*moduleSize, y+float64(row)*moduleSize, float64(col-start)*moduleSize, moduleSize)
+		}
+	}
+}
+
+// bitBuffer is a sequence of bits, each stored as a byte of 0 or 1.
+type bitBuffer []byte
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, byte(value>>i&1))
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		result[i>>3] |= bit << (7 - i&7)
+	}
+	return result
+}
+
+// reedSolomonDivisor is the generator polynomial of the degree, with the highest coefficient, which is always one, left out.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder is the error correction codewords of the data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in the Galois field GF(2^8) with the polynomial of QR codes.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,309 @@
+package label
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" as 1-M alphanumeric data
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestQRCodewordCounts(t *testing.T) {
+	tests := []struct {
+		version  int
+		wantRaw  int
+		wantData int
+	}{
+		{1, 26, 16},
+		{2, 44, 28},
+		{5, 134, 86},
+		{7, 196, 124},
+		{10, 346, 216},
+		{40, 3706, 2334},
+	}
+	for _, test := range tests {
+		if got := qrRawCodewords(test.version); test.wantRaw != got {
+			t.Errorf("version %v: wanted %v raw codewords, got %v", test.version, test.wantRaw, got)
+		}
+		if got := qrDataCodewords(test.version); test.wantData != got {
+			t.Errorf("version %v: wanted %v data codewords, got %v", test.version, test.wantData, got)
+		}
+	}
+}
+
+func TestQRAlignmentPositions(t *testing.T) {
+	tests := []struct {
+		version int
+		want    []int
+	}{
+		{1, nil},
+		{2, []int{6, 18}},
+		{7, []int{6, 22, 38}},
+		{32, []int{6, 34, 60, 86, 112, 138}},
+		{40, []int{6, 30, 58, 86, 114, 142, 170}},
+	}
+	for _, test := range tests {
+		if got := qrAlignmentPositions(test.version); !reflect.DeepEqual(test.want, got) {
+			t.Errorf("version %v: wanted %v, got %v", test.version, test.want, got)
+		}
+	}
+}
+
+func TestQRFormat(t *testing.T) {
+	want := []string{ // M level
+		"101010000010010",
+		"101000100100101",
+		"101111001111100",
+		"101101101001011",
+		"100010111111001",
+		"100000011001110",
+		"100111110010111",
+		"100101010100000",
+	}
+	q := newTestQRCode(1)
+	for mask, w := range want {
+		q.drawFormat(mask)
+		first, second := q.formatBits()
+		if got := strconv.FormatInt(int64(first), 2); w != got {
+			t.Errorf("mask %v: wanted first format bits of %v, got %v", mask, w, got)
+		}
+		if first != second {
+			t.Errorf("mask %v: format copies not equal: %b, %b", mask, first, second)
+		}
+	}
+	if !q.Dark(8, q.Size-8) {
+		t.Errorf("wanted dark module")
+	}
+}
+
+func TestQRVersionInfo(t *testing.T) {
+	q := newTestQRCode(7)
+	q.drawFunctionPatterns(7)
+	var bits, bits2 int
+	for i := 17; i >= 0; i-- {
+		a, b := q.Size-11+i%3, i/3
+		bits <<= 1
+		bits2 <<= 1
+		if q.Dark(a, b) {
+			bits |= 1
+		}
+		if q.Dark(b, a) {
+			bits2 |= 1
+		}
+	}
+	if want, got := "111110010010100", strconv.FormatInt(int64(bits), 2); want != got { // 000111110010010100
+		t.Errorf("wanted version bits of %v, got %v", want, got)
+	}
+	if bits != bits2 {
+		t.Errorf("version copies not equal: %b, %b", bits, bits2)
+	}
+}
+
+func TestQR(t *testing.T) {
+	t.Run("too long", func(t *testing.T) {
+		if _, err := QR(strings.Repeat("x", 2332)); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	tests := []struct {
+		text        string
+		wantVersion int
+	}{
+		{"", 1},
+		{"https://a.b/c?d", 2},
+		{"https://example.com", 2},
+		{"https://kuuf-library.example.com/book?id=5ff7b2a4e38e0a0b5b2d9c0a", 5},
+		{strings.Repeat("é", 100), 10},
+		{strings.Repeat("x", 213), 10},
+		{strings.Repeat("x", 2331), 40},
+	}
+	for _, test := range tests {
+		q, err := QR(test.text)
+		if err != nil {
+			t.Errorf("QR(%q): unwanted error: %v", test.text, err)
+			continue
+		}
+		if want, got := 4*test.wantVersion+17, q.Size; want != got {
+			t.Errorf("QR(%q): wanted size %v, got %v", test.text, want, got)
+			continue
+		}
+		for _, c := range [][2]int{{3, 3}, {q.Size - 4, 3}, {3, q.Size - 4}} {
+			if !q.Dark(c[0], c[1]) || q.Dark(c[0]+2, c[1]) || !q.Dark(c[0]+3, c[1]) {
+				t.Errorf("QR(%q): missing finder pattern at %v", test.text, c)
+			}
+		}
+		if got := decodeQR(t, q, test.wantVersion); test.text != got {
+			t.Errorf("decoded text not equal: \n wanted: %q \n got:    %q", test.text, got)
+		}
+	}
+}
+
+func TestQRCodeWrite(t *testing.T) {
+	q, err := QR("abc")
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := q.WritePNG(&buf, 2); err != nil {
+		t.Fatalf("writing png: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding png: %v", err)
+	}
+	if want, got := 2*(21+8), img.Bounds().Dx(); want != got {
+		t.Errorf("wanted png width of %v, got %v", want, got)
+	}
+	for _, p := range [][2]int{{0, 0}, {7, 7}} { // quiet zone
+		if r, _, _, _ := img.At(p[0], p[1]).RGBA(); r == 0 {
+			t.Errorf("wanted light pixel at %v", p)
+		}
+	}
+	if r, _, _, _ := img.At(8, 8).RGBA(); r != 0 {
+		t.Errorf("wanted dark pixel at corner of finder pattern")
+	}
+	var sb strings.Builder
+	if err := q.WriteSVG(&sb); err != nil {
+		t.Fatalf("writing svg: %v", err)
+	}
+	for _, want := range []string{`viewBox="0 0 29 29"`, `<rect x="4" y="4" width="7" height="1"/>`} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("wanted %q in svg, got: \n %v", want, sb.String())
+		}
+	}
+}
+
+func newTestQRCode(version int) QRCode {
+	size := 4*version + 17
+	return QRCode{
+		Size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+// formatBits reads both copies of the format bits.
+func (q QRCode) formatBits() (first, second int) {
+	bit := func(x, y, i int, dest *int) {
+		if q.Dark(x, y) {
+			*dest |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		bit(8, i, i, &first)
+	}
+	bit(8, 7, 6, &first)
+	bit(8, 8, 7, &first)
+	bit(7, 8, 8, &first)
+	for i := 9; i < 15; i++ {
+		bit(14-i, 8, i, &first)
+	}
+	for i := 0; i < 8; i++ {
+		bit(q.Size-1-i, 8, i, &second)
+	}
+	for i := 8; i < 15; i++ {
+		bit(8, q.Size-15+i, i, &second)
+	}
+	return first, second
+}
+
+// decodeQR reads the text of the QR code, checking the error correction codewords.
+func decodeQR(t *testing.T, q *QRCode, version int) string {
+	t.Helper()
+	format, _ := q.formatBits()
+	format ^= 0x5412
+	if format>>13 != qrFormatM {
+		t.Fatalf("wanted M error correction level, got %b", format>>13)
+	}
+	mask := format >> 10 & 7
+	p := newTestQRCode(version)
+	p.drawFunctionPatterns(version)
+	copy(p.modules, q.modules)
+	p.applyMask(mask)
+	var bits bitBuffer
+	for right := p.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < p.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = p.Size - 1 - vert
+				}
+				if !p.function[y*p.Size+x] {
+					bit := 0
+					if p.Dark(x, y) {
+						bit = 1
+					}
+					bits.append(bit, 1)
+				}
+			}
+		}
+	}
+	codewords := bits[:8*qrRawCodewords(version)].bytes()
+	numBlocks := qrBlocks[version]
+	ecLen := qrECCodewords[version]
+	blocks := make([][]byte, numBlocks)
+	raw := qrRawCodewords(version)
+	numShortBlocks := numBlocks - raw%numBlocks
+	shortDataLen := raw/numBlocks - ecLen
+	k := 0
+	for i := 0; i < shortDataLen+1; i++ {
+		for j := range blocks {
+			if i < shortDataLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, b...)
+	}
+	divisor := reedSolomonDivisor(ecLen)
+	for i := 0; i < ecLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+	for j, b := range blocks {
+		n := len(b) - ecLen
+		if want, got := b[n:], reedSolomonRemainder(b[:n], divisor); !reflect.DeepEqual(want, got) {
+			t.Errorf("block %v: error correction codewords not equal: \n wanted: %v \n got:    %v", j, want, got)
+		}
+	}
+	var r bitBuffer
+	for _, b := range data {
+		r.append(int(b), 8)
+	}
+	read := func(n int) int {
+		v := 0
+		for _, bit := range r[:n] {
+			v = v<<1 | int(bit)
+		}
+		r = r[n:]
+		return v
+	}
+	if mode := read(4); mode != qrByteMode {
+		t.Fatalf("wanted byte mode, got %b", mode)
+	}
+	n := read(qrCountBits(version))
+	text := make([]byte, n)
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
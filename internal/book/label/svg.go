@@ -0,0 +1,67 @@
+package label
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+type svgCanvas struct {
+	w *bufio.Writer
+}
+
+// pageGap is the space between pages in svg files.
+const pageGap = 0.25 * inch
+
+// WriteSVG draws the labels on the pages of the layout as a svg image.
+// Pages are stacked with a gap between them, so svg files are best for previews and single sheets.
+func WriteSVG(w io.Writer, layout Layout, skip int, labels ...Label) error {
+	if err := layout.Validate(); err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	}
+	pages := layout.pages(skip, labels)
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+	height := float64(len(pages))*(layout.PageHeight+pageGap) - pageGap
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]vpt" height="%[2]vpt" viewBox="0 0 %[1]v %[2]v">`+"\n", num(layout.PageWidth), num(height))
+	c := svgCanvas{bw}
+	for i, page := range pages {
+		fmt.Fprintf(bw, `<g transform="translate(0 %v)" font-family="Helvetica, Arial, sans-serif">`+"\n", num(float64(i)*(layout.PageHeight+pageGap)))
+		fmt.Fprintf(bw, `<rect width="%v" height="%v" fill="white" stroke="lightgray"/>`+"\n", num(layout.PageWidth), num(layout.PageHeight))
+		for _, p := range page {
+			p.draw(c, p.x, p.y, layout.LabelWidth, layout.LabelHeight)
+		}
+		bw.WriteString("</g>\n")
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+func (c svgCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(c.w, `<rect x="%v" y="%v" width="%v" height="%v"/>`+"\n", num(x), num(y), num(w), num(h))
+}
+
+func (c svgCanvas) text(x, y, size float64, s string) {
+	if len(s) == 0 {
+		return
+	}
+	fmt.Fprintf(c.w, `<text x="%v" y="%v" font-size="%v">%v</text>`+"\n", num(x), num(y), num(size), html.EscapeString(s))
+}
+
+// num formats the length with at most two decimal places.
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package label
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	barcode, err := EAN13("9780306406157")
+	if err != nil {
+		t.Fatalf("creating barcode: %v", err)
+	}
+	l := Label{ShelfLocation: "599.8", Author: "DOE", Title: "Lemurs & <Apes>", Barcode: *barcode}
+	layout := Layouts["avery-5160"]
+	t.Run("bad layout", func(t *testing.T) {
+		var sb strings.Builder
+		if err := WriteSVG(&sb, Layout{}, 0, l); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	tests := []struct {
+		name      string
+		labels    []Label
+		wantParts []string
+	}{
+		{
+			name: "empty",
+			wantParts: []string{
+				`width="612pt" height="792pt" viewBox="0 0 612 792"`,
+				`<g transform="translate(0 0)"`,
+			},
+		},
+		{
+			name:   "two pages",
+			labels: []Label{l, l},
+			wantParts: []string{
+				`height="1602pt"`,
+				`<g transform="translate(0 810)"`,
+				`font-size="13.94">599.8</text>`,
+				`DOE Lemurs &amp; &lt;Apes&gt;</text>`,
+				`>9780306406157</text>`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			skip := 0
+			if len(test.labels) != 0 {
+				skip = layout.Columns*layout.Rows - 1
+			}
+			if err := WriteSVG(&sb, layout, skip, test.labels...); err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			got := sb.String()
+			for _, want := range test.wantParts {
+				if !strings.Contains(got, want) {
+					t.Errorf("wanted %q in svg, got: \n %v", want, got)
+				}
+			}
+			d := xml.NewDecoder(strings.NewReader(got))
+			for {
+				_, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("invalid xml: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNum(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want string
+	}{
+		{0, "0"},
+		{-0.001, "0"},
+		{12, "12"},
+		{1.5, "1.5"},
+		{1.256, "1.26"},
+		{100.10, "100.1"},
+	}
+	for _, test := range tests {
+		if got := num(test.f); test.want != got {
+			t.Errorf("num(%v): wanted %q, got %q", test.f, test.want, got)
+		}
+	}
+}
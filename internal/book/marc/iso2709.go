@@ -0,0 +1,203 @@
+package marc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// Encoder writes books as MARC 21 records in the ISO 2709 exchange format.
+	Encoder struct {
+		w io.Writer
+	}
+	// Decoder reads books from MARC 21 records in the ISO 2709 exchange format.
+	Decoder struct {
+		r *bufio.Reader
+	}
+)
+
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+	leaderLength      = 24
+	directoryEntryLen = 12
+	maxRecordLength   = 99999
+)
+
+func NewEncoder(w io.Writer) *Encoder {
+	e := Encoder{
+		w: w,
+	}
+	return &e
+}
+
+// Encode writes the book as a record.
+func (e *Encoder) Encode(b book.Book) error {
+	r := FromBook(b)
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encoding book %q: %w", b.ID, err)
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return fmt.Errorf("writing book %q: %w", b.ID, err)
+	}
+	return nil
+}
+
+// Close does nothing; it exists so the Encoder can be used like the XMLEncoder.
+func (e *Encoder) Close() error {
+	return nil
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	d := Decoder{
+		r: bufio.NewReader(r),
+	}
+	return &d
+}
+
+// Decode reads the next book.
+// It returns io.EOF when there are no more records.
+func (d *Decoder) Decode() (*book.Book, error) {
+	data, err := d.r.ReadBytes(recordTerminator)
+	switch {
+	case err == io.EOF && len(strings.TrimSpace(string(data))) == 0:
+		return nil, io.EOF
+	case err != nil && err != io.EOF:
+		return nil, fmt.Errorf("reading record: %w", err)
+	}
+	var r Record
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	b := r.Book()
+	return &b, nil
+}
+
+// MarshalBinary encodes the record in the ISO 2709 exchange format.
+// The lengths and addresses in the leader and directory are calculated from the fields.
+func (r Record) MarshalBinary() ([]byte, error) {
+	var directory, fields strings.Builder
+	addField := func(tag, data string) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("tag %q is not three characters", tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, len(data), fields.Len())
+		fields.WriteString(data)
+		return nil
+	}
+	for _, f := range r.ControlFields {
+		if err := addField(f.Tag, f.Value+string(rune(fieldTerminator))); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range r.DataFields {
+		var sb strings.Builder
+		sb.WriteString(indicator(f.Ind1))
+		sb.WriteString(indicator(f.Ind2))
+		for _, sf := range f.Subfields {
+			sb.WriteByte(subfieldDelimiter)
+			sb.WriteString(sf.Code)
+			sb.WriteString(sf.Value)
+		}
+		sb.WriteByte(fieldTerminator)
+		if err := addField(f.Tag, sb.String()); err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+	baseAddress := leaderLength + directory.Len()
+	recordLength := baseAddress + fields.Len() + 1
+	if recordLength > maxRecordLength {
+		return nil, fmt.Errorf("record too long: %v bytes", recordLength)
+	}
+	leader := r.Leader
+	if len(leader) != leaderLength {
+		leader = strings.Repeat("0", 5) + leaderType + strings.Repeat("0", 5) + leaderSuffix
+	}
+	leader = fmt.Sprintf("%05d%s%05d%s", recordLength, leader[5:12], baseAddress, leader[17:])
+	data := leader + directory.String() + fields.String() + string(rune(recordTerminator))
+	return []byte(data), nil
+}
+
+// UnmarshalBinary decodes the record from the ISO 2709 exchange format.
+// The record terminator is optional.
+func (r *Record) UnmarshalBinary(data []byte) error {
+	s := strings.TrimLeft(string(data), "\r\n") // some files put records on separate lines
+	if len(s) < leaderLength {
+		return fmt.Errorf("record shorter than leader: %q", s)
+	}
+	r.Leader = s[:leaderLength]
+	baseAddress, err := strconv.Atoi(r.Leader[12:17])
+	if err != nil || baseAddress < leaderLength || baseAddress > len(s) {
+		return fmt.Errorf("invalid base address in leader: %q", r.Leader)
+	}
+	directory := strings.TrimSuffix(s[leaderLength:baseAddress], string(rune(fieldTerminator)))
+	if len(directory)%directoryEntryLen != 0 {
+		return fmt.Errorf("invalid directory length: %v", len(directory))
+	}
+	fields := s[baseAddress:]
+	r.ControlFields = nil
+	r.DataFields = nil
+	for i := 0; i < len(directory); i += directoryEntryLen {
+		entry := directory[i : i+directoryEntryLen]
+		tag := entry[:3]
+		length, err1 := strconv.Atoi(entry[3:7])
+		start, err2 := strconv.Atoi(entry[7:12])
+		if err1 != nil || err2 != nil || start+length > len(fields) {
+			return fmt.Errorf("invalid directory entry: %q", entry)
+		}
+		value := strings.TrimSuffix(fields[start:start+length], string(rune(fieldTerminator)))
+		if strings.HasPrefix(tag, "00") {
+			f := ControlField{
+				Tag:   tag,
+				Value: value,
+			}
+			r.ControlFields = append(r.ControlFields, f)
+			continue
+		}
+		f, err := dataField(tag, value)
+		if err != nil {
+			return err
+		}
+		r.DataFields = append(r.DataFields, f)
+	}
+	return nil
+}
+
+func dataField(tag, value string) (DataField, error) {
+	parts := strings.Split(value, string(rune(subfieldDelimiter)))
+	if len(parts[0]) != 2 {
+		return DataField{}, fmt.Errorf("field %v: wanted two indicators, got %q", tag, parts[0])
+	}
+	f := DataField{
+		Tag:  tag,
+		Ind1: parts[0][:1],
+		Ind2: parts[0][1:],
+	}
+	for _, part := range parts[1:] {
+		if len(part) == 0 {
+			continue
+		}
+		sf := Subfield{
+			Code:  part[:1],
+			Value: part[1:],
+		}
+		f.Subfields = append(f.Subfields, sf)
+	}
+	return f, nil
+}
+
+// indicator is the indicator, or a blank if it is not set.
+func indicator(ind string) string {
+	if len(ind) != 1 {
+		return " "
+	}
+	return ind
+}
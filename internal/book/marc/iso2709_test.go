@@ -0,0 +1,173 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestEncoderDecoderRoundTrip(t *testing.T) {
+	books := []book.Book{
+		testBook,
+		{Header: book.Header{ID: "6", Title: "Lemurs"}, Language: "en", Format: "print", Description: "Ünïcödé"},
+	}
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for _, b := range books {
+		if err := e.Encode(b); err != nil {
+			t.Fatalf("unwanted error encoding: %v", err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("unwanted error closing encoder: %v", err)
+	}
+	d := NewDecoder(&buf)
+	var got []book.Book
+	for {
+		b, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error decoding: %v", err)
+		}
+		got = append(got, *b)
+	}
+	if !reflect.DeepEqual(books, got) {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", books, got)
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	r := Record{
+		ControlFields: []ControlField{{Tag: "001", Value: "5"}},
+		DataFields:    []DataField{{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Zoology"}}}},
+	}
+	want := "00064nam a2200049   4500" +
+		"001000200000" + "245001200002" + "\x1e" +
+		"5\x1e" +
+		"10\x1faZoology\x1e" +
+		"\x1d"
+	got, err := r.MarshalBinary()
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case want != string(got):
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestMarshalBinaryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Record
+	}{
+		{
+			name: "bad tag",
+			r:    Record{ControlFields: []ControlField{{Tag: "1", Value: "5"}}},
+		},
+		{
+			name: "too long",
+			r:    Record{ControlFields: []ControlField{{Tag: "001", Value: strings.Repeat("x", maxRecordLength)}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.r.MarshalBinary(); err == nil {
+				t.Errorf("wanted error")
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	valid := "00064nam a2200049   4500001000200000245001200002\x1e5\x1e10\x1faZoology\x1e\x1d"
+	tests := []struct {
+		name   string
+		data   string
+		want   Record
+		wantOk bool
+	}{
+		{
+			name: "short",
+			data: "00066nam",
+		},
+		{
+			name: "bad base address",
+			data: "00066nam a22000XX   4500",
+		},
+		{
+			name: "bad directory length",
+			data: "00066nam a2200030   450000100020\x1e",
+		},
+		{
+			name: "bad directory entry",
+			data: "00064nam a2200049   4500001000200099245001200002\x1e5\x1e10\x1faZoology\x1e\x1d",
+		},
+		{
+			name: "missing indicators",
+			data: "00058nam a2200049   4500001000200000245000600002\x1e5\x1e\x1faZoo\x1e\x1d",
+		},
+		{
+			name: "valid",
+			data: valid,
+			want: Record{
+				Leader:        valid[:24],
+				ControlFields: []ControlField{{Tag: "001", Value: "5"}},
+				DataFields:    []DataField{{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Zoology"}}}},
+			},
+			wantOk: true,
+		},
+		{
+			name: "valid after newline",
+			data: "\n" + valid,
+			want: Record{
+				Leader:        valid[:24],
+				ControlFields: []ControlField{{Tag: "001", Value: "5"}},
+				DataFields:    []DataField{{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Zoology"}}}},
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got Record
+			err := got.UnmarshalBinary([]byte(test.data))
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDecoderError(t *testing.T) {
+	d := NewDecoder(strings.NewReader("bad record\x1d"))
+	if _, err := d.Decode(); err == nil || err == io.EOF {
+		t.Errorf("wanted decode error, got %v", err)
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("write error")
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	e := NewEncoder(errWriter{})
+	if err := e.Encode(testBook); err == nil {
+		t.Errorf("wanted error")
+	}
+}
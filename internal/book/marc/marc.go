@@ -0,0 +1,319 @@
+// Package marc reads and writes books as MARC 21 bibliographic records, either in the ISO 2709 exchange format or as MARCXML.
+package marc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// Record is a MARC 21 bibliographic record.
+	Record struct {
+		Leader        string         `xml:"leader"`
+		ControlFields []ControlField `xml:"controlfield"`
+		DataFields    []DataField    `xml:"datafield"`
+	}
+	// ControlField is a field with tag 001 to 009 that only has a value.
+	ControlField struct {
+		Tag   string `xml:"tag,attr"`
+		Value string `xml:",chardata"`
+	}
+	// DataField is a field with two indicators and subfields.
+	DataField struct {
+		Tag       string     `xml:"tag,attr"`
+		Ind1      string     `xml:"ind1,attr"`
+		Ind2      string     `xml:"ind2,attr"`
+		Subfields []Subfield `xml:"subfield"`
+	}
+	// Subfield is part of a data field that is identified by a code.
+	Subfield struct {
+		Code  string `xml:"code,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+const (
+	// leaderSuffix is the part of the leader after the length and base address: an undefined encoding level, descriptive cataloging form, and multipart resource level, and the standard entry map.
+	leaderSuffix = "   4500"
+	// leaderType describes a new, monographic record of language material that is encoded in UTF-8 with two character indicators and subfield codes.
+	leaderType = "nam a22"
+	// dateEnteredLayout is the layout of the date the record was entered, the first part of the 008 field.
+	dateEnteredLayout = "060102"
+	// upcLength is the length of the UPC-A codes that some books have instead of isbn-10s.
+	upcLength = 12
+	// fixedLengthData is the rest of the 008 field after the date entered, where "|" means no attempt to code.
+	// The language code is at languageStart.
+	fixedLengthData = "||||||||||||||||||||||||||||||||||"
+	// languageStart is the position of the three letter language code in the 008 field.
+	languageStart = 35
+)
+
+var (
+	firstNumber = regexp.MustCompile(`\d+`)
+	year        = regexp.MustCompile(`\d{4}`)
+	// languageCodes are the MARC codes of common ISO 639-1 languages.
+	// Other languages are recorded with their codes as they are.
+	languageCodes = map[string]string{
+		"ar": "ara",
+		"de": "ger",
+		"en": "eng",
+		"es": "spa",
+		"fr": "fre",
+		"it": "ita",
+		"ja": "jpn",
+		"ko": "kor",
+		"la": "lat",
+		"nl": "dut",
+		"pt": "por",
+		"ru": "rus",
+		"zh": "chi",
+	}
+)
+
+// FromBook creates a record from the book.
+// The publish date is only recorded by year, as is usual for MARC records, and the image is not recorded.
+// The subject and tags are recorded as 650 topical terms.
+// The language is recorded as a MARC code in the 008 field and in the 041 field, and the format is recorded as a 655 form term.
+func FromBook(b book.Book) Record {
+	var r Record
+	r.Leader = strings.Repeat("0", 5) + leaderType + strings.Repeat("0", 5) + leaderSuffix
+	r.addControlField("001", b.ID)
+	language := marcLanguage(b.Language)
+	if !b.AddedDate.IsZero() || len(language) != 0 {
+		r.addControlField("008", fixedLengthField(b.AddedDate, language))
+	}
+	r.addDataField("020", " ", " ", Subfield{"a", b.EanIsbn13})
+	if len(b.UpcIsbn10) == upcLength {
+		r.addDataField("024", "1", " ", Subfield{"a", b.UpcIsbn10}) // 1: Universal Product Code
+	} else {
+		r.addDataField("020", " ", " ", Subfield{"a", b.UpcIsbn10})
+	}
+	r.addDataField("041", "0", " ", Subfield{"a", language})
+	r.addDataField("082", "0", "4", Subfield{"a", b.DeweyDecClass})
+	r.addDataField("100", "1", " ", Subfield{"a", b.Author})
+	r.addDataField("245", "1", "0", Subfield{"a", b.Title})
+	r.addDataField("250", " ", " ", Subfield{"a", b.Edition})
+	var publishYear string
+	if !b.PublishDate.IsZero() {
+		publishYear = strconv.Itoa(b.PublishDate.Year())
+	}
+	r.addDataField("264", " ", "1", Subfield{"b", b.Publisher}, Subfield{"c", publishYear})
+	var pages string
+	if b.Pages > 0 {
+		pages = strconv.Itoa(b.Pages) + " pages"
+	}
+	r.addDataField("300", " ", " ", Subfield{"a", pages})
+	r.addDataField("520", " ", " ", Subfield{"a", b.Description})
+	r.addDataField("521", " ", " ", Subfield{"a", b.ReadingLevel})
+	r.addDataField("650", " ", "4", Subfield{"a", b.Subject})
+	for _, t := range b.Tags {
+		r.addDataField("650", " ", "4", Subfield{"a", t})
+	}
+	r.addDataField("655", " ", "4", Subfield{"a", b.Format})
+	return r
+}
+
+// fixedLengthField creates the 008 field with the date entered and the language, which are not coded if they are empty.
+func fixedLengthField(dateEntered time.Time, language string) string {
+	date := strings.Repeat("|", len(dateEnteredLayout))
+	if !dateEntered.IsZero() {
+		date = dateEntered.Format(dateEnteredLayout)
+	}
+	f := date + fixedLengthData
+	if len(language) == 3 {
+		f = f[:languageStart] + language + f[languageStart+len(language):]
+	}
+	return f
+}
+
+// marcLanguage is the MARC code of the ISO 639-1 language.
+func marcLanguage(language string) string {
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	return language
+}
+
+// bookLanguage is the ISO 639-1 language of the MARC code.
+func bookLanguage(code string) string {
+	code = strings.ToLower(code)
+	for language, c := range languageCodes {
+		if c == code {
+			return language
+		}
+	}
+	return code
+}
+
+// addControlField adds the field if the value is not empty.
+func (r *Record) addControlField(tag, value string) {
+	if len(value) == 0 {
+		return
+	}
+	f := ControlField{
+		Tag:   tag,
+		Value: value,
+	}
+	r.ControlFields = append(r.ControlFields, f)
+}
+
+// addDataField adds the field with the subfields that are not empty if any are not empty.
+func (r *Record) addDataField(tag, ind1, ind2 string, subfields ...Subfield) {
+	f := DataField{
+		Tag:  tag,
+		Ind1: ind1,
+		Ind2: ind2,
+	}
+	for _, sf := range subfields {
+		if len(sf.Value) != 0 {
+			f.Subfields = append(f.Subfields, sf)
+		}
+	}
+	if len(f.Subfields) != 0 {
+		r.DataFields = append(r.DataFields, f)
+	}
+}
+
+// Book creates a book from the record.
+// Fields that are not recognized are ignored, as is ISBD punctuation at the end of subfields.
+// Records without an 001 control number create books without ids.
+// The first 650 topical term is the subject and the others are tags.
+// Books have the default language and format if their records do not have them.
+func (r Record) Book() book.Book {
+	var b book.Book
+	b.ID = r.controlField("001")
+	var language string
+	if f := r.controlField("008"); len(f) >= len(dateEnteredLayout) {
+		if t, err := time.Parse(dateEnteredLayout, f[:len(dateEnteredLayout)]); err == nil {
+			b.AddedDate = t
+		}
+		if len(f) >= languageStart+3 {
+			language = strings.Trim(f[languageStart:languageStart+3], " |")
+		}
+	}
+	if code := r.subfield("041", "a"); len(code) != 0 {
+		language = code
+	}
+	b.Language = book.NormalizeLanguage(bookLanguage(language))
+	for _, f := range r.fields("020") {
+		isbn := isbnDigits(f.subfield("a"))
+		switch len(isbn) {
+		case 13:
+			b.EanIsbn13 = isbn
+		case 10:
+			b.UpcIsbn10 = isbn
+		}
+	}
+	for _, f := range r.fields("024") {
+		if upc := isbnDigits(f.subfield("a")); f.Ind1 == "1" && len(upc) == upcLength && len(b.UpcIsbn10) == 0 {
+			b.UpcIsbn10 = upc
+		}
+	}
+	b.DeweyDecClass = r.subfield("082", "a")
+	b.Author = r.subfield("100", "a")
+	b.Title = r.subfield("245", "a")
+	if subtitle := r.subfield("245", "b"); len(subtitle) != 0 {
+		b.Title += ": " + subtitle
+	}
+	b.Edition = r.subfield("250", "a")
+	for _, tag := range []string{"264", "260"} {
+		if len(b.Publisher) == 0 {
+			b.Publisher = r.subfield(tag, "b")
+		}
+		if b.PublishDate.IsZero() {
+			if y := year.FindString(r.subfield(tag, "c")); len(y) != 0 {
+				yyyy, _ := strconv.Atoi(y) // the regexp only matches digits
+				b.PublishDate = time.Date(yyyy, 1, 1, 0, 0, 0, 0, time.UTC)
+			}
+		}
+	}
+	if n := firstNumber.FindString(r.subfield("300", "a")); len(n) != 0 {
+		b.Pages, _ = strconv.Atoi(n) // the regexp only matches digits
+	}
+	if f, ok := r.field("520"); ok {
+		b.Description = strings.TrimSpace(f.subfield("a")) // keep the sentence punctuation
+	}
+	b.Subject = r.subfield("650", "a")
+	var tags []string
+	for _, f := range r.fields("650") {
+		tags = append(tags, trimPunctuation(f.subfield("a")))
+	}
+	b.Tags = book.NormalizeTags(b.Subject, tags)
+	b.Format = book.DefaultFormat
+	for _, f := range r.fields("655") {
+		if format, err := book.ParseFormat(trimPunctuation(f.subfield("a"))); err == nil {
+			b.Format = format
+			break
+		}
+	}
+	if level, err := book.ParseReadingLevel(r.subfield("521", "a")); err == nil {
+		b.ReadingLevel = level
+	}
+	return b
+}
+
+func (r Record) controlField(tag string) string {
+	for _, f := range r.ControlFields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func (r Record) fields(tag string) []DataField {
+	var fields []DataField
+	for _, f := range r.DataFields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// field is the first field with the tag.
+func (r Record) field(tag string) (DataField, bool) {
+	for _, f := range r.DataFields {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+	return DataField{}, false
+}
+
+// subfield is the value of the subfield in the first field with the tag, without ISBD punctuation.
+func (r Record) subfield(tag, code string) string {
+	f, _ := r.field(tag)
+	return trimPunctuation(f.subfield(code))
+}
+
+func (f DataField) subfield(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// trimPunctuation removes the ISBD punctuation that separates subfields, such as the " /" at the end of titles before the statement of responsibility.
+func trimPunctuation(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, " /:;,=")
+	if strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "..") {
+		s = strings.TrimSuffix(s, ".")
+	}
+	return strings.TrimSpace(s)
+}
+
+// isbnDigits is the first word of the isbn without hyphens, without qualifiers such as "(pbk.)".
+func isbnDigits(isbn string) string {
+	if fields := strings.Fields(isbn); len(fields) != 0 {
+		isbn = fields[0]
+	}
+	return strings.ReplaceAll(isbn, "-", "")
+}
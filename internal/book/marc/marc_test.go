@@ -0,0 +1,209 @@
+package marc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// testBook has every field that is recorded, with the publish date at the start of the year.
+var testBook = book.Book{
+	Header: book.Header{
+		ID:      "5",
+		Title:   "Zoology",
+		Author:  "Boas",
+		Subject: "Animals",
+	},
+	Tags:          []string{"Lemurs", "Zoology"},
+	Language:      "fr",
+	Edition:       "2nd",
+	Format:        book.FormatHardcover,
+	ReadingLevel:  "young adult",
+	Description:   "About animals.",
+	DeweyDecClass: "590",
+	Pages:         100,
+	Publisher:     "Lemur Press",
+	PublishDate:   time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+	AddedDate:     time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC),
+	EanIsbn13:     "9780000000002",
+	UpcIsbn10:     "0000000000",
+}
+
+func TestFromBookRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		b    book.Book
+		want book.Book
+	}{
+		{
+			name: "empty",
+			want: book.Book{
+				Language: book.DefaultLanguage,
+				Format:   book.DefaultFormat,
+			},
+		},
+		{
+			name: "all fields",
+			b:    testBook,
+			want: testBook,
+		},
+		{
+			name: "upc-a",
+			b:    book.Book{UpcIsbn10: "036000291452"},
+			want: book.Book{UpcIsbn10: "036000291452", Language: book.DefaultLanguage, Format: book.DefaultFormat},
+		},
+		{
+			name: "language without marc code",
+			b:    book.Book{Language: "esperanto", Format: book.FormatAudiobook},
+			want: book.Book{Language: "esperanto", Format: book.FormatAudiobook},
+		},
+		{
+			name: "publish date only has year, image not recorded",
+			b: book.Book{
+				Header: book.Header{
+					Title: "Secrets",
+				},
+				PublishDate: time.Date(2001, 5, 6, 0, 0, 0, 0, time.UTC),
+				ImageBase64: "AAAA",
+			},
+			want: book.Book{
+				Header: book.Header{
+					Title: "Secrets",
+				},
+				Language:    book.DefaultLanguage,
+				Format:      book.DefaultFormat,
+				PublishDate: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := FromBook(test.b)
+			if got := r.Book(); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFromBookFields(t *testing.T) {
+	r := FromBook(testBook)
+	var tags []string
+	for _, f := range r.ControlFields {
+		tags = append(tags, f.Tag)
+	}
+	for _, f := range r.DataFields {
+		tags = append(tags, f.Tag)
+	}
+	want := []string{"001", "008", "020", "020", "041", "082", "100", "245", "250", "264", "300", "520", "521", "650", "650", "650", "655"}
+	if !reflect.DeepEqual(want, tags) {
+		t.Errorf("tags not equal: \n wanted: %q \n got:    %q", want, tags)
+	}
+	if want, got := 24, len(r.Leader); want != got {
+		t.Errorf("wanted leader length of %v, got %v: %q", want, got, r.Leader)
+	}
+	if want, got := 40, len(r.controlField("008")); want != got {
+		t.Errorf("wanted 008 field length of %v, got %v", want, got)
+	}
+	if want, got := "fre", r.controlField("008")[languageStart:languageStart+3]; want != got {
+		t.Errorf("wanted 008 language of %q, got %q", want, got)
+	}
+}
+
+func TestRecordBook(t *testing.T) {
+	r := Record{
+		ControlFields: []ControlField{
+			{Tag: "001", Value: "ocm123"},
+			{Tag: "008", Value: "bad"},
+		},
+		DataFields: []DataField{
+			{Tag: "020", Subfields: []Subfield{{Code: "a", Value: "0-306-40615-2 (pbk.)"}}},
+			{Tag: "100", Subfields: []Subfield{{Code: "a", Value: "Tolkien, J. R. R.,"}}},
+			{Tag: "245", Subfields: []Subfield{{Code: "a", Value: "The hobbit :"}, {Code: "b", Value: "or, There and back again /"}, {Code: "c", Value: "J.R.R. Tolkien."}}},
+			{Tag: "260", Subfields: []Subfield{{Code: "a", Value: "Boston :"}, {Code: "b", Value: "Houghton Mifflin,"}, {Code: "c", Value: "c1966."}}},
+			{Tag: "300", Subfields: []Subfield{{Code: "a", Value: "xii, 317 p. :"}}},
+			{Tag: "520", Subfields: []Subfield{{Code: "a", Value: " A hobbit goes on an adventure... "}}},
+			{Tag: "650", Subfields: []Subfield{{Code: "a", Value: "Fantasy fiction."}}},
+			{Tag: "650", Subfields: []Subfield{{Code: "a", Value: "Dragons."}}},
+		},
+	}
+	want := book.Book{
+		Header: book.Header{
+			ID:      "ocm123",
+			Title:   "The hobbit: or, There and back again",
+			Author:  "Tolkien, J. R. R",
+			Subject: "Fantasy fiction",
+		},
+		Tags:        []string{"Dragons"},
+		Language:    book.DefaultLanguage,
+		Format:      book.DefaultFormat,
+		Description: "A hobbit goes on an adventure...",
+		Pages:       317,
+		Publisher:   "Houghton Mifflin",
+		PublishDate: time.Date(1966, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpcIsbn10:   "0306406152",
+	}
+	if got := r.Book(); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestRecordBookLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Record
+		want string
+	}{
+		{
+			name: "default",
+			want: book.DefaultLanguage,
+		},
+		{
+			name: "008 not coded",
+			r:    Record{ControlFields: []ControlField{{Tag: "008", Value: "220304" + fixedLengthData}}},
+			want: book.DefaultLanguage,
+		},
+		{
+			name: "008",
+			r:    Record{ControlFields: []ControlField{{Tag: "008", Value: "220304s1999    xx            000 0 spa d"}}},
+			want: "es",
+		},
+		{
+			name: "041 preferred",
+			r: Record{
+				ControlFields: []ControlField{{Tag: "008", Value: "220304s1999    xx            000 0 spa d"}},
+				DataFields:    []DataField{{Tag: "041", Subfields: []Subfield{{Code: "a", Value: "GER"}}}},
+			},
+			want: "de",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if want, got := test.want, test.r.Book().Language; want != got {
+				t.Errorf("wanted %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestTrimPunctuation(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"Title /", "Title"},
+		{"Boston :", "Boston"},
+		{"Publisher,", "Publisher"},
+		{"Subject.", "Subject"},
+		{"Wait...", "Wait..."},
+		{" spaces ", "spaces"},
+	}
+	for _, test := range tests {
+		if want, got := test.want, trimPunctuation(test.s); want != got {
+			t.Errorf("trimPunctuation(%q): wanted %q, got %q", test.s, want, got)
+		}
+	}
+}
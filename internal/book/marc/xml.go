@@ -0,0 +1,117 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// XMLEncoder writes books as MARCXML records in a collection.
+	XMLEncoder struct {
+		w       io.Writer
+		e       *xml.Encoder
+		started bool
+	}
+	// XMLDecoder reads books from MARCXML records, either in a collection or as a single record.
+	XMLDecoder struct {
+		d *xml.Decoder
+	}
+)
+
+// Namespace is the MARCXML namespace.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+func NewXMLEncoder(w io.Writer) *XMLEncoder {
+	e := XMLEncoder{
+		w: w,
+		e: xml.NewEncoder(w),
+	}
+	e.e.Indent("", "  ")
+	return &e
+}
+
+// Encode writes the book as a record, starting the collection before the first record.
+func (e *XMLEncoder) Encode(b book.Book) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	r := FromBook(b)
+	start := xml.StartElement{
+		Name: xml.Name{Local: "record"},
+	}
+	if err := e.e.EncodeElement(r, start); err != nil {
+		return fmt.Errorf("writing book %q: %w", b.ID, err)
+	}
+	return nil
+}
+
+// Close ends the collection.
+func (e *XMLEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	end := xml.EndElement{
+		Name: xml.Name{Local: "collection"},
+	}
+	if err := e.e.EncodeToken(end); err != nil {
+		return fmt.Errorf("ending collection: %w", err)
+	}
+	if err := e.e.Flush(); err != nil {
+		return fmt.Errorf("ending collection: %w", err)
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+func (e *XMLEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return fmt.Errorf("writing xml header: %w", err)
+	}
+	start := xml.StartElement{
+		Name: xml.Name{Local: "collection"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}},
+	}
+	if err := e.e.EncodeToken(start); err != nil {
+		return fmt.Errorf("starting collection: %w", err)
+	}
+	return nil
+}
+
+func NewXMLDecoder(r io.Reader) *XMLDecoder {
+	d := XMLDecoder{
+		d: xml.NewDecoder(r),
+	}
+	return &d
+}
+
+// Decode reads the next book.
+// It returns io.EOF when there are no more records.
+// Records do not need to be in the MARCXML namespace.
+func (d *XMLDecoder) Decode() (*book.Book, error) {
+	for {
+		t, err := d.d.Token()
+		switch {
+		case err == io.EOF:
+			return nil, io.EOF
+		case err != nil:
+			return nil, fmt.Errorf("reading xml: %w", err)
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var r Record
+		if err := d.d.DecodeElement(&r, &start); err != nil {
+			return nil, fmt.Errorf("reading record: %w", err)
+		}
+		b := r.Book()
+		return &b, nil
+	}
+}
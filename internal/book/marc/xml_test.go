@@ -0,0 +1,139 @@
+package marc
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestXMLEncoder(t *testing.T) {
+	b := book.Book{
+		Header: book.Header{
+			ID:    "5",
+			Title: "Fish & Chips",
+		},
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000nam a2200000   4500</leader>
+    <controlfield tag="001">5</controlfield>
+    <datafield tag="245" ind1="1" ind2="0">
+      <subfield code="a">Fish &amp; Chips</subfield>
+    </datafield>
+  </record>
+</collection>
+`
+	var sb strings.Builder
+	e := NewXMLEncoder(&sb)
+	if err := e.Encode(b); err != nil {
+		t.Fatalf("unwanted error encoding: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("unwanted error closing: %v", err)
+	}
+	if got := sb.String(); want != got {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestXMLEncoderEmpty(t *testing.T) {
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim"></collection>
+`
+	var sb strings.Builder
+	e := NewXMLEncoder(&sb)
+	if err := e.Close(); err != nil {
+		t.Fatalf("unwanted error closing: %v", err)
+	}
+	if got := sb.String(); want != got {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestXMLDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		xml    string
+		want   []book.Book
+		wantOk bool
+	}{
+		{
+			name:   "empty",
+			wantOk: true,
+		},
+		{
+			name: "invalid xml",
+			xml:  `<collection><record>`,
+		},
+		{
+			name: "single record",
+			xml: `<record xmlns="http://www.loc.gov/MARC21/slim">
+<controlfield tag="001">5</controlfield>
+<datafield tag="245" ind1="1" ind2="0"><subfield code="a">Zoology /</subfield></datafield>
+</record>`,
+			want:   []book.Book{{Header: book.Header{ID: "5", Title: "Zoology"}, Language: "en", Format: "print"}},
+			wantOk: true,
+		},
+		{
+			name: "collection with prefix",
+			xml: `<?xml version="1.0"?>
+<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+<marc:record><marc:datafield tag="100" ind1="1" ind2=" "><marc:subfield code="a">Boas</marc:subfield></marc:datafield></marc:record>
+<marc:record><marc:datafield tag="300" ind1=" " ind2=" "><marc:subfield code="a">100 p.</marc:subfield></marc:datafield></marc:record>
+</marc:collection>`,
+			want: []book.Book{
+				{Header: book.Header{Author: "Boas"}, Language: "en", Format: "print"},
+				{Language: "en", Format: "print", Pages: 100},
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewXMLDecoder(strings.NewReader(test.xml))
+			var got []book.Book
+			var err error
+			for {
+				var b *book.Book
+				b, err = d.Decode()
+				if err != nil {
+					break
+				}
+				got = append(got, *b)
+			}
+			switch {
+			case !test.wantOk:
+				if err == io.EOF {
+					t.Errorf("wanted error")
+				}
+			case err != io.EOF:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	var sb strings.Builder
+	e := NewXMLEncoder(&sb)
+	if err := e.Encode(testBook); err != nil {
+		t.Fatalf("unwanted error encoding: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("unwanted error closing: %v", err)
+	}
+	d := NewXMLDecoder(strings.NewReader(sb.String()))
+	got, err := d.Decode()
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(testBook, *got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", testBook, *got)
+	}
+}
@@ -0,0 +1,386 @@
+// Package openlibrary looks up book metadata by isbn in a local Open Library data dump, so books can be cataloged without network access.
+package openlibrary
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
+	"go.etcd.io/bbolt"
+)
+
+type (
+	// Dump is book metadata from an Open Library dump, imported into a Bolt file that is indexed by isbn.
+	// It is safe for concurrent use.
+	Dump struct {
+		db *bbolt.DB
+	}
+	// record is an edition or author in a dump.
+	record struct {
+		Type              key             `json:"type"`
+		Key               string          `json:"key"`
+		Name              string          `json:"name"`
+		Title             string          `json:"title"`
+		Subtitle          string          `json:"subtitle"`
+		Authors           []key           `json:"authors"`
+		ByStatement       string          `json:"by_statement"`
+		Publishers        []string        `json:"publishers"`
+		PublishDate       string          `json:"publish_date"`
+		NumberOfPages     int             `json:"number_of_pages"`
+		Subjects          []string        `json:"subjects"`
+		DeweyDecimalClass []string        `json:"dewey_decimal_class"`
+		Description       json.RawMessage `json:"description"`
+		Isbn13            []string        `json:"isbn_13"`
+		Isbn10            []string        `json:"isbn_10"`
+	}
+	key struct {
+		Key string `json:"key"`
+	}
+	// text is a description, which is either a string or an object with the string as the value.
+	text struct {
+		Value string `json:"value"`
+	}
+)
+
+const (
+	editionType = "/type/edition"
+	authorType  = "/type/author"
+	// maxLineSize is the size of the longest record that can be read.
+	maxLineSize = 16 << 20
+	// batchSize is the number of records that are imported in each transaction.
+	batchSize = 10_000
+	// openTimeout is how long to wait for other servers that have the index open.
+	openTimeout = 1 * time.Second
+)
+
+var (
+	// editionsBucket maps normalized isbns to edition records.
+	editionsBucket = []byte("editions")
+	// authorsBucket maps author keys to names.
+	authorsBucket = []byte("authors")
+	// infoBucket has the isbn count, which is set when the import is done.
+	infoBucket = []byte("info")
+	isbnsKey   = []byte("isbns")
+)
+
+// publishDateLayouts are the common formats of edition publish dates.
+var publishDateLayouts = []string{
+	"2006",
+	"January 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2006-01-02",
+	"2006-01",
+}
+
+// OpenDump opens the index of the dump file, which must have been imported by ImportDumpFile.
+// Importing takes a long time for the full dumps, so it is not done when opening the index.
+// The stale flag is set if the index is older than the dump, which should be imported again.
+func OpenDump(dumpPath, indexPath string) (d *Dump, stale bool, err error) {
+	dumpInfo, err := os.Stat(dumpPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking dump: %w", err)
+	}
+	indexInfo, err := os.Stat(indexPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, false, fmt.Errorf("index %q has not been imported: %w", indexPath, err)
+	case err != nil:
+		return nil, false, fmt.Errorf("checking index: %w", err)
+	}
+	if d, err = openIndex(indexPath); err != nil {
+		return nil, false, err
+	}
+	return d, indexInfo.ModTime().Before(dumpInfo.ModTime()), nil
+}
+
+// ImportDumpFile imports the dump into the index file.
+// The dump may be gzipped like the files Open Library publishes.
+// The dump is imported into a temporary file that replaces the index when it is complete, so partial imports are not used.
+// Temporary files that are left by imports that were stopped are replaced.
+func ImportDumpFile(dumpPath, indexPath string) error {
+	f, err := os.Open(dumpPath)
+	if err != nil {
+		return fmt.Errorf("opening dump: %w", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(dumpPath, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("reading gzipped dump: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+	tmpPath := indexPath + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing partial index: %w", err)
+	}
+	db, err := bbolt.Open(tmpPath, 0o600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("creating index: %w", err)
+	}
+	if err := ImportDump(db, r); err != nil {
+		db.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("closing index: %w", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return fmt.Errorf("replacing index: %w", err)
+	}
+	return nil
+}
+
+func openIndex(path string) (*Dump, error) {
+	opts := bbolt.Options{
+		Timeout:  openTimeout,
+		ReadOnly: true,
+	}
+	db, err := bbolt.Open(path, 0o600, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening index: %w", err)
+	}
+	d := Dump{
+		db: db,
+	}
+	if err := db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket(infoBucket); b == nil || b.Get(isbnsKey) == nil {
+			return fmt.Errorf("index %q is incomplete, delete it to import the dump again", path)
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ImportDump streams the editions and authors in the dump into the buckets of the database.
+// Each line is a record; the tab-separated columns of full dumps are allowed, in which case the json is in the last column.
+// Other records and editions without isbns are skipped.
+// The first edition with each isbn is kept.
+func ImportDump(db *bbolt.DB, r io.Reader) error {
+	var isbns uint64
+	tx, err := db.Begin(true)
+	if err != nil {
+		return fmt.Errorf("beginning import: %w", err)
+	}
+	defer func() { tx.Rollback() }() // the transaction is replaced after each batch
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; sc.Scan(); line++ {
+		data := sc.Bytes()
+		if i := bytes.LastIndexByte(data, '\t'); i >= 0 {
+			data = data[i+1:]
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("decoding record on line %v: %w", line, err)
+		}
+		n, err := rec.put(tx)
+		if err != nil {
+			return fmt.Errorf("importing record on line %v: %w", line, err)
+		}
+		isbns += n
+		if line%batchSize == 0 {
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("committing records through line %v: %w", line, err)
+			}
+			if tx, err = db.Begin(true); err != nil {
+				return fmt.Errorf("beginning import: %w", err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading dump: %w", err)
+	}
+	b, err := tx.CreateBucketIfNotExists(infoBucket)
+	if err != nil {
+		return fmt.Errorf("creating info bucket: %w", err)
+	}
+	if err := b.Put(isbnsKey, binary.BigEndian.AppendUint64(nil, isbns)); err != nil {
+		return fmt.Errorf("saving isbn count: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing import: %w", err)
+	}
+	return nil
+}
+
+// put saves the author or edition, returning the number of new isbns.
+// Authors can be after their editions, so they are resolved when books are looked up.
+func (rec record) put(tx *bbolt.Tx) (uint64, error) {
+	switch rec.Type.Key {
+	case authorType:
+		b, err := tx.CreateBucketIfNotExists(authorsBucket)
+		if err != nil {
+			return 0, err
+		}
+		return 0, b.Put([]byte(rec.Key), []byte(rec.Name))
+	case editionType:
+		if len(rec.Isbn13) == 0 && len(rec.Isbn10) == 0 {
+			return 0, nil
+		}
+		b, err := tx.CreateBucketIfNotExists(editionsBucket)
+		if err != nil {
+			return 0, err
+		}
+		value, err := json.Marshal(rec)
+		if err != nil {
+			return 0, err
+		}
+		var n uint64
+		for _, v := range append(rec.Isbn13, rec.Isbn10...) {
+			k := []byte(isbn.Normalize(v))
+			if len(k) == 0 || b.Get(k) != nil { // keep the first edition
+				continue
+			}
+			if err := b.Put(k, value); err != nil {
+				return 0, err
+			}
+			n++
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// Close closes the index.
+func (d *Dump) Close() error {
+	return d.db.Close()
+}
+
+// Len is the number of isbns in the dump.
+func (d *Dump) Len() int {
+	var n int
+	d.db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket(infoBucket); b != nil {
+			if v := b.Get(isbnsKey); len(v) == 8 {
+				n = int(binary.BigEndian.Uint64(v))
+			}
+		}
+		return nil
+	})
+	return n
+}
+
+// Lookup finds the metadata of the book with the isbn.
+// The book does not have an id, added date, or image.
+func (d *Dump) Lookup(ctx context.Context, isbn string) (*book.Book, error) {
+	var b book.Book
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		editions := tx.Bucket(editionsBucket)
+		if editions == nil {
+			return book.ISBNNotFoundError(isbn)
+		}
+		value := editions.Get([]byte(isbn))
+		if value == nil {
+			return book.ISBNNotFoundError(isbn)
+		}
+		var rec record
+		if err := json.Unmarshal(value, &rec); err != nil {
+			return fmt.Errorf("decoding edition: %w", err)
+		}
+		authors := make(map[string]string, len(rec.Authors))
+		if a := tx.Bucket(authorsBucket); a != nil {
+			for _, k := range rec.Authors {
+				if name := a.Get([]byte(k.Key)); name != nil {
+					authors[k.Key] = string(name)
+				}
+			}
+		}
+		b = rec.Book(authors)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Book converts the edition to a book, using the names of the authors from the dump.
+func (rec record) Book(authors map[string]string) book.Book {
+	title := rec.Title
+	if len(rec.Subtitle) != 0 {
+		title += ": " + rec.Subtitle
+	}
+	var names []string
+	for _, a := range rec.Authors {
+		if name, ok := authors[a.Key]; ok && len(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	author := strings.Join(names, ", ")
+	if len(author) == 0 {
+		author = strings.TrimSuffix(strings.TrimSpace(rec.ByStatement), ".")
+	}
+	b := book.Book{
+		Header: book.Header{
+			Title:   title,
+			Author:  author,
+			Subject: first(rec.Subjects),
+		},
+		Description:   rec.description(),
+		DeweyDecClass: first(rec.DeweyDecimalClass),
+		Pages:         rec.NumberOfPages,
+		Publisher:     first(rec.Publishers),
+		PublishDate:   parsePublishDate(rec.PublishDate),
+	}
+	isbn13, isbn10, err := isbn.Pair(first(rec.Isbn13), first(rec.Isbn10))
+	if err == nil { // invalid isbns are left for the admin to fix
+		b.EanIsbn13, b.UpcIsbn10 = isbn13, isbn10
+	}
+	return b
+}
+
+func (rec record) description() string {
+	if len(rec.Description) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(rec.Description, &s); err == nil {
+		return s
+	}
+	var t text
+	if err := json.Unmarshal(rec.Description, &t); err == nil {
+		return t.Value
+	}
+	return ""
+}
+
+func parsePublishDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range publishDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func first(a []string) string {
+	if len(a) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(a[0])
+}
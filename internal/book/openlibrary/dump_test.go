@@ -0,0 +1,240 @@
+package openlibrary
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"go.etcd.io/bbolt"
+)
+
+const testDump = `/type/edition	/books/OL1M	3	2010-04-14T02:53:29.176614	{"type": {"key": "/type/edition"}, "key": "/books/OL1M", "title": "Lemurs", "subtitle": "a natural history", "authors": [{"key": "/authors/OL1A"}, {"key": "/authors/OL2A"}], "publishers": ["Plenum Press"], "publish_date": "March 3, 1999", "number_of_pages": 312, "subjects": ["Primates", "Madagascar"], "dewey_decimal_class": ["599.8"], "description": {"type": "/type/text", "value": "All about lemurs."}, "isbn_13": ["978-0-306-40615-7"], "isbn_10": ["0306406152"]}
+/type/edition	/books/OL2M	1	2010-04-14T02:53:29.176614	{"type": {"key": "/type/edition"}, "key": "/books/OL2M", "title": "Zebras", "by_statement": "by Ann Smith.", "publish_date": "1985", "description": "Stripes.", "isbn_10": ["0-8044-2957-x"]}
+/type/edition	/books/OL3M	1	2010-04-14T02:53:29.176614	{"type": {"key": "/type/edition"}, "key": "/books/OL3M", "title": "No isbn"}
+/type/work	/works/OL1W	1	2010-04-14T02:53:29.176614	{"type": {"key": "/type/work"}, "key": "/works/OL1W", "title": "Lemurs"}
+/type/author	/authors/OL1A	1	2010-04-14T02:53:29.176614	{"type": {"key": "/type/author"}, "key": "/authors/OL1A", "name": "Jane Doe"}
+{"type": {"key": "/type/author"}, "key": "/authors/OL2A", "name": "John Roe"}
+`
+
+func openTestIndex(t *testing.T) *bbolt.DB {
+	t.Helper()
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "index.db"), 0o600, nil)
+	if err != nil {
+		t.Fatalf("opening index: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestImportDump(t *testing.T) {
+	t.Run("bad json", func(t *testing.T) {
+		db := openTestIndex(t)
+		r := strings.NewReader("/type/edition\t/books/OL1M\t1\t2010\t{bad")
+		if err := ImportDump(db, r); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		db := openTestIndex(t)
+		if err := ImportDump(db, strings.NewReader("")); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		d := Dump{db: db}
+		if got := d.Len(); got != 0 {
+			t.Errorf("wanted no isbns, got %v", got)
+		}
+		if _, err := d.Lookup(context.Background(), "0306406152"); !errors.Is(err, book.ErrNotFound) {
+			t.Errorf("wanted ErrNotFound, got %v", err)
+		}
+	})
+	db := openTestIndex(t)
+	r := strings.NewReader(testDump + testDump) // the first edition of each isbn is kept
+	if err := ImportDump(db, r); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	d := Dump{db: db}
+	if want, got := 3, d.Len(); want != got {
+		t.Errorf("wanted %v isbns, got %v", want, got)
+	}
+	lemurs := book.Book{
+		Header:        book.Header{Title: "Lemurs: a natural history", Author: "Jane Doe, John Roe", Subject: "Primates"},
+		Description:   "All about lemurs.",
+		DeweyDecClass: "599.8",
+		Pages:         312,
+		Publisher:     "Plenum Press",
+		PublishDate:   time.Date(1999, 3, 3, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:     "9780306406157",
+		UpcIsbn10:     "0306406152",
+	}
+	zebras := book.Book{
+		Header:      book.Header{Title: "Zebras", Author: "by Ann Smith"},
+		Description: "Stripes.",
+		PublishDate: time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:   "9780804429573",
+		UpcIsbn10:   "080442957X",
+	}
+	tests := []struct {
+		isbn   string
+		wantOk bool
+		want   book.Book
+	}{
+		{"9780306406157", true, lemurs},
+		{"0306406152", true, lemurs},
+		{"080442957X", true, zebras},
+		{"9780804429573", false, book.Book{}}, // only the isbn-10 is in the dump
+		{"", false, book.Book{}},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		got, err := d.Lookup(ctx, test.isbn)
+		switch {
+		case !test.wantOk:
+			if !errors.Is(err, book.ErrNotFound) {
+				t.Errorf("Lookup(%q): wanted ErrNotFound, got %v", test.isbn, err)
+			}
+		case err != nil:
+			t.Errorf("Lookup(%q): unwanted error: %v", test.isbn, err)
+		case !reflect.DeepEqual(test.want, *got):
+			t.Errorf("Lookup(%q): not equal: \n wanted: %+v \n got:    %+v", test.isbn, test.want, *got)
+		}
+	}
+}
+
+func TestImportDumpFile(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "editions.txt")
+	if err := os.WriteFile(plain, []byte(testDump), 0o600); err != nil {
+		t.Fatalf("writing dump: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plain.gz"), []byte(testDump), 0o600); err != nil {
+		t.Fatalf("writing dump: %v", err)
+	}
+	gzipped := filepath.Join(dir, "editions.txt.gz")
+	f, err := os.Create(gzipped)
+	if err != nil {
+		t.Fatalf("creating gzipped dump: %v", err)
+	}
+	gw := gzip.NewWriter(f)
+	gw.Write([]byte(testDump))
+	gw.Close()
+	f.Close()
+	tests := []struct {
+		name        string
+		dumpPath    string
+		leftoverTmp bool
+		wantOk      bool
+	}{
+		{name: "missing", dumpPath: filepath.Join(dir, "missing.txt")},
+		{name: "not gzipped", dumpPath: filepath.Join(dir, "plain.gz")},
+		{name: "plain", dumpPath: plain, wantOk: true},
+		{name: "gzipped", dumpPath: gzipped, wantOk: true},
+		{name: "leftover partial index", dumpPath: plain, leftoverTmp: true, wantOk: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexPath := filepath.Join(t.TempDir(), "index.db")
+			if test.leftoverTmp {
+				if err := os.WriteFile(indexPath+".tmp", []byte("not a bolt file"), 0o600); err != nil {
+					t.Fatalf("writing partial index: %v", err)
+				}
+			}
+			err := ImportDumpFile(test.dumpPath, indexPath)
+			if _, err := os.Stat(indexPath + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("wanted partial index to be removed, got %v", err)
+			}
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("unwanted error: %v", err)
+			}
+			d, stale, err := OpenDump(test.dumpPath, indexPath)
+			switch {
+			case err != nil:
+				t.Fatalf("unwanted error opening dump: %v", err)
+			case stale:
+				t.Errorf("wanted imported index to not be stale")
+			case d.Len() != 3:
+				t.Errorf("wanted 3 isbns, got %v", d.Len())
+			}
+			d.Close()
+		})
+	}
+}
+
+func TestOpenDump(t *testing.T) {
+	dumpPath := filepath.Join(t.TempDir(), "editions.txt")
+	if err := os.WriteFile(dumpPath, []byte(testDump), 0o600); err != nil {
+		t.Fatalf("writing dump: %v", err)
+	}
+	t.Run("not imported", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.db")
+		if _, _, err := OpenDump(dumpPath, indexPath); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted not exist error, got %v", err)
+		}
+		if _, err := os.Stat(indexPath); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted dump to not be imported when opened, got %v", err)
+		}
+	})
+	t.Run("stale", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.db")
+		if err := ImportDumpFile(dumpPath, indexPath); err != nil {
+			t.Fatalf("importing dump: %v", err)
+		}
+		earlier := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(indexPath, earlier, earlier); err != nil {
+			t.Fatalf("updating index time: %v", err)
+		}
+		d, stale, err := OpenDump(dumpPath, indexPath)
+		switch {
+		case err != nil:
+			t.Fatalf("unwanted error: %v", err)
+		case !stale:
+			t.Errorf("wanted index older than dump to be stale")
+		case d.Len() != 3:
+			t.Errorf("wanted stale index to still be used, got %v isbns", d.Len())
+		}
+		d.Close()
+	})
+	t.Run("incomplete index", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.db")
+		db, err := bbolt.Open(indexPath, 0o600, nil)
+		if err != nil {
+			t.Fatalf("creating index: %v", err)
+		}
+		db.Close()
+		if _, _, err := OpenDump(dumpPath, indexPath); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+}
+
+func TestParsePublishDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"", time.Time{}},
+		{"sometime", time.Time{}},
+		{"1999", time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"May 1999", time.Date(1999, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"Dec 6, 1999", time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)},
+		{" 6 December 1999 ", time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)},
+		{"1999-12-06", time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := parsePublishDate(test.value); !test.want.Equal(got) {
+			t.Errorf("parsePublishDate(%q): wanted %v, got %v", test.value, test.want, got)
+		}
+	}
+}
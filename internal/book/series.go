@@ -0,0 +1,133 @@
+package book
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// SeriesBook is the header of a book with its series and volume number, which is enough to list the books of series in order.
+	SeriesBook struct {
+		Header
+		Series string
+		Volume int
+	}
+	// Series is the books of a series in volume order.
+	Series struct {
+		Name string
+		// Volumes are the volumes of the series from the first to the last that the library has, with missing volumes grouped into gaps.
+		Volumes []SeriesVolume
+		// Unnumbered are the books of the series that do not have volume numbers.
+		Unnumbered []Header
+	}
+	// SeriesVolume is the books that are a volume of a series, or a gap of missing volumes from First to Last if there are no books.
+	SeriesVolume struct {
+		First int
+		Last  int
+		Books []Header
+	}
+)
+
+// Missing determines if the volume is a gap of volumes that the library does not have.
+func (v SeriesVolume) Missing() bool {
+	return len(v.Books) == 0
+}
+
+// Gaps are the missing volumes of the series.
+func (s Series) Gaps() []SeriesVolume {
+	var gaps []SeriesVolume
+	for _, v := range s.Volumes {
+		if v.Missing() {
+			gaps = append(gaps, v)
+		}
+	}
+	return gaps
+}
+
+// SeriesBooks returns the headers, series, and volumes of the books that are in series.
+func (books Books) SeriesBooks() []SeriesBook {
+	var seriesBooks []SeriesBook
+	for _, b := range books {
+		if len(strings.TrimSpace(b.Series)) == 0 {
+			continue
+		}
+		sb := SeriesBook{
+			Header: b.Header,
+			Series: b.Series,
+			Volume: b.Volume,
+		}
+		seriesBooks = append(seriesBooks, sb)
+	}
+	return seriesBooks
+}
+
+// GroupSeries groups the books by series, sorted by name.
+// Series are grouped like subjects, under the first of their names.
+// The books of each volume, and the books without volume numbers, are sorted by title.
+// Volumes start at one, so a series that only has its third volume is missing its first two.
+func GroupSeries(books []SeriesBook) []Series {
+	sorted := make([]SeriesBook, len(books))
+	copy(sorted, books)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Volume != sorted[j].Volume {
+			return sorted[i].Volume < sorted[j].Volume
+		}
+		return sorted[i].Title < sorted[j].Title
+	})
+	m := make(map[string]*Series)
+	var keys []string
+	for _, b := range sorted {
+		k := SubjectKey(b.Series)
+		if len(k) == 0 {
+			continue
+		}
+		s, ok := m[k]
+		if !ok {
+			s = new(Series)
+			m[k] = s
+			keys = append(keys, k)
+		}
+		if name := NormalizeSubject(b.Series); !ok || name < s.Name {
+			s.Name = name
+		}
+		s.add(b)
+	}
+	series := make([]Series, len(keys))
+	for i, k := range keys {
+		series[i] = *m[k]
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if k1, k2 := strings.ToLower(series[i].Name), strings.ToLower(series[j].Name); k1 != k2 {
+			return k1 < k2
+		}
+		return series[i].Name < series[j].Name
+	})
+	return series
+}
+
+// add adds the book to the series, which must be added in volume order.
+func (s *Series) add(b SeriesBook) {
+	if b.Volume <= 0 {
+		s.Unnumbered = append(s.Unnumbered, b.Header)
+		return
+	}
+	last := 0
+	if n := len(s.Volumes); n != 0 {
+		last = s.Volumes[n-1].Last
+	}
+	switch {
+	case b.Volume == last:
+		v := &s.Volumes[len(s.Volumes)-1]
+		v.Books = append(v.Books, b.Header)
+		return
+	case b.Volume > last+1:
+		gap := SeriesVolume{First: last + 1, Last: b.Volume - 1}
+		s.Volumes = append(s.Volumes, gap)
+	}
+	v := SeriesVolume{
+		First: b.Volume,
+		Last:  b.Volume,
+		Books: []Header{b.Header},
+	}
+	s.Volumes = append(s.Volumes, v)
+}
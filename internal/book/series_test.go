@@ -0,0 +1,74 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBooksSeriesBooks(t *testing.T) {
+	books := Books{
+		{Header: Header{ID: "1"}, Series: "Dune", Volume: 2},
+		{Header: Header{ID: "2"}},
+		{Header: Header{ID: "3"}, Series: " "},
+		{Header: Header{ID: "4"}, Series: "Dune"},
+	}
+	want := []SeriesBook{
+		{Header: Header{ID: "1"}, Series: "Dune", Volume: 2},
+		{Header: Header{ID: "4"}, Series: "Dune"},
+	}
+	if got := books.SeriesBooks(); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
+
+func TestGroupSeries(t *testing.T) {
+	header := func(title string) Header {
+		return Header{Title: title}
+	}
+	books := []SeriesBook{
+		{Header: header("Heretics"), Series: "dune", Volume: 5},
+		{Header: header("Dune"), Series: "Dune", Volume: 1},
+		{Header: header("Guide"), Series: "Dune"},
+		{Header: header("Messiah"), Series: "Dune ", Volume: 2},
+		{Header: header("Messiah, again"), Series: "Dune", Volume: 2},
+		{Header: header("Redwall"), Series: "Redwall", Volume: 3},
+		{Header: header("Mossflower"), Series: "Redwall", Volume: 2},
+		{Header: header("Alone"), Series: "Anthology", Volume: -1},
+		{Header: header("None"), Series: " "},
+	}
+	want := []Series{
+		{
+			Name:       "Anthology",
+			Unnumbered: []Header{header("Alone")},
+		},
+		{
+			Name: "Dune",
+			Volumes: []SeriesVolume{
+				{First: 1, Last: 1, Books: []Header{header("Dune")}},
+				{First: 2, Last: 2, Books: []Header{header("Messiah"), header("Messiah, again")}},
+				{First: 3, Last: 4},
+				{First: 5, Last: 5, Books: []Header{header("Heretics")}},
+			},
+			Unnumbered: []Header{header("Guide")},
+		},
+		{
+			Name: "Redwall",
+			Volumes: []SeriesVolume{
+				{First: 1, Last: 1},
+				{First: 2, Last: 2, Books: []Header{header("Mossflower")}},
+				{First: 3, Last: 3, Books: []Header{header("Redwall")}},
+			},
+		},
+	}
+	got := GroupSeries(books)
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+	wantGaps := []SeriesVolume{{First: 3, Last: 4}}
+	if gotGaps := got[1].Gaps(); !reflect.DeepEqual(wantGaps, gotGaps) {
+		t.Errorf("gaps not equal: \n wanted: %+v \n got:    %+v", wantGaps, gotGaps)
+	}
+	if gaps := got[0].Gaps(); len(gaps) != 0 {
+		t.Errorf("wanted no gaps for series without numbered volumes, got %+v", gaps)
+	}
+}
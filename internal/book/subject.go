@@ -0,0 +1,189 @@
+package book
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// SubjectAlias is another name of a subject in the subject registry.
+	// Books that are saved with the alias as their subject are given the canonical subject instead.
+	SubjectAlias struct {
+		Name      string
+		Canonical string
+	}
+	// SubjectAliases is the subject registry, sorted by name.
+	SubjectAliases []SubjectAlias
+)
+
+// TagSeparator separates the tags of a book when they are written as text, such as in csv files and forms.
+const TagSeparator = ";"
+
+// NormalizeSubject trims the subject and replaces runs of whitespace in it with single spaces.
+func NormalizeSubject(subject string) string {
+	return strings.Join(strings.Fields(subject), " ")
+}
+
+// SubjectKey is what subjects are grouped by: the subject without surrounding whitespace, in lowercase.
+// Databases group subjects by the same key, so "Fiction", "fiction", and "Fiction " are the same subject.
+func SubjectKey(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subject))
+}
+
+// SubjectKeys are the distinct keys of the subjects, in order.
+func SubjectKeys(subjects ...string) []string {
+	keys := make([]string, 0, len(subjects))
+	seen := make(map[string]struct{}, len(subjects))
+	for _, s := range subjects {
+		k := SubjectKey(s)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ParseTags splits the text into normalized tags.
+func ParseTags(text string) []string {
+	var tags []string
+	for _, t := range strings.Split(text, TagSeparator) {
+		if t = NormalizeSubject(t); len(t) != 0 {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// FormatTags joins the tags so they can be parsed again.
+func FormatTags(tags []string) string {
+	return strings.Join(tags, TagSeparator+" ")
+}
+
+// NormalizeTags normalizes the tags, removing empty tags and tags that have the same key as the subject or another tag.
+// The tags are a set, so they are sorted by key.
+func NormalizeTags(subject string, tags []string) []string {
+	seen := map[string]struct{}{
+		SubjectKey(subject): {},
+	}
+	var normalized []string
+	for _, t := range tags {
+		t = NormalizeSubject(strings.ReplaceAll(t, TagSeparator, " "))
+		k := SubjectKey(t)
+		if _, ok := seen[k]; ok || len(k) == 0 {
+			continue
+		}
+		seen[k] = struct{}{}
+		normalized = append(normalized, t)
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		return SubjectKey(normalized[i]) < SubjectKey(normalized[j])
+	})
+	return normalized
+}
+
+// Subjects are the subject and tags of the book.
+func (b Book) Subjects() []string {
+	return append([]string{b.Subject}, b.Tags...)
+}
+
+// HasSubject determines if the subject is the subject or one of the tags of the book, ignoring case and surrounding whitespace.
+func (b Book) HasSubject(subject string) bool {
+	k := SubjectKey(subject)
+	for _, s := range b.Subjects() {
+		if SubjectKey(s) == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonical is the canonical subject of the alias that has the same key as the subject.
+// Subjects that are not aliases are normalized.
+func (aliases SubjectAliases) Canonical(subject string) string {
+	k := SubjectKey(subject)
+	for _, a := range aliases {
+		if SubjectKey(a.Name) == k {
+			return a.Canonical
+		}
+	}
+	return NormalizeSubject(subject)
+}
+
+// CanonicalTags replaces the tags that are aliases with their canonical subjects, normalizing the tags.
+func (aliases SubjectAliases) CanonicalTags(subject string, tags []string) []string {
+	canonical := make([]string, len(tags))
+	for i, t := range tags {
+		canonical[i] = aliases.Canonical(t)
+	}
+	return NormalizeTags(subject, canonical)
+}
+
+// Merge returns the registry after merging the subjects into the canonical subject.
+// The subjects become aliases of the canonical subject, as do the aliases of the subjects.
+// The canonical subject is no longer an alias.
+func (aliases SubjectAliases) Merge(canonical string, subjects ...string) SubjectAliases {
+	canonical = NormalizeSubject(canonical)
+	merged := make(map[string]struct{}, len(subjects))
+	for _, k := range SubjectKeys(subjects...) {
+		merged[k] = struct{}{}
+	}
+	canonicalKey := SubjectKey(canonical)
+	m := make(map[string]SubjectAlias, len(aliases)+len(subjects))
+	for _, a := range aliases {
+		if _, ok := merged[SubjectKey(a.Canonical)]; ok {
+			a.Canonical = canonical
+		}
+		m[SubjectKey(a.Name)] = a
+	}
+	for _, s := range subjects {
+		m[SubjectKey(s)] = SubjectAlias{
+			Name:      NormalizeSubject(s),
+			Canonical: canonical,
+		}
+	}
+	delete(m, canonicalKey)
+	result := make(SubjectAliases, 0, len(m))
+	for _, a := range m {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// MergeSubjects gives the books with any of the subjects or a variant of the canonical subject the canonical subject.
+// Tags of the books are merged the same way.
+// The number of books that were changed is returned.
+func (books Books) MergeSubjects(canonical string, subjects ...string) int {
+	canonical = NormalizeSubject(canonical)
+	keys := make(map[string]struct{}, len(subjects)+1)
+	for _, k := range SubjectKeys(append([]string{canonical}, subjects...)...) {
+		keys[k] = struct{}{}
+	}
+	merge := func(subject string) string {
+		if _, ok := keys[SubjectKey(subject)]; ok {
+			return canonical
+		}
+		return subject
+	}
+	n := 0
+	for i, b := range books {
+		subject := merge(b.Subject)
+		tags := make([]string, len(b.Tags))
+		for j, t := range b.Tags {
+			tags[j] = merge(t)
+		}
+		tags = NormalizeTags(subject, tags)
+		if subject == b.Subject && reflect.DeepEqual(tags, NormalizeTags(b.Subject, b.Tags)) {
+			continue
+		}
+		books[i].Subject = subject
+		books[i].Tags = tags
+		n++
+	}
+	return n
+}
@@ -0,0 +1,193 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"", ""},
+		{"Fiction", "Fiction"},
+		{" Fiction \t", "Fiction"},
+		{"Science  \n Fiction", "Science Fiction"},
+	}
+	for _, test := range tests {
+		if want, got := test.want, NormalizeSubject(test.subject); want != got {
+			t.Errorf("normalizing %q: wanted %q, got %q", test.subject, want, got)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{" ; ", nil},
+		{"History", []string{"History"}},
+		{" Biography;History  of  Art;", []string{"Biography", "History of Art"}},
+	}
+	for _, test := range tests {
+		if want, got := test.want, ParseTags(test.text); !reflect.DeepEqual(want, got) {
+			t.Errorf("parsing %q: wanted %q, got %q", test.text, want, got)
+		}
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	tags := []string{"Biography", "History"}
+	text := FormatTags(tags)
+	if want, got := "Biography; History", text; want != got {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+	if got := ParseTags(text); !reflect.DeepEqual(tags, got) {
+		t.Errorf("tags not parsed again: wanted %q, got %q", tags, got)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags("History", []string{"world  war", "", "history ", "Biography", "biography", "a;b"})
+	want := []string{"a b", "Biography", "world war"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestSubjectKeys(t *testing.T) {
+	got := SubjectKeys("Fiction", "fiction ", " History", "FICTION")
+	want := []string{"fiction", "history"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestBooksSubjects(t *testing.T) {
+	books := Books{
+		{Header: Header{Subject: "fiction"}},
+		{Header: Header{Subject: "Fiction "}},
+		{Header: Header{Subject: "History"}},
+		{Header: Header{Subject: "Fiction"}},
+		{Header: Header{Subject: "Biography"}, Tags: []string{"history", "fiction"}},
+		{Header: Header{Subject: "History"}, Tags: []string{"history "}},
+	}
+	want := Subjects{
+		{Name: "Biography", Count: 1},
+		{Name: "Fiction", Count: 4},
+		{Name: "History", Count: 3},
+	}
+	if got := books.Subjects(10, 0); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
+
+func TestSubjectAliasesCanonical(t *testing.T) {
+	aliases := SubjectAliases{
+		{Name: "sci-fi", Canonical: "Science Fiction"},
+	}
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Sci-Fi ", "Science Fiction"},
+		{" Poetry  Books", "Poetry Books"},
+	}
+	for _, test := range tests {
+		if want, got := test.want, aliases.Canonical(test.subject); want != got {
+			t.Errorf("canonical subject of %q: wanted %q, got %q", test.subject, want, got)
+		}
+	}
+}
+
+func TestSubjectAliasesCanonicalTags(t *testing.T) {
+	aliases := SubjectAliases{
+		{Name: "sci-fi", Canonical: "Science Fiction"},
+		{Name: "bios", Canonical: "Biography"},
+	}
+	got := aliases.CanonicalTags("Biography", []string{"Sci-Fi", "Bios", " Space"})
+	want := []string{"Science Fiction", "Space"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
+
+func TestSubjectAliasesMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		aliases   SubjectAliases
+		canonical string
+		subjects  []string
+		want      SubjectAliases
+	}{
+		{
+			name:      "new aliases",
+			canonical: " Fiction",
+			subjects:  []string{"fiction", "Novels ", "novels"},
+			want: SubjectAliases{
+				{Name: "novels", Canonical: "Fiction"},
+			},
+		},
+		{
+			name: "aliases of merged subjects",
+			aliases: SubjectAliases{
+				{Name: "Sci-Fi", Canonical: "SF"},
+				{Name: "Bios", Canonical: "Biography"},
+			},
+			canonical: "Science Fiction",
+			subjects:  []string{"sf"},
+			want: SubjectAliases{
+				{Name: "Bios", Canonical: "Biography"},
+				{Name: "Sci-Fi", Canonical: "Science Fiction"},
+				{Name: "sf", Canonical: "Science Fiction"},
+			},
+		},
+		{
+			name: "alias becomes canonical",
+			aliases: SubjectAliases{
+				{Name: "Novels", Canonical: "Fiction"},
+			},
+			canonical: "novels",
+			subjects:  []string{"Fiction"},
+			want: SubjectAliases{
+				{Name: "Fiction", Canonical: "novels"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.aliases.Merge(test.canonical, test.subjects...)
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestBooksMergeSubjects(t *testing.T) {
+	books := Books{
+		{Header: Header{ID: "1", Subject: "Fiction"}},
+		{Header: Header{ID: "2", Subject: "fiction "}},
+		{Header: Header{ID: "3", Subject: "Novels"}},
+		{Header: Header{ID: "4", Subject: "History"}},
+		{Header: Header{ID: "5", Subject: "History"}, Tags: []string{"Novels", "War"}},
+		{Header: Header{ID: "6", Subject: "Novels"}, Tags: []string{"fiction"}},
+	}
+	got := books.MergeSubjects("Fiction", "novels")
+	if want := 4; want != got {
+		t.Errorf("wanted %v books changed, got %v", want, got)
+	}
+	want := []string{"Fiction", "Fiction", "Fiction", "History", "History", "Fiction"}
+	wantTags := [][]string{nil, nil, nil, nil, {"Fiction", "War"}, nil}
+	for i, b := range books {
+		if want[i] != b.Subject {
+			t.Errorf("book %v: wanted subject %q, got %q", b.ID, want[i], b.Subject)
+		}
+		if !reflect.DeepEqual(wantTags[i], b.Tags) {
+			t.Errorf("book %v: wanted tags %q, got %q", b.ID, wantTags[i], b.Tags)
+		}
+	}
+}
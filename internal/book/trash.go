@@ -0,0 +1,51 @@
+package book
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type (
+	// TrashedBook is the header of a book that was deleted and when it was deleted, which is enough to list the trash.
+	TrashedBook struct {
+		Header
+		DeletedDate time.Time
+	}
+	// DeletedBook is a book in the trash, which keeps all of its fields so it can be restored.
+	DeletedBook struct {
+		Book
+		DeletedDate time.Time
+	}
+)
+
+// TrashedBook returns the header of the book and when it was deleted.
+func (b DeletedBook) TrashedBook() TrashedBook {
+	return TrashedBook{
+		Header:      b.Header,
+		DeletedDate: b.DeletedDate,
+	}
+}
+
+// TrashNotFoundError reports that no book in the trash has the id.
+func TrashNotFoundError(id string) error {
+	return fmt.Errorf("%w: no book in the trash with id of %q", ErrNotFound, id)
+}
+
+// SortTrash sorts the books from the most recently deleted to the least recently deleted.
+func SortTrash(trash []TrashedBook) {
+	sort.SliceStable(trash, func(i, j int) bool {
+		return trash[i].DeletedDate.After(trash[j].DeletedDate)
+	})
+}
+
+// ExpiredIDs are the ids of the books that were deleted before the time.
+func ExpiredIDs(trash []TrashedBook, deletedBefore time.Time) []string {
+	var ids []string
+	for _, b := range trash {
+		if b.DeletedDate.Before(deletedBefore) {
+			ids = append(ids, b.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,64 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeletedBookTrashedBook(t *testing.T) {
+	d := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	b := DeletedBook{
+		Book:        Book{Header: Header{ID: "1", Title: "t"}, Pages: 9, ImageBase64: "IMG"},
+		DeletedDate: d,
+	}
+	want := TrashedBook{Header: Header{ID: "1", Title: "t"}, DeletedDate: d}
+	if got := b.TrashedBook(); want != got {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
+
+func TestSortTrash(t *testing.T) {
+	d := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	trash := []TrashedBook{
+		{Header: Header{ID: "1"}, DeletedDate: d},
+		{Header: Header{ID: "2"}, DeletedDate: d.Add(time.Hour)},
+		{Header: Header{ID: "3"}, DeletedDate: d},
+		{Header: Header{ID: "4"}, DeletedDate: d.Add(-time.Hour)},
+	}
+	want := []TrashedBook{
+		{Header: Header{ID: "2"}, DeletedDate: d.Add(time.Hour)},
+		{Header: Header{ID: "1"}, DeletedDate: d},
+		{Header: Header{ID: "3"}, DeletedDate: d},
+		{Header: Header{ID: "4"}, DeletedDate: d.Add(-time.Hour)},
+	}
+	SortTrash(trash)
+	if !reflect.DeepEqual(want, trash) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, trash)
+	}
+}
+
+func TestExpiredIDs(t *testing.T) {
+	d := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	trash := []TrashedBook{
+		{Header: Header{ID: "1"}, DeletedDate: d.Add(time.Hour)},
+		{Header: Header{ID: "2"}, DeletedDate: d},
+		{Header: Header{ID: "3"}, DeletedDate: d.Add(-time.Second)},
+	}
+	tests := []struct {
+		name   string
+		before time.Time
+		want   []string
+	}{
+		{"none", d.Add(-time.Hour), nil},
+		{"exactly", d, []string{"3"}},
+		{"all", d.Add(24 * time.Hour), []string{"1", "2", "3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExpiredIDs(trash, test.before); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
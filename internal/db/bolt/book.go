@@ -0,0 +1,204 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// bBook is stored as json in the books bucket.
+// The id is the key of the book and the image is stored separately.
+type bBook struct {
+	Title         string            `json:"title"`
+	Author        string            `json:"author"`
+	Subject       string            `json:"subject"`
+	Tags          []string          `json:"tags,omitempty"`
+	Contributors  []bContributor    `json:"contributors,omitempty"`
+	Series        string            `json:"series,omitempty"`
+	Volume        int               `json:"volume,omitempty"`
+	Language      string            `json:"language"`
+	Edition       string            `json:"edition,omitempty"`
+	Format        string            `json:"format"`
+	ReadingLevel  string            `json:"reading_level,omitempty"`
+	Custom        map[string]string `json:"custom,omitempty"`
+	Description   string            `json:"description"`
+	DeweyDecClass string            `json:"dewey_dec_class"`
+	Pages         int               `json:"pages"`
+	Publisher     string            `json:"publisher"`
+	PublishDate   time.Time         `json:"publish_date"`
+	AddedDate     time.Time         `json:"added_date"`
+	EanIsbn13     string            `json:"ean_isbn13"`
+	UpcIsbn10     string            `json:"upc_isbn10"`
+}
+
+// bDeletedBook is stored as json in the trash bucket, keyed by the id of the book.
+// The image is kept with the book so it can be restored.
+type bDeletedBook struct {
+	bBook
+	ImageBase64 string    `json:"image_base64,omitempty"`
+	DeletedDate time.Time `json:"deleted_date"`
+}
+
+// bCustomField is stored as json in the custom fields of the meta bucket.
+type bCustomField struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options,omitempty"`
+}
+
+// bAuditEntry is stored as json in the bucket of its book in the audit log bucket.
+type bAuditEntry struct {
+	ID      string         `json:"id"`
+	Time    time.Time      `json:"time"`
+	Actor   string         `json:"actor"`
+	Action  string         `json:"action"`
+	Changes []bFieldChange `json:"changes,omitempty"`
+}
+
+// bFieldChange is stored as json in the changes of bAuditEntries.
+type bFieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// bContributor is stored as json in the contributors of bBooks.
+type bContributor struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	SortName string `json:"sort_name"`
+}
+
+func boltContributors(contributors []book.Contributor) []bContributor {
+	if len(contributors) == 0 {
+		return nil
+	}
+	m := make([]bContributor, len(contributors))
+	for i, c := range contributors {
+		m[i] = bContributor(c)
+	}
+	return m
+}
+
+func boltCustomFields(fields []book.CustomField) []bCustomField {
+	m := make([]bCustomField, len(fields))
+	for i, f := range fields {
+		m[i] = bCustomField(f)
+	}
+	return m
+}
+
+func customFields(m []bCustomField) []book.CustomField {
+	fields := make([]book.CustomField, len(m))
+	for i, f := range m {
+		fields[i] = book.CustomField(f)
+	}
+	return fields
+}
+
+func boltDeletedBook(b book.DeletedBook) bDeletedBook {
+	return bDeletedBook{
+		bBook:       boltBook(b.Book),
+		ImageBase64: b.ImageBase64,
+		DeletedDate: b.DeletedDate,
+	}
+}
+
+func (m bDeletedBook) DeletedBook(id string) book.DeletedBook {
+	return book.DeletedBook{
+		Book:        m.Book(id, m.ImageBase64),
+		DeletedDate: m.DeletedDate,
+	}
+}
+
+func boltAuditEntry(e book.AuditEntry) bAuditEntry {
+	m := bAuditEntry{
+		ID:     e.ID,
+		Time:   e.Time,
+		Actor:  e.Actor,
+		Action: e.Action,
+	}
+	for _, c := range e.Changes {
+		m.Changes = append(m.Changes, bFieldChange(c))
+	}
+	return m
+}
+
+func (m bAuditEntry) AuditEntry(bookID string) book.AuditEntry {
+	e := book.AuditEntry{
+		ID:     m.ID,
+		BookID: bookID,
+		Time:   m.Time,
+		Actor:  m.Actor,
+		Action: m.Action,
+	}
+	for _, c := range m.Changes {
+		e.Changes = append(e.Changes, book.FieldChange(c))
+	}
+	return e
+}
+
+func (m bBook) contributors() []book.Contributor {
+	if len(m.Contributors) == 0 {
+		return nil
+	}
+	contributors := make([]book.Contributor, len(m.Contributors))
+	for i, c := range m.Contributors {
+		contributors[i] = book.Contributor(c)
+	}
+	return contributors
+}
+
+func boltBook(b book.Book) bBook {
+	return bBook{
+		Title:         b.Title,
+		Author:        b.Author,
+		Subject:       b.Subject,
+		Tags:          b.Tags,
+		Contributors:  boltContributors(b.Contributors),
+		Series:        b.Series,
+		Volume:        b.Volume,
+		Language:      b.Language,
+		Edition:       b.Edition,
+		Format:        b.Format,
+		ReadingLevel:  b.ReadingLevel,
+		Custom:        b.Custom,
+		Description:   b.Description,
+		DeweyDecClass: b.DeweyDecClass,
+		Pages:         b.Pages,
+		Publisher:     b.Publisher,
+		PublishDate:   b.PublishDate,
+		AddedDate:     b.AddedDate,
+		EanIsbn13:     b.EanIsbn13,
+		UpcIsbn10:     b.UpcIsbn10,
+	}
+}
+
+func (m bBook) Book(id, imageBase64 string) book.Book {
+	return book.Book{
+		Header: book.Header{
+			ID:      id,
+			Title:   m.Title,
+			Author:  m.Author,
+			Subject: m.Subject,
+		},
+		Tags:          m.Tags,
+		Contributors:  m.contributors(),
+		Series:        m.Series,
+		Volume:        m.Volume,
+		Language:      m.Language,
+		Edition:       m.Edition,
+		Format:        m.Format,
+		ReadingLevel:  m.ReadingLevel,
+		Custom:        m.Custom,
+		Description:   m.Description,
+		DeweyDecClass: m.DeweyDecClass,
+		Pages:         m.Pages,
+		Publisher:     m.Publisher,
+		PublishDate:   m.PublishDate,
+		AddedDate:     m.AddedDate,
+		EanIsbn13:     m.EanIsbn13,
+		UpcIsbn10:     m.UpcIsbn10,
+		ImageBase64:   imageBase64,
+	}
+}
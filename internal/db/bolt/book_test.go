@@ -0,0 +1,81 @@
+package bolt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestBoltBook(t *testing.T) {
+	b := book.Book{
+		Header: book.Header{
+			ID:      "id1",
+			Title:   "title2",
+			Author:  "author3",
+			Subject: "subject4",
+		},
+		Tags:          []string{"tag4"},
+		Contributors:  []book.Contributor{{Name: "c1", Role: "editor", SortName: "s1"}},
+		Series:        "s2",
+		Volume:        2,
+		Language:      "fr",
+		Edition:       "2nd",
+		Format:        "paperback",
+		ReadingLevel:  "children",
+		Custom:        map[string]string{"Donor": "Ann"},
+		Description:   "description5",
+		DeweyDecClass: "ddc6",
+		Pages:         7,
+		Publisher:     "publisher8",
+		PublishDate:   time.Date(2001, 7, 4, 0, 0, 0, 0, time.UTC),
+		AddedDate:     time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:     "ean11",
+		UpcIsbn10:     "upc12",
+		ImageBase64:   "image13",
+	}
+	m := bBook{
+		Title:         "title2",
+		Author:        "author3",
+		Subject:       "subject4",
+		Tags:          []string{"tag4"},
+		Contributors:  []bContributor{{Name: "c1", Role: "editor", SortName: "s1"}},
+		Series:        "s2",
+		Volume:        2,
+		Language:      "fr",
+		Edition:       "2nd",
+		Format:        "paperback",
+		ReadingLevel:  "children",
+		Custom:        map[string]string{"Donor": "Ann"},
+		Description:   "description5",
+		DeweyDecClass: "ddc6",
+		Pages:         7,
+		Publisher:     "publisher8",
+		PublishDate:   time.Date(2001, 7, 4, 0, 0, 0, 0, time.UTC),
+		AddedDate:     time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:     "ean11",
+		UpcIsbn10:     "upc12",
+	}
+	t.Run("boltBook", func(t *testing.T) {
+		if want, got := m, boltBook(b); !reflect.DeepEqual(want, got) {
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("Book", func(t *testing.T) {
+		if want, got := b, m.Book("id1", "image13"); !reflect.DeepEqual(want, got) {
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+}
+
+func TestBoltDeletedBook(t *testing.T) {
+	b := book.DeletedBook{
+		Book:        book.Book{Header: book.Header{ID: "id1", Title: "title2"}, Language: "en", ImageBase64: "image3"},
+		DeletedDate: time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC),
+	}
+	m := boltDeletedBook(b)
+	if want, got := b, m.DeletedBook("id1"); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
@@ -0,0 +1,836 @@
+// Package bolt provides a database for the library that is stored in a single file by an embedded key/value store.
+// It is written in pure Go, so it does not require CGO.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"go.etcd.io/bbolt"
+)
+
+type Database struct {
+	db *bbolt.DB
+}
+
+var (
+	booksBucket  = []byte("books")
+	imagesBucket = []byte("images")
+	usersBucket  = []byte("users")
+	isbnsBucket  = []byte("isbns")           // isbn, book id -> book id
+	aliasBucket  = []byte("subject_aliases") // alias -> canonical subject
+	metaBucket   = []byte("meta")            // settings of the database, such as its schema version
+	auditBucket  = []byte("audit_log")       // book id -> bucket of audit entries, by sequence
+	trashBucket  = []byte("trash")           // book id -> deleted book, with its image
+	adminKey     = []byte("admin")
+	versionKey   = []byte("schema_version")
+	fieldsKey    = []byte("custom_fields") // json of the custom fields, in order
+)
+
+// migrations update the books of databases that were created with older schema versions.
+// The schema version is the number of migrations that have been applied, so new migrations must be added to the end.
+var migrations = []func(tx *bbolt.Tx) error{
+	setBookDefaults,
+	reindexISBNs,
+}
+
+const openTimeout = 1 * time.Second
+
+// NewDatabase opens the database file referenced by the url, creating it if it does not exist.
+// Urls are like "bolt:library.db" or "bolt:///home/username/library.db".
+func NewDatabase(databaseURL string) (*Database, error) {
+	path, err := filePath(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	opts := bbolt.Options{
+		Timeout: openTimeout, // the file is locked by other servers that have it open
+	}
+	db, err := bbolt.Open(path, 0o600, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	d := Database{
+		db: db,
+	}
+	if err := d.setupBuckets(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting up buckets: %w", err)
+	}
+	return &d, nil
+}
+
+func filePath(databaseURL string) (string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing database url: %w", err)
+	}
+	path := u.Opaque
+	if len(path) == 0 {
+		path = u.Path
+	}
+	if len(path) == 0 {
+		return "", fmt.Errorf("database url missing file path: %q", databaseURL)
+	}
+	return path, nil
+}
+
+func (d *Database) setupBuckets() error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{booksBucket, imagesBucket, usersBucket, aliasBucket, metaBucket, auditBucket, trashBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("creating %s bucket: %w", name, err)
+			}
+		}
+		if tx.Bucket(isbnsBucket) == nil {
+			if err := createISBNsBucket(tx); err != nil {
+				return err
+			}
+		}
+		return migrate(tx)
+	})
+}
+
+// createISBNsBucket creates the isbns bucket and indexes the books that were added before it existed.
+func createISBNsBucket(tx *bbolt.Tx) error {
+	if _, err := tx.CreateBucket(isbnsBucket); err != nil {
+		return fmt.Errorf("creating %s bucket: %w", isbnsBucket, err)
+	}
+	return tx.Bucket(booksBucket).ForEach(func(k, v []byte) error {
+		var m bBook
+		if err := json.Unmarshal(v, &m); err != nil {
+			return fmt.Errorf("decoding book %q: %w", k, err)
+		}
+		return indexISBNs(tx, m.Book(string(k), ""))
+	})
+}
+
+// migrate applies the migrations that are newer than the schema version of the database.
+func migrate(tx *bbolt.Tx) error {
+	meta := tx.Bucket(metaBucket)
+	version := 0
+	if data := meta.Get(versionKey); data != nil {
+		v, err := strconv.Atoi(string(data))
+		if err != nil {
+			return fmt.Errorf("parsing schema version: %w", err)
+		}
+		version = v
+	}
+	if version >= len(migrations) {
+		return nil
+	}
+	for ; version < len(migrations); version++ {
+		if err := migrations[version](tx); err != nil {
+			return fmt.Errorf("migrating to schema version %v: %w", version+1, err)
+		}
+	}
+	return meta.Put(versionKey, []byte(strconv.Itoa(version)))
+}
+
+// setBookDefaults sets the language and format of books that were added before books had them to the defaults.
+func setBookDefaults(tx *bbolt.Tx) error {
+	books := tx.Bucket(booksBucket)
+	updated := make(map[string][]byte)
+	err := books.ForEach(func(k, v []byte) error {
+		var m bBook
+		if err := json.Unmarshal(v, &m); err != nil {
+			return fmt.Errorf("decoding book %q: %w", k, err)
+		}
+		if len(m.Language) != 0 && len(m.Format) != 0 {
+			return nil
+		}
+		if len(m.Language) == 0 {
+			m.Language = book.DefaultLanguage
+		}
+		if len(m.Format) == 0 {
+			m.Format = book.DefaultFormat
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encoding book %q: %w", k, err)
+		}
+		updated[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for k, data := range updated { // buckets cannot be changed while iterating over them
+		if err := books.Put([]byte(k), data); err != nil {
+			return fmt.Errorf("writing book %q: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// reindexISBNs recreates the isbns bucket with keys that have the ids of the books, so books with the same isbns are all indexed.
+func reindexISBNs(tx *bbolt.Tx) error {
+	if err := tx.DeleteBucket(isbnsBucket); err != nil {
+		return fmt.Errorf("deleting %s bucket: %w", isbnsBucket, err)
+	}
+	return createISBNsBucket(tx)
+}
+
+// Close releases the database file.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// CreateBooks creates the books with new ids, adding an entry to the audit log for each audit of each book in the same transaction.
+func (d *Database) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+	created := make([]book.Book, len(books))
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		for i, b := range books {
+			b.ID = book.NewID()
+			if err := putBook(tx, b, true); err != nil {
+				return err
+			}
+			if err := putAuditEntries(tx, book.Entries(audits, book.Book{}, b)...); err != nil {
+				return err
+			}
+			created[i] = b
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating books: %w", err)
+	}
+	return created, nil
+}
+
+// ImportBooks creates the books with the ids they already have.
+// Books with ids that already exist are replaced if upsert is true.
+// Otherwise, no books are imported if any of the ids already exist.
+func (d *Database) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if err := book.Books(books).CheckIDs(); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		var existingIDs []string
+		for _, b := range books {
+			if err := bookExists(tx, b.ID); err == nil {
+				existingIDs = append(existingIDs, b.ID)
+			}
+			if err := putBook(tx, b, true); err != nil {
+				return err
+			}
+		}
+		if len(existingIDs) != 0 && !upsert {
+			return book.ExistingIDsError(existingIDs) // rolls back the transaction
+		}
+		return putAuditEntries(tx, entries...)
+	})
+	if err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
+	books, err := d.allBooks()
+	if err != nil {
+		return nil, fmt.Errorf("reading book subjects: %w", err)
+	}
+	subjects := books.Subjects(limit, offset)
+	return subjects, nil
+}
+
+func (d *Database) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	books, err := d.allBooks()
+	if err != nil {
+		return nil, fmt.Errorf("reading book authors: %w", err)
+	}
+	authors := books.Authors(limit, offset)
+	return authors, nil
+}
+
+func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
+	books, err := d.allBooks()
+	if err != nil {
+		return nil, fmt.Errorf("reading book headers: %w", err)
+	}
+	books.Sort()
+	headers := books.Headers(filter, limit, offset)
+	return headers, nil
+}
+
+func (d *Database) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	books, err := d.allBooks()
+	if err != nil {
+		return nil, fmt.Errorf("reading shelved books: %w", err)
+	}
+	return books.Shelved(), nil
+}
+
+func (d *Database) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	books, err := d.allBooks()
+	if err != nil {
+		return nil, fmt.Errorf("reading series books: %w", err)
+	}
+	return books.SeriesBooks(), nil
+}
+
+func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error) {
+	var b book.Book
+	err := d.db.View(func(tx *bbolt.Tx) (err error) {
+		b, err = readBook(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading book: %w", err)
+	}
+	return &b, nil
+}
+
+// ReadBookMetadata reads the book without reading its image from the images bucket.
+func (d *Database) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	var b book.Book
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(booksBucket).Get([]byte(id))
+		if data == nil {
+			return book.IDNotFoundError(id)
+		}
+		var m bBook
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("decoding book: %w", err)
+		}
+		b = m.Book(id, "")
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading book: %w", err)
+	}
+	return &b, nil
+}
+
+// ReadBookByISBN reads the book that has the isbn-13 or isbn-10, using the isbns bucket as an index.
+// If books share the isbn, the one with the first id is read.
+func (d *Database) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	var b book.Book
+	err := d.db.View(func(tx *bbolt.Tx) (err error) {
+		var id []byte
+		if len(isbn) != 0 {
+			prefix := isbnKey(isbn, "")
+			if k, v := tx.Bucket(isbnsBucket).Cursor().Seek(prefix); bytes.HasPrefix(k, prefix) {
+				id = v
+			}
+		}
+		if id == nil {
+			return book.ISBNNotFoundError(isbn)
+		}
+		b, err = readBook(tx, string(id))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading book by isbn: %w", err)
+	}
+	return &b, nil
+}
+
+// UpdateBook replaces the book, adding an entry to the audit log for each audit with the book from before the update in the same transaction.
+func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		if err := bookExists(tx, b.ID); err != nil {
+			return err
+		}
+		before, err := readBook(tx, b.ID)
+		if err != nil {
+			return err
+		}
+		if err := putBook(tx, b, updateImage); err != nil {
+			return err
+		}
+		return putAuditEntries(tx, book.Entries(audits, before, b)...)
+	})
+	if err != nil {
+		return fmt.Errorf("updating book: %w", err)
+	}
+	return nil
+}
+
+// DeleteBook moves the book to the trash, adding the entries to the audit log in the same transaction.
+func (d *Database) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		if err := bookExists(tx, id); err != nil {
+			return err
+		}
+		old, err := readBook(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := unindexISBNs(tx, old); err != nil {
+			return err
+		}
+		deleted := book.DeletedBook{
+			Book:        old,
+			DeletedDate: time.Now().UTC(),
+		}
+		data, err := json.Marshal(boltDeletedBook(deleted))
+		if err != nil {
+			return fmt.Errorf("encoding deleted book: %w", err)
+		}
+		key := []byte(id)
+		if err := tx.Bucket(trashBucket).Put(key, data); err != nil {
+			return fmt.Errorf("moving book to trash: %w", err)
+		}
+		if err := tx.Bucket(booksBucket).Delete(key); err != nil {
+			return err
+		}
+		if err := tx.Bucket(imagesBucket).Delete(key); err != nil {
+			return err
+		}
+		return putAuditEntries(tx, entries...)
+	})
+	if err != nil {
+		return fmt.Errorf("deleting book: %w", err)
+	}
+	return nil
+}
+
+// ReadTrash reads the books in the trash, most recently deleted first.
+func (d *Database) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	var trash []book.TrashedBook
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(trashBucket).ForEach(func(k, v []byte) error {
+			var m bDeletedBook
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("decoding deleted book %q: %w", k, err)
+			}
+			trash = append(trash, m.DeletedBook(string(k)).TrashedBook())
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading trash: %w", err)
+	}
+	book.SortTrash(trash)
+	return trash, nil
+}
+
+// ReadDeletedBook reads the book in the trash with its image.
+func (d *Database) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	var b book.DeletedBook
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(trashBucket).Get([]byte(id))
+		if data == nil {
+			return book.TrashNotFoundError(id)
+		}
+		var m bDeletedBook
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("decoding deleted book: %w", err)
+		}
+		b = m.DeletedBook(id)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading deleted book: %w", err)
+	}
+	return &b, nil
+}
+
+// ImportTrash adds the deleted books to the trash with the times they were deleted.
+// Books with ids that are already in the trash are skipped.
+func (d *Database) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		trash := tx.Bucket(trashBucket)
+		for _, b := range books {
+			key := []byte(b.ID)
+			if trash.Get(key) != nil {
+				continue
+			}
+			data, err := json.Marshal(boltDeletedBook(b))
+			if err != nil {
+				return fmt.Errorf("encoding deleted book %q: %w", b.ID, err)
+			}
+			if err := trash.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("importing trash: %w", err)
+	}
+	return nil
+}
+
+// RestoreBook moves the book out of the trash, adding the entries to the audit log in the same transaction.
+// Books cannot be restored if another book has their id.
+func (d *Database) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		key := []byte(id)
+		data := tx.Bucket(trashBucket).Get(key)
+		if data == nil {
+			return book.TrashNotFoundError(id)
+		}
+		if err := bookExists(tx, id); err == nil {
+			return book.ExistingIDsError([]string{id})
+		}
+		var m bDeletedBook
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("decoding deleted book: %w", err)
+		}
+		if err := putBook(tx, m.DeletedBook(id).Book, true); err != nil {
+			return err
+		}
+		if err := tx.Bucket(trashBucket).Delete(key); err != nil {
+			return err
+		}
+		return putAuditEntries(tx, entries...)
+	})
+	if err != nil {
+		return fmt.Errorf("restoring book: %w", err)
+	}
+	return nil
+}
+
+// PurgeBooks permanently removes the books from the trash.
+// Ids of books that are not in the trash are ignored.
+func (d *Database) PurgeBooks(ctx context.Context, ids ...string) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		trash := tx.Bucket(trashBucket)
+		for _, id := range ids {
+			if err := trash.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("purging books: %w", err)
+	}
+	return nil
+}
+
+// ReadBookHistory reads the audit log of the book, newest entries first.
+func (d *Database) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	var history []book.AuditEntry
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(auditBucket).Bucket([]byte(bookID))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var m bAuditEntry
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("decoding audit entry %x: %w", k, err)
+			}
+			history = append(history, m.AuditEntry(bookID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading book history: %w", err)
+	}
+	return history, nil
+}
+
+// ReadAuditLog reads the entries of the audit log, oldest first.
+func (d *Database) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	var entries []book.AuditEntry
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		entries, err = readAuditEntries(tx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+func (d *Database) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		existing, err := readAuditEntries(tx)
+		if err != nil {
+			return err
+		}
+		n := len(existing)
+		all := book.AppendAuditEntries(existing, entries...)
+		return putAuditEntries(tx, all[n:]...)
+	})
+	if err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		password := tx.Bucket(usersBucket).Get(adminKey)
+		hashedPassword = append(hashedPassword, password...) // copy: the value is only valid in the transaction
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading admin password: %w", err)
+	}
+	return hashedPassword, nil
+}
+
+func (d *Database) UpdateAdminPassword(ctx context.Context, hashedPassword string) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(usersBucket).Put(adminKey, []byte(hashedPassword))
+	})
+	if err != nil {
+		return fmt.Errorf("updating admin password: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	var aliases []book.SubjectAlias
+	err := d.db.View(func(tx *bbolt.Tx) (err error) {
+		aliases, err = readSubjectAliases(tx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading subject aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// MergeSubjects gives the books with any of the subjects the canonical subject in one transaction, making the subjects aliases of it.
+// The entries are added to the audit log in the same transaction.
+func (d *Database) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		var merged book.Books
+		err := tx.Bucket(booksBucket).ForEach(func(k, v []byte) error {
+			var m bBook
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("decoding book %q: %w", k, err)
+			}
+			b := book.Books{m.Book(string(k), "")}
+			if b.MergeSubjects(canonical, subjects...) != 0 {
+				merged = append(merged, b...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, b := range merged {
+			if err := putBook(tx, b, false); err != nil {
+				return err
+			}
+		}
+		aliases, err := readSubjectAliases(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(aliasBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(aliasBucket)
+		if err != nil {
+			return err
+		}
+		for _, a := range aliases.Merge(canonical, subjects...) {
+			if err := bucket.Put([]byte(a.Name), []byte(a.Canonical)); err != nil {
+				return fmt.Errorf("writing subject alias %q: %w", a.Name, err)
+			}
+		}
+		return putAuditEntries(tx, entries...)
+	})
+	if err != nil {
+		return fmt.Errorf("merging subjects: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	var m []bCustomField
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(fieldsKey)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &m)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading custom fields: %w", err)
+	}
+	return customFields(m), nil
+}
+
+// UpdateCustomFields replaces the custom fields.
+// The values of books are kept for fields that are removed.
+func (d *Database) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	data, err := json.Marshal(boltCustomFields(fields))
+	if err != nil {
+		return fmt.Errorf("encoding custom fields: %w", err)
+	}
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(fieldsKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("updating custom fields: %w", err)
+	}
+	return nil
+}
+
+// putAuditEntries adds the entries to the buckets of their books in the audit log bucket.
+// The entries are keyed by the sequence of the bucket, so they are kept in the order they were added.
+func putAuditEntries(tx *bbolt.Tx, entries ...book.AuditEntry) error {
+	for _, e := range entries {
+		bucket, err := tx.Bucket(auditBucket).CreateBucketIfNotExists([]byte(e.BookID))
+		if err != nil {
+			return fmt.Errorf("creating audit log bucket for book %q: %w", e.BookID, err)
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(boltAuditEntry(e))
+		if err != nil {
+			return fmt.Errorf("encoding audit entry %q: %w", e.ID, err)
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := bucket.Put(key, data); err != nil {
+			return fmt.Errorf("writing audit entry %q: %w", e.ID, err)
+		}
+	}
+	return nil
+}
+
+// readAuditEntries reads the entries in the buckets of all books in the audit log bucket.
+func readAuditEntries(tx *bbolt.Tx) ([]book.AuditEntry, error) {
+	var entries []book.AuditEntry
+	err := tx.Bucket(auditBucket).ForEachBucket(func(bookID []byte) error {
+		return tx.Bucket(auditBucket).Bucket(bookID).ForEach(func(k, v []byte) error {
+			var m bAuditEntry
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("decoding audit entry %x: %w", k, err)
+			}
+			entries = append(entries, m.AuditEntry(string(bookID)))
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// allBooks reads all books without images.
+func (d *Database) allBooks() (book.Books, error) {
+	var books book.Books
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(booksBucket).ForEach(func(k, v []byte) error {
+			var m bBook
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("decoding book %q: %w", k, err)
+			}
+			books = append(books, m.Book(string(k), ""))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading all books: %w", err)
+	}
+	return books, nil
+}
+
+// readSubjectAliases reads the aliases, which are sorted by name because bolt keys are sorted.
+func readSubjectAliases(tx *bbolt.Tx) (book.SubjectAliases, error) {
+	var aliases book.SubjectAliases
+	err := tx.Bucket(aliasBucket).ForEach(func(k, v []byte) error {
+		aliases = append(aliases, book.SubjectAlias{Name: string(k), Canonical: string(v)})
+		return nil
+	})
+	return aliases, err
+}
+
+func bookExists(tx *bbolt.Tx, id string) error {
+	if tx.Bucket(booksBucket).Get([]byte(id)) == nil {
+		return fmt.Errorf("no book with id of %q", id)
+	}
+	return nil
+}
+
+// readBook reads the book with its image.
+func readBook(tx *bbolt.Tx, id string) (book.Book, error) {
+	key := []byte(id)
+	data := tx.Bucket(booksBucket).Get(key)
+	if data == nil {
+		return book.Book{}, book.IDNotFoundError(id)
+	}
+	var m bBook
+	if err := json.Unmarshal(data, &m); err != nil {
+		return book.Book{}, fmt.Errorf("decoding book: %w", err)
+	}
+	imageBase64 := tx.Bucket(imagesBucket).Get(key)
+	return m.Book(id, string(imageBase64)), nil
+}
+
+func putBook(tx *bbolt.Tx, b book.Book, updateImage bool) error {
+	data, err := json.Marshal(boltBook(b))
+	if err != nil {
+		return fmt.Errorf("encoding book: %w", err)
+	}
+	if old, err := readBook(tx, b.ID); err == nil {
+		if err := unindexISBNs(tx, old); err != nil {
+			return err
+		}
+	}
+	key := []byte(b.ID)
+	if err := tx.Bucket(booksBucket).Put(key, data); err != nil {
+		return fmt.Errorf("writing book: %w", err)
+	}
+	if err := indexISBNs(tx, b); err != nil {
+		return err
+	}
+	if !updateImage {
+		return nil
+	}
+	images := tx.Bucket(imagesBucket)
+	if len(b.ImageBase64) == 0 {
+		return images.Delete(key)
+	}
+	return images.Put(key, []byte(b.ImageBase64))
+}
+
+// isbnKey is the key of the book with the isbn in the isbns bucket.
+// The key starts with the isbn so the books with it can be found by seeking to the key of the isbn without an id.
+func isbnKey(isbn, id string) []byte {
+	return []byte(isbn + "\x00" + id)
+}
+
+// indexISBNs points the isbns of the book to its id.
+// Books with the same isbns are indexed together rather than replacing each other.
+func indexISBNs(tx *bbolt.Tx, b book.Book) error {
+	isbns := tx.Bucket(isbnsBucket)
+	for _, isbn := range []string{b.EanIsbn13, b.UpcIsbn10} {
+		if len(isbn) == 0 {
+			continue
+		}
+		if err := isbns.Put(isbnKey(isbn, b.ID), []byte(b.ID)); err != nil {
+			return fmt.Errorf("indexing isbn %q: %w", isbn, err)
+		}
+	}
+	return nil
+}
+
+// unindexISBNs removes the isbns of the book from the index.
+func unindexISBNs(tx *bbolt.Tx, b book.Book) error {
+	isbns := tx.Bucket(isbnsBucket)
+	for _, isbn := range []string{b.EanIsbn13, b.UpcIsbn10} {
+		if len(isbn) == 0 {
+			continue
+		}
+		if err := isbns.Delete(isbnKey(isbn, b.ID)); err != nil {
+			return fmt.Errorf("removing isbn %q from index: %w", isbn, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,663 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"go.etcd.io/bbolt"
+)
+
+func DatabaseHelper(t *testing.T) *Database {
+	t.Helper()
+	url := "bolt://" + filepath.Join(t.TempDir(), "library.db")
+	d, err := NewDatabase(url)
+	if err != nil {
+		t.Fatalf("creating database: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestNewDatabase(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		wantOk bool
+	}{
+		{"bad url", "bolt ://", false},
+		{"missing path", "bolt://", false},
+		{"folder does not exist", "bolt:///missing/folder/library.db", false},
+		{"happy path", "bolt://" + filepath.Join(t.TempDir(), "library.db"), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewDatabase(test.url)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			default:
+				got.Close()
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	url := "bolt://" + filepath.Join(t.TempDir(), "library.db")
+	d, err := NewDatabase(url)
+	if err != nil {
+		t.Fatalf("creating database: %v", err)
+	}
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		books := tx.Bucket(booksBucket)
+		if err := books.Put([]byte("old"), []byte(`{"title":"Old Book","ean_isbn13":"9780306406157"}`)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(isbnsBucket).Put([]byte("9780306406157"), []byte("old")); err != nil { // isbns used to be the only parts of keys
+			return err
+		}
+		if err := books.Put([]byte("new"), []byte(`{"title":"New Book","language":"es","format":"dvd"}`)); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Delete(versionKey) // as if the database was created before migrations
+	})
+	if err != nil {
+		t.Fatalf("writing books without defaults: %v", err)
+	}
+	d.Close()
+	d, err = NewDatabase(url)
+	if err != nil {
+		t.Fatalf("reopening database: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	for id, want := range map[string][2]string{"old": {"en", "print"}, "new": {"es", "dvd"}} {
+		b, err := d.ReadBook(ctx, id)
+		switch {
+		case err != nil:
+			t.Errorf("reading %q book: %v", id, err)
+		case want != [2]string{b.Language, b.Format}:
+			t.Errorf("wanted %q book to have language and format of %q, got %q and %q", id, want, b.Language, b.Format)
+		}
+	}
+	if b, err := d.ReadBookByISBN(ctx, "9780306406157"); err != nil || b.ID != "old" {
+		t.Errorf("wanted old book to be reindexed by isbn, got %v (error: %v)", b, err)
+	}
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		if want, got := "2", string(tx.Bucket(metaBucket).Get(versionKey)); want != got {
+			t.Errorf("wanted schema version %q, got %q", want, got)
+		}
+		if tx.Bucket(isbnsBucket).Get([]byte("9780306406157")) != nil {
+			t.Errorf("wanted isbn key without id to be removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("reading schema version: %v", err)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"bolt:library.db", "library.db"},
+		{"bolt:///home/username/library.db", "/home/username/library.db"},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			got, err := filePath(test.url)
+			switch {
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.want != got:
+				t.Errorf("not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestBooks(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	addedDate := time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC)
+	created, err := d.CreateBooks(ctx, []book.Book{
+		{Header: book.Header{Title: "Zoology", Author: "Ann Lee", Subject: "Animals"}, Contributors: []book.Contributor{{Name: "Bob Smith", Role: "editor", SortName: "Smith, Bob"}}, Series: "Zoos", Volume: 2, AddedDate: addedDate, ImageBase64: "i1"},
+		{Header: book.Header{Title: "Lemurs", Subject: "Animals"}, DeweyDecClass: "599.8", AddedDate: addedDate},
+		{Header: book.Header{Title: "Secrets", Subject: "Behind others"}, AddedDate: addedDate},
+	})
+	if err != nil {
+		t.Fatalf("creating books: %v", err)
+	}
+	if len(created) != 3 || len(created[0].ID) == 0 || created[0].ID == created[1].ID {
+		t.Fatalf("wanted three books with different ids, got %v", created)
+	}
+	t.Run("ReadBookSubjects", func(t *testing.T) {
+		want := []book.Subject{{Name: "Animals", Count: 2}, {Name: "Behind others", Count: 1}}
+		got, err := d.ReadBookSubjects(ctx, 5, 0)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBookAuthors", func(t *testing.T) {
+		want := []book.Author{{Name: "Ann Lee", SortName: "Lee, Ann", Count: 1}, {Name: "Bob Smith", SortName: "Smith, Bob", Count: 1}}
+		got, err := d.ReadBookAuthors(ctx, 5, 0)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBookHeaders", func(t *testing.T) {
+		filter := book.Filter{Subject: "Animals"}
+		want := []book.Header{created[0].Header}
+		got, err := d.ReadBookHeaders(ctx, filter, 1, 1)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadShelvedBooks", func(t *testing.T) {
+		got, err := d.ReadShelvedBooks(ctx)
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		want := make(map[string]book.ShelvedBook, len(created))
+		for _, b := range created {
+			want[b.ID] = book.ShelvedBook{Header: b.Header, DeweyDecClass: b.DeweyDecClass}
+		}
+		if len(want) != len(got) {
+			t.Errorf("wanted %v books, got %v", len(want), len(got))
+		}
+		for _, b := range got {
+			if want[b.ID] != b {
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", want[b.ID], b)
+			}
+		}
+	})
+	t.Run("ReadSeriesBooks", func(t *testing.T) {
+		want := []book.SeriesBook{{Header: created[0].Header, Series: "Zoos", Volume: 2}}
+		got, err := d.ReadSeriesBooks(ctx)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBook", func(t *testing.T) {
+		want := created[0]
+		got, err := d.ReadBook(ctx, want.ID)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, *got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+		}
+		if _, err := d.ReadBook(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+			t.Errorf("wanted ErrNotFound reading unknown book, got %v", err)
+		}
+	})
+	t.Run("UpdateBook", func(t *testing.T) {
+		b := created[0]
+		b.Title = "Zoology 2"
+		b.ImageBase64 = "ignored"
+		if err := d.UpdateBook(ctx, b, false); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		b.ImageBase64 = "i1"
+		if got, err := d.ReadBook(ctx, b.ID); err != nil || !reflect.DeepEqual(b, *got) {
+			t.Errorf("wanted %v, got %v (error: %v)", b, got, err)
+		}
+		b.ImageBase64 = ""
+		if err := d.UpdateBook(ctx, b, true); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		if got, err := d.ReadBook(ctx, b.ID); err != nil || !reflect.DeepEqual(b, *got) {
+			t.Errorf("wanted image cleared: %v, got %v (error: %v)", b, got, err)
+		}
+		if err := d.UpdateBook(ctx, book.Book{Header: book.Header{ID: "unknown"}}, true); err == nil {
+			t.Errorf("wanted error updating unknown book")
+		}
+	})
+	t.Run("DeleteBook", func(t *testing.T) {
+		id := created[2].ID
+		if err := d.DeleteBook(ctx, id); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		if err := d.DeleteBook(ctx, id); err == nil {
+			t.Errorf("wanted error deleting book twice")
+		}
+		if _, err := d.ReadBook(ctx, id); err == nil {
+			t.Errorf("wanted error reading deleted book")
+		}
+	})
+}
+
+func TestReadBookByISBN(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	created, err := d.CreateBooks(ctx, []book.Book{
+		{Header: book.Header{Title: "t1"}, EanIsbn13: "9780306406157", UpcIsbn10: "0306406152"},
+		{Header: book.Header{Title: "t2"}, EanIsbn13: "9791234567896"},
+		{Header: book.Header{Title: "t3"}, EanIsbn13: "9791234567896"},
+	})
+	if err != nil {
+		t.Fatalf("creating books: %v", err)
+	}
+	wantISBN := func(isbn, wantID string) {
+		t.Helper()
+		got, err := d.ReadBookByISBN(ctx, isbn)
+		switch {
+		case len(wantID) == 0:
+			if !errors.Is(err, book.ErrNotFound) {
+				t.Errorf("wanted ErrNotFound for %q, got %v", isbn, err)
+			}
+		case err != nil:
+			t.Errorf("unwanted error for %q: %v", isbn, err)
+		case wantID != got.ID:
+			t.Errorf("wanted book %q for %q, got %q", wantID, isbn, got.ID)
+		}
+	}
+	first, second := created[1].ID, created[2].ID
+	if second < first {
+		first, second = second, first
+	}
+	wantISBN("9780306406157", created[0].ID)
+	wantISBN("0306406152", created[0].ID)
+	wantISBN("9791234567896", first)
+	wantISBN("", "")
+	b := created[0]
+	b.EanIsbn13, b.UpcIsbn10 = "9780804429573", "080442957X"
+	if err := d.UpdateBook(ctx, b, false); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	wantISBN("9780306406157", "")
+	wantISBN("080442957X", created[0].ID)
+	if err := d.DeleteBook(ctx, first); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	wantISBN("9791234567896", second)
+	if err := d.DeleteBook(ctx, second); err != nil {
+		t.Fatalf("deleting other book: %v", err)
+	}
+	wantISBN("9791234567896", "")
+}
+
+func TestReadBookMetadata(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	created, err := d.CreateBooks(ctx, []book.Book{book.Book{Header: book.Header{Title: "t1"}, ImageBase64: "img"}})
+	if err != nil {
+		t.Fatalf("creating book: %v", err)
+	}
+	want := created[0]
+	want.ImageBase64 = ""
+	got, err := d.ReadBookMetadata(ctx, want.ID)
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(want, *got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+	}
+	if _, err := d.ReadBookMetadata(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted ErrNotFound, got %v", err)
+	}
+}
+
+func TestAdminPassword(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	got, err := d.ReadAdminPassword(ctx)
+	if err != nil || len(got) != 0 {
+		t.Errorf("wanted no password before it is set, got %q (error: %v)", got, err)
+	}
+	if err := d.UpdateAdminPassword(ctx, "hash48"); err != nil {
+		t.Fatalf("updating admin password: %v", err)
+	}
+	got, err = d.ReadAdminPassword(ctx)
+	if want := "hash48"; err != nil || want != string(got) {
+		t.Errorf("wanted %q, got %q (error: %v)", want, got, err)
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	got, err := d.ReadCustomFields(ctx)
+	if err != nil || len(got) != 0 {
+		t.Errorf("wanted no custom fields before they are updated, got %v (error: %v)", got, err)
+	}
+	want := []book.CustomField{
+		{Name: "Donor", Type: book.FieldText},
+		{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+	}
+	if err := d.UpdateCustomFields(ctx, want...); err != nil {
+		t.Fatalf("updating custom fields: %v", err)
+	}
+	got, err = d.ReadCustomFields(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading custom fields: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestCreateBooksHistory(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	audit := book.Audit{Actor: "Ann", Action: book.ActionCreate}
+	created, err := d.CreateBooks(ctx, []book.Book{{Header: book.Header{Title: "Dune"}}}, audit)
+	if err != nil {
+		t.Fatalf("creating book: %v", err)
+	}
+	got, err := d.ReadBookHistory(ctx, created[0].ID)
+	wantChange := book.FieldChange{Field: "title", After: "Dune"}
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 1, got[0].BookID != created[0].ID, got[0].Actor != "Ann", got[0].Action != book.ActionCreate:
+		t.Errorf("wanted create entry by Ann, got %v", got)
+	case len(got[0].Changes) == 0 || got[0].Changes[0] != wantChange:
+		t.Errorf("wanted title change %v, got %v", wantChange, got[0].Changes)
+	}
+}
+
+func TestBookHistory(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	if got, err := d.ReadBookHistory(ctx, "1"); err != nil || len(got) != 0 {
+		t.Errorf("wanted no history before any changes, got %v (error: %v)", got, err)
+	}
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune"}},
+		{Header: book.Header{ID: "2", Title: "Emma"}},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e2 := book.AuditEntry{ID: "e2", BookID: "2", Time: time1, Actor: "Ann", Action: book.ActionDelete}
+	e3 := book.AuditEntry{ID: "e3", BookID: "1", Time: time.Now().Add(time.Hour).UTC(), Actor: "Bob", Action: book.ActionDelete}
+	b := books[0]
+	b.Title = "Dune 2"
+	if err := d.UpdateBook(ctx, b, false, book.Audit{Actor: "Ann", Action: book.ActionUpdate}); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "unknown", book.AuditEntry{ID: "bad", BookID: "1"}); err == nil {
+		t.Errorf("wanted error deleting unknown book")
+	}
+	if err := d.DeleteBook(ctx, "2", e2); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "1", e3); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	wantChanges := []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}
+	got, err := d.ReadBookHistory(ctx, "1")
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 2 || !reflect.DeepEqual(e3, got[0]):
+		t.Errorf("wanted delete entry first: %v", got)
+	case got[1].BookID != "1", got[1].Actor != "Ann", got[1].Action != book.ActionUpdate:
+		t.Errorf("unwanted update entry: %v", got[1])
+	case !reflect.DeepEqual(wantChanges, got[1].Changes):
+		t.Errorf("changes not equal: \n wanted: %v \n got:    %v", wantChanges, got[1].Changes)
+	}
+}
+
+func TestImportTrash(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	b1 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "1", Title: "Dune"}, ImageBase64: "IMG"}, DeletedDate: time1}
+	b2 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "2", Title: "Emma"}}, DeletedDate: time1.Add(time.Hour)}
+	if err := d.ImportTrash(ctx, b1); err != nil {
+		t.Fatalf("importing trash: %v", err)
+	}
+	old := b1
+	old.Title = "Old"
+	if err := d.ImportTrash(ctx, old, b2); err != nil {
+		t.Fatalf("importing trash again: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 2 || got[0] != b2.TrashedBook() || got[1] != b1.TrashedBook() {
+		t.Errorf("unwanted trash, wanted books already in the trash to be kept: %v (error: %v)", got, err)
+	}
+	got, err := d.ReadDeletedBook(ctx, "1")
+	switch {
+	case err != nil:
+		t.Errorf("reading deleted book: %v", err)
+	case !reflect.DeepEqual(b1, *got):
+		t.Errorf("deleted books not equal: \n wanted: %v \n got:    %v", b1, *got)
+	}
+	if _, err := d.ReadDeletedBook(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error reading book that is not in the trash, got %v", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "2", Time: time1.Add(time.Hour), Actor: "Ann", Action: book.ActionDelete}
+	e3 := book.AuditEntry{ID: "e3", BookID: "1", Time: time1.Add(2 * time.Hour), Actor: "Bob", Action: book.ActionRestore}
+	if err := d.ImportAuditLog(ctx, e1, e2); err != nil {
+		t.Fatalf("importing audit log: %v", err)
+	}
+	if err := d.ImportAuditLog(ctx, e2, e3); err != nil {
+		t.Fatalf("importing audit log again: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2, e3}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestImportAndMergeAuditEntries(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionImport, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "1", Time: time1.Add(time.Hour), Actor: "Bob", Action: book.ActionMerge, Changes: []book.FieldChange{{Field: "subject", Before: "sci-fi", After: "Science Fiction"}}}
+	books := []book.Book{{Header: book.Header{ID: "1", Title: "Dune 2", Subject: "sci-fi"}}}
+	if err := d.ImportBooks(ctx, false, books, e1); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"sci-fi"}, e2); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune"}, EanIsbn13: "9780306406157", ImageBase64: "IMG"},
+		{Header: book.Header{ID: "2", Title: "Emma"}},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	for _, b := range books {
+		if err := d.DeleteBook(ctx, b.ID); err != nil {
+			t.Fatalf("deleting book: %v", err)
+		}
+	}
+	if got, err := d.ReadBookHeaders(ctx, book.Filter{}, 10, 0); err != nil || len(got) != 0 {
+		t.Errorf("wanted trashed books to be hidden, got %v (error: %v)", got, err)
+	}
+	if _, err := d.ReadBookByISBN(ctx, "9780306406157"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted trashed book to be hidden from isbn lookups, got %v", err)
+	}
+	trash, err := d.ReadTrash(ctx)
+	switch {
+	case err != nil:
+		t.Fatalf("reading trash: %v", err)
+	case len(trash) != 2, trash[1].Header != books[0].Header, trash[1].DeletedDate.IsZero():
+		t.Errorf("unwanted trash, wanted most recently deleted first: %v", trash)
+	}
+	e := book.AuditEntry{ID: "e1", BookID: "1", Action: book.ActionRestore}
+	if err := d.RestoreBook(ctx, "1", e); err != nil {
+		t.Fatalf("restoring book: %v", err)
+	}
+	if got, err := d.ReadBookByISBN(ctx, "9780306406157"); err != nil || !reflect.DeepEqual(books[0], *got) {
+		t.Errorf("wanted restored book to keep its image and isbn: wanted %v, got %v (error: %v)", books[0], got, err)
+	}
+	if err := d.RestoreBook(ctx, "1"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error restoring book that is not in the trash, got %v", err)
+	}
+	if got, err := d.ReadBookHistory(ctx, "1"); err != nil || !reflect.DeepEqual([]book.AuditEntry{e}, got) {
+		t.Errorf("wanted restore in history, got %v (error: %v)", got, err)
+	}
+	if err := d.ImportBooks(ctx, false, []book.Book{books[1]}); err != nil {
+		t.Fatalf("importing book: %v", err)
+	}
+	if err := d.RestoreBook(ctx, "2"); !errors.Is(err, book.ErrIDExists) {
+		t.Errorf("wanted id exists error restoring book with the id of another book, got %v", err)
+	}
+	if err := d.PurgeBooks(ctx, "2", "unknown"); err != nil {
+		t.Fatalf("purging books: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted empty trash after purge, got %v (error: %v)", got, err)
+	}
+}
+
+func TestMergeSubjects(t *testing.T) {
+	d := DatabaseHelper(t)
+	ctx := context.Background()
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune", Subject: "sci-fi"}, ImageBase64: "i1"},
+		{Header: book.Header{ID: "2", Title: "Emma", Subject: "Fiction"}, Tags: []string{"sci-fi"}},
+		{Header: book.Header{ID: "3", Title: "Ubik", Subject: "science fiction "}},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"Sci-Fi"}); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "SF", []string{"science fiction"}); err != nil {
+		t.Fatalf("merging subjects again: %v", err)
+	}
+	wantSubjects := []book.Subject{{Name: "Fiction", Count: 1}, {Name: "SF", Count: 3}}
+	gotSubjects, err := d.ReadBookSubjects(ctx, 5, 0)
+	switch {
+	case err != nil:
+		t.Errorf("reading subjects: %v", err)
+	case !reflect.DeepEqual(wantSubjects, gotSubjects):
+		t.Errorf("subjects not equal: \n wanted: %v \n got:    %v", wantSubjects, gotSubjects)
+	}
+	wantAliases := []book.SubjectAlias{
+		{Name: "Sci-Fi", Canonical: "SF"},
+		{Name: "science fiction", Canonical: "SF"},
+	}
+	gotAliases, err := d.ReadSubjectAliases(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading aliases: %v", err)
+	case !reflect.DeepEqual(wantAliases, gotAliases):
+		t.Errorf("aliases not equal: \n wanted: %v \n got:    %v", wantAliases, gotAliases)
+	}
+	if b, err := d.ReadBook(ctx, "1"); err != nil || b.ImageBase64 != "i1" {
+		t.Errorf("wanted image to be kept, got %v (error: %v)", b, err)
+	}
+	if b, err := d.ReadBook(ctx, "2"); err != nil || !reflect.DeepEqual([]string{"SF"}, b.Tags) {
+		t.Errorf("wanted tag to be merged, got %v (error: %v)", b, err)
+	}
+}
+
+func TestImportBooks(t *testing.T) {
+	existing := book.Book{Header: book.Header{ID: "1", Title: "Lemurs"}, ImageBase64: "i1"}
+	replacement := book.Book{Header: book.Header{ID: "1", Title: "Lemurs, 2nd edition"}}
+	added := book.Book{Header: book.Header{ID: "2", Title: "Zoology"}, ImageBase64: "i2"}
+	tests := []struct {
+		name      string
+		upsert    bool
+		books     []book.Book
+		wantOk    bool
+		wantBooks []book.Book
+	}{
+		{
+			name:      "collision",
+			books:     []book.Book{added, replacement},
+			wantBooks: []book.Book{existing},
+		},
+		{
+			name:      "happy path",
+			books:     []book.Book{added},
+			wantOk:    true,
+			wantBooks: []book.Book{existing, added},
+		},
+		{
+			name:      "upsert",
+			upsert:    true,
+			books:     []book.Book{added, replacement},
+			wantOk:    true,
+			wantBooks: []book.Book{replacement, added},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t)
+			ctx := context.Background()
+			if err := d.ImportBooks(ctx, false, []book.Book{existing}); err != nil {
+				t.Fatalf("importing existing book: %v", err)
+			}
+			err := d.ImportBooks(ctx, test.upsert, test.books)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+			var got []book.Book
+			for _, want := range test.wantBooks {
+				b, err := d.ReadBook(ctx, want.ID)
+				if err != nil {
+					t.Fatalf("reading book: %v", err)
+				}
+				got = append(got, *b)
+			}
+			if !reflect.DeepEqual(test.wantBooks, got) {
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.wantBooks, got)
+			}
+			if _, err := d.ReadBook(ctx, added.ID); !test.wantOk && err == nil {
+				t.Errorf("book imported despite error")
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// Package csv provides a read-only database for the library from the embedded CSV file.
+// Package csv provides databases for the library that are stored as CSV, either read-only from the embedded file or writable from a file on disk.
 package csv
 
 import (
@@ -11,27 +11,56 @@ import (
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
 )
 
+// Database is a read-only database of the books of a csv file.
+// CustomFields are the text fields of the custom columns of the file.
 type Database struct {
-	Books []book.Book
+	Books        []book.Book
+	CustomFields []book.CustomField
 }
 
 const (
-	header     = "id,title,author,description,subject,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64"
+	header     = "id,title,author,contributors,description,subject,tags,series,volume,language,edition,format,reading-level,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64"
 	dateLayout = book.SlashMMDDYYYY
+	// customColumnPrefix starts the names of the columns of custom fields, which follow the columns of the header.
+	customColumnPrefix = "custom:"
 )
 
-var headerRecord = strings.Split(header, ",")
+var (
+	headerRecord = strings.Split(header, ",")
+	// optionalColumns were added to the header after files were written without them.
+	// Files without optional columns are read as if the columns were empty.
+	optionalColumns = map[string]struct{}{
+		"contributors":  {},
+		"tags":          {},
+		"series":        {},
+		"volume":        {},
+		"language":      {},
+		"edition":       {},
+		"format":        {},
+		"reading-level": {},
+		"image-base64":  {}, // left out of dumps without images
+	}
+	// imageColumn is the index of the image-base64 column, which is the last column of the header.
+	imageColumn = len(headerRecord) - 1
+)
+
+// columns are the indexes of the columns of the header and the custom columns in the records of a file, or -1 for optional columns the file does not have.
+type columns []int
 
 func NewDatabase(r io.Reader) (*Database, error) {
-	records, err := readRecords(r)
+	records, customNames, err := readRecords(r)
 	if err != nil {
 		return nil, err
 	}
 	d := Database{
 		Books: make([]book.Book, len(records)),
 	}
+	for _, name := range customNames {
+		f := book.CustomField{Name: name, Type: book.FieldText}
+		d.CustomFields = append(d.CustomFields, f)
+	}
 	for i, r := range records {
-		b, err := bookFromRecord(r)
+		b, err := bookFromRecord(r, customNames)
 		if err != nil {
 			return nil, fmt.Errorf("reading book %v: %w", i, err)
 		}
@@ -41,122 +70,184 @@ func NewDatabase(r io.Reader) (*Database, error) {
 	return &d, nil
 }
 
-func readRecords(r io.Reader) ([][]string, error) {
+// readRecords reads the records of the file and the names of its custom columns.
+func readRecords(r io.Reader) ([][]string, []string, error) {
 	csvR := csv.NewReader(r)
 	records, err := csvR.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("reading library csv: %w", err)
+		return nil, nil, fmt.Errorf("reading library csv: %w", err)
 	}
 	if len(records) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
-	wantHeader := headerRecord
-	gotHeader := records[0]
-	if len(wantHeader) != len(gotHeader) {
-		return nil, fmt.Errorf("header too short/long: wanted %q", header)
-	}
-	for i := range wantHeader {
-		if want, got := wantHeader[i], gotHeader[i]; want != got {
-			return nil, fmt.Errorf("header column %v: wanted %q, got %q", i, want, got)
-		}
+	cols, customNames, err := checkHeader(records[0])
+	if err != nil {
+		return nil, nil, err
 	}
 	records = records[1:] // skip header row
-	return records, nil
+	for i, r := range records {
+		records[i] = cols.upgrade(r)
+	}
+	return records, customNames, nil
 }
 
-func (d Database) ReadBookSubjects(limit, offset int) ([]book.Subject, error) {
-	if limit < 0 {
-		return []book.Subject{}, nil
+// checkHeader ensures the header is the header, possibly without some of the optional columns, followed by any custom columns.
+// The names of the custom fields of the custom columns are returned.
+func checkHeader(gotHeader []string) (columns, []string, error) {
+	cols := make(columns, len(headerRecord))
+	j := 0
+	for i, want := range headerRecord {
+		if j < len(gotHeader) && gotHeader[j] == want {
+			cols[i] = j
+			j++
+			continue
+		}
+		if _, ok := optionalColumns[want]; ok {
+			cols[i] = -1
+			continue
+		}
+		if j >= len(gotHeader) {
+			return nil, nil, fmt.Errorf("header too short: wanted %q", header)
+		}
+		return nil, nil, fmt.Errorf("header column %v: wanted %q, got %q", j, want, gotHeader[j])
 	}
-	if offset < 0 {
-		offset = 0
+	var customNames []string
+	seen := make(map[string]struct{})
+	for ; j < len(gotHeader); j++ {
+		name := strings.TrimPrefix(gotHeader[j], customColumnPrefix)
+		if name == gotHeader[j] {
+			return nil, nil, fmt.Errorf("header too long: wanted %q, followed by custom columns starting with %q", header, customColumnPrefix)
+		}
+		name = book.NormalizeSubject(name)
+		if _, ok := seen[name]; ok || len(name) == 0 {
+			return nil, nil, fmt.Errorf("header column %v: invalid or duplicate custom column: %q", j, gotHeader[j])
+		}
+		seen[name] = struct{}{}
+		cols = append(cols, j)
+		customNames = append(customNames, name)
 	}
-	m := make(map[string]int)
-	for _, b := range d.Books {
-		m[b.Subject]++
+	return cols, customNames, nil
+}
+
+// upgrade adds empty optional columns that the file does not have to the record.
+// Records with the wrong number of columns are not changed so bookFromRecord reports them.
+func (cols columns) upgrade(r []string) []string {
+	n := 0
+	for _, c := range cols {
+		if c >= 0 {
+			n++
+		}
 	}
-	if offset > len(m) {
-		return []book.Subject{}, nil
+	if n == len(cols) || len(r) != n {
+		return r
 	}
-	subjects := make(book.Subjects, 0, len(m))
-	for name, count := range m {
-		s := book.Subject{
-			Name:  name,
-			Count: count,
+	upgraded := make([]string, len(cols))
+	for i, c := range cols {
+		if c >= 0 {
+			upgraded[i] = r[c]
 		}
-		subjects = append(subjects, s)
-	}
-	subjects.Sort()
-	subjects = subjects[offset:]
-	if len(subjects) > limit {
-		subjects = subjects[:limit]
 	}
+	return upgraded
+}
+
+func (d Database) ReadBookSubjects(limit, offset int) ([]book.Subject, error) {
+	subjects := book.Books(d.Books).Subjects(limit, offset)
 	return subjects, nil
 }
 
+func (d Database) ReadBookAuthors(limit, offset int) ([]book.Author, error) {
+	authors := book.Books(d.Books).Authors(limit, offset)
+	return authors, nil
+}
+
 func (d Database) ReadBookHeaders(filter book.Filter, limit, offset int) ([]book.Header, error) {
-	books := d.Books
-	if limit < 0 || offset > len(books) {
-		return []book.Header{}, nil
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	headers := make([]book.Header, 0, limit+offset)
-	for _, b := range books {
-		if !filter.Matches(b) {
-			continue
-		}
-		headers = append(headers, b.Header)
-		if len(headers) == cap(headers) {
-			break
-		}
-	}
-	headers = headers[offset:]
-	if len(headers) > limit {
-		headers = headers[:limit]
-	}
+	headers := book.Books(d.Books).Headers(filter, limit, offset)
 	return headers, nil
 }
 
+func (d Database) ReadShelvedBooks() ([]book.ShelvedBook, error) {
+	return book.Books(d.Books).Shelved(), nil
+}
+
+func (d Database) ReadSeriesBooks() ([]book.SeriesBook, error) {
+	return book.Books(d.Books).SeriesBooks(), nil
+}
+
 func (d Database) ReadBook(id string) (*book.Book, error) {
 	for _, b := range d.Books {
 		if b.ID == id {
 			return &b, nil
 		}
 	}
-	return nil, fmt.Errorf("no book with id of %q", id)
+	return nil, book.IDNotFoundError(id)
 }
 
-func bookFromRecord(r []string) (*book.Book, error) {
-	if want, got := len(headerRecord), len(r); want != got {
+func (d Database) ReadBookByISBN(isbn string) (*book.Book, error) {
+	return book.Books(d.Books).FindISBN(isbn)
+}
+
+// ReadSubjectAliases returns no aliases because the embedded file has no subject registry.
+func (d Database) ReadSubjectAliases() ([]book.SubjectAlias, error) {
+	return nil, nil
+}
+
+func (d Database) ReadCustomFields() ([]book.CustomField, error) {
+	return d.CustomFields, nil
+}
+
+// bookFromRecord reads the book from the record, which has the columns of the header followed by the custom columns.
+func bookFromRecord(r []string, customNames []string) (*book.Book, error) {
+	if want, got := len(headerRecord)+len(customNames), len(r); want != got {
 		return nil, fmt.Errorf("expected %v columns, got %v", want, got)
 	}
 	sb := book.StringBook{
 		ID:            r[0],
 		Title:         r[1],
 		Author:        r[2],
-		Description:   r[3],
-		Subject:       r[4],
-		DeweyDecClass: r[5],
-		Pages:         r[6],
-		Publisher:     r[7],
-		PublishDate:   r[8],
-		AddedDate:     r[9],
-		EanIsbn13:     r[10],
-		UpcIsbn10:     r[11],
-		ImageBase64:   r[12],
+		Contributors:  r[3],
+		Description:   r[4],
+		Subject:       r[5],
+		Tags:          r[6],
+		Series:        r[7],
+		Volume:        r[8],
+		Language:      r[9],
+		Edition:       r[10],
+		Format:        r[11],
+		ReadingLevel:  r[12],
+		DeweyDecClass: r[13],
+		Pages:         r[14],
+		Publisher:     r[15],
+		PublishDate:   r[16],
+		AddedDate:     r[17],
+		EanIsbn13:     r[18],
+		UpcIsbn10:     r[19],
+		ImageBase64:   r[20],
+	}
+	for i, name := range customNames {
+		if sb.Custom == nil {
+			sb.Custom = make(map[string]string, len(customNames))
+		}
+		sb.Custom[name] = r[len(headerRecord)+i]
 	}
 	return sb.Book(dateLayout)
 }
 
-func record(b book.Book) []string {
-	return []string{
+// record writes the book with the values of the custom fields.
+func record(b book.Book, customNames []string) []string {
+	r := []string{
 		b.ID,
 		b.Title,
 		b.Author,
+		book.FormatContributors(b.Contributors),
 		b.Description,
 		b.Subject,
+		book.FormatTags(b.Tags),
+		b.Series,
+		volume(b.Volume),
+		b.Language,
+		b.Edition,
+		b.Format,
+		b.ReadingLevel,
 		b.DeweyDecClass,
 		strconv.Itoa(b.Pages),
 		b.Publisher,
@@ -166,23 +257,67 @@ func record(b book.Book) []string {
 		b.UpcIsbn10,
 		b.ImageBase64,
 	}
+	for _, name := range customNames {
+		r = append(r, b.Custom[name])
+	}
+	return r
+}
+
+// customHeader is the header followed by the columns of the custom fields.
+func customHeader(customNames []string) []string {
+	h := append([]string{}, headerRecord...)
+	for _, name := range customNames {
+		h = append(h, customColumnPrefix+name)
+	}
+	return h
+}
+
+// volume is the text of the volume number of a book, which is empty if the book is not numbered in a series.
+func volume(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
 }
 
 type Dump struct {
-	w *csv.Writer
+	w             *csv.Writer
+	customNames   []string
+	excludeImages bool
 }
 
-func NewDump(w io.Writer) *Dump {
+// NewDump writes the header to the writer, followed by a column for each of the custom fields.
+func NewDump(w io.Writer, customNames ...string) *Dump {
+	return newDump(w, false, customNames)
+}
+
+// NewDumpWithoutImages writes the header without the image-base64 column to the writer, followed by a column for each of the custom fields.
+// Files without the column are read as if the books have no images, so importing them keeps the images of the books they update.
+func NewDumpWithoutImages(w io.Writer, customNames ...string) *Dump {
+	return newDump(w, true, customNames)
+}
+
+func newDump(w io.Writer, excludeImages bool, customNames []string) *Dump {
 	d := Dump{
-		w: csv.NewWriter(w),
+		w:             csv.NewWriter(w),
+		customNames:   customNames,
+		excludeImages: excludeImages,
 	}
-	d.w.Write(headerRecord)
+	d.w.Write(d.withoutImage(customHeader(customNames)))
 	return &d
 }
 
 func (d *Dump) Write(books ...book.Book) {
 	for _, b := range books {
-		d.w.Write(record(b))
+		d.w.Write(d.withoutImage(record(b, d.customNames)))
 	}
 	d.w.Flush()
 }
+
+// withoutImage removes the image column from the record if the dump excludes images.
+func (d *Dump) withoutImage(r []string) []string {
+	if !d.excludeImages {
+		return r
+	}
+	return append(r[:imageColumn:imageColumn], r[imageColumn+1:]...)
+}
@@ -50,6 +50,29 @@ func TestNewDatabase(t *testing.T) {
 				return string(b)
 			}(),
 		},
+		{
+			name: "header without optional columns",
+			csv: headerWithoutOptionalColumns + `
+1,2,3,5,4,6,7,8,07/04/2001,11/16/2022,11,12,13
+`,
+			wantOk: true,
+			want:   &Database{Books: exampleCSV.books[:1]},
+		},
+		{
+			name: "custom columns",
+			csv: header + ",custom:Box" + `
+1,2,3,,5,4,,,,en,,print,,6,7,8,07/04/2001,11/16/2022,11,12,13, 12 
+`,
+			wantOk: true,
+			want: &Database{
+				Books: func() []book.Book {
+					b := exampleCSV.books[0]
+					b.Custom = map[string]string{"Box": "12"}
+					return []book.Book{b}
+				}(),
+				CustomFields: []book.CustomField{{Name: "Box", Type: "text"}},
+			},
+		},
 		{
 			name: "bad book (header is invalid book)",
 			csv:  header + "\n" + header,
@@ -124,7 +147,7 @@ func TestReadBookSubjects(t *testing.T) {
 		{Header: book.Header{Subject: "animals"}},
 		{Header: book.Header{Subject: "animals"}},
 		{Header: book.Header{Subject: "plants"}},
-		{Header: book.Header{Subject: "animals"}},
+		{Header: book.Header{Subject: "Animals "}},
 		{Header: book.Header{Subject: "liquids"}},
 	}
 	tests := []struct {
@@ -133,7 +156,7 @@ func TestReadBookSubjects(t *testing.T) {
 		offset int
 		want   []book.Subject
 	}{
-		{"zero offset", 2, 0, []book.Subject{{Name: "animals", Count: 3}, {Name: "liquids", Count: 1}}},
+		{"zero offset", 2, 0, []book.Subject{{Name: "Animals", Count: 3}, {Name: "liquids", Count: 1}}},
 		{"middle", 1, 1, []book.Subject{{Name: "liquids", Count: 1}}},
 		{"Last only", 3, 2, []book.Subject{{Name: "plants", Count: 2}}},
 		{"Past end", 2, 5, []book.Subject{}},
@@ -157,6 +180,45 @@ func TestReadBookSubjects(t *testing.T) {
 	}
 }
 
+func TestReadShelvedBooks(t *testing.T) {
+	d := Database{
+		Books: []book.Book{
+			{Header: book.Header{ID: "a", Title: "Lemurs"}, DeweyDecClass: "599.8", Pages: 9},
+			{Header: book.Header{ID: "b", Title: "Zebras"}},
+		},
+	}
+	want := []book.ShelvedBook{
+		{Header: book.Header{ID: "a", Title: "Lemurs"}, DeweyDecClass: "599.8"},
+		{Header: book.Header{ID: "b", Title: "Zebras"}},
+	}
+	got, err := d.ReadShelvedBooks()
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestReadSeriesBooks(t *testing.T) {
+	d := Database{
+		Books: []book.Book{
+			{Header: book.Header{ID: "a", Title: "Dune"}, Series: "Dune", Volume: 1, Pages: 9},
+			{Header: book.Header{ID: "b", Title: "Zebras"}},
+		},
+	}
+	want := []book.SeriesBook{
+		{Header: book.Header{ID: "a", Title: "Dune"}, Series: "Dune", Volume: 1},
+	}
+	got, err := d.ReadSeriesBooks()
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
 func TestReadBook(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -207,13 +269,89 @@ func TestReadBook(t *testing.T) {
 	}
 }
 
+func TestCheckHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		record    string
+		wantOk    bool
+		wantTitle string
+		wantTags  string
+	}{
+		{"too short", "id,title", "", false, "", ""},
+		{"too long", header + ",extra", "", false, "", ""},
+		{"duplicate custom column", header + ",custom:Box,custom: Box", "", false, "", ""},
+		{"empty custom column", header + ",custom: ", "", false, "", ""},
+		{"custom columns", header + ",custom:Box,custom:Donor", "1,t,a,,d,s,x,,,,,,,,1,,,01/02/2006,,,,12,Ann", true, "t", "x"},
+		{"missing required column", strings.Replace(header, "title,", "", 1), "", false, "", ""},
+		{"full header", header, "1,t,a,,d,s,x,,,,,,,,1,,,01/02/2006,,,", true, "t", "x"},
+		{
+			name:      "header with series but not language",
+			header:    "id,title,author,contributors,description,subject,tags,series,volume,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64",
+			record:    "1,t,a,,d,s,x,,,,1,,,01/02/2006,,,",
+			wantOk:    true,
+			wantTitle: "t",
+			wantTags:  "x",
+		},
+		{
+			name:      "header with tags and contributors but not series",
+			header:    "id,title,author,contributors,description,subject,tags,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64",
+			record:    "1,t,a,,d,s,x,,1,,,01/02/2006,,,",
+			wantOk:    true,
+			wantTitle: "t",
+			wantTags:  "x",
+		},
+		{
+			name:      "header with tags but not contributors",
+			header:    "id,title,author,description,subject,tags,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64",
+			record:    "1,t,a,d,s,x,,1,,,01/02/2006,,,",
+			wantOk:    true,
+			wantTitle: "t",
+			wantTags:  "x",
+		},
+		{"header without optional columns", headerWithoutOptionalColumns, "1,t,a,d,s,,1,,,01/02/2006,,,", true, "t", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cols, customNames, err := checkHeader(strings.Split(test.header, ","))
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			default:
+				r := cols.upgrade(strings.Split(test.record, ","))
+				switch {
+				case len(headerRecord)+len(customNames) != len(r):
+					t.Errorf("wanted upgraded record to have %v columns, got %v", len(headerRecord)+len(customNames), len(r))
+				case test.wantTitle != r[1], test.wantTags != r[6]:
+					t.Errorf("columns moved: %q", r)
+				}
+				if want, got := 2, len(cols.upgrade([]string{"too", "short"})); want != got {
+					t.Errorf("wanted record with wrong number of columns to not be upgraded, got %v columns", got)
+				}
+			}
+		})
+	}
+}
+
 func TestBookRecord(t *testing.T) {
 	r := []string{
 		"1",
 		"2",
 		"3",
+		"c1 | editor | s1",
 		"5",
 		"4", // subject should appear after description in csv
+		"t1; t2",
+		"ser",
+		"9",
+		"fr",
+		"3rd",
+		"dvd",
+		"children",
 		"6",
 		"7",
 		"8",
@@ -230,6 +368,14 @@ func TestBookRecord(t *testing.T) {
 			Author:  "3",
 			Subject: "4",
 		},
+		Tags:          []string{"t1", "t2"},
+		Contributors:  []book.Contributor{{Name: "c1", Role: "editor", SortName: "s1"}},
+		Series:        "ser",
+		Volume:        9,
+		Language:      "fr",
+		Edition:       "3rd",
+		Format:        "dvd",
+		ReadingLevel:  "children",
 		Description:   "5",
 		DeweyDecClass: "6",
 		Pages:         7,
@@ -242,12 +388,17 @@ func TestBookRecord(t *testing.T) {
 	}
 	t.Run("bookFromRecord", func(t *testing.T) {
 		t.Run("too short", func(t *testing.T) {
-			if _, err := bookFromRecord([]string{"single"}); err == nil {
+			if _, err := bookFromRecord([]string{"single"}, nil); err == nil {
 				t.Errorf("wanted error for record with one column")
 			}
 		})
+		t.Run("missing custom column", func(t *testing.T) {
+			if _, err := bookFromRecord(r, []string{"Box"}); err == nil {
+				t.Errorf("wanted error for record without custom column")
+			}
+		})
 		want := &b
-		got, err := bookFromRecord(r)
+		got, err := bookFromRecord(r, nil)
 		switch {
 		case err != nil:
 			t.Errorf("unwanted error: %v", err)
@@ -256,10 +407,26 @@ func TestBookRecord(t *testing.T) {
 		}
 	})
 	t.Run("record (to book)", func(t *testing.T) {
-		if want, got := r, record(b); !reflect.DeepEqual(want, got) {
+		if want, got := r, record(b, nil); !reflect.DeepEqual(want, got) {
 			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
 		}
 	})
+	t.Run("custom values", func(t *testing.T) {
+		customNames := []string{"Box", "Donor"}
+		b := b
+		b.Custom = map[string]string{"Donor": "Ann"}
+		customR := append(append([]string{}, r...), "", "Ann")
+		if want, got := customR, record(b, customNames); !reflect.DeepEqual(want, got) {
+			t.Errorf("record not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+		got, err := bookFromRecord(customR, customNames)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(&b, got):
+			t.Errorf("book not equal: \n wanted: %v \n got:    %v", b, got)
+		}
+	})
 }
 
 func TestDump(t *testing.T) {
@@ -273,16 +440,55 @@ func TestDump(t *testing.T) {
 	if want != got {
 		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
 	}
+	t.Run("custom fields", func(t *testing.T) {
+		b := books[0]
+		b.Custom = map[string]string{"Donor": "Ann"}
+		var sb strings.Builder
+		d := NewDump(&sb, "Box", "Donor")
+		d.Write(b)
+		want := header + ",custom:Box,custom:Donor" + `
+1,2,3,,5,4,,,,en,,print,,6,7,8,07/04/2001,11/16/2022,11,12,13,,Ann
+`
+		if got := sb.String(); want != got {
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("without images", func(t *testing.T) {
+		b := books[0]
+		b.Custom = map[string]string{"Box": "9"}
+		var sb strings.Builder
+		d := NewDumpWithoutImages(&sb, "Box")
+		d.Write(b)
+		want := strings.TrimSuffix(header, ",image-base64") + ",custom:Box" + `
+1,2,3,,5,4,,,,en,,print,,6,7,8,07/04/2001,11/16/2022,11,12,9
+`
+		if got := sb.String(); want != got {
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+		rows, err := ReadRows(strings.NewReader(sb.String()))
+		b.ImageBase64 = ""
+		switch {
+		case err != nil:
+			t.Errorf("reading dump: %v", err)
+		case len(rows) != 1 || rows[0].Err != nil:
+			t.Errorf("wanted 1 valid row, got %v", rows)
+		case !Equal(b, *rows[0].Book):
+			t.Errorf("books not equal: \n wanted: %v \n got:    %v", b, *rows[0].Book)
+		}
+	})
 }
 
+// headerWithoutOptionalColumns is the header of files from before books had tags, contributors, series, languages, editions, formats, and reading levels.
+const headerWithoutOptionalColumns = "id,title,author,description,subject,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64"
+
 var exampleCSV = struct {
 	csv   string
 	books []book.Book
 }{
 	csv: header + `
-1,2,3,5,4,6,7,8,07/04/2001,11/16/2022,11,12,13
-id1,title2,author3,description5,subject4,ddc6,32,publisher8,01/11/2008,06/01/2020,ean11,upc12,image13
-xyz*34,Thoughts,Anonymous,"Many essays about ""life,"" abridged.",poems,88.79,123,the world,07/04/2009,08/26/2022,xxx,yyy,zzz
+1,2,3,,5,4,,,,en,,print,,6,7,8,07/04/2001,11/16/2022,11,12,13
+id1,title2,author3,"Ann Lee | editor | Lee, A.",description5,subject4,,Readings,2,es,2nd,paperback,young adult,ddc6,32,publisher8,01/11/2008,06/01/2020,ean11,upc12,image13
+xyz*34,Thoughts,Anonymous,,"Many essays about ""life,"" abridged.",poems,essays; life,,,en,,print,,88.79,123,the world,07/04/2009,08/26/2022,xxx,yyy,zzz
 `,
 	books: []book.Book{
 		{
@@ -292,6 +498,8 @@ xyz*34,Thoughts,Anonymous,"Many essays about ""life,"" abridged.",poems,88.79,12
 				Author:  "3",
 				Subject: "4",
 			},
+			Language:      "en",
+			Format:        "print",
 			Description:   "5",
 			DeweyDecClass: "6",
 			Pages:         7,
@@ -309,6 +517,13 @@ xyz*34,Thoughts,Anonymous,"Many essays about ""life,"" abridged.",poems,88.79,12
 				Author:  "author3",
 				Subject: "subject4",
 			},
+			Contributors:  []book.Contributor{{Name: "Ann Lee", Role: "editor", SortName: "Lee, A."}},
+			Series:        "Readings",
+			Volume:        2,
+			Language:      "es",
+			Edition:       "2nd",
+			Format:        "paperback",
+			ReadingLevel:  "young adult",
 			Description:   "description5",
 			DeweyDecClass: "ddc6",
 			Pages:         32,
@@ -326,6 +541,9 @@ xyz*34,Thoughts,Anonymous,"Many essays about ""life,"" abridged.",poems,88.79,12
 				Author:  "Anonymous",
 				Subject: "poems",
 			},
+			Tags:          []string{"essays", "life"},
+			Language:      "en",
+			Format:        "print",
 			Description:   `Many essays about "life," abridged.`,
 			DeweyDecClass: "88.79",
 			Pages:         123,
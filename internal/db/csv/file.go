@@ -0,0 +1,711 @@
+package csv
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// FileDatabase is a writable database that stores books in a csv file.
+	// The file is rewritten atomically after each change.
+	// The admin password, subject aliases, and custom fields are stored in a sidecar json file next to the csv file.
+	// The values of custom fields are stored in custom columns of the csv file.
+	// The audit log is appended to a json lines file next to the csv file.
+	// Deleted books are moved to a trash json file next to the csv file.
+	FileDatabase struct {
+		mu       sync.RWMutex
+		path     string
+		lockFile *os.File
+		db       Database
+	}
+	// sidecar contains data that does not fit into the csv file.
+	sidecar struct {
+		AdminPassword string `json:"admin_password"`
+		// SubjectAliases are the canonical subjects of the aliases, by alias.
+		SubjectAliases map[string]string  `json:"subject_aliases,omitempty"`
+		CustomFields   []book.CustomField `json:"custom_fields,omitempty"`
+	}
+)
+
+const (
+	sidecarSuffix  = ".json"
+	lockSuffix     = ".lock"
+	auditLogSuffix = ".audit.jsonl"
+	trashSuffix    = ".trash.json"
+)
+
+// NewFileDatabase opens the csv file referenced by the url, creating it if it does not exist.
+// Urls are like "csvfile:library.csv" or "csvfile:///home/username/library.csv".
+// The file is locked while the database is open so other servers cannot use it.
+func NewFileDatabase(databaseURL string) (*FileDatabase, error) {
+	path, err := filePath(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	lockFile, err := lockPath(path + lockSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("locking database file: %w", err)
+	}
+	d := FileDatabase{
+		path:     path,
+		lockFile: lockFile,
+	}
+	if err := d.load(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return &d, nil
+}
+
+func filePath(databaseURL string) (string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing database url: %w", err)
+	}
+	path := u.Opaque
+	if len(path) == 0 {
+		path = u.Path
+	}
+	if len(path) == 0 {
+		return "", fmt.Errorf("database url missing file path: %q", databaseURL)
+	}
+	return path, nil
+}
+
+func (d *FileDatabase) load() error {
+	f, err := os.Open(d.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		d.db.Books = []book.Book{}
+		return d.save(d.db.Books)
+	case err != nil:
+		return fmt.Errorf("opening database file: %w", err)
+	}
+	defer f.Close()
+	db, err := NewDatabase(f)
+	if err != nil {
+		return fmt.Errorf("reading database file: %w", err)
+	}
+	d.db = *db
+	return nil
+}
+
+// Close releases the lock on the database file.
+func (d *FileDatabase) Close() error {
+	f := d.lockFile
+	d.lockFile = nil
+	return unlockFile(f)
+}
+
+// CreateBooks creates the books with new ids, appending an entry to the audit log for each audit of each book after the books are saved.
+func (d *FileDatabase) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	all := d.copyBooks(len(books))
+	created := make([]book.Book, len(books))
+	var entries []book.AuditEntry
+	for i, b := range books {
+		b.ID = book.NewID()
+		all = append(all, b)
+		created[i] = b
+		entries = append(entries, book.Entries(audits, book.Book{}, b)...)
+	}
+	if err := d.save(all); err != nil {
+		return nil, fmt.Errorf("creating books: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return nil, fmt.Errorf("books created, but writing audit log: %w", err)
+	}
+	return created, nil
+}
+
+// ImportBooks creates the books with the ids they already have, appending the entries to the audit log after the books are saved.
+// Books with ids that already exist are replaced if upsert is true.
+// Otherwise, no books are imported if any of the ids already exist.
+func (d *FileDatabase) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if err := book.Books(books).CheckIDs(); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	all := d.copyBooks(len(books))
+	var existingIDs []string
+	for _, b := range books {
+		i, err := d.bookIndex(b.ID)
+		if err != nil {
+			all = append(all, b)
+			continue
+		}
+		existingIDs = append(existingIDs, b.ID)
+		all[i] = b
+	}
+	if len(existingIDs) != 0 && !upsert {
+		return book.ExistingIDsError(existingIDs)
+	}
+	if err := d.save(all); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return fmt.Errorf("books imported, but writing audit log: %w", err)
+	}
+	return nil
+}
+
+func (d *FileDatabase) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadBookSubjects(limit, offset)
+}
+
+func (d *FileDatabase) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadBookAuthors(limit, offset)
+}
+
+func (d *FileDatabase) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadBookHeaders(filter, limit, offset)
+}
+
+func (d *FileDatabase) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadShelvedBooks()
+}
+
+func (d *FileDatabase) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadSeriesBooks()
+}
+
+func (d *FileDatabase) ReadBook(ctx context.Context, id string) (*book.Book, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadBook(id)
+}
+
+// ReadBookMetadata reads the book without its image.
+func (d *FileDatabase) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	b, err := d.ReadBook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b.ImageBase64 = ""
+	return b, nil
+}
+
+func (d *FileDatabase) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.ReadBookByISBN(isbn)
+}
+
+// UpdateBook replaces the book, appending an entry to the audit log for each audit with the book from before the update after the book is saved.
+func (d *FileDatabase) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	all := d.copyBooks(0)
+	i, err := d.bookIndex(b.ID)
+	if err != nil {
+		return err
+	}
+	if !updateImage {
+		b.ImageBase64 = all[i].ImageBase64
+	}
+	entries := book.Entries(audits, all[i], b)
+	all[i] = b
+	if err := d.save(all); err != nil {
+		return fmt.Errorf("updating book: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return fmt.Errorf("book updated, but writing audit log: %w", err)
+	}
+	return nil
+}
+
+// DeleteBook moves the book to the trash, appending the entries to the audit log after the books are saved.
+// The trash is written before the books, so the book is not lost if the books cannot be saved.
+func (d *FileDatabase) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i, err := d.bookIndex(id)
+	if err != nil {
+		return err
+	}
+	trash, err := d.readTrash()
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+	all := d.copyBooks(0)
+	deleted := book.DeletedBook{
+		Book:        all[i],
+		DeletedDate: time.Now().UTC(),
+	}
+	trash = append(trash, deleted)
+	if err := d.writeTrash(trash); err != nil {
+		return fmt.Errorf("moving book to trash: %w", err)
+	}
+	all = append(all[:i], all[i+1:]...)
+	if err := d.save(all); err != nil {
+		return fmt.Errorf("deleting book: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return fmt.Errorf("book deleted, but writing audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadTrash reads the books in the trash, most recently deleted first.
+func (d *FileDatabase) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	deleted, err := d.readTrash()
+	if err != nil {
+		return nil, fmt.Errorf("reading trash: %w", err)
+	}
+	trash := make([]book.TrashedBook, len(deleted))
+	for i, b := range deleted {
+		trash[i] = b.TrashedBook()
+	}
+	book.SortTrash(trash)
+	return trash, nil
+}
+
+// ReadDeletedBook reads the book in the trash with its image.
+func (d *FileDatabase) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	trash, err := d.readTrash()
+	if err != nil {
+		return nil, fmt.Errorf("reading trash: %w", err)
+	}
+	i, err := trashIndex(trash, id)
+	if err != nil {
+		return nil, err
+	}
+	return &trash[i], nil
+}
+
+// ImportTrash adds the deleted books to the trash with the times they were deleted.
+// Books with ids that are already in the trash are skipped.
+func (d *FileDatabase) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trash, err := d.readTrash()
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+	n := len(trash)
+	for _, b := range books {
+		if _, err := trashIndex(trash, b.ID); err == nil {
+			continue
+		}
+		trash = append(trash, b)
+	}
+	if len(trash) == n {
+		return nil
+	}
+	if err := d.writeTrash(trash); err != nil {
+		return fmt.Errorf("importing trash: %w", err)
+	}
+	return nil
+}
+
+// RestoreBook moves the book out of the trash, appending the entries to the audit log after the books are saved.
+// The books are saved before the trash is written, so the book is not lost if the trash cannot be written.
+// Books cannot be restored if another book has their id.
+func (d *FileDatabase) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trash, err := d.readTrash()
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+	i, err := trashIndex(trash, id)
+	if err != nil {
+		return err
+	}
+	if _, err := d.bookIndex(id); err == nil {
+		return book.ExistingIDsError([]string{id})
+	}
+	all := d.copyBooks(1)
+	all = append(all, trash[i].Book)
+	if err := d.save(all); err != nil {
+		return fmt.Errorf("restoring book: %w", err)
+	}
+	trash = append(trash[:i], trash[i+1:]...)
+	if err := d.writeTrash(trash); err != nil {
+		return fmt.Errorf("book restored, but removing it from trash: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return fmt.Errorf("book restored, but writing audit log: %w", err)
+	}
+	return nil
+}
+
+// PurgeBooks permanently removes the books from the trash.
+// Ids of books that are not in the trash are ignored.
+func (d *FileDatabase) PurgeBooks(ctx context.Context, ids ...string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trash, err := d.readTrash()
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+	n := len(trash)
+	for _, id := range ids {
+		if i, err := trashIndex(trash, id); err == nil {
+			trash = append(trash[:i], trash[i+1:]...)
+		}
+	}
+	if len(trash) == n {
+		return nil
+	}
+	if err := d.writeTrash(trash); err != nil {
+		return fmt.Errorf("purging books: %w", err)
+	}
+	return nil
+}
+
+// ReadBookHistory reads the audit log of the book, newest entries first.
+func (d *FileDatabase) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	entries, err := d.readAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	var history []book.AuditEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.BookID == bookID {
+			history = append(history, e)
+		}
+	}
+	return history, nil
+}
+
+// ReadAuditLog reads the entries of the audit log, oldest first.
+func (d *FileDatabase) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.readAuditLog()
+}
+
+// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+func (d *FileDatabase) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	existing, err := d.readAuditLog()
+	if err != nil {
+		return err
+	}
+	n := len(existing)
+	all := book.AppendAuditEntries(existing, entries...)
+	if err := d.appendAuditLog(all[n:]...); err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	return nil
+}
+
+func (d *FileDatabase) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return nil, fmt.Errorf("reading admin password: %w", err)
+	}
+	return []byte(s.AdminPassword), nil
+}
+
+func (d *FileDatabase) UpdateAdminPassword(ctx context.Context, hashedPassword string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return fmt.Errorf("reading sidecar file: %w", err)
+	}
+	s.AdminPassword = hashedPassword
+	if err := d.writeSidecar(*s); err != nil {
+		return fmt.Errorf("updating admin password: %w", err)
+	}
+	return nil
+}
+
+func (d *FileDatabase) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return nil, fmt.Errorf("reading subject aliases: %w", err)
+	}
+	return s.subjectAliases(), nil
+}
+
+// MergeSubjects gives the books with any of the subjects the canonical subject, making the subjects aliases of it.
+// The aliases are written before the books, so the books are unchanged if the aliases cannot be written.
+// The entries are appended to the audit log after the books are saved.
+func (d *FileDatabase) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return fmt.Errorf("reading sidecar file: %w", err)
+	}
+	aliases := s.subjectAliases().Merge(canonical, subjects...)
+	s.SubjectAliases = make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		s.SubjectAliases[a.Name] = a.Canonical
+	}
+	if err := d.writeSidecar(*s); err != nil {
+		return fmt.Errorf("updating subject aliases: %w", err)
+	}
+	all := d.copyBooks(0)
+	book.Books(all).MergeSubjects(canonical, subjects...)
+	if err := d.save(all); err != nil {
+		return fmt.Errorf("merging subjects: %w", err)
+	}
+	if err := d.appendAuditLog(entries...); err != nil {
+		return fmt.Errorf("subjects merged, but writing audit log: %w", err)
+	}
+	return nil
+}
+
+func (d *FileDatabase) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return nil, fmt.Errorf("reading custom fields: %w", err)
+	}
+	return s.CustomFields, nil
+}
+
+// UpdateCustomFields replaces the custom fields.
+// The values of books are kept for fields that are removed.
+func (d *FileDatabase) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSidecar()
+	if err != nil {
+		return fmt.Errorf("reading sidecar file: %w", err)
+	}
+	s.CustomFields = fields
+	if err := d.writeSidecar(*s); err != nil {
+		return fmt.Errorf("updating custom fields: %w", err)
+	}
+	return nil
+}
+
+// copyBooks copies the books so they can be changed without affecting readers if the save fails.
+func (d *FileDatabase) copyBooks(extra int) []book.Book {
+	all := make([]book.Book, len(d.db.Books), len(d.db.Books)+extra)
+	copy(all, d.db.Books)
+	return all
+}
+
+func (d *FileDatabase) bookIndex(id string) (int, error) {
+	for i, b := range d.db.Books {
+		if b.ID == id {
+			return i, nil
+		}
+	}
+	return 0, book.IDNotFoundError(id)
+}
+
+// save writes the books to the file and replaces the books in memory if successful.
+// The file has a custom column for each custom field that any of the books have a value for.
+func (d *FileDatabase) save(books []book.Book) error {
+	book.Books(books).Sort()
+	values := make([]map[string]string, len(books))
+	for i, b := range books {
+		values[i] = b.Custom
+	}
+	customNames := book.CustomNames(nil, values...)
+	write := func(w io.Writer) error {
+		csvW := csv.NewWriter(w)
+		csvW.Write(customHeader(customNames))
+		for _, b := range books {
+			csvW.Write(record(b, customNames))
+		}
+		csvW.Flush()
+		return csvW.Error()
+	}
+	if err := writeFileAtomic(d.path, write); err != nil {
+		return fmt.Errorf("writing database file: %w", err)
+	}
+	d.db.Books = books
+	return nil
+}
+
+func (d *FileDatabase) readSidecar() (*sidecar, error) {
+	var s sidecar
+	data, err := os.ReadFile(d.path + sidecarSuffix)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &s, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding sidecar file: %w", err)
+	}
+	return &s, nil
+}
+
+// subjectAliases are the aliases in the sidecar, sorted by name.
+func (s sidecar) subjectAliases() book.SubjectAliases {
+	aliases := make(book.SubjectAliases, 0, len(s.SubjectAliases))
+	for name, canonical := range s.SubjectAliases {
+		aliases = append(aliases, book.SubjectAlias{Name: name, Canonical: canonical})
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Name < aliases[j].Name
+	})
+	return aliases
+}
+
+// readAuditLog reads the entries of the audit log file, which is empty if the file does not exist.
+func (d *FileDatabase) readAuditLog() ([]book.AuditEntry, error) {
+	f, err := os.Open(d.path + auditLogSuffix)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+	var entries []book.AuditEntry
+	dec := json.NewDecoder(f)
+	for {
+		var e book.AuditEntry
+		err := dec.Decode(&e)
+		switch {
+		case err == io.EOF:
+			return entries, nil
+		case err != nil:
+			return nil, fmt.Errorf("decoding audit log: %w", err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// appendAuditLog adds the entries to the end of the audit log file, one json object per line.
+func (d *FileDatabase) appendAuditLog(entries ...book.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(d.path+auditLogSuffix, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("encoding audit entry: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// readTrash reads the books in the trash file, which is empty if the file does not exist.
+func (d *FileDatabase) readTrash() ([]book.DeletedBook, error) {
+	var trash []book.DeletedBook
+	data, err := os.ReadFile(d.path + trashSuffix)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return trash, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &trash); err != nil {
+		return nil, fmt.Errorf("decoding trash file: %w", err)
+	}
+	return trash, nil
+}
+
+func (d *FileDatabase) writeTrash(trash []book.DeletedBook) error {
+	write := func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(trash)
+	}
+	return writeFileAtomic(d.path+trashSuffix, write)
+}
+
+func trashIndex(trash []book.DeletedBook, id string) (int, error) {
+	for i, b := range trash {
+		if b.ID == id {
+			return i, nil
+		}
+	}
+	return 0, book.TrashNotFoundError(id)
+}
+
+func (d *FileDatabase) writeSidecar(s sidecar) error {
+	write := func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(s)
+	}
+	return writeFileAtomic(d.path+sidecarSuffix, write)
+}
+
+// writeFileAtomic writes to a temporary file in the same folder as the path before renaming it to the path.
+// Readers of the path will see either the old or new contents, never a partially written file.
+// The folder is synced after the rename so the new contents are not lost if the computer crashes.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	dir, name := filepath.Split(path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName) // no-op after rename
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("syncing temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("replacing file: %w", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("syncing folder: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes the folder to disk so the rename of the file in it survives crashes.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,665 @@
+package csv
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestNewFileDatabase(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    func(dir string) string
+		csv    string
+		wantOk bool
+		want   []book.Book
+	}{
+		{
+			name: "bad url",
+			url: func(dir string) string {
+				return "csvfile ://"
+			},
+		},
+		{
+			name: "missing path",
+			url: func(dir string) string {
+				return "csvfile://"
+			},
+		},
+		{
+			name: "bad csv",
+			url: func(dir string) string {
+				return "csvfile://" + filepath.Join(dir, "library.csv")
+			},
+			csv: "bad header row",
+		},
+		{
+			name: "new file",
+			url: func(dir string) string {
+				return "csvfile://" + filepath.Join(dir, "new.csv")
+			},
+			wantOk: true,
+			want:   []book.Book{},
+		},
+		{
+			name: "existing file",
+			url: func(dir string) string {
+				return "csvfile://" + filepath.Join(dir, "library.csv")
+			},
+			csv:    exampleCSV.csv,
+			wantOk: true,
+			want: func() []book.Book {
+				books := make(book.Books, len(exampleCSV.books))
+				copy(books, exampleCSV.books)
+				books.Sort()
+				return books
+			}(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if len(test.csv) != 0 {
+				if err := os.WriteFile(filepath.Join(dir, "library.csv"), []byte(test.csv), 0o644); err != nil {
+					t.Fatalf("writing test file: %v", err)
+				}
+			}
+			got, err := NewFileDatabase(test.url(dir))
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			default:
+				defer got.Close()
+				if !reflect.DeepEqual(test.want, got.db.Books) {
+					t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got.db.Books)
+				}
+			}
+		})
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"csvfile:library.csv", "library.csv"},
+		{"csvfile:///home/username/library.csv", "/home/username/library.csv"},
+		{"csvfile://localhost/home/username/library.csv", "/home/username/library.csv"},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			got, err := filePath(test.url)
+			switch {
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.want != got:
+				t.Errorf("not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFileDatabaseLocked(t *testing.T) {
+	url := "csvfile://" + filepath.Join(t.TempDir(), "library.csv")
+	d, err := NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if _, err := NewFileDatabase(url); err == nil {
+		t.Errorf("wanted error opening locked database")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("unwanted error closing database: %v", err)
+	}
+	d2, err := NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("wanted to open database after it was closed: %v", err)
+	}
+	d2.Close()
+}
+
+func TestFileDatabaseBooks(t *testing.T) {
+	dir := t.TempDir()
+	url := "csvfile://" + filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	ctx := context.Background()
+	addedDate := time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC)
+	created, err := d.CreateBooks(ctx, []book.Book{
+		{Header: book.Header{Title: "t1", Subject: "s1"}, Language: "en", Format: "print", AddedDate: addedDate, EanIsbn13: "9780306406157", ImageBase64: "i1"},
+		{Header: book.Header{Title: "t2", Subject: "s2"}, AddedDate: addedDate},
+	})
+	if err != nil {
+		t.Fatalf("creating books: %v", err)
+	}
+	if len(created) != 2 || len(created[0].ID) == 0 || created[0].ID == created[1].ID {
+		t.Fatalf("wanted two books with different ids, got %v", created)
+	}
+	b := created[0]
+	b.Title = "t1-updated"
+	b.ImageBase64 = "ignored"
+	if err := d.UpdateBook(ctx, b, false); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	if err := d.UpdateBook(ctx, book.Book{Header: book.Header{ID: "unknown"}}, false); err == nil {
+		t.Errorf("wanted error updating unknown book")
+	}
+	if err := d.DeleteBook(ctx, created[1].ID); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, created[1].ID); err == nil {
+		t.Errorf("wanted error deleting book twice")
+	}
+	d.Close()
+	d, err = NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("reopening database: %v", err)
+	}
+	defer d.Close()
+	got, err := d.ReadBook(ctx, b.ID)
+	b.ImageBase64 = "i1"
+	switch {
+	case err != nil:
+		t.Errorf("reading book: %v", err)
+	case !reflect.DeepEqual(b, *got):
+		t.Errorf("not equal after reopen: \n wanted: %v \n got:    %v", b, *got)
+	}
+	if got, err := d.ReadBookByISBN(ctx, b.EanIsbn13); err != nil || got.ID != b.ID {
+		t.Errorf("wanted book %q by isbn, got %v (error: %v)", b.ID, got, err)
+	}
+	if _, err := d.ReadBookByISBN(ctx, "0306406152"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted ErrNotFound reading unknown isbn, got %v", err)
+	}
+	headers, err := d.ReadBookHeaders(ctx, book.Filter{}, 10, 0)
+	if want := []book.Header{b.Header}; err != nil || !reflect.DeepEqual(want, headers) {
+		t.Errorf("wanted %v, got %v (error: %v)", want, headers, err)
+	}
+	shelved, err := d.ReadShelvedBooks(ctx)
+	if want := []book.ShelvedBook{{Header: b.Header}}; err != nil || !reflect.DeepEqual(want, shelved) {
+		t.Errorf("wanted %v, got %v (error: %v)", want, shelved, err)
+	}
+	subjects, err := d.ReadBookSubjects(ctx, 10, 0)
+	if want := []book.Subject{{Name: "s1", Count: 1}}; err != nil || !reflect.DeepEqual(want, subjects) {
+		t.Errorf("wanted %v, got %v (error: %v)", want, subjects, err)
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil || len(matches) != 0 {
+		t.Errorf("wanted temporary files to be removed, got %v (error: %v)", matches, err)
+	}
+}
+
+func TestFileDatabaseAdminPassword(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	got, err := d.ReadAdminPassword(ctx)
+	if err != nil || len(got) != 0 {
+		t.Errorf("wanted no password before it is set, got %q (error: %v)", got, err)
+	}
+	if err := d.UpdateAdminPassword(ctx, "hash48"); err != nil {
+		t.Fatalf("updating admin password: %v", err)
+	}
+	got, err = d.ReadAdminPassword(ctx)
+	if want := "hash48"; err != nil || want != string(got) {
+		t.Errorf("wanted %q, got %q (error: %v)", want, got, err)
+	}
+	if _, err := os.Stat(path + sidecarSuffix); err != nil {
+		t.Errorf("wanted sidecar file: %v", err)
+	}
+	if err := os.WriteFile(path+sidecarSuffix, []byte("{bad json"), 0o644); err != nil {
+		t.Fatalf("writing bad sidecar file: %v", err)
+	}
+	if _, err := d.ReadAdminPassword(ctx); err == nil {
+		t.Errorf("wanted error reading bad sidecar file")
+	}
+	if err := d.UpdateAdminPassword(ctx, "hash49"); err == nil {
+		t.Errorf("wanted error updating bad sidecar file")
+	}
+}
+
+func TestFileDatabaseMergeSubjects(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune", Subject: "sci-fi"}},
+		{Header: book.Header{ID: "2", Title: "Ubik", Subject: "science fiction "}},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	if err := d.UpdateAdminPassword(ctx, "hash48"); err != nil {
+		t.Fatalf("updating admin password: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"Sci-Fi"}); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	d.Close()
+	d, err = NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("reopening database: %v", err)
+	}
+	defer d.Close()
+	wantSubjects := []book.Subject{{Name: "Science Fiction", Count: 2}}
+	gotSubjects, err := d.ReadBookSubjects(ctx, 5, 0)
+	switch {
+	case err != nil:
+		t.Errorf("reading subjects: %v", err)
+	case !reflect.DeepEqual(wantSubjects, gotSubjects):
+		t.Errorf("subjects not equal: \n wanted: %v \n got:    %v", wantSubjects, gotSubjects)
+	}
+	wantAliases := []book.SubjectAlias{{Name: "Sci-Fi", Canonical: "Science Fiction"}}
+	gotAliases, err := d.ReadSubjectAliases(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading aliases: %v", err)
+	case !reflect.DeepEqual(wantAliases, gotAliases):
+		t.Errorf("aliases not equal: \n wanted: %v \n got:    %v", wantAliases, gotAliases)
+	}
+	if got, err := d.ReadAdminPassword(ctx); err != nil || string(got) != "hash48" {
+		t.Errorf("admin password should be kept, got %q (error: %v)", got, err)
+	}
+}
+
+func TestFileDatabaseCustomFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	fields := []book.CustomField{
+		{Name: "Donor", Type: book.FieldText},
+		{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+	}
+	if err := d.UpdateCustomFields(ctx, fields...); err != nil {
+		t.Fatalf("updating custom fields: %v", err)
+	}
+	b := book.Book{Header: book.Header{ID: "1", Title: "Dune"}, Language: "en", Format: "print", Custom: map[string]string{"Donor": "Ann", "Old": "x"}}
+	if err := d.ImportBooks(ctx, false, []book.Book{b}); err != nil {
+		t.Fatalf("importing book: %v", err)
+	}
+	d.Close()
+	d, err = NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("reopening database: %v", err)
+	}
+	defer d.Close()
+	gotFields, err := d.ReadCustomFields(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading custom fields: %v", err)
+	case !reflect.DeepEqual(fields, gotFields):
+		t.Errorf("fields not equal: \n wanted: %v \n got:    %v", fields, gotFields)
+	}
+	got, err := d.ReadBook(ctx, b.ID)
+	switch {
+	case err != nil:
+		t.Errorf("reading book: %v", err)
+	case !reflect.DeepEqual(b, *got):
+		t.Errorf("book not equal after reopen: \n wanted: %v \n got:    %v", b, *got)
+	}
+}
+
+func TestFileDatabaseCreateBooksHistory(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewFileDatabase("csvfile://" + filepath.Join(dir, "library.csv"))
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	audit := book.Audit{Actor: "Ann", Action: book.ActionCreate}
+	created, err := d.CreateBooks(ctx, []book.Book{{Header: book.Header{Title: "Dune"}, Language: "en", Format: "print"}}, audit)
+	if err != nil {
+		t.Fatalf("creating book: %v", err)
+	}
+	got, err := d.ReadBookHistory(ctx, created[0].ID)
+	wantChange := book.FieldChange{Field: "title", After: "Dune"}
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 1, got[0].BookID != created[0].ID, got[0].Actor != "Ann", got[0].Action != book.ActionCreate:
+		t.Errorf("wanted create entry by Ann, got %v", got)
+	case len(got[0].Changes) == 0 || got[0].Changes[0] != wantChange:
+		t.Errorf("wanted title change %v, got %v", wantChange, got[0].Changes)
+	}
+}
+
+func TestFileDatabaseBookHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	if got, err := d.ReadBookHistory(ctx, "1"); err != nil || len(got) != 0 {
+		t.Errorf("wanted no history before any changes, got %v (error: %v)", got, err)
+	}
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune"}, Language: "en", Format: "print"},
+		{Header: book.Header{ID: "2", Title: "Emma"}, Language: "en", Format: "print"},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e2 := book.AuditEntry{ID: "e2", BookID: "2", Time: time1, Actor: "Ann", Action: book.ActionDelete}
+	e3 := book.AuditEntry{ID: "e3", BookID: "1", Time: time.Now().Add(time.Hour).UTC(), Actor: "Bob", Action: book.ActionDelete}
+	b := books[0]
+	b.Title = "Dune 2"
+	if err := d.UpdateBook(ctx, b, false, book.Audit{Actor: "Ann", Action: book.ActionUpdate}); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "2", e2); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "1", e3); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	wantChanges := []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}
+	got, err := d.ReadBookHistory(ctx, "1")
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 2 || !reflect.DeepEqual(e3, got[0]):
+		t.Errorf("wanted delete entry first: %v", got)
+	case got[1].BookID != "1", got[1].Actor != "Ann", got[1].Action != book.ActionUpdate:
+		t.Errorf("unwanted update entry: %v", got[1])
+	case !reflect.DeepEqual(wantChanges, got[1].Changes):
+		t.Errorf("changes not equal: \n wanted: %v \n got:    %v", wantChanges, got[1].Changes)
+	}
+	if err := os.WriteFile(path+auditLogSuffix, []byte("{bad json"), 0o644); err != nil {
+		t.Fatalf("writing bad audit log: %v", err)
+	}
+	if _, err := d.ReadBookHistory(ctx, "1"); err == nil {
+		t.Errorf("wanted error reading bad audit log")
+	}
+}
+
+func TestFileDatabaseImportTrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	b1 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "1", Title: "Dune"}, ImageBase64: "IMG"}, DeletedDate: time1}
+	b2 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "2", Title: "Emma"}}, DeletedDate: time1.Add(time.Hour)}
+	if err := d.ImportTrash(ctx, b1); err != nil {
+		t.Fatalf("importing trash: %v", err)
+	}
+	old := b1
+	old.Title = "Old"
+	if err := d.ImportTrash(ctx, old, b2); err != nil {
+		t.Fatalf("importing trash again: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 2 || got[0] != b2.TrashedBook() || got[1] != b1.TrashedBook() {
+		t.Errorf("unwanted trash, wanted books already in the trash to be kept: %v (error: %v)", got, err)
+	}
+	got, err := d.ReadDeletedBook(ctx, "1")
+	switch {
+	case err != nil:
+		t.Errorf("reading deleted book: %v", err)
+	case !reflect.DeepEqual(b1, *got):
+		t.Errorf("deleted books not equal: \n wanted: %v \n got:    %v", b1, *got)
+	}
+	if _, err := d.ReadDeletedBook(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error reading book that is not in the trash, got %v", err)
+	}
+}
+
+func TestFileDatabaseAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "2", Time: time1.Add(time.Hour), Actor: "Ann", Action: book.ActionDelete}
+	e3 := book.AuditEntry{ID: "e3", BookID: "1", Time: time1.Add(2 * time.Hour), Actor: "Bob", Action: book.ActionRestore}
+	if err := d.ImportAuditLog(ctx, e1, e2); err != nil {
+		t.Fatalf("importing audit log: %v", err)
+	}
+	if err := d.ImportAuditLog(ctx, e2, e3); err != nil {
+		t.Fatalf("importing audit log again: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2, e3}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestFileDatabaseImportAndMergeAuditEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	d, err := NewFileDatabase("csvfile://" + path)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionImport, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "1", Time: time1.Add(time.Hour), Actor: "Bob", Action: book.ActionMerge, Changes: []book.FieldChange{{Field: "subject", Before: "sci-fi", After: "Science Fiction"}}}
+	books := []book.Book{{Header: book.Header{ID: "1", Title: "Dune 2", Subject: "sci-fi"}}}
+	if err := d.ImportBooks(ctx, false, books, e1); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"sci-fi"}, e2); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestFileDatabaseTrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	url := "csvfile://" + path
+	d, err := NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	ctx := context.Background()
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted empty trash before any books are deleted, got %v (error: %v)", got, err)
+	}
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune"}, Language: "en", Format: "print", ImageBase64: "IMG"},
+		{Header: book.Header{ID: "2", Title: "Emma"}, Language: "en", Format: "print"},
+	}
+	if err := d.ImportBooks(ctx, false, books); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	for _, b := range books {
+		if err := d.DeleteBook(ctx, b.ID); err != nil {
+			t.Fatalf("deleting book: %v", err)
+		}
+	}
+	d.Close()
+	d, err = NewFileDatabase(url)
+	if err != nil {
+		t.Fatalf("reopening database: %v", err)
+	}
+	defer d.Close()
+	if got, err := d.ReadBookHeaders(ctx, book.Filter{}, 10, 0); err != nil || len(got) != 0 {
+		t.Errorf("wanted trashed books to be hidden, got %v (error: %v)", got, err)
+	}
+	trash, err := d.ReadTrash(ctx)
+	switch {
+	case err != nil:
+		t.Fatalf("reading trash: %v", err)
+	case len(trash) != 2, trash[1].Header != books[0].Header, trash[1].DeletedDate.IsZero():
+		t.Errorf("unwanted trash, wanted most recently deleted first: %v", trash)
+	}
+	e := book.AuditEntry{ID: "e1", BookID: "1", Action: book.ActionRestore}
+	if err := d.RestoreBook(ctx, "1", e); err != nil {
+		t.Fatalf("restoring book: %v", err)
+	}
+	if got, err := d.ReadBook(ctx, "1"); err != nil || !reflect.DeepEqual(books[0], *got) {
+		t.Errorf("wanted restored book to keep its image: wanted %v, got %v (error: %v)", books[0], got, err)
+	}
+	if err := d.RestoreBook(ctx, "1"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error restoring book that is not in the trash, got %v", err)
+	}
+	if got, err := d.ReadBookHistory(ctx, "1"); err != nil || !reflect.DeepEqual([]book.AuditEntry{e}, got) {
+		t.Errorf("wanted restore in history, got %v (error: %v)", got, err)
+	}
+	if err := d.PurgeBooks(ctx, "2", "unknown"); err != nil {
+		t.Fatalf("purging books: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted empty trash after purge, got %v (error: %v)", got, err)
+	}
+	if err := os.WriteFile(path+trashSuffix, []byte("{bad json"), 0o644); err != nil {
+		t.Fatalf("writing bad trash: %v", err)
+	}
+	if _, err := d.ReadTrash(ctx); err == nil {
+		t.Errorf("wanted error reading bad trash")
+	}
+	if err := d.DeleteBook(ctx, "1"); err == nil {
+		t.Errorf("wanted error deleting book when the trash is bad")
+	}
+	if _, err := d.ReadBook(ctx, "1"); err != nil {
+		t.Errorf("wanted book to be kept when it cannot be moved to the trash: %v", err)
+	}
+}
+
+func TestFileDatabaseImportBooks(t *testing.T) {
+	existing := book.Book{Header: book.Header{ID: "1", Title: "Lemurs"}, Language: "en", Format: "print"}
+	replacement := book.Book{Header: book.Header{ID: "1", Title: "Lemurs"}, Edition: "2nd", Language: "en", Format: "print"}
+	added := book.Book{Header: book.Header{ID: "2", Title: "Zoology"}, Language: "fr", Format: "hardcover", ImageBase64: "i2"}
+	tests := []struct {
+		name      string
+		upsert    bool
+		books     []book.Book
+		wantOk    bool
+		wantBooks []book.Book
+	}{
+		{
+			name:  "duplicate id",
+			books: []book.Book{added, added},
+		},
+		{
+			name:  "collision",
+			books: []book.Book{added, replacement},
+		},
+		{
+			name:      "happy path",
+			books:     []book.Book{added},
+			wantOk:    true,
+			wantBooks: []book.Book{existing, added},
+		},
+		{
+			name:      "upsert",
+			upsert:    true,
+			books:     []book.Book{added, replacement},
+			wantOk:    true,
+			wantBooks: []book.Book{replacement, added},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "library.csv")
+			d, err := NewFileDatabase("csvfile:" + path)
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			ctx := context.Background()
+			if err := d.ImportBooks(ctx, false, []book.Book{existing}); err != nil {
+				t.Fatalf("importing existing book: %v", err)
+			}
+			err = d.ImportBooks(ctx, test.upsert, test.books)
+			d.Close()
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				return
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+				return
+			}
+			d2, err := NewFileDatabase("csvfile:" + path)
+			if err != nil {
+				t.Fatalf("reopening database: %v", err)
+			}
+			defer d2.Close()
+			if want, got := test.wantBooks, d2.db.Books; !reflect.DeepEqual(want, got) {
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.csv")
+	write := func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}
+	if err := writeFileAtomic(path, write); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "new" {
+		t.Errorf("wanted new contents, got %q (error: %v)", got, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading folder: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("wanted temporary file to be renamed, got %v files", len(entries))
+	}
+	if err := syncDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("wanted error syncing missing folder")
+	}
+}
@@ -0,0 +1,60 @@
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// Row is a book read from a line of a csv file that is being imported.
+// If the book could not be read, Err is set instead of Book.
+type Row struct {
+	Line int
+	Book *book.Book
+	Err  error
+}
+
+// ReadRows reads the books of a csv file that has the same header as the database, possibly without some of the optional columns or with custom columns.
+// Rows that cannot be read are reported with their errors rather than stopping the read so all problems can be fixed at once.
+func ReadRows(r io.Reader) ([]Row, error) {
+	csvR := csv.NewReader(r)
+	csvR.FieldsPerRecord = -1 // rows with the wrong number of columns are reported by bookFromRecord
+	gotHeader, err := csvR.Read()
+	switch {
+	case err == io.EOF:
+		return nil, fmt.Errorf("missing header")
+	case err != nil:
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	cols, customNames, err := checkHeader(gotHeader)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	for {
+		record, err := csvR.Read()
+		switch {
+		case err == io.EOF:
+			return rows, nil
+		case err != nil:
+			return nil, fmt.Errorf("reading csv: %w", err) // the rest of the file cannot be read reliably, such as after an unclosed quote
+		}
+		line, _ := csvR.FieldPos(0)
+		row := Row{
+			Line: line,
+		}
+		record = cols.upgrade(record)
+		row.Book, row.Err = bookFromRecord(record, customNames)
+		rows = append(rows, row)
+	}
+}
+
+// Equal determines if the books would be written to the same csv record.
+// Dates are compared by day, as they are stored.
+func Equal(a, b book.Book) bool {
+	customNames := book.CustomNames(nil, a.Custom, b.Custom)
+	return reflect.DeepEqual(record(a, customNames), record(b, customNames))
+}
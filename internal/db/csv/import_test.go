@@ -0,0 +1,115 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestReadRows(t *testing.T) {
+	tests := []struct {
+		name      string
+		csv       string
+		wantOk    bool
+		wantLines []int
+		wantErrs  []bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "bad header",
+			csv:  "id,title",
+		},
+		{
+			name: "unclosed quote",
+			csv:  header + "\n" + `"id`,
+		},
+		{
+			name:   "header only",
+			csv:    header,
+			wantOk: true,
+		},
+		{
+			name: "good and bad rows",
+			csv: header + "\n" +
+				"1,t1,a1,,d1,s1,,,,,,,,,1,,,01/02/2006,,," + "\n" +
+				"2,too,few,columns" + "\n" +
+				`3,t3,a3,,"multi` + "\n" + `line",s3,,,,,,,,,NaN,,,01/02/2006,,,` + "\n" +
+				"4,t4,a4,c4 | editor,d4,s4,tag,ser,4,fr,,ebook,adult,,4,,,01/02/2006,,,",
+			wantOk:    true,
+			wantLines: []int{2, 3, 4, 6},
+			wantErrs:  []bool{false, true, true, false},
+		},
+		{
+			name: "custom columns",
+			csv: header + ",custom:Donor" + "\n" +
+				"1,t1,a1,,d1,s1,,,,,,,,,1,,,01/02/2006,,,,Ann" + "\n" +
+				"2,t2,a2,,d2,s2,,,,,,,,,1,,,01/02/2006,,,",
+			wantOk:    true,
+			wantLines: []int{2, 3},
+			wantErrs:  []bool{false, true},
+		},
+		{
+			name: "header without optional columns",
+			csv: headerWithoutOptionalColumns + "\n" +
+				"1,t1,a1,d1,s1,,1,,,01/02/2006,,," + "\n" +
+				"2,too,few,columns",
+			wantOk:    true,
+			wantLines: []int{2, 3},
+			wantErrs:  []bool{false, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := strings.NewReader(test.csv)
+			got, err := ReadRows(r)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("unwanted error: %v", err)
+			case len(test.wantLines) != len(got):
+				t.Fatalf("wanted %v rows, got %v", len(test.wantLines), len(got))
+			}
+			for i, row := range got {
+				if want, got := test.wantLines[i], row.Line; want != got {
+					t.Errorf("row %v: wanted line %v, got %v", i, want, got)
+				}
+				if want, got := test.wantErrs[i], row.Err != nil; want != got {
+					t.Errorf("row %v: wanted error: %v, got %v", i, want, row.Err)
+				}
+				if row.Err == nil && row.Book == nil {
+					t.Errorf("row %v: book not set", i)
+				}
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	b := book.Book{
+		Header:    book.Header{ID: "1", Title: "t"},
+		AddedDate: time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+	}
+	local := b
+	local.AddedDate = time.Date(2022, 11, 16, 0, 0, 0, 0, time.Local)
+	changed := b
+	changed.Title = "t2"
+	if !Equal(b, local) {
+		t.Errorf("wanted books with the same day to be equal")
+	}
+	if Equal(b, changed) {
+		t.Errorf("wanted books with different titles to not be equal")
+	}
+	custom := b
+	custom.Custom = map[string]string{"Donor": "Ann"}
+	if Equal(b, custom) || Equal(custom, b) {
+		t.Errorf("wanted books with different custom values to not be equal")
+	}
+}
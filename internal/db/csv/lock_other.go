@@ -0,0 +1,25 @@
+//go:build !unix
+
+package csv
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockPath opens the file at the path.
+// Locking is only supported on unix systems, so the file is not locked.
+func lockPath(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+	return f, nil
+}
+
+func unlockFile(f *os.File) error {
+	if f == nil {
+		return nil
+	}
+	return f.Close()
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package csv
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockPath opens the file at the path and holds an exclusive lock on it.
+// An error is returned if another process has the file locked.
+func lockPath(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("file is locked by another process: %w", err)
+	}
+	return f, nil
+}
+
+func unlockFile(f *os.File) error {
+	if f == nil {
+		return nil
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); err != nil {
+		f.Close()
+		return fmt.Errorf("unlocking file: %w", err)
+	}
+	return f.Close()
+}
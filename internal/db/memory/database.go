@@ -0,0 +1,344 @@
+// Package memory provides a database for the library that only stores books in memory.
+// It is useful for demos and tests because all changes are lost when the server stops.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// Database stores books in memory.
+// It is safe for concurrent use.
+type Database struct {
+	mu             sync.RWMutex
+	books          book.Books
+	aliases        book.SubjectAliases
+	customFields   []book.CustomField
+	auditLog       []book.AuditEntry
+	trash          []book.DeletedBook
+	hashedPassword string
+}
+
+// NewDatabase creates a database with the books, keeping their ids.
+func NewDatabase(books ...book.Book) *Database {
+	d := Database{
+		books: make(book.Books, len(books)),
+	}
+	copy(d.books, books)
+	d.books.Sort()
+	return &d
+}
+
+// CreateBooks creates the books with new ids, adding an entry to the audit log for each audit of each book.
+func (d *Database) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	created := make([]book.Book, len(books))
+	for i, b := range books {
+		b.ID = book.NewID()
+		d.books = append(d.books, b)
+		d.auditLog = append(d.auditLog, book.Entries(audits, book.Book{}, b)...)
+		created[i] = b
+	}
+	d.books.Sort()
+	return created, nil
+}
+
+// ImportBooks creates the books with the ids they already have, adding the entries to the audit log.
+// Books with ids that already exist are replaced if upsert is true.
+// Otherwise, no books are imported if any of the ids already exist.
+func (d *Database) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if err := book.Books(books).CheckIDs(); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var existingIDs []string
+	for _, b := range books {
+		if _, err := d.bookIndex(b.ID); err == nil {
+			existingIDs = append(existingIDs, b.ID)
+		}
+	}
+	if len(existingIDs) != 0 && !upsert {
+		return book.ExistingIDsError(existingIDs)
+	}
+	for _, b := range books {
+		if i, err := d.bookIndex(b.ID); err == nil {
+			d.books[i] = b
+			continue
+		}
+		d.books = append(d.books, b)
+	}
+	d.books.Sort()
+	d.auditLog = append(d.auditLog, entries...)
+	return nil
+}
+
+func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	subjects := d.books.Subjects(limit, offset)
+	return subjects, nil
+}
+
+func (d *Database) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	authors := d.books.Authors(limit, offset)
+	return authors, nil
+}
+
+func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	headers := d.books.Headers(filter, limit, offset)
+	return headers, nil
+}
+
+func (d *Database) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.books.Shelved(), nil
+}
+
+func (d *Database) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.books.SeriesBooks(), nil
+}
+
+func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	i, err := d.bookIndex(id)
+	if err != nil {
+		return nil, err
+	}
+	b := d.books[i]
+	return &b, nil
+}
+
+// ReadBookMetadata reads the book without its image.
+func (d *Database) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	b, err := d.ReadBook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b.ImageBase64 = ""
+	return b, nil
+}
+
+func (d *Database) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.books.FindISBN(isbn)
+}
+
+// UpdateBook replaces the book, adding an entry to the audit log for each audit with the book from before the update.
+func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i, err := d.bookIndex(b.ID)
+	if err != nil {
+		return err
+	}
+	before := d.books[i]
+	if !updateImage {
+		b.ImageBase64 = before.ImageBase64
+	}
+	d.books[i] = b
+	d.books.Sort()
+	d.auditLog = append(d.auditLog, book.Entries(audits, before, b)...)
+	return nil
+}
+
+// DeleteBook moves the book to the trash, adding the entries to the audit log.
+func (d *Database) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i, err := d.bookIndex(id)
+	if err != nil {
+		return err
+	}
+	deleted := book.DeletedBook{
+		Book:        d.books[i],
+		DeletedDate: time.Now().UTC(),
+	}
+	d.trash = append(d.trash, deleted)
+	d.books = append(d.books[:i], d.books[i+1:]...)
+	d.auditLog = append(d.auditLog, entries...)
+	return nil
+}
+
+// ReadTrash reads the books in the trash, most recently deleted first.
+func (d *Database) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	trash := make([]book.TrashedBook, len(d.trash))
+	for i, b := range d.trash {
+		trash[i] = b.TrashedBook()
+	}
+	book.SortTrash(trash)
+	return trash, nil
+}
+
+// ReadDeletedBook reads the book in the trash with its image.
+func (d *Database) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	i, err := d.trashIndex(id)
+	if err != nil {
+		return nil, err
+	}
+	b := d.trash[i]
+	return &b, nil
+}
+
+// ImportTrash adds the deleted books to the trash with the times they were deleted.
+// Books with ids that are already in the trash are skipped.
+func (d *Database) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, b := range books {
+		if _, err := d.trashIndex(b.ID); err == nil {
+			continue
+		}
+		d.trash = append(d.trash, b)
+	}
+	return nil
+}
+
+// RestoreBook moves the book out of the trash, adding the entries to the audit log.
+// Books cannot be restored if another book has their id.
+func (d *Database) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i, err := d.trashIndex(id)
+	if err != nil {
+		return err
+	}
+	if _, err := d.bookIndex(id); err == nil {
+		return book.ExistingIDsError([]string{id})
+	}
+	d.books = append(d.books, d.trash[i].Book)
+	d.books.Sort()
+	d.trash = append(d.trash[:i], d.trash[i+1:]...)
+	d.auditLog = append(d.auditLog, entries...)
+	return nil
+}
+
+// PurgeBooks permanently removes the books from the trash.
+// Ids of books that are not in the trash are ignored.
+func (d *Database) PurgeBooks(ctx context.Context, ids ...string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range ids {
+		if i, err := d.trashIndex(id); err == nil {
+			d.trash = append(d.trash[:i], d.trash[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// ReadBookHistory reads the audit log of the book, newest entries first.
+func (d *Database) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var history []book.AuditEntry
+	for i := len(d.auditLog) - 1; i >= 0; i-- {
+		if e := d.auditLog[i]; e.BookID == bookID {
+			history = append(history, e)
+		}
+	}
+	return history, nil
+}
+
+// ReadAuditLog reads the entries of the audit log, oldest first.
+func (d *Database) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	entries := make([]book.AuditEntry, len(d.auditLog))
+	copy(entries, d.auditLog)
+	return entries, nil
+}
+
+// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+func (d *Database) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.auditLog = book.AppendAuditEntries(d.auditLog, entries...)
+	return nil
+}
+
+func (d *Database) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	aliases := make([]book.SubjectAlias, len(d.aliases))
+	copy(aliases, d.aliases)
+	return aliases, nil
+}
+
+// MergeSubjects gives the books with any of the subjects the canonical subject, making the subjects aliases of it.
+// The entries are added to the audit log.
+func (d *Database) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.books.MergeSubjects(canonical, subjects...)
+	d.books.Sort()
+	d.aliases = d.aliases.Merge(canonical, subjects...)
+	d.auditLog = append(d.auditLog, entries...)
+	return nil
+}
+
+func (d *Database) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	fields := make([]book.CustomField, len(d.customFields))
+	copy(fields, d.customFields)
+	return fields, nil
+}
+
+// UpdateCustomFields replaces the custom fields.
+// The values of books are kept for fields that are removed.
+func (d *Database) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.customFields = make([]book.CustomField, len(fields))
+	copy(d.customFields, fields)
+	return nil
+}
+
+func (d *Database) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return []byte(d.hashedPassword), nil
+}
+
+func (d *Database) UpdateAdminPassword(ctx context.Context, hashedPassword string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hashedPassword = hashedPassword
+	return nil
+}
+
+func (d *Database) bookIndex(id string) (int, error) {
+	for i, b := range d.books {
+		if b.ID == id {
+			return i, nil
+		}
+	}
+	return 0, book.IDNotFoundError(id)
+}
+
+func (d *Database) trashIndex(id string) (int, error) {
+	for i, b := range d.trash {
+		if b.ID == id {
+			return i, nil
+		}
+	}
+	return 0, book.TrashNotFoundError(id)
+}
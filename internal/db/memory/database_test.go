@@ -0,0 +1,488 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestNewDatabase(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "2", Title: "Zoology", Subject: "Animals"}},
+		{Header: book.Header{ID: "1", Title: "Lemurs", Subject: "Animals"}},
+	}
+	d := NewDatabase(books...)
+	want := book.Books{books[1], books[0]}
+	if !reflect.DeepEqual(want, d.books) {
+		t.Errorf("wanted sorted books with original ids: \n wanted: %v \n got:    %v", want, d.books)
+	}
+	books[0].Title = "changed"
+	if d.books[1].Title != "Zoology" {
+		t.Errorf("database should copy books")
+	}
+}
+
+func TestBooks(t *testing.T) {
+	d := NewDatabase(book.Book{Header: book.Header{ID: "seed", Title: "Secrets", Subject: "Behind others"}})
+	ctx := context.Background()
+	created, err := d.CreateBooks(ctx, []book.Book{
+		{Header: book.Header{Title: "Zoology", Author: "Ann Lee", Subject: "Animals"}, Contributors: []book.Contributor{{Name: "Bob Smith", Role: "editor", SortName: "Smith, Bob"}}, Series: "Zoos", Volume: 2, ImageBase64: "i1"},
+		{Header: book.Header{Title: "Lemurs", Subject: "Animals"}, EanIsbn13: "9780306406157", UpcIsbn10: "0306406152"},
+	})
+	if err != nil {
+		t.Fatalf("creating books: %v", err)
+	}
+	if len(created) != 2 || len(created[0].ID) == 0 || created[0].ID == created[1].ID {
+		t.Fatalf("wanted two books with different ids, got %v", created)
+	}
+	t.Run("ReadBookSubjects", func(t *testing.T) {
+		want := []book.Subject{{Name: "Animals", Count: 2}, {Name: "Behind others", Count: 1}}
+		got, err := d.ReadBookSubjects(ctx, 5, 0)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBookAuthors", func(t *testing.T) {
+		want := []book.Author{{Name: "Ann Lee", SortName: "Lee, Ann", Count: 1}, {Name: "Bob Smith", SortName: "Smith, Bob", Count: 1}}
+		got, err := d.ReadBookAuthors(ctx, 5, 0)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBookHeaders", func(t *testing.T) {
+		filter := book.Filter{HeaderPart: "o"}
+		want := []book.Header{created[0].Header, {ID: "seed", Title: "Secrets", Subject: "Behind others"}}
+		got, err := d.ReadBookHeaders(ctx, filter, 5, 0)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadShelvedBooks", func(t *testing.T) {
+		want := []book.ShelvedBook{
+			{Header: created[1].Header},
+			{Header: created[0].Header},
+			{Header: book.Header{ID: "seed", Title: "Secrets", Subject: "Behind others"}},
+		}
+		got, err := d.ReadShelvedBooks(ctx)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadSeriesBooks", func(t *testing.T) {
+		want := []book.SeriesBook{{Header: created[0].Header, Series: "Zoos", Volume: 2}}
+		got, err := d.ReadSeriesBooks(ctx)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+		}
+	})
+	t.Run("ReadBook", func(t *testing.T) {
+		want := created[0]
+		got, err := d.ReadBook(ctx, want.ID)
+		switch {
+		case err != nil:
+			t.Errorf("unwanted error: %v", err)
+		case !reflect.DeepEqual(want, *got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+		}
+		if _, err := d.ReadBook(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+			t.Errorf("wanted ErrNotFound reading unknown book, got %v", err)
+		}
+	})
+	t.Run("ReadBookByISBN", func(t *testing.T) {
+		want := created[1]
+		for _, isbn := range []string{want.EanIsbn13, want.UpcIsbn10} {
+			got, err := d.ReadBookByISBN(ctx, isbn)
+			switch {
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(want, *got):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+			}
+		}
+		if _, err := d.ReadBookByISBN(ctx, "9780804429573"); !errors.Is(err, book.ErrNotFound) {
+			t.Errorf("wanted ErrNotFound reading unknown isbn, got %v", err)
+		}
+	})
+	t.Run("UpdateBook", func(t *testing.T) {
+		b := created[0]
+		b.Title = "Zoology 2"
+		b.ImageBase64 = "ignored"
+		if err := d.UpdateBook(ctx, b, false); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		b.ImageBase64 = "i1"
+		if got, err := d.ReadBook(ctx, b.ID); err != nil || !reflect.DeepEqual(b, *got) {
+			t.Errorf("wanted %v, got %v (error: %v)", b, got, err)
+		}
+		if err := d.UpdateBook(ctx, book.Book{Header: book.Header{ID: "unknown"}}, true); err == nil {
+			t.Errorf("wanted error updating unknown book")
+		}
+	})
+	t.Run("DeleteBook", func(t *testing.T) {
+		if err := d.DeleteBook(ctx, "seed"); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		if err := d.DeleteBook(ctx, "seed"); err == nil {
+			t.Errorf("wanted error deleting book twice")
+		}
+	})
+}
+
+func TestAdminPassword(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	got, err := d.ReadAdminPassword(ctx)
+	if err != nil || len(got) != 0 {
+		t.Errorf("wanted no password before it is set, got %q (error: %v)", got, err)
+	}
+	if err := d.UpdateAdminPassword(ctx, "hash48"); err != nil {
+		t.Fatalf("updating admin password: %v", err)
+	}
+	got, err = d.ReadAdminPassword(ctx)
+	if want := "hash48"; err != nil || want != string(got) {
+		t.Errorf("wanted %q, got %q (error: %v)", want, got, err)
+	}
+}
+
+func TestMergeSubjects(t *testing.T) {
+	d := NewDatabase(
+		book.Book{Header: book.Header{ID: "1", Title: "Dune", Subject: "sci-fi"}},
+		book.Book{Header: book.Header{ID: "2", Title: "Emma", Subject: "Fiction "}},
+		book.Book{Header: book.Header{ID: "3", Title: "Ubik", Subject: "Science Fiction"}},
+	)
+	ctx := context.Background()
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"Sci-Fi"}); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	wantSubjects := []book.Subject{{Name: "Fiction", Count: 1}, {Name: "Science Fiction", Count: 2}}
+	gotSubjects, err := d.ReadBookSubjects(ctx, 5, 0)
+	switch {
+	case err != nil:
+		t.Errorf("reading subjects: %v", err)
+	case !reflect.DeepEqual(wantSubjects, gotSubjects):
+		t.Errorf("subjects not equal: \n wanted: %v \n got:    %v", wantSubjects, gotSubjects)
+	}
+	wantAliases := []book.SubjectAlias{{Name: "Sci-Fi", Canonical: "Science Fiction"}}
+	gotAliases, err := d.ReadSubjectAliases(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading aliases: %v", err)
+	case !reflect.DeepEqual(wantAliases, gotAliases):
+		t.Errorf("aliases not equal: \n wanted: %v \n got:    %v", wantAliases, gotAliases)
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	got, err := d.ReadCustomFields(ctx)
+	if err != nil || len(got) != 0 {
+		t.Errorf("wanted no custom fields before they are updated, got %v (error: %v)", got, err)
+	}
+	want := []book.CustomField{
+		{Name: "Donor", Type: book.FieldText},
+		{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+	}
+	if err := d.UpdateCustomFields(ctx, want...); err != nil {
+		t.Fatalf("updating custom fields: %v", err)
+	}
+	got, err = d.ReadCustomFields(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading custom fields: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestCreateBooksHistory(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	audit := book.Audit{Actor: "Ann", Action: book.ActionCreate}
+	created, err := d.CreateBooks(ctx, []book.Book{{Header: book.Header{Title: "Dune"}}}, audit)
+	if err != nil {
+		t.Fatalf("creating book: %v", err)
+	}
+	got, err := d.ReadBookHistory(ctx, created[0].ID)
+	wantChange := book.FieldChange{Field: "title", After: "Dune"}
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 1, got[0].BookID != created[0].ID, got[0].Actor != "Ann", got[0].Action != book.ActionCreate:
+		t.Errorf("wanted create entry by Ann, got %v", got)
+	case len(got[0].Changes) == 0 || got[0].Changes[0] != wantChange:
+		t.Errorf("wanted title change %v, got %v", wantChange, got[0].Changes)
+	}
+}
+
+func TestBookHistory(t *testing.T) {
+	d := NewDatabase(book.Book{Header: book.Header{ID: "a"}}, book.Book{Header: book.Header{ID: "b"}})
+	ctx := context.Background()
+	e3 := book.AuditEntry{ID: "3", BookID: "a", Action: book.ActionDelete}
+	if err := d.UpdateBook(ctx, book.Book{Header: book.Header{ID: "a", Title: "A"}}, false, book.Audit{Actor: "Ann", Action: book.ActionUpdate}); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	if err := d.UpdateBook(ctx, book.Book{Header: book.Header{ID: "b"}}, false, book.Audit{Actor: "Ann", Action: book.ActionUpdate}); err != nil {
+		t.Fatalf("updating book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "unknown", book.AuditEntry{ID: "bad", BookID: "a"}); err == nil {
+		t.Errorf("wanted error deleting unknown book")
+	}
+	if err := d.DeleteBook(ctx, "a", e3); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	wantChanges := []book.FieldChange{{Field: "title", After: "A"}}
+	got, err := d.ReadBookHistory(ctx, "a")
+	switch {
+	case err != nil:
+		t.Errorf("reading history: %v", err)
+	case len(got) != 2 || !reflect.DeepEqual(e3, got[0]):
+		t.Errorf("wanted delete entry first: %v", got)
+	case got[1].BookID != "a", got[1].Action != book.ActionUpdate, !reflect.DeepEqual(wantChanges, got[1].Changes):
+		t.Errorf("unwanted update entry: %v", got[1])
+	}
+}
+
+func TestImportTrash(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	b1 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "1", Title: "Dune"}, ImageBase64: "IMG"}, DeletedDate: time1}
+	b2 := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "2", Title: "Emma"}}, DeletedDate: time1.Add(time.Hour)}
+	if err := d.ImportTrash(ctx, b1); err != nil {
+		t.Fatalf("importing trash: %v", err)
+	}
+	old := b1
+	old.Title = "Old"
+	if err := d.ImportTrash(ctx, old, b2); err != nil {
+		t.Fatalf("importing trash again: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 2 || got[0] != b2.TrashedBook() || got[1] != b1.TrashedBook() {
+		t.Errorf("unwanted trash, wanted books already in the trash to be kept: %v (error: %v)", got, err)
+	}
+	got, err := d.ReadDeletedBook(ctx, "1")
+	switch {
+	case err != nil:
+		t.Errorf("reading deleted book: %v", err)
+	case !reflect.DeepEqual(b1, *got):
+		t.Errorf("deleted books not equal: \n wanted: %v \n got:    %v", b1, *got)
+	}
+	if _, err := d.ReadDeletedBook(ctx, "unknown"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error reading book that is not in the trash, got %v", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "2", Time: time1.Add(time.Hour), Actor: "Ann", Action: book.ActionDelete}
+	e3 := book.AuditEntry{ID: "e3", BookID: "1", Time: time1.Add(2 * time.Hour), Actor: "Bob", Action: book.ActionRestore}
+	if err := d.ImportAuditLog(ctx, e1, e2); err != nil {
+		t.Fatalf("importing audit log: %v", err)
+	}
+	if err := d.ImportAuditLog(ctx, e2, e3); err != nil {
+		t.Fatalf("importing audit log again: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2, e3}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestImportAndMergeAuditEntries(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	e1 := book.AuditEntry{ID: "e1", BookID: "1", Time: time1, Actor: "Ann", Action: book.ActionImport, Changes: []book.FieldChange{{Field: "title", Before: "Dune", After: "Dune 2"}}}
+	e2 := book.AuditEntry{ID: "e2", BookID: "1", Time: time1.Add(time.Hour), Actor: "Bob", Action: book.ActionMerge, Changes: []book.FieldChange{{Field: "subject", Before: "sci-fi", After: "Science Fiction"}}}
+	books := []book.Book{{Header: book.Header{ID: "1", Title: "Dune 2", Subject: "sci-fi"}}}
+	if err := d.ImportBooks(ctx, false, books, e1); err != nil {
+		t.Fatalf("importing books: %v", err)
+	}
+	if err := d.MergeSubjects(ctx, "Science Fiction", []string{"sci-fi"}, e2); err != nil {
+		t.Fatalf("merging subjects: %v", err)
+	}
+	want := []book.AuditEntry{e1, e2}
+	got, err := d.ReadAuditLog(ctx)
+	switch {
+	case err != nil:
+		t.Errorf("reading audit log: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	b1 := book.Book{Header: book.Header{ID: "a", Title: "Alpha"}, ImageBase64: "IMG"}
+	b2 := book.Book{Header: book.Header{ID: "b", Title: "Beta"}}
+	d := NewDatabase(b1, b2)
+	ctx := context.Background()
+	if err := d.DeleteBook(ctx, "a"); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if err := d.DeleteBook(ctx, "b"); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if got, err := d.ReadBookHeaders(ctx, book.Filter{}, 5, 0); err != nil || len(got) != 0 {
+		t.Errorf("wanted trashed books to be hidden, got %v (error: %v)", got, err)
+	}
+	trash, err := d.ReadTrash(ctx)
+	switch {
+	case err != nil:
+		t.Fatalf("reading trash: %v", err)
+	case len(trash) != 2, trash[0].ID != "b", trash[1].Header != b1.Header, trash[1].DeletedDate.IsZero():
+		t.Errorf("unwanted trash, wanted most recently deleted first: %v", trash)
+	}
+	e := book.AuditEntry{ID: "1", BookID: "a", Action: book.ActionRestore}
+	if err := d.RestoreBook(ctx, "a", e); err != nil {
+		t.Fatalf("restoring book: %v", err)
+	}
+	if got, err := d.ReadBook(ctx, "a"); err != nil || !reflect.DeepEqual(b1, *got) {
+		t.Errorf("wanted restored book to keep its image: wanted %v, got %v (error: %v)", b1, got, err)
+	}
+	if err := d.RestoreBook(ctx, "a"); err == nil {
+		t.Errorf("wanted error restoring book that is not in the trash")
+	}
+	if got, err := d.ReadBookHistory(ctx, "a"); err != nil || !reflect.DeepEqual([]book.AuditEntry{e}, got) {
+		t.Errorf("wanted restore in history, got %v (error: %v)", got, err)
+	}
+	if err := d.PurgeBooks(ctx, "b", "unknown"); err != nil {
+		t.Fatalf("purging books: %v", err)
+	}
+	if got, err := d.ReadTrash(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted empty trash after purge, got %v (error: %v)", got, err)
+	}
+	if err := d.RestoreBook(ctx, "b"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error restoring purged book, got %v", err)
+	}
+}
+
+func TestReadBookMetadata(t *testing.T) {
+	d := NewDatabase(book.Book{Header: book.Header{ID: "a", Title: "t1"}, ImageBase64: "img"})
+	ctx := context.Background()
+	want := book.Book{Header: book.Header{ID: "a", Title: "t1"}}
+	got, err := d.ReadBookMetadata(ctx, "a")
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(want, *got):
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+	}
+	if d.books[0].ImageBase64 != "img" {
+		t.Errorf("reading metadata should not change the stored image")
+	}
+}
+
+func TestRestoreBookExistingID(t *testing.T) {
+	d := NewDatabase(book.Book{Header: book.Header{ID: "a"}})
+	ctx := context.Background()
+	if err := d.DeleteBook(ctx, "a"); err != nil {
+		t.Fatalf("deleting book: %v", err)
+	}
+	if err := d.ImportBooks(ctx, false, []book.Book{book.Book{Header: book.Header{ID: "a"}}}); err != nil {
+		t.Fatalf("importing book: %v", err)
+	}
+	if err := d.RestoreBook(ctx, "a"); !errors.Is(err, book.ErrIDExists) {
+		t.Errorf("wanted id exists error, got %v", err)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	d := NewDatabase()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	n := 20
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.CreateBooks(ctx, []book.Book{book.Book{}})
+			d.ReadBookHeaders(ctx, book.Filter{}, n, 0)
+		}()
+	}
+	wg.Wait()
+	if got, _ := d.ReadBookHeaders(ctx, book.Filter{}, n+1, 0); len(got) != n {
+		t.Errorf("wanted %v books, got %v", n, len(got))
+	}
+}
+
+func TestImportBooks(t *testing.T) {
+	existing := book.Book{Header: book.Header{ID: "1", Title: "Lemurs"}}
+	replacement := book.Book{Header: book.Header{ID: "1", Title: "Lemurs, 2nd edition"}}
+	added := book.Book{Header: book.Header{ID: "2", Title: "Zoology"}, ImageBase64: "i2"}
+	tests := []struct {
+		name      string
+		upsert    bool
+		books     []book.Book
+		wantOk    bool
+		wantBooks book.Books
+	}{
+		{
+			name:  "missing id",
+			books: []book.Book{{Header: book.Header{Title: "no id"}}},
+		},
+		{
+			name:  "collision",
+			books: []book.Book{added, replacement},
+		},
+		{
+			name:      "happy path",
+			books:     []book.Book{added},
+			wantOk:    true,
+			wantBooks: book.Books{existing, added},
+		},
+		{
+			name:      "upsert",
+			upsert:    true,
+			books:     []book.Book{added, replacement},
+			wantOk:    true,
+			wantBooks: book.Books{replacement, added},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDatabase(existing)
+			ctx := context.Background()
+			err := d.ImportBooks(ctx, test.upsert, test.books)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if want, got := (book.Books{existing}), d.books; !reflect.DeepEqual(want, got) {
+					t.Errorf("books changed after error: \n wanted: %v \n got:    %v", want, got)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantBooks, d.books):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.wantBooks, d.books)
+			}
+		})
+	}
+}
@@ -5,6 +5,15 @@ import "github.com/jacobpatterson1549/kuuf-library/internal/book"
 func mongoBook(b book.Book) mBook {
 	return mBook{
 		Header:        mongoHeader(b.Header),
+		Tags:          book.NormalizeTags(b.Subject, b.Tags),
+		Contributors:  mongoContributors(b.Contributors),
+		Series:        b.Series,
+		Volume:        b.Volume,
+		Language:      b.Language,
+		Edition:       b.Edition,
+		Format:        b.Format,
+		ReadingLevel:  b.ReadingLevel,
+		Custom:        b.Custom,
 		Description:   b.Description,
 		DeweyDecClass: b.DeweyDecClass,
 		Pages:         b.Pages,
@@ -17,6 +26,95 @@ func mongoBook(b book.Book) mBook {
 	}
 }
 
+func mongoDeletedBook(b book.DeletedBook) mDeletedBook {
+	return mDeletedBook{
+		Book:        mongoBook(b.Book),
+		DeletedDate: b.DeletedDate,
+	}
+}
+
+func (m mDeletedBook) DeletedBook() book.DeletedBook {
+	return book.DeletedBook{
+		Book:        m.Book.Book(),
+		DeletedDate: m.DeletedDate,
+	}
+}
+
+func mongoContributors(contributors []book.Contributor) []mContributor {
+	contributors = book.NormalizeContributors(contributors)
+	if len(contributors) == 0 {
+		return nil
+	}
+	m := make([]mContributor, len(contributors))
+	for i, c := range contributors {
+		m[i] = mContributor(c)
+	}
+	return m
+}
+
+func (m mBook) contributors() []book.Contributor {
+	if len(m.Contributors) == 0 {
+		return nil
+	}
+	contributors := make([]book.Contributor, len(m.Contributors))
+	for i, c := range m.Contributors {
+		contributors[i] = book.Contributor(c)
+	}
+	return contributors
+}
+
+// custom are the custom values of the book, which are nil rather than empty if the book has no values.
+func (m mBook) custom() map[string]string {
+	if len(m.Custom) == 0 {
+		return nil
+	}
+	return m.Custom
+}
+
+func mongoCustomFields(fields []book.CustomField) []mCustomField {
+	m := make([]mCustomField, len(fields))
+	for i, f := range fields {
+		m[i] = mCustomField(f)
+	}
+	return m
+}
+
+func (m mCustomFields) CustomFields() []book.CustomField {
+	fields := make([]book.CustomField, len(m.Fields))
+	for i, f := range m.Fields {
+		fields[i] = book.CustomField(f)
+	}
+	return fields
+}
+
+func mongoAuditEntry(e book.AuditEntry) mAuditEntry {
+	m := mAuditEntry{
+		ID:     e.ID,
+		BookID: e.BookID,
+		Time:   e.Time,
+		Actor:  e.Actor,
+		Action: e.Action,
+	}
+	for _, c := range e.Changes {
+		m.Changes = append(m.Changes, mFieldChange(c))
+	}
+	return m
+}
+
+func (m mAuditEntry) AuditEntry() book.AuditEntry {
+	e := book.AuditEntry{
+		ID:     m.ID,
+		BookID: m.BookID,
+		Time:   m.Time,
+		Actor:  m.Actor,
+		Action: m.Action,
+	}
+	for _, c := range m.Changes {
+		e.Changes = append(e.Changes, book.FieldChange(c))
+	}
+	return e
+}
+
 func mongoHeader(h book.Header) mHeader {
 	return mHeader{
 		ID:      h.ID,
@@ -29,6 +127,15 @@ func mongoHeader(h book.Header) mHeader {
 func (m mBook) Book() book.Book {
 	return book.Book{
 		Header:        m.Header.Header(),
+		Tags:          book.NormalizeTags(m.Header.Subject, m.Tags),
+		Contributors:  m.contributors(),
+		Series:        m.Series,
+		Volume:        m.Volume,
+		Language:      m.Language,
+		Edition:       m.Edition,
+		Format:        m.Format,
+		ReadingLevel:  m.ReadingLevel,
+		Custom:        m.custom(),
 		Description:   m.Description,
 		DeweyDecClass: m.DeweyDecClass,
 		Pages:         m.Pages,
@@ -50,9 +157,24 @@ func (m mHeader) Header() book.Header {
 	}
 }
 
+func (m mAuthor) Author() book.Author {
+	return book.Author{
+		Name:     m.Name,
+		SortName: m.SortName,
+		Count:    m.Count,
+	}
+}
+
 func (m mSubject) Subject() book.Subject {
 	return book.Subject{
 		Name:  m.Name,
 		Count: m.Count,
 	}
 }
+
+func (m mSubjectAlias) SubjectAlias() book.SubjectAlias {
+	return book.SubjectAlias{
+		Name:      m.Name,
+		Canonical: m.Canonical,
+	}
+}
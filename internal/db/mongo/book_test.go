@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +20,11 @@ func TestMBook(t *testing.T) {
 			Author:  "3",
 			Subject: "4",
 		},
+		Tags:          []string{"4a", "4b"},
+		Contributors:  []mContributor{{Name: "c1", Role: "editor", SortName: "s1"}},
+		Series:        "4c",
+		Volume:        4,
+		Custom:        map[string]string{"Donor": "Ann"},
 		Description:   "5",
 		DeweyDecClass: "6",
 		Pages:         7,
@@ -36,6 +42,11 @@ func TestMBook(t *testing.T) {
 			Author:  "3",
 			Subject: "4",
 		},
+		Tags:          []string{"4a", "4b"},
+		Contributors:  []book.Contributor{{Name: "c1", Role: "editor", SortName: "s1"}},
+		Series:        "4c",
+		Volume:        4,
+		Custom:        map[string]string{"Donor": "Ann"},
 		Description:   "5",
 		DeweyDecClass: "6",
 		Pages:         7,
@@ -47,13 +58,13 @@ func TestMBook(t *testing.T) {
 		ImageBase64:   "13",
 	}
 	t.Run("mBook.Book()", func(t *testing.T) {
-		if want, got := b, m.Book(); want != got {
+		if want, got := b, m.Book(); !reflect.DeepEqual(want, got) {
 			t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
 		}
 	})
 	t.Run("mongoBook(book.Book)", func(t *testing.T) {
 		want, got := m, mongoBook(b)
-		if want != got {
+		if !reflect.DeepEqual(want, got) {
 			t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
 		}
 	})
@@ -87,3 +98,49 @@ func TestMSubject(t *testing.T) {
 		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
 	}
 }
+
+func TestMAuditEntry(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	m := mAuditEntry{
+		ID:      "e1",
+		BookID:  "b1",
+		Time:    time1,
+		Actor:   "Ann",
+		Action:  "update",
+		Changes: []mFieldChange{{Field: "title", Before: "a", After: "b"}},
+	}
+	e := book.AuditEntry{
+		ID:      "e1",
+		BookID:  "b1",
+		Time:    time1,
+		Actor:   "Ann",
+		Action:  "update",
+		Changes: []book.FieldChange{{Field: "title", Before: "a", After: "b"}},
+	}
+	if want, got := e, m.AuditEntry(); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+	if want, got := m, mongoAuditEntry(e); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
+
+func TestMDeletedBook(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	b := book.DeletedBook{
+		Book: book.Book{
+			Header:      book.Header{ID: "b1", Title: "t", Author: "a", Subject: "s"},
+			Language:    "en",
+			Format:      "print",
+			ImageBase64: "IMG",
+		},
+		DeletedDate: time1,
+	}
+	m := mongoDeletedBook(b)
+	if want, got := time1, m.DeletedDate; !want.Equal(got) {
+		t.Errorf("deleted dates not equal: wanted %v, got %v", want, got)
+	}
+	if want, got := b, m.DeletedBook(); !reflect.DeepEqual(want, got) {
+		t.Errorf("not equal: \n wanted: %+v \n got:    %+v", want, got)
+	}
+}
@@ -7,16 +7,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// Filter converts book filters to mongo filters.
+// Subjects are matched by the subject key or, if set, the tags key.
+// Authors are matched by the author key or, if set, the contributors key.
+// Languages, formats, and reading levels are matched by their keys.
 type Filter struct {
-	SubjectKey string
-	HeaderKeys []string
+	SubjectKey      string
+	TagsKey         string
+	AuthorKey       string
+	ContributorsKey string
+	LanguageKey     string
+	FormatKey       string
+	ReadingLevelKey string
+	HeaderKeys      []string
 }
 
 func (f Filter) From(filter book.Filter) []bson.E {
-	parts := make([]bson.E, 0, 2)
+	parts := make([]bson.E, 0, 6)
 	if len(filter.Subject) != 0 {
-		subjectPart := E(f.SubjectKey, filter.Subject)
-		parts = append(parts, subjectPart)
+		parts = append(parts, matchPart(filter.Subject, f.SubjectKey, f.TagsKey))
+	}
+	if len(filter.Author) != 0 {
+		parts = append(parts, matchPart(filter.Author, f.AuthorKey, f.ContributorsKey))
+	}
+	if len(filter.Language) != 0 {
+		parts = append(parts, matchPart(filter.Language, f.LanguageKey, ""))
+	}
+	if len(filter.Format) != 0 {
+		parts = append(parts, matchPart(filter.Format, f.FormatKey, ""))
+	}
+	if len(filter.ReadingLevel) != 0 {
+		parts = append(parts, matchPart(filter.ReadingLevel, f.ReadingLevelKey, ""))
 	}
 	if len(filter.HeaderPart) != 0 {
 		regex := primitive.MatchIgnoreCaseRegex(filter.HeaderPart)
@@ -27,12 +48,40 @@ func (f Filter) From(filter book.Filter) []bson.E {
 		headerParts := E("$or", A(headerFilters...))
 		parts = append(parts, headerParts)
 	}
-	if len(parts) == 0 {
+	switch {
+	case len(parts) == 0:
 		parts = append(parts, E("", nil))
+	case hasDuplicateKeys(parts): // parts that are $or can only be a key once
+		ands := make([]interface{}, len(parts))
+		for i, p := range parts {
+			ands[i] = D(p)
+		}
+		parts = []bson.E{E("$and", A(ands...))}
 	}
 	return parts
 }
 
+// matchPart matches the value to the key or, if set, the other key, ignoring case and surrounding whitespace.
+func matchPart(value, key, otherKey string) bson.E {
+	regex := primitive.MatchSubjectRegex(value)
+	part := E(key, regex)
+	if len(otherKey) != 0 {
+		part = E("$or", A(D(part), D(E(otherKey, regex))))
+	}
+	return part
+}
+
+func hasDuplicateKeys(parts []bson.E) bool {
+	keys := make(map[string]struct{}, len(parts))
+	for _, p := range parts {
+		if _, ok := keys[p.Key]; ok {
+			return true
+		}
+		keys[p.Key] = struct{}{}
+	}
+	return false
+}
+
 func D(e ...bson.E) bson.D {
 	return bson.D(e)
 }
@@ -90,7 +90,7 @@ func TestFilter(t *testing.T) {
 			filter: book.Filter{
 				Subject: "abc",
 			},
-			want: []bson.E{{Key: "k1", Value: "abc"}},
+			want: []bson.E{{Key: "k1", Value: primitive.MatchSubjectRegex("abc")}},
 		},
 		{
 			name: "query only, with escapes",
@@ -111,7 +111,7 @@ func TestFilter(t *testing.T) {
 				HeaderPart: "good",
 			},
 			want: []bson.E{
-				{Key: "k1", Value: "simple"},
+				{Key: "k1", Value: primitive.MatchSubjectRegex("simple")},
 				{
 					Key: "$or",
 					Value: bson.A{
@@ -130,3 +130,154 @@ func TestFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterTags(t *testing.T) {
+	f := Filter{
+		SubjectKey: "k1",
+		TagsKey:    "k2",
+		HeaderKeys: []string{"k3"},
+	}
+	subjectPart := bson.E{
+		Key: "$or",
+		Value: bson.A{
+			bson.D{bson.E{Key: "k1", Value: primitive.MatchSubjectRegex("simple")}},
+			bson.D{bson.E{Key: "k2", Value: primitive.MatchSubjectRegex("simple")}},
+		},
+	}
+	headerPart := bson.E{
+		Key: "$or",
+		Value: bson.A{
+			bson.D{bson.E{Key: "k3", Value: primitive.MatchIgnoreCaseRegex("good")}},
+		},
+	}
+	tests := []struct {
+		name   string
+		filter book.Filter
+		want   []bson.E
+	}{
+		{
+			name:   "subject only",
+			filter: book.Filter{Subject: "simple"},
+			want:   []bson.E{subjectPart},
+		},
+		{
+			name: "full filter",
+			filter: book.Filter{
+				Subject:    "simple",
+				HeaderPart: "good",
+			},
+			want: []bson.E{{
+				Key: "$and",
+				Value: bson.A{
+					bson.D{subjectPart},
+					bson.D{headerPart},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if want, got := test.want, f.From(test.filter); !reflect.DeepEqual(want, got) {
+				t.Errorf("not equal: \n wanted %v \n got:   %v", want, got)
+			}
+		})
+	}
+}
+
+func TestFilterAuthors(t *testing.T) {
+	f := Filter{
+		SubjectKey:      "k1",
+		AuthorKey:       "k2",
+		ContributorsKey: "k3",
+		HeaderKeys:      []string{"k4"},
+	}
+	subjectPart := bson.E{Key: "k1", Value: primitive.MatchSubjectRegex("simple")}
+	authorPart := bson.E{
+		Key: "$or",
+		Value: bson.A{
+			bson.D{bson.E{Key: "k2", Value: primitive.MatchSubjectRegex("Ann Lee")}},
+			bson.D{bson.E{Key: "k3", Value: primitive.MatchSubjectRegex("Ann Lee")}},
+		},
+	}
+	headerPart := bson.E{
+		Key: "$or",
+		Value: bson.A{
+			bson.D{bson.E{Key: "k4", Value: primitive.MatchIgnoreCaseRegex("good")}},
+		},
+	}
+	tests := []struct {
+		name   string
+		filter book.Filter
+		want   []bson.E
+	}{
+		{
+			name:   "author only",
+			filter: book.Filter{Author: "Ann Lee"},
+			want:   []bson.E{authorPart},
+		},
+		{
+			name:   "subject and author",
+			filter: book.Filter{Subject: "simple", Author: "Ann Lee"},
+			want:   []bson.E{subjectPart, authorPart},
+		},
+		{
+			name: "full filter",
+			filter: book.Filter{
+				Subject:    "simple",
+				Author:     "Ann Lee",
+				HeaderPart: "good",
+			},
+			want: []bson.E{{
+				Key: "$and",
+				Value: bson.A{
+					bson.D{subjectPart},
+					bson.D{authorPart},
+					bson.D{headerPart},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if want, got := test.want, f.From(test.filter); !reflect.DeepEqual(want, got) {
+				t.Errorf("not equal: \n wanted %v \n got:   %v", want, got)
+			}
+		})
+	}
+}
+
+func TestFilterDetails(t *testing.T) {
+	f := Filter{
+		SubjectKey:      "k1",
+		LanguageKey:     "k2",
+		FormatKey:       "k3",
+		ReadingLevelKey: "k4",
+	}
+	tests := []struct {
+		name   string
+		filter book.Filter
+		want   []bson.E
+	}{
+		{
+			name:   "language only",
+			filter: book.Filter{Language: "es"},
+			want:   []bson.E{{Key: "k2", Value: primitive.MatchSubjectRegex("es")}},
+		},
+		{
+			name:   "subject, format, and reading level",
+			filter: book.Filter{Subject: "simple", Format: "dvd", ReadingLevel: "young adult"},
+			want: []bson.E{
+				{Key: "k1", Value: primitive.MatchSubjectRegex("simple")},
+				{Key: "k3", Value: primitive.MatchSubjectRegex("dvd")},
+				{Key: "k4", Value: primitive.MatchSubjectRegex("young adult")},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if want, got := test.want, f.From(test.filter); !reflect.DeepEqual(want, got) {
+				t.Errorf("not equal: \n wanted %v \n got:   %v", want, got)
+			}
+		})
+	}
+}
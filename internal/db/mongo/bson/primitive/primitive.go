@@ -4,6 +4,7 @@ package primitive
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -32,3 +33,13 @@ func MatchIgnoreCaseRegex(word string) primitive.Regex {
 	}
 	return r
 }
+
+// MatchSubjectRegex matches the subject, ignoring case and surrounding whitespace.
+func MatchSubjectRegex(subject string) primitive.Regex {
+	subject = regexp.QuoteMeta(strings.TrimSpace(subject))
+	r := primitive.Regex{
+		Pattern: `^\s*` + subject + `\s*$`,
+		Options: "i",
+	}
+	return r
+}
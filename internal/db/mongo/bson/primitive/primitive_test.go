@@ -106,3 +106,22 @@ func TestMatchIgnoreCaseRegex(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchSubjectRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    primitive.Regex
+	}{
+		{"simple", "Fiction", primitive.Regex{Pattern: `^\s*Fiction\s*$`, Options: "i"}},
+		{"trimmed", " Science Fiction\t", primitive.Regex{Pattern: `^\s*Science Fiction\s*$`, Options: "i"}},
+		{"specials", "C++", primitive.Regex{Pattern: `^\s*C\+\+\s*$`, Options: "i"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if want, got := test.want, MatchSubjectRegex(test.subject); !reflect.DeepEqual(want, got) {
+				t.Errorf("not equal: \n wanted %v \n got:   %v", test.want, got)
+			}
+		})
+	}
+}
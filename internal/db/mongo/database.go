@@ -3,6 +3,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,8 +16,16 @@ import (
 
 type (
 	Database struct {
-		booksCollection mCollection
-		usersCollection mCollection
+		booksCollection    mCollection
+		usersCollection    mCollection
+		aliasesCollection  mCollection
+		settingsCollection mCollection
+		auditCollection    mCollection
+		trashCollection    mCollection
+		booksIndexes       mIndexView
+	}
+	mIndexView interface {
+		CreateMany(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
 	}
 	mCollection interface {
 		InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
@@ -24,19 +33,37 @@ type (
 		Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
 		FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
 		UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+		UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
 		DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+		DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+		FindOneAndUpdate(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult
 	}
 	mBook struct {
-		Header        mHeader   `bson:",inline"`
-		Description   string    `bson:"description"`
-		DeweyDecClass string    `bson:"dewey_dec_class"`
-		Pages         int       `bson:"pages"`
-		Publisher     string    `bson:"publisher"`
-		PublishDate   time.Time `bson:"publish_date"`
-		AddedDate     time.Time `bson:"added_date"`
-		EanIsbn13     string    `bson:"ean_isbn13"`
-		UpcIsbn10     string    `bson:"upc_isbn10"`
-		ImageBase64   string    `bson:"image_base64"`
+		Header        mHeader           `bson:",inline"`
+		Tags          []string          `bson:"tags,omitempty"`
+		Contributors  []mContributor    `bson:"contributors,omitempty"`
+		Series        string            `bson:"series,omitempty"`
+		Volume        int               `bson:"volume,omitempty"`
+		Language      string            `bson:"language"`
+		Edition       string            `bson:"edition,omitempty"`
+		Format        string            `bson:"format"`
+		ReadingLevel  string            `bson:"reading_level,omitempty"`
+		Custom        map[string]string `bson:"custom,omitempty"`
+		Description   string            `bson:"description"`
+		DeweyDecClass string            `bson:"dewey_dec_class"`
+		Pages         int               `bson:"pages"`
+		Publisher     string            `bson:"publisher"`
+		PublishDate   time.Time         `bson:"publish_date"`
+		AddedDate     time.Time         `bson:"added_date"`
+		EanIsbn13     string            `bson:"ean_isbn13"`
+		UpcIsbn10     string            `bson:"upc_isbn10"`
+		ImageBase64   string            `bson:"image_base64"`
+	}
+	// mDeletedBook is a document of the trash, which keeps the whole book so it can be restored.
+	// The id of the book is kept as a string.
+	mDeletedBook struct {
+		Book        mBook     `bson:",inline"`
+		DeletedDate time.Time `bson:"deleted_date"`
 	}
 	mHeader struct {
 		ID      string `bson:"_id,omitempty"`
@@ -44,10 +71,50 @@ type (
 		Author  string `bson:"author"`
 		Subject string `bson:"subject"`
 	}
+	mContributor struct {
+		Name     string `bson:"name"`
+		Role     string `bson:"role"`
+		SortName string `bson:"sort_name"`
+	}
+	mAuthor struct {
+		Key      string `bson:"_id"`
+		Name     string `bson:"name"`
+		SortName string `bson:"sort_name"`
+		Count    int    `bson:"count"`
+	}
 	mSubject struct {
-		Name  string `bson:"_id"`
+		Key   string `bson:"_id"`
+		Name  string `bson:"name"`
 		Count int    `bson:"count"`
 	}
+	mSubjectAlias struct {
+		Key       string `bson:"_id"`
+		Name      string `bson:"name"`
+		Canonical string `bson:"canonical"`
+	}
+	// mCustomFields is the settings document of the custom fields, in order.
+	mCustomFields struct {
+		Fields []mCustomField `bson:"fields"`
+	}
+	mCustomField struct {
+		Name    string   `bson:"name"`
+		Type    string   `bson:"type"`
+		Options []string `bson:"options,omitempty"`
+	}
+	// mAuditEntry is a document of the audit log, which is only inserted into.
+	mAuditEntry struct {
+		ID      string         `bson:"_id"`
+		BookID  string         `bson:"book_id"`
+		Time    time.Time      `bson:"time"`
+		Actor   string         `bson:"actor"`
+		Action  string         `bson:"action"`
+		Changes []mFieldChange `bson:"changes,omitempty"`
+	}
+	mFieldChange struct {
+		Field  string `bson:"field"`
+		Before string `bson:"before"`
+		After  string `bson:"after"`
+	}
 	mUser struct {
 		Username string `bson:"username"`
 		Password string `bson:"password"`
@@ -58,11 +125,27 @@ const (
 	libraryDatabase        = "kuuf_library_db"
 	booksCollection        = "books"
 	usersCollection        = "users"
+	aliasesCollection      = "subject_aliases"
+	settingsCollection     = "settings"
+	auditCollection        = "audit_log"
+	trashCollection        = "trash"
+	customFieldsID         = "custom_fields"
 	adminUsername          = "admin"
 	bookIDField            = "_id"
 	bookTitleField         = "title"
 	bookAuthorField        = "author"
 	bookSubjectField       = "subject"
+	bookTagsField          = "tags"
+	bookContributorsField  = "contributors"
+	contributorNameField   = "name"
+	contributorSortField   = "sort_name"
+	bookSeriesField        = "series"
+	bookVolumeField        = "volume"
+	bookLanguageField      = "language"
+	bookEditionField       = "edition"
+	bookFormatField        = "format"
+	bookReadingLevelField  = "reading_level"
+	bookCustomField        = "custom"
 	bookDescriptionField   = "description"
 	bookDeweyDecClassField = "dewey_dec_class"
 	bookPagesField         = "pages"
@@ -72,8 +155,21 @@ const (
 	bookEanIsbn13Field     = "ean_isbn13"
 	bookUpcIsbn0Field      = "upc_isbn10"
 	bookImageBase64Field   = "image_base64"
-	subjectNameField       = "_id"
+	subjectKeyField        = "_id"
+	subjectNameField       = "name"
 	subjectCountField      = "count"
+	authorKeyField         = "_id"
+	authorNameField        = "name"
+	authorSortNameField    = "sort_name"
+	authorCountField       = "count"
+	aliasKeyField          = "_id"
+	aliasNameField         = "name"
+	aliasCanonicalField    = "canonical"
+	settingIDField         = "_id"
+	customFieldsField      = "fields"
+	auditBookIDField       = "book_id"
+	auditTimeField         = "time"
+	trashDeletedDateField  = "deleted_date"
 	usernameField          = "username"
 	passwordField          = "password"
 	dateLayout             = book.HyphenatedYYYYMMDD
@@ -89,14 +185,60 @@ func NewDatabase(ctx context.Context, url string) (*Database, error) {
 	database := client.Database(libraryDatabase)
 	booksCollection := database.Collection(booksCollection)
 	usersCollection := database.Collection(usersCollection)
+	aliasesCollection := database.Collection(aliasesCollection)
+	settingsCollection := database.Collection(settingsCollection)
+	auditCollection := database.Collection(auditCollection)
+	trashCollection := database.Collection(trashCollection)
 	d := Database{
-		booksCollection: booksCollection,
-		usersCollection: usersCollection,
+		booksCollection:    booksCollection,
+		usersCollection:    usersCollection,
+		aliasesCollection:  aliasesCollection,
+		settingsCollection: settingsCollection,
+		auditCollection:    auditCollection,
+		trashCollection:    trashCollection,
+		booksIndexes:       booksCollection.Indexes(),
 	}
 	return &d, nil
 }
 
-func (d *Database) CreateBooks(ctx context.Context, books ...book.Book) ([]book.Book, error) {
+// CreateIndexes ensures the books collection has the indexes used to look up books by isbn, tag, contributor, and series.
+// Indexes that already exist are not changed.
+func (d *Database) CreateIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D(bson.E(bookEanIsbn13Field, 1))},
+		{Keys: bson.D(bson.E(bookUpcIsbn0Field, 1))},
+		{Keys: bson.D(bson.E(bookTagsField, 1))}, // multikey index of the tags array
+		{Keys: bson.D(bson.E(bookContributorsField+"."+contributorNameField, 1))},
+		{Keys: bson.D(bson.E(bookSeriesField, 1))},
+	}
+	opts := options.CreateIndexes()
+	if _, err := d.booksIndexes.CreateMany(ctx, models, opts); err != nil {
+		return fmt.Errorf("creating book indexes: %w", err)
+	}
+	return nil
+}
+
+// SetDefaults sets the language and format of books that were added before books had them to the defaults.
+func (d *Database) SetDefaults(ctx context.Context) error {
+	defaults := []struct {
+		field, value string
+	}{
+		{bookLanguageField, book.DefaultLanguage},
+		{bookFormatField, book.DefaultFormat},
+	}
+	opts := options.Update()
+	for _, df := range defaults {
+		filter := bson.D(bson.E(df.field, bson.D(bson.E("$in", bson.A(nil, "")))))
+		update := bson.D(bson.E("$set", bson.D(bson.E(df.field, df.value))))
+		if _, err := d.booksCollection.UpdateMany(ctx, filter, update, opts); err != nil {
+			return fmt.Errorf("setting default book %v: %w", df.field, err)
+		}
+	}
+	return nil
+}
+
+// CreateBooks creates the books with new ids, adding an entry to the audit log for each audit of each book after the books are inserted.
+func (d *Database) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
 	if len(books) == 0 {
 		return nil, nil
 	}
@@ -121,13 +263,110 @@ func (d *Database) CreateBooks(ctx context.Context, books ...book.Book) ([]book.
 		}
 		books[i].ID = objID.Hex()
 	}
+	var entries []book.AuditEntry
+	for _, b := range books {
+		entries = append(entries, book.Entries(audits, book.Book{}, b)...)
+	}
+	if err := d.insertAuditEntries(ctx, entries...); err != nil {
+		return nil, fmt.Errorf("books created, but writing audit log: %w", err)
+	}
 	return books, nil
 }
 
+// ImportBooks creates the books with the ids they already have.
+// Books with ids that already exist are replaced if upsert is true.
+// Otherwise, no books are imported if any of the ids already exist.
+// Ids that are not ObjectIDs are stored as strings.
+// The entries are added to the audit log after the books are imported.
+func (d *Database) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if err := book.Books(books).CheckIDs(); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	if len(books) == 0 {
+		return nil
+	}
+	if !upsert {
+		existingIDs, err := d.existingIDs(ctx, books...)
+		if err != nil {
+			return fmt.Errorf("checking for existing ids: %w", err)
+		}
+		if len(existingIDs) != 0 {
+			return book.ExistingIDsError(existingIDs)
+		}
+	}
+	opts := options.Update().
+		SetUpsert(true)
+	coll := d.booksCollection
+	for _, b := range books {
+		filter, err := d.idFilter(b.ID)
+		if err != nil {
+			return err
+		}
+		update := bson.D(bson.E("$set", bookSets(b, true)))
+		result, err := coll.UpdateOne(ctx, filter, update, opts)
+		if err != nil {
+			return fmt.Errorf("upserting document for book %q: %w", b.ID, err)
+		}
+		if err := d.expectSingleModify(result.MatchedCount + result.UpsertedCount); err != nil {
+			return fmt.Errorf("upserting document for book %q: %w", b.ID, err)
+		}
+	}
+	return d.insertAuditEntries(ctx, entries...)
+}
+
+// existingIDs finds the ids of the books that are already in the database.
+func (d *Database) existingIDs(ctx context.Context, books ...book.Book) ([]string, error) {
+	ids := make([]interface{}, len(books))
+	for i, b := range books {
+		ids[i] = mongoID(b.ID)
+	}
+	filter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A(ids...)))))
+	opts := options.Find().
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+		))
+	coll := d.booksCollection
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mHeader
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding headers: %w", err)
+	}
+	existingIDs := make([]string, len(all))
+	for i, m := range all {
+		existingIDs[i] = m.ID
+	}
+	return existingIDs, nil
+}
+
+// ReadBookSubjects counts the books for each subject or tag.
+// The subject and tags of each book are unwound and grouped by book first so books are only counted once for each subject.
 func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
+	const (
+		subjectsField = "subjects"
+		bookField     = "book"
+		keyField      = "key"
+	)
 	pipeline := mongo.Pipeline{
+		bson.D(bson.E("$project", bson.D(
+			bson.E(subjectsField, bson.D(bson.E("$concatArrays", bson.A(
+				bson.A("$"+bookSubjectField),
+				bson.D(bson.E("$ifNull", bson.A("$"+bookTagsField, bson.A()))),
+			)))),
+		))),
+		bson.D(bson.E("$unwind", "$"+subjectsField)),
+		bson.D(bson.E("$group", bson.D(
+			bson.E(subjectKeyField, bson.D(
+				bson.E(bookField, "$"+bookIDField),
+				bson.E(keyField, subjectKey(subjectsField)),
+			)),
+			bson.E(subjectNameField, bson.D(bson.E("$min", trimmed(subjectsField)))),
+		))),
 		bson.D(bson.E("$group", bson.D(
-			bson.E(subjectNameField, "$"+bookSubjectField),
+			bson.E(subjectKeyField, "$"+subjectKeyField+"."+keyField),
+			bson.E(subjectNameField, bson.D(bson.E("$min", "$"+subjectNameField))),
 			bson.E(subjectCountField, bson.D(bson.E("$sum", 1))),
 		))),
 		bson.D(bson.E("$sort", bson.D(
@@ -153,13 +392,94 @@ func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]b
 	return subjects, nil
 }
 
+// ReadBookAuthors counts the books for each author or contributor, sorted by the sort names of the contributors.
+// The author and contributors of each book are unwound and grouped by book first so books are only counted once for each author.
+// Authors that are not contributors are given sort names from their names, like book.SortName.
+func (d *Database) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	const (
+		authorsField = "authors"
+		bookField    = "book"
+		keyField     = "key"
+		sortKeyField = "sort_key"
+	)
+	authorName := authorsField + "." + contributorNameField
+	pipeline := mongo.Pipeline{
+		bson.D(bson.E("$project", bson.D(
+			bson.E(authorsField, bson.D(bson.E("$concatArrays", bson.A(
+				bson.A(bson.D(
+					bson.E(contributorNameField, "$"+bookAuthorField),
+					bson.E(contributorSortField, ""),
+				)),
+				bson.D(bson.E("$ifNull", bson.A("$"+bookContributorsField, bson.A()))),
+			)))),
+		))),
+		bson.D(bson.E("$unwind", "$"+authorsField)),
+		bson.D(bson.E("$group", bson.D(
+			bson.E(authorKeyField, bson.D(
+				bson.E(bookField, "$"+bookIDField),
+				bson.E(keyField, subjectKey(authorName)),
+			)),
+			bson.E(authorNameField, bson.D(bson.E("$min", trimmed(authorName)))),
+			bson.E(authorSortNameField, bson.D(bson.E("$max", "$"+authorsField+"."+contributorSortField))),
+		))),
+		bson.D(bson.E("$match", bson.D(
+			bson.E(authorKeyField+"."+keyField, bson.D(bson.E("$ne", ""))),
+		))),
+		bson.D(bson.E("$group", bson.D(
+			bson.E(authorKeyField, "$"+authorKeyField+"."+keyField),
+			bson.E(authorNameField, bson.D(bson.E("$min", "$"+authorNameField))),
+			bson.E(authorSortNameField, bson.D(bson.E("$max", "$"+authorSortNameField))),
+			bson.E(authorCountField, bson.D(bson.E("$sum", 1))),
+		))),
+		bson.D(bson.E("$set", bson.D(
+			bson.E(authorSortNameField, bson.D(bson.E("$cond", bson.A(
+				bson.D(bson.E("$eq", bson.A("$"+authorSortNameField, ""))),
+				sortName(authorNameField),
+				"$"+authorSortNameField,
+			)))),
+		))),
+		bson.D(bson.E("$set", bson.D(
+			bson.E(sortKeyField, bson.D(bson.E("$toLower", "$"+authorSortNameField))),
+		))),
+		bson.D(bson.E("$sort", bson.D(
+			bson.E(sortKeyField, 1),
+			bson.E(authorNameField, 1),
+		))),
+		bson.D(bson.E("$skip", offset)),
+		bson.D(bson.E("$limit", limit)),
+	}
+	opts := options.Aggregate()
+	coll := d.booksCollection
+	cur, err := coll.Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating documents: %w", err)
+	}
+	var all []mAuthor
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding authors: %w", err)
+	}
+	authors := make([]book.Author, len(all))
+	for i, m := range all {
+		authors[i] = m.Author()
+	}
+	return authors, nil
+}
+
 func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
 	bsonFilter := bson.Filter{
-		SubjectKey: bookSubjectField,
+		SubjectKey:      bookSubjectField,
+		TagsKey:         bookTagsField,
+		AuthorKey:       bookAuthorField,
+		ContributorsKey: bookContributorsField + "." + contributorNameField,
+		LanguageKey:     bookLanguageField,
+		FormatKey:       bookFormatField,
+		ReadingLevelKey: bookReadingLevelField,
 		HeaderKeys: []string{
 			bookTitleField,
 			bookAuthorField,
 			bookSubjectField,
+			bookTagsField,
+			bookContributorsField + "." + contributorNameField,
 		},
 	}
 	mongoFilter := bson.D(bsonFilter.From(filter)...)
@@ -192,6 +512,66 @@ func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limi
 	return headers, nil
 }
 
+func (d *Database) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	opts := options.Find().
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+			bson.E(bookTitleField, 1),
+			bson.E(bookAuthorField, 1),
+			bson.E(bookSubjectField, 1),
+			bson.E(bookDeweyDecClassField, 1),
+		))
+	coll := d.booksCollection
+	cur, err := coll.Find(ctx, bson.D(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mBook
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding shelved books: %w", err)
+	}
+	shelved := make([]book.ShelvedBook, len(all))
+	for i, m := range all {
+		shelved[i] = book.ShelvedBook{
+			Header:        m.Header.Header(),
+			DeweyDecClass: m.DeweyDecClass,
+		}
+	}
+	return shelved, nil
+}
+
+// ReadSeriesBooks reads the books that have series, which are the books with series that are greater than the empty string.
+func (d *Database) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	filter := bson.D(bson.E(bookSeriesField, bson.D(bson.E("$gt", ""))))
+	opts := options.Find().
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+			bson.E(bookTitleField, 1),
+			bson.E(bookAuthorField, 1),
+			bson.E(bookSubjectField, 1),
+			bson.E(bookSeriesField, 1),
+			bson.E(bookVolumeField, 1),
+		))
+	coll := d.booksCollection
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mBook
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding series books: %w", err)
+	}
+	seriesBooks := make([]book.SeriesBook, len(all))
+	for i, m := range all {
+		seriesBooks[i] = book.SeriesBook{
+			Header: m.Header.Header(),
+			Series: m.Series,
+			Volume: m.Volume,
+		}
+	}
+	return seriesBooks, nil
+}
+
 func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error) {
 	filter, err := d.idFilter(id)
 	if err != nil {
@@ -202,55 +582,476 @@ func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error)
 	result := coll.FindOne(ctx, filter, opts)
 	var m mBook
 	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, book.IDNotFoundError(id)
+		}
 		return nil, fmt.Errorf("decoding book: %w", err)
 	}
 	b := m.Book()
 	return &b, nil
 }
 
-func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool) error {
-	filter, err := d.idFilter(b.ID)
+// ReadBookMetadata reads the book without its image, which is left out by the projection.
+func (d *Database) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	filter, err := d.idFilter(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	sets := bson.D(
-		bson.E(bookTitleField, b.Title),
-		bson.E(bookAuthorField, b.Author),
-		bson.E(bookSubjectField, b.Subject),
-		bson.E(bookDescriptionField, b.Description),
-		bson.E(bookDeweyDecClassField, b.DeweyDecClass),
-		bson.E(bookPagesField, b.Pages),
-		bson.E(bookPublisherField, b.Publisher),
-		bson.E(bookPublishDateField, b.PublishDate),
-		bson.E(bookAddedDateField, b.AddedDate),
-		bson.E(bookEanIsbn13Field, b.EanIsbn13),
-		bson.E(bookUpcIsbn0Field, b.UpcIsbn10),
+	coll := d.booksCollection
+	opts := options.FindOne().
+		SetProjection(bson.D(
+			bson.E(bookImageBase64Field, 0),
+		))
+	result := coll.FindOne(ctx, filter, opts)
+	var m mBook
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, book.IDNotFoundError(id)
+		}
+		return nil, fmt.Errorf("decoding book: %w", err)
+	}
+	b := m.Book()
+	return &b, nil
+}
+
+// ReadBookByISBN reads the book that has the isbn-13 or isbn-10.
+func (d *Database) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	if len(isbn) == 0 {
+		return nil, book.ISBNNotFoundError(isbn)
+	}
+	filter := bson.D(
+		bson.E("$or", bson.A(
+			bson.D(bson.E(bookEanIsbn13Field, isbn)),
+			bson.D(bson.E(bookUpcIsbn0Field, isbn)),
+		)),
 	)
-	if updateImage {
-		sets = append(sets, bson.E(bookImageBase64Field, b.ImageBase64))
+	coll := d.booksCollection
+	opts := options.FindOne()
+	result := coll.FindOne(ctx, filter, opts)
+	var m mBook
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, book.ISBNNotFoundError(isbn)
+		}
+		return nil, fmt.Errorf("decoding book: %w", err)
 	}
+	b := m.Book()
+	return &b, nil
+}
+
+// UpdateBook replaces the book, adding an entry to the audit log for each audit after the book is updated.
+// The book from before the update is returned by the same atomic operation that updates it, so the entries have the changes that were made.
+// The audit log is written alongside the book rather than in a transaction because transactions require replica sets.
+func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	filter, err := d.idFilter(b.ID)
+	if err != nil {
+		return err
+	}
+	sets := bookSets(b, updateImage)
 	update := bson.D(bson.E("$set", sets))
-	opts := options.Update()
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.D(
+			bson.E(bookImageBase64Field, 0),
+		))
 	coll := d.booksCollection
-	result, err := coll.UpdateOne(ctx, filter, update, opts)
-	if err != nil {
+	result := coll.FindOneAndUpdate(ctx, filter, update, opts)
+	var m mBook
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.IDNotFoundError(b.ID)
+		}
 		return fmt.Errorf("updating one document: %w", err)
 	}
-	return d.expectSingleModify(result.ModifiedCount)
+	entries := book.Entries(audits, m.Book(), b)
+	return d.insertAuditEntries(ctx, entries...)
 }
 
-func (d *Database) DeleteBook(ctx context.Context, id string) error {
+// DeleteBook moves the book to the trash, adding the entries to the audit log after the book is deleted.
+// The book is inserted into the trash before it is deleted, so it is not lost if it cannot be deleted.
+func (d *Database) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
 	filter, err := d.idFilter(id)
 	if err != nil {
 		return err
 	}
+	b, err := d.ReadBook(ctx, id)
+	if err != nil {
+		return err
+	}
+	deleted := book.DeletedBook{
+		Book:        *b,
+		DeletedDate: time.Now().UTC(),
+	}
+	docs := []interface{}{mongoDeletedBook(deleted)}
+	if _, err := d.trashCollection.InsertMany(ctx, docs, options.InsertMany()); err != nil {
+		return fmt.Errorf("inserting document into trash: %w", err)
+	}
 	opts := options.Delete()
 	coll := d.booksCollection
 	result, err := coll.DeleteOne(ctx, filter, opts)
 	if err != nil {
 		return fmt.Errorf("deleting one document: %w", err)
 	}
-	return d.expectSingleModify(result.DeletedCount)
+	if err := d.expectSingleModify(result.DeletedCount); err != nil {
+		return err
+	}
+	return d.insertAuditEntries(ctx, entries...)
+}
+
+// ReadTrash reads the books in the trash, most recently deleted first.
+func (d *Database) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	opts := options.Find().
+		SetSort(bson.D(
+			bson.E(trashDeletedDateField, -1),
+		)).
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+			bson.E(bookTitleField, 1),
+			bson.E(bookAuthorField, 1),
+			bson.E(bookSubjectField, 1),
+			bson.E(trashDeletedDateField, 1),
+		))
+	coll := d.trashCollection
+	cur, err := coll.Find(ctx, bson.D(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mDeletedBook
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding trash: %w", err)
+	}
+	trash := make([]book.TrashedBook, len(all))
+	for i, m := range all {
+		trash[i] = m.DeletedBook().TrashedBook()
+	}
+	return trash, nil
+}
+
+// ReadDeletedBook reads the book in the trash with its image.
+func (d *Database) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	filter := bson.D(bson.E(bookIDField, id))
+	result := d.trashCollection.FindOne(ctx, filter, options.FindOne())
+	var m mDeletedBook
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, book.TrashNotFoundError(id)
+		}
+		return nil, fmt.Errorf("decoding deleted book: %w", err)
+	}
+	b := m.DeletedBook()
+	return &b, nil
+}
+
+// ImportTrash adds the deleted books to the trash with the times they were deleted.
+// Books with ids that are already in the trash are skipped.
+func (d *Database) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+	filter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A(ids...)))))
+	opts := options.Find().
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+		))
+	coll := d.trashCollection
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("finding documents: %w", err)
+	}
+	var existing []mHeader
+	if err := cur.All(ctx, &existing); err != nil {
+		return fmt.Errorf("decoding headers: %w", err)
+	}
+	trashed := make(map[string]struct{}, len(existing))
+	for _, m := range existing {
+		trashed[m.ID] = struct{}{}
+	}
+	var docs []interface{}
+	for _, b := range books {
+		if _, ok := trashed[b.ID]; ok {
+			continue
+		}
+		trashed[b.ID] = struct{}{}
+		docs = append(docs, mongoDeletedBook(b))
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if _, err := coll.InsertMany(ctx, docs, options.InsertMany()); err != nil {
+		return fmt.Errorf("inserting documents into trash: %w", err)
+	}
+	return nil
+}
+
+// RestoreBook moves the book out of the trash, adding the entries to the audit log after the book is restored.
+// The book is restored before it is removed from the trash, so it is not lost if it cannot be removed.
+// Books cannot be restored if another book has their id.
+// The book is only inserted if no book has its id in the same upsert, so a book that is created at the same time cannot take the id.
+func (d *Database) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	filter := bson.D(bson.E(bookIDField, id))
+	result := d.trashCollection.FindOne(ctx, filter, options.FindOne())
+	var m mDeletedBook
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.TrashNotFoundError(id)
+		}
+		return fmt.Errorf("decoding deleted book: %w", err)
+	}
+	b := m.DeletedBook().Book
+	bookFilter, err := d.idFilter(id)
+	if err != nil {
+		return err
+	}
+	update := bson.D(bson.E("$setOnInsert", bookSets(b, true)))
+	opts := options.Update().
+		SetUpsert(true)
+	updateResult, err := d.booksCollection.UpdateOne(ctx, bookFilter, update, opts)
+	if err != nil {
+		return fmt.Errorf("upserting document: %w", err)
+	}
+	if updateResult.MatchedCount != 0 {
+		return book.ExistingIDsError([]string{id})
+	}
+	if err := d.expectSingleModify(updateResult.UpsertedCount); err != nil {
+		return err
+	}
+	deleteResult, err := d.trashCollection.DeleteOne(ctx, filter, options.Delete())
+	if err != nil {
+		return fmt.Errorf("book restored, but deleting it from trash: %w", err)
+	}
+	if err := d.expectSingleModify(deleteResult.DeletedCount); err != nil {
+		return fmt.Errorf("book restored, but deleting it from trash: %w", err)
+	}
+	return d.insertAuditEntries(ctx, entries...)
+}
+
+// PurgeBooks permanently removes the books from the trash.
+// Ids of books that are not in the trash are ignored.
+func (d *Database) PurgeBooks(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]interface{}, len(ids))
+	for i, id := range ids {
+		keys[i] = id
+	}
+	filter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A(keys...)))))
+	opts := options.Delete()
+	coll := d.trashCollection
+	if _, err := coll.DeleteMany(ctx, filter, opts); err != nil {
+		return fmt.Errorf("deleting documents: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) insertAuditEntries(ctx context.Context, entries ...book.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(entries))
+	for i, e := range entries {
+		docs[i] = mongoAuditEntry(e)
+	}
+	opts := options.InsertMany()
+	coll := d.auditCollection
+	if _, err := coll.InsertMany(ctx, docs, opts); err != nil {
+		return fmt.Errorf("inserting audit entries: %w", err)
+	}
+	return nil
+}
+
+// ReadBookHistory reads the audit log of the book, newest entries first.
+func (d *Database) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	filter := bson.D(bson.E(auditBookIDField, bookID))
+	opts := options.Find().
+		SetSort(bson.D(
+			bson.E(auditTimeField, -1),
+		))
+	coll := d.auditCollection
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mAuditEntry
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding audit entries: %w", err)
+	}
+	history := make([]book.AuditEntry, len(all))
+	for i, m := range all {
+		history[i] = m.AuditEntry()
+	}
+	return history, nil
+}
+
+// ReadAuditLog reads the entries of the audit log, oldest first.
+func (d *Database) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	opts := options.Find().
+		SetSort(bson.D(
+			bson.E(auditTimeField, 1),
+		))
+	coll := d.auditCollection
+	cur, err := coll.Find(ctx, bson.D(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mAuditEntry
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding audit entries: %w", err)
+	}
+	entries := make([]book.AuditEntry, len(all))
+	for i, m := range all {
+		entries[i] = m.AuditEntry()
+	}
+	return entries, nil
+}
+
+// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+func (d *Database) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	filter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A(ids...)))))
+	opts := options.Find().
+		SetProjection(bson.D(
+			bson.E(bookIDField, 1),
+		))
+	coll := d.auditCollection
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("finding documents: %w", err)
+	}
+	var existing []mAuditEntry
+	if err := cur.All(ctx, &existing); err != nil {
+		return fmt.Errorf("decoding audit entries: %w", err)
+	}
+	log := make([]book.AuditEntry, len(existing))
+	for i, m := range existing {
+		log[i] = m.AuditEntry()
+	}
+	n := len(log)
+	log = book.AppendAuditEntries(log, entries...)
+	if err := d.insertAuditEntries(ctx, log[n:]...); err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	opts := options.Find().
+		SetSort(bson.D(
+			bson.E(aliasNameField, 1),
+		))
+	coll := d.aliasesCollection
+	cur, err := coll.Find(ctx, bson.D(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents: %w", err)
+	}
+	var all []mSubjectAlias
+	if err := cur.All(ctx, &all); err != nil {
+		return nil, fmt.Errorf("decoding subject aliases: %w", err)
+	}
+	aliases := make([]book.SubjectAlias, len(all))
+	for i, m := range all {
+		aliases[i] = m.SubjectAlias()
+	}
+	return aliases, nil
+}
+
+// MergeSubjects gives the books with any of the subjects the canonical subject, making the subjects aliases of it.
+// Tags with any of the subjects are replaced with the canonical subject.
+// Aliases of the subjects become aliases of the canonical subject.
+// The subjects and tags of books are each changed by a single update, but the aliases are changed after them rather than in a transaction because transactions require replica sets.
+// The entries are added to the audit log last.
+func (d *Database) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	canonical = book.NormalizeSubject(canonical)
+	keys := book.SubjectKeys(append([]string{canonical}, subjects...)...)
+	inKeys := func(field string) interface{} {
+		keyArgs := make([]interface{}, len(keys))
+		for i, k := range keys {
+			keyArgs[i] = k
+		}
+		return bson.D(bson.E("$expr", bson.D(bson.E("$in", bson.A(subjectKey(field), bson.A(keyArgs...))))))
+	}
+	opts := options.Update()
+	update := bson.D(bson.E("$set", bson.D(bson.E(bookSubjectField, canonical))))
+	if _, err := d.booksCollection.UpdateMany(ctx, inKeys(bookSubjectField), update, opts); err != nil {
+		return fmt.Errorf("updating book subjects: %w", err)
+	}
+	regexes := make([]interface{}, len(keys))
+	for i, k := range keys {
+		regexes[i] = primitive.MatchSubjectRegex(k)
+	}
+	const tagElement = "tag"
+	tagFilter := bson.D(bson.E(bookTagsField, bson.D(bson.E("$in", bson.A(regexes...)))))
+	update = bson.D(bson.E("$set", bson.D(bson.E(bookTagsField+".$["+tagElement+"]", canonical))))
+	tagOpts := options.Update().
+		SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{
+				bson.D(bson.E(tagElement, bson.D(bson.E("$in", bson.A(regexes...))))),
+			},
+		})
+	if _, err := d.booksCollection.UpdateMany(ctx, tagFilter, update, tagOpts); err != nil {
+		return fmt.Errorf("updating book tags: %w", err)
+	}
+	coll := d.aliasesCollection
+	update = bson.D(bson.E("$set", bson.D(bson.E(aliasCanonicalField, canonical))))
+	if _, err := coll.UpdateMany(ctx, inKeys(aliasCanonicalField), update, opts); err != nil {
+		return fmt.Errorf("updating aliases of merged subjects: %w", err)
+	}
+	filter := bson.D(bson.E(aliasKeyField, book.SubjectKey(canonical)))
+	if _, err := coll.DeleteOne(ctx, filter, options.Delete()); err != nil {
+		return fmt.Errorf("deleting alias of canonical subject: %w", err)
+	}
+	upsert := options.Update().
+		SetUpsert(true)
+	for _, a := range book.SubjectAliases(nil).Merge(canonical, subjects...) {
+		filter := bson.D(bson.E(aliasKeyField, book.SubjectKey(a.Name)))
+		update := bson.D(bson.E("$set", bson.D(
+			bson.E(aliasNameField, a.Name),
+			bson.E(aliasCanonicalField, a.Canonical),
+		)))
+		if _, err := coll.UpdateOne(ctx, filter, update, upsert); err != nil {
+			return fmt.Errorf("upserting alias %q: %w", a.Name, err)
+		}
+	}
+	return d.insertAuditEntries(ctx, entries...)
+}
+
+func (d *Database) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	filter := bson.D(bson.E(settingIDField, customFieldsID))
+	coll := d.settingsCollection
+	opts := options.FindOne()
+	result := coll.FindOne(ctx, filter, opts)
+	var m mCustomFields
+	if err := result.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("finding one document: %w", err)
+	}
+	return m.CustomFields(), nil
+}
+
+// UpdateCustomFields replaces the custom fields, which are stored in a single settings document.
+// The values of books are kept for fields that are removed.
+func (d *Database) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	filter := bson.D(bson.E(settingIDField, customFieldsID))
+	update := bson.D(bson.E("$set", bson.D(bson.E(customFieldsField, mongoCustomFields(fields)))))
+	opts := options.Update().
+		SetUpsert(true)
+	coll := d.settingsCollection
+	if _, err := coll.UpdateOne(ctx, filter, update, opts); err != nil {
+		return fmt.Errorf("updating one document: %w", err)
+	}
+	return nil
 }
 
 func (d *Database) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
@@ -279,12 +1080,110 @@ func (d *Database) UpdateAdminPassword(ctx context.Context, hashedPassword strin
 	return d.expectSingleModify(result.ModifiedCount)
 }
 
+// bookSets are the fields of the book to set when updating its document.
+func bookSets(b book.Book, updateImage bool) interface{} {
+	sets := bson.D(
+		bson.E(bookTitleField, b.Title),
+		bson.E(bookAuthorField, b.Author),
+		bson.E(bookSubjectField, b.Subject),
+		bson.E(bookTagsField, tags(b)),
+		bson.E(bookContributorsField, contributors(b)),
+		bson.E(bookSeriesField, b.Series),
+		bson.E(bookVolumeField, b.Volume),
+		bson.E(bookLanguageField, b.Language),
+		bson.E(bookEditionField, b.Edition),
+		bson.E(bookFormatField, b.Format),
+		bson.E(bookReadingLevelField, b.ReadingLevel),
+		bson.E(bookCustomField, custom(b)),
+		bson.E(bookDescriptionField, b.Description),
+		bson.E(bookDeweyDecClassField, b.DeweyDecClass),
+		bson.E(bookPagesField, b.Pages),
+		bson.E(bookPublisherField, b.Publisher),
+		bson.E(bookPublishDateField, b.PublishDate),
+		bson.E(bookAddedDateField, b.AddedDate),
+		bson.E(bookEanIsbn13Field, b.EanIsbn13),
+		bson.E(bookUpcIsbn0Field, b.UpcIsbn10),
+	)
+	if !updateImage {
+		return sets
+	}
+	return append(sets, bson.E(bookImageBase64Field, b.ImageBase64))
+}
+
+// tags are the normalized tags of the book, which are stored as an empty array rather than null if the book has no tags.
+func tags(b book.Book) []string {
+	tags := book.NormalizeTags(b.Subject, b.Tags)
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func contributors(b book.Book) []mContributor {
+	contributors := mongoContributors(b.Contributors)
+	if contributors == nil {
+		return []mContributor{}
+	}
+	return contributors
+}
+
+// custom are the custom values of the book, which are stored as an empty document rather than null if the book has no values.
+func custom(b book.Book) map[string]string {
+	if b.Custom == nil {
+		return map[string]string{}
+	}
+	return b.Custom
+}
+
+// subjectKey is the expression of the key that the subject field is grouped by: the field without surrounding whitespace, in lowercase.
+func subjectKey(field string) interface{} {
+	return bson.D(bson.E("$toLower", trimmed(field)))
+}
+
+// sortName is the expression of the field with its last word first, like book.SortName.
+func sortName(field string) interface{} {
+	name := "$" + field
+	lastWord := bson.D(bson.E("$arrayElemAt", bson.A("$$words", -1)))
+	return bson.D(bson.E("$let", bson.D(
+		bson.E("vars", bson.D(bson.E("words", bson.D(bson.E("$split", bson.A(name, " ")))))),
+		bson.E("in", bson.D(bson.E("$cond", bson.A(
+			bson.D(bson.E("$or", bson.A(
+				bson.D(bson.E("$gte", bson.A(bson.D(bson.E("$indexOfCP", bson.A(name, ","))), 0))),
+				bson.D(bson.E("$lt", bson.A(bson.D(bson.E("$size", "$$words")), 2))),
+			))),
+			name,
+			bson.D(bson.E("$concat", bson.A(
+				lastWord,
+				", ",
+				bson.D(bson.E("$trim", bson.D(bson.E("input", bson.D(bson.E("$substrCP", bson.A(
+					name,
+					0,
+					bson.D(bson.E("$subtract", bson.A(bson.D(bson.E("$strLenCP", name)), bson.D(bson.E("$strLenCP", lastWord))))),
+				))))))),
+			))),
+		)))),
+	)))
+}
+
+// trimmed is the expression of the field without surrounding whitespace.
+func trimmed(field string) interface{} {
+	return bson.D(bson.E("$trim", bson.D(bson.E("input", "$"+field))))
+}
+
 func (*Database) idFilter(id string) (interface{}, error) {
-	objID, err := primitive.ObjectIDFromString(id)
-	if err != nil {
-		return nil, err
+	if len(id) == 0 {
+		return nil, fmt.Errorf("missing book id")
+	}
+	return bson.D(bson.E(bookIDField, mongoID(id))), nil
+}
+
+// mongoID converts the id to an ObjectID if possible.
+// Books created by the database have ObjectIDs, but imported books can keep ids from other databases.
+func mongoID(id string) interface{} {
+	if objID, err := primitive.ObjectIDFromString(id); err == nil {
+		return objID
 	}
-	return bson.D(bson.E(bookIDField, objID)), nil
+	return id
 }
 
 func (*Database) expectSingleModify(got int64) error {
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -38,6 +39,122 @@ func TestNewDatabase(t *testing.T) {
 				t.Errorf("books collection not set")
 			case d.usersCollection == nil:
 				t.Errorf("users collection not set")
+			case d.aliasesCollection == nil:
+				t.Errorf("subject aliases collection not set")
+			case d.auditCollection == nil:
+				t.Errorf("audit log collection not set")
+			case d.trashCollection == nil:
+				t.Errorf("trash collection not set")
+			case d.booksIndexes == nil:
+				t.Errorf("books indexes not set")
+			}
+		})
+	}
+}
+
+func TestCreateIndexes(t *testing.T) {
+	tests := []struct {
+		name           string
+		CreateManyFunc func(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+		wantOk         bool
+	}{
+		{
+			name: "db error",
+			CreateManyFunc: func(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+				return nil, fmt.Errorf("db error")
+			},
+		},
+		{
+			name: "happy path",
+			CreateManyFunc: func(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+				want := []mongo.IndexModel{
+					{Keys: bson.D(bson.E(bookEanIsbn13Field, 1))},
+					{Keys: bson.D(bson.E(bookUpcIsbn0Field, 1))},
+					{Keys: bson.D(bson.E(bookTagsField, 1))},
+					{Keys: bson.D(bson.E("contributors.name", 1))},
+					{Keys: bson.D(bson.E("series", 1))},
+				}
+				if !reflect.DeepEqual(want, models) {
+					t.Errorf("index models not equal: \n wanted: %#v \n got:    %#v", want, models)
+				}
+				return []string{"ean_isbn13_1", "upc_isbn10_1"}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksIndexes: mockIndexView{
+					CreateManyFunc: test.CreateManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.CreateIndexes(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	missing := bson.D(bson.E("$in", bson.A(nil, "")))
+	wantFilters := []interface{}{
+		bson.D(bson.E(bookLanguageField, missing)),
+		bson.D(bson.E(bookFormatField, missing)),
+	}
+	wantUpdates := []interface{}{
+		bson.D(bson.E("$set", bson.D(bson.E(bookLanguageField, "en")))),
+		bson.D(bson.E("$set", bson.D(bson.E(bookFormatField, "print")))),
+	}
+	tests := []struct {
+		name   string
+		err    error
+		wantOk bool
+	}{
+		{
+			name: "db error",
+			err:  fmt.Errorf("db error"),
+		},
+		{
+			name:   "happy path",
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotFilters, gotUpdates []interface{}
+			d := Database{
+				booksCollection: mockCollection{
+					UpdateManyFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+						if test.err != nil {
+							return nil, test.err
+						}
+						gotFilters = append(gotFilters, filter)
+						gotUpdates = append(gotUpdates, update)
+						return &mongo.UpdateResult{ModifiedCount: 3}, nil
+					},
+				},
+			}
+			ctx := context.Background()
+			err := d.SetDefaults(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(wantFilters, gotFilters):
+				t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilters, gotFilters)
+			case !reflect.DeepEqual(wantUpdates, gotUpdates):
+				t.Errorf("updates not equal: \n wanted: %#v \n got:    %#v", wantUpdates, gotUpdates)
 			}
 		})
 	}
@@ -69,8 +186,11 @@ func TestCreateBooks(t *testing.T) {
 		name           string
 		InsertManyFunc func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
 		insertBooks    []book.Book
+		audits         []book.Audit
+		auditErr       error
 		wantOk         bool
 		want           []book.Book
+		wantEntries    int
 	}{
 		{
 			name:   "no books (calling coll.InsertMany(nil) is illegal)",
@@ -138,16 +258,64 @@ func TestCreateBooks(t *testing.T) {
 				func() book.Book { b := b2; b.ID = okID2; return b }(),
 			},
 		},
+		{
+			name:        "audit log error",
+			insertBooks: []book.Book{b1},
+			audits:      []book.Audit{{Actor: "Ann", Action: book.ActionCreate}},
+			auditErr:    fmt.Errorf("insert error"),
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				result := mongo.InsertManyResult{
+					InsertedIDs: []interface{}{objectIDHelper(t, okID1)},
+				}
+				return &result, nil
+			},
+			wantEntries: 1,
+		},
+		{
+			name:        "happy path with audit log",
+			insertBooks: []book.Book{b1, b2},
+			audits:      []book.Audit{{Actor: "Ann", Action: book.ActionCreate}},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				result := mongo.InsertManyResult{
+					InsertedIDs: []interface{}{
+						objectIDHelper(t, okID1),
+						objectIDHelper(t, okID2),
+					},
+				}
+				return &result, nil
+			},
+			wantOk: true,
+			want: []book.Book{
+				func() book.Book { b := b1; b.ID = okID1; return b }(),
+				func() book.Book { b := b2; b.ID = okID2; return b }(),
+			},
+			wantEntries: 2,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			var gotEntries int
 			d := Database{
 				booksCollection: mockCollection{
 					InsertManyFunc: test.InsertManyFunc,
 				},
+				auditCollection: mockCollection{
+					InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+						for _, doc := range documents {
+							if e, ok := doc.(mAuditEntry); !ok || e.BookID == "" || e.Action != book.ActionCreate {
+								t.Errorf("unwanted audit entry: %#v", doc)
+							}
+						}
+						gotEntries += len(documents)
+						return new(mongo.InsertManyResult), test.auditErr
+					},
+				},
 			}
 			ctx := context.Background()
-			got, err := d.CreateBooks(ctx, test.insertBooks...)
+			got, err := d.CreateBooks(ctx, test.insertBooks, test.audits...)
+			if test.wantEntries != gotEntries {
+				t.Errorf("wanted %v audit entries to be inserted, got %v", test.wantEntries, gotEntries)
+			}
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -162,6 +330,143 @@ func TestCreateBooks(t *testing.T) {
 	}
 }
 
+func TestImportBooks(t *testing.T) {
+	b1 := book.Book{Header: book.Header{ID: okID1, Title: "t1"}, ImageBase64: "i1"}
+	b2 := book.Book{Header: book.Header{ID: "csv-id", Title: "t2"}}
+	wantExistingFilter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A(objectIDHelper(t, okID1), "csv-id")))))
+	findExisting := func(ids ...string) func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+		return func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+			if !reflect.DeepEqual(wantExistingFilter, filter) {
+				t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantExistingFilter, filter)
+			}
+			documents := make([]interface{}, len(ids))
+			for i, id := range ids {
+				documents[i] = mHeader{ID: id}
+			}
+			return mongo.NewCursorFromDocuments(documents, nil, nil)
+		}
+	}
+	tests := []struct {
+		name          string
+		upsert        bool
+		books         []book.Book
+		entries       []book.AuditEntry
+		FindFunc      func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error)
+		UpdateOneFunc func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+		wantOk        bool
+		wantErr       error
+		wantFilters   []interface{}
+		wantEntries   int
+	}{
+		{
+			name:  "missing id",
+			books: []book.Book{{}},
+		},
+		{
+			name:  "find error",
+			books: []book.Book{b1, b2},
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name:     "collision",
+			books:    []book.Book{b1, b2},
+			FindFunc: findExisting("csv-id"),
+			wantErr:  book.ErrIDExists,
+		},
+		{
+			name:     "update error",
+			books:    []book.Book{b1, b2},
+			FindFunc: findExisting(),
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return nil, fmt.Errorf("update error")
+			},
+		},
+		{
+			name:     "happy path",
+			books:    []book.Book{b1, b2},
+			FindFunc: findExisting(),
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return &mongo.UpdateResult{UpsertedCount: 1}, nil
+			},
+			wantOk: true,
+			wantFilters: []interface{}{
+				bson.D(bson.E(bookIDField, objectIDHelper(t, okID1))),
+				bson.D(bson.E(bookIDField, "csv-id")),
+			},
+		},
+		{
+			name:   "upsert",
+			upsert: true,
+			books:  []book.Book{b2},
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return &mongo.UpdateResult{MatchedCount: 1}, nil
+			},
+			wantOk: true,
+			wantFilters: []interface{}{
+				bson.D(bson.E(bookIDField, "csv-id")),
+			},
+		},
+		{
+			name:    "audit entries",
+			upsert:  true,
+			books:   []book.Book{b2},
+			entries: []book.AuditEntry{{ID: "e1", BookID: "csv-id", Action: book.ActionImport}},
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return &mongo.UpdateResult{MatchedCount: 1}, nil
+			},
+			wantOk: true,
+			wantFilters: []interface{}{
+				bson.D(bson.E(bookIDField, "csv-id")),
+			},
+			wantEntries: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotFilters []interface{}
+			var gotEntries int
+			d := Database{
+				booksCollection: mockCollection{
+					FindFunc: test.FindFunc,
+					UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+						gotOpts := options.MergeUpdateOptions(opts...)
+						if gotOpts.Upsert == nil || !*gotOpts.Upsert {
+							t.Errorf("wanted upsert option")
+						}
+						gotFilters = append(gotFilters, filter)
+						return test.UpdateOneFunc(ctx, filter, update, opts...)
+					},
+				},
+				auditCollection: mockCollection{
+					InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+						gotEntries += len(documents)
+						return &mongo.InsertManyResult{}, nil
+					},
+				},
+			}
+			ctx := context.Background()
+			err := d.ImportBooks(ctx, test.upsert, test.books, test.entries...)
+			switch {
+			case !test.wantOk:
+				switch {
+				case err == nil:
+					t.Errorf("wanted error")
+				case test.wantErr != nil && !errors.Is(err, test.wantErr):
+					t.Errorf("wanted error to be %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantFilters, gotFilters):
+				t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", test.wantFilters, gotFilters)
+			case test.wantEntries != gotEntries:
+				t.Errorf("wanted %v audit entries to be inserted, got %v", test.wantEntries, gotEntries)
+			}
+		})
+	}
+}
+
 func TestReadBookSubjects(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -194,8 +499,23 @@ func TestReadBookSubjects(t *testing.T) {
 			offset: 8,
 			AggregateFunc: func(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
 				wantPipeline := mongo.Pipeline{
+					bson.D(bson.E("$project", bson.D(
+						bson.E("subjects", bson.D(bson.E("$concatArrays", bson.A(
+							bson.A("$subject"),
+							bson.D(bson.E("$ifNull", bson.A("$tags", bson.A()))),
+						)))),
+					))),
+					bson.D(bson.E("$unwind", "$subjects")),
+					bson.D(bson.E("$group", bson.D(
+						bson.E("_id", bson.D(
+							bson.E("book", "$_id"),
+							bson.E("key", bson.D(bson.E("$toLower", bson.D(bson.E("$trim", bson.D(bson.E("input", "$subjects"))))))),
+						)),
+						bson.E(subjectNameField, bson.D(bson.E("$min", bson.D(bson.E("$trim", bson.D(bson.E("input", "$subjects"))))))),
+					))),
 					bson.D(bson.E("$group", bson.D(
-						bson.E(subjectNameField, "$"+bookSubjectField),
+						bson.E("_id", "$_id.key"),
+						bson.E(subjectNameField, bson.D(bson.E("$min", "$name"))),
 						bson.E(subjectCountField, bson.D(bson.E("$sum", 1))),
 					))),
 					bson.D(bson.E("$sort", bson.D(
@@ -214,8 +534,8 @@ func TestReadBookSubjects(t *testing.T) {
 					t.Errorf("opts not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
 				}
 				documents := []interface{}{
-					mSubject{Name: "sub-I", Count: 3},
-					mSubject{Name: "sub-J", Count: 4},
+					mSubject{Key: "sub-i", Name: "sub-I", Count: 3},
+					mSubject{Key: "sub-j", Name: "sub-J", Count: 4},
 				}
 				return mongo.NewCursorFromDocuments(documents, nil, nil)
 			},
@@ -249,6 +569,125 @@ func TestReadBookSubjects(t *testing.T) {
 	}
 }
 
+func TestReadBookAuthors(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		offset        int
+		AggregateFunc func(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error)
+		wantOk        bool
+		want          []book.Author
+	}{
+		{
+			name: "aggregate error",
+			AggregateFunc: func(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+				return nil, fmt.Errorf("aggregate error")
+			},
+		},
+		{
+			name: "decode error",
+			AggregateFunc: func(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						authorCountField: "cannot decode string into an integer type",
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name:   "happy path ",
+			limit:  2,
+			offset: 8,
+			AggregateFunc: func(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+				trimmedName := bson.D(bson.E("$trim", bson.D(bson.E("input", "$authors.name"))))
+				wantPipeline := mongo.Pipeline{
+					bson.D(bson.E("$project", bson.D(
+						bson.E("authors", bson.D(bson.E("$concatArrays", bson.A(
+							bson.A(bson.D(bson.E("name", "$author"), bson.E("sort_name", ""))),
+							bson.D(bson.E("$ifNull", bson.A("$contributors", bson.A()))),
+						)))),
+					))),
+					bson.D(bson.E("$unwind", "$authors")),
+					bson.D(bson.E("$group", bson.D(
+						bson.E("_id", bson.D(
+							bson.E("book", "$_id"),
+							bson.E("key", bson.D(bson.E("$toLower", trimmedName))),
+						)),
+						bson.E("name", bson.D(bson.E("$min", trimmedName))),
+						bson.E("sort_name", bson.D(bson.E("$max", "$authors.sort_name"))),
+					))),
+					bson.D(bson.E("$match", bson.D(
+						bson.E("_id.key", bson.D(bson.E("$ne", ""))),
+					))),
+					bson.D(bson.E("$group", bson.D(
+						bson.E("_id", "$_id.key"),
+						bson.E("name", bson.D(bson.E("$min", "$name"))),
+						bson.E("sort_name", bson.D(bson.E("$max", "$sort_name"))),
+						bson.E("count", bson.D(bson.E("$sum", 1))),
+					))),
+					bson.D(bson.E("$set", bson.D(
+						bson.E("sort_name", bson.D(bson.E("$cond", bson.A(
+							bson.D(bson.E("$eq", bson.A("$sort_name", ""))),
+							sortName("name"),
+							"$sort_name",
+						)))),
+					))),
+					bson.D(bson.E("$set", bson.D(
+						bson.E("sort_key", bson.D(bson.E("$toLower", "$sort_name"))),
+					))),
+					bson.D(bson.E("$sort", bson.D(
+						bson.E("sort_key", 1),
+						bson.E("name", 1),
+					))),
+					bson.D(bson.E("$skip", 8)),
+					bson.D(bson.E("$limit", 2)),
+				}
+				gotPipeline := pipeline
+				wantOpts := options.Aggregate()
+				gotOpts := options.MergeAggregateOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantPipeline, gotPipeline):
+					t.Errorf("pipelines not equal: \n wanted: %q \n got:    %q", wantPipeline, gotPipeline)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("opts not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mAuthor{Key: "ann lee", Name: "Ann Lee", SortName: "Lee, Ann", Count: 3},
+					mAuthor{Key: "bob", Name: "Bob", SortName: "Bob", Count: 4},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.Author{
+				{Name: "Ann Lee", SortName: "Lee, Ann", Count: 3},
+				{Name: "Bob", SortName: "Bob", Count: 4},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection: mockCollection{
+					AggregateFunc: test.AggregateFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadBookAuthors(ctx, test.limit, test.offset)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("authors not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
 func TestReadBookHeaders(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -283,11 +722,16 @@ func TestReadBookHeaders(t *testing.T) {
 			offset: 9,
 			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
 				bsonFilter := bson.Filter{
-					SubjectKey: bookSubjectField,
+					SubjectKey:      bookSubjectField,
+					TagsKey:         bookTagsField,
+					AuthorKey:       bookAuthorField,
+					ContributorsKey: "contributors.name",
 					HeaderKeys: []string{
 						bookTitleField,
 						bookAuthorField,
 						bookSubjectField,
+						bookTagsField,
+						"contributors.name",
 					},
 				}
 				bookFilter := book.Filter{HeaderPart: "T"}
@@ -351,24 +795,183 @@ func TestReadBookHeaders(t *testing.T) {
 	}
 }
 
-func TestReadBook(t *testing.T) {
-	b := book.Book{
-		Header:      book.Header{ID: "1", Title: "2", Author: "3", Subject: "4"},
-		Description: "5", DeweyDecClass: "6", Pages: 7, Publisher: "8",
-		PublishDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
-		AddedDate:   time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
-		EanIsbn13:   "11", UpcIsbn10: "12", ImageBase64: "13",
-	}
+func TestReadShelvedBooks(t *testing.T) {
 	tests := []struct {
-		name        string
-		bookID      string
-		FindOneFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
-		wantOk      bool
-		want        *book.Book
-	}{
-		{
-			name:   "bad id",
-			bookID: "bad id",
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.ShelvedBook
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "decode error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						bookDeweyDecClassField: -1,
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D()
+				wantOpts := options.Find().
+					SetProjection(bson.D(
+						bson.E(bookIDField, 1),
+						bson.E(bookTitleField, 1),
+						bson.E(bookAuthorField, 1),
+						bson.E(bookSubjectField, 1),
+						bson.E(bookDeweyDecClassField, 1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("opts not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mBook{Header: mHeader{ID: "2b8", Title: "T3", Author: "a8", Subject: "a"}, DeweyDecClass: "599.8"},
+					mBook{Header: mHeader{ID: "3b7", Title: "T2", Author: "a6", Subject: "b"}},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.ShelvedBook{
+				{Header: book.Header{ID: "2b8", Title: "T3", Author: "a8", Subject: "a"}, DeweyDecClass: "599.8"},
+				{Header: book.Header{ID: "3b7", Title: "T2", Author: "a6", Subject: "b"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadShelvedBooks(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadSeriesBooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.SeriesBook
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "decode error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						bookVolumeField: "cannot decode string into an integer type",
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D(bson.E("series", bson.D(bson.E("$gt", ""))))
+				wantOpts := options.Find().
+					SetProjection(bson.D(
+						bson.E(bookIDField, 1),
+						bson.E(bookTitleField, 1),
+						bson.E(bookAuthorField, 1),
+						bson.E(bookSubjectField, 1),
+						bson.E(bookSeriesField, 1),
+						bson.E(bookVolumeField, 1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("opts not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mBook{Header: mHeader{ID: "2b8", Title: "T3", Author: "a8", Subject: "a"}, Series: "Dune", Volume: 3},
+					mBook{Header: mHeader{ID: "3b7", Title: "T2", Author: "a6", Subject: "b"}, Series: "Dune"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.SeriesBook{
+				{Header: book.Header{ID: "2b8", Title: "T3", Author: "a8", Subject: "a"}, Series: "Dune", Volume: 3},
+				{Header: book.Header{ID: "3b7", Title: "T2", Author: "a6", Subject: "b"}, Series: "Dune"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadSeriesBooks(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadBook(t *testing.T) {
+	b := book.Book{
+		Header:      book.Header{ID: "1", Title: "2", Author: "3", Subject: "4"},
+		Description: "5", DeweyDecClass: "6", Pages: 7, Publisher: "8",
+		PublishDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+		AddedDate:   time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:   "11", UpcIsbn10: "12", ImageBase64: "13",
+	}
+	tests := []struct {
+		name        string
+		bookID      string
+		FindOneFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		wantOk      bool
+		want        *book.Book
+	}{
+		{
+			name:   "empty id",
+			bookID: "",
 		},
 		{
 			name:   "bad book",
@@ -422,15 +1025,100 @@ func TestReadBook(t *testing.T) {
 	}
 }
 
+func TestReadBookByISBN(t *testing.T) {
+	b := book.Book{
+		Header:    book.Header{ID: "1", Title: "2", Author: "3", Subject: "4"},
+		AddedDate: time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		EanIsbn13: "9780306406157", UpcIsbn10: "0306406152",
+	}
+	tests := []struct {
+		name         string
+		isbn         string
+		FindOneFunc  func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		wantOk       bool
+		wantNotFound bool
+		want         *book.Book
+	}{
+		{
+			name:         "empty isbn",
+			wantNotFound: true,
+		},
+		{
+			name: "db error",
+			isbn: "0306406152",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				err := fmt.Errorf("db error")
+				return mongo.NewSingleResultFromDocument(nil, err, nil)
+			},
+		},
+		{
+			name: "not found",
+			isbn: "0306406152",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mBook{}, mongo.ErrNoDocuments, nil)
+			},
+			wantNotFound: true,
+		},
+		{
+			name: "happy path",
+			isbn: "0306406152",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				wantFilter := bson.D(
+					bson.E("$or", bson.A(
+						bson.D(bson.E(bookEanIsbn13Field, "0306406152")),
+						bson.D(bson.E(bookUpcIsbn0Field, "0306406152")),
+					)),
+				)
+				if !reflect.DeepEqual(wantFilter, filter) {
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				}
+				document := mongoBook(b)
+				return mongo.NewSingleResultFromDocument(document, nil, nil)
+			},
+			wantOk: true,
+			want:   &b,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection: mockCollection{
+					FindOneFunc: test.FindOneFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadBookByISBN(ctx, test.isbn)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if want, got := test.wantNotFound, errors.Is(err, book.ErrNotFound); want != got {
+					t.Errorf("wanted ErrNotFound: %v, got error: %v", want, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
 func TestUpdateBook(t *testing.T) {
-	happyPathUpdateOneFunc := func(t *testing.T, wantUpdate interface{}) func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	before := book.Book{Header: book.Header{ID: okID1, Title: "1"}, Language: "en", Format: "print"}
+	happyPathUpdateFunc := func(t *testing.T, wantUpdate interface{}) func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
 		t.Helper()
-		return func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+		return func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
 			wantFilter := bson.D(bson.E(bookIDField, objectIDHelper(t, okID1)))
 			gotFilter := filter
 			gotUpdate := update
-			wantOpts := options.Update()
-			gotOps := options.MergeUpdateOptions(opts...)
+			wantOpts := options.FindOneAndUpdate().
+				SetReturnDocument(options.Before).
+				SetProjection(bson.D(
+					bson.E(bookImageBase64Field, 0),
+				))
+			gotOps := options.MergeFindOneAndUpdateOptions(opts...)
 			switch {
 			case !reflect.DeepEqual(wantFilter, gotFilter):
 				t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, gotFilter)
@@ -439,11 +1127,16 @@ func TestUpdateBook(t *testing.T) {
 			case !reflect.DeepEqual(wantOpts, gotOps):
 				t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOps)
 			}
-			return &mongo.UpdateResult{ModifiedCount: 1}, nil
+			return mongo.NewSingleResultFromDocument(mongoBook(before), nil, nil)
 		}
 	}
 	b := book.Book{
-		Header:      book.Header{ID: okID1, Title: "2", Author: "3", Subject: "4"},
+		Header:       book.Header{ID: okID1, Title: "2", Author: "3", Subject: "4"},
+		Tags:         []string{"4", " 4a"},
+		Contributors: []book.Contributor{{Name: "4b", Role: "Editor"}},
+		Series:       "4c", Volume: 4,
+		Language: "4d", Edition: "4e", Format: "dvd", ReadingLevel: "adult",
+		Custom:      map[string]string{"Donor": "Ann"},
 		Description: "5", DeweyDecClass: "6", Pages: 7, Publisher: "8",
 		PublishDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
 		AddedDate:   time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
@@ -453,6 +1146,15 @@ func TestUpdateBook(t *testing.T) {
 		bson.E(bookTitleField, b.Title),
 		bson.E(bookAuthorField, b.Author),
 		bson.E(bookSubjectField, b.Subject),
+		bson.E(bookTagsField, []string{"4a"}),
+		bson.E(bookContributorsField, []mContributor{{Name: "4b", Role: "editor", SortName: "4b"}}),
+		bson.E(bookSeriesField, "4c"),
+		bson.E(bookVolumeField, 4),
+		bson.E(bookLanguageField, "4d"),
+		bson.E(bookEditionField, "4e"),
+		bson.E(bookFormatField, "dvd"),
+		bson.E(bookReadingLevelField, "adult"),
+		bson.E(bookCustomField, map[string]string{"Donor": "Ann"}),
 		bson.E(bookDescriptionField, b.Description),
 		bson.E(bookDeweyDecClassField, b.DeweyDecClass),
 		bson.E(bookPagesField, b.Pages),
@@ -466,6 +1168,15 @@ func TestUpdateBook(t *testing.T) {
 		bson.E(bookTitleField, b.Title),
 		bson.E(bookAuthorField, b.Author),
 		bson.E(bookSubjectField, b.Subject),
+		bson.E(bookTagsField, []string{"4a"}),
+		bson.E(bookContributorsField, []mContributor{{Name: "4b", Role: "editor", SortName: "4b"}}),
+		bson.E(bookSeriesField, "4c"),
+		bson.E(bookVolumeField, 4),
+		bson.E(bookLanguageField, "4d"),
+		bson.E(bookEditionField, "4e"),
+		bson.E(bookFormatField, "dvd"),
+		bson.E(bookReadingLevelField, "adult"),
+		bson.E(bookCustomField, map[string]string{"Donor": "Ann"}),
 		bson.E(bookDescriptionField, b.Description),
 		bson.E(bookDeweyDecClassField, b.DeweyDecClass),
 		bson.E(bookPagesField, b.Pages),
@@ -476,62 +1187,100 @@ func TestUpdateBook(t *testing.T) {
 		bson.E(bookUpcIsbn0Field, b.UpcIsbn10),
 		bson.E(bookImageBase64Field, b.ImageBase64),
 	)))
+	audit := book.Audit{Actor: "Ann", Action: book.ActionUpdate}
 	tests := []struct {
-		name          string
-		book          book.Book
-		updateImage   bool
-		UpdateOneFunc func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
-		wantOk        bool
+		name                 string
+		book                 book.Book
+		updateImage          bool
+		audits               []book.Audit
+		FindOneAndUpdateFunc func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult
+		InsertManyFunc       func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		wantOk               bool
+		wantNotFound         bool
 	}{
 		{
-			name: "bad id",
-			book: func() book.Book { b2 := b; b2.ID = "bad id"; return b2 }(),
+			name: "empty id",
+			book: func() book.Book { b2 := b; b2.ID = ""; return b2 }(),
 		},
 		{
 			name: "update error",
 			book: b,
-			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
-				return nil, fmt.Errorf("update error")
+			FindOneAndUpdateFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mBook{}, fmt.Errorf("update error"), nil)
 			},
 		},
 		{
-			name: "bad ModifiedCount: 0",
+			name: "not found",
 			book: b,
-			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
-				return &mongo.UpdateResult{ModifiedCount: 0}, nil
+			FindOneAndUpdateFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mBook{}, mongo.ErrNoDocuments, nil)
 			},
+			wantNotFound: true,
 		},
 		{
-			name: "bad ModifiedCount: 2",
-			book: b,
-			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
-				return &mongo.UpdateResult{ModifiedCount: 2}, nil
-			},
+			name:                 "happy path",
+			book:                 b,
+			FindOneAndUpdateFunc: happyPathUpdateFunc(t, wantUpdate1),
+			wantOk:               true,
 		},
 		{
-			name:          "happy path",
-			book:          b,
-			UpdateOneFunc: happyPathUpdateOneFunc(t, wantUpdate1),
-			wantOk:        true,
+			name:                 "happy path updateImage",
+			book:                 b,
+			updateImage:          true,
+			FindOneAndUpdateFunc: happyPathUpdateFunc(t, wantUpdate2),
+			wantOk:               true,
+		},
+		{
+			name:                 "audit log error",
+			book:                 b,
+			audits:               []book.Audit{audit},
+			FindOneAndUpdateFunc: happyPathUpdateFunc(t, wantUpdate1),
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				return nil, fmt.Errorf("insert error")
+			},
 		},
 		{
-			name:          "happy path updateImage",
-			book:          b,
-			updateImage:   true,
-			UpdateOneFunc: happyPathUpdateOneFunc(t, wantUpdate2),
-			wantOk:        true,
+			name:                 "happy path with audit log",
+			book:                 b,
+			audits:               []book.Audit{audit},
+			FindOneAndUpdateFunc: happyPathUpdateFunc(t, wantUpdate1),
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				want := book.Diff(before, b)
+				switch {
+				case len(documents) != 1:
+					t.Errorf("wanted 1 audit entry, got %v", len(documents))
+				default:
+					e := documents[0].(mAuditEntry)
+					got := e.AuditEntry().Changes
+					switch {
+					case e.BookID != okID1, e.Actor != "Ann", e.Action != book.ActionUpdate:
+						t.Errorf("unwanted audit entry: %#v", e)
+					case !reflect.DeepEqual(want, got):
+						t.Errorf("changes not equal: \n wanted: %v \n got:    %v", want, got)
+					}
+				}
+				return &mongo.InsertManyResult{InsertedIDs: []interface{}{"e1"}}, nil
+			},
+			wantOk: true,
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			d := Database{
 				booksCollection: mockCollection{
-					UpdateOneFunc: test.UpdateOneFunc,
+					FindOneAndUpdateFunc: test.FindOneAndUpdateFunc,
+				},
+				auditCollection: mockCollection{
+					InsertManyFunc: test.InsertManyFunc,
 				},
 			}
 			ctx := context.Background()
-			err := d.UpdateBook(ctx, test.book, test.updateImage)
+			err := d.UpdateBook(ctx, test.book, test.updateImage, test.audits...)
 			switch {
+			case test.wantNotFound:
+				if !errors.Is(err, book.ErrNotFound) {
+					t.Errorf("wanted ErrNotFound, got %v", err)
+				}
 			case !test.wantOk:
 				if err == nil {
 					t.Errorf("wanted error")
@@ -545,40 +1294,79 @@ func TestUpdateBook(t *testing.T) {
 
 func TestDeleteBook(t *testing.T) {
 	const okID = okID1
+	b := book.Book{Header: book.Header{ID: okID, Title: "t1"}, Language: "en", Format: "print", ImageBase64: "IMG"}
+	findBook := func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+		return mongo.NewSingleResultFromDocument(mongoBook(b), nil, nil)
+	}
+	insertTrash := func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+		return &mongo.InsertManyResult{InsertedIDs: []interface{}{okID}}, nil
+	}
 	tests := []struct {
-		name          string
-		bookID        string
-		DeleteOneFunc func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
-		wantOk        bool
+		name                string
+		bookID              string
+		entries             []book.AuditEntry
+		FindOneFunc         func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		TrashInsertManyFunc func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		DeleteOneFunc       func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+		InsertManyFunc      func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		wantOk              bool
 	}{
 		{
-			name:   "bad id",
-			bookID: "bad id",
+			name:   "empty id",
+			bookID: "",
 		},
 		{
-			name:   "delete error",
+			name:   "read error",
 			bookID: okID,
-			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
-				return nil, fmt.Errorf("update error")
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(nil, fmt.Errorf("find error"), nil)
 			},
 		},
 		{
-			name:   "bad ModifiedCount: 0",
-			bookID: okID,
+			name:        "trash error",
+			bookID:      okID,
+			FindOneFunc: findBook,
+			TrashInsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				return nil, fmt.Errorf("insert error")
+			},
+		},
+		{
+			name:                "delete error",
+			bookID:              okID,
+			FindOneFunc:         findBook,
+			TrashInsertManyFunc: insertTrash,
 			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
-				return &mongo.DeleteResult{DeletedCount: 0}, nil
+				return nil, fmt.Errorf("update error")
 			},
 		},
 		{
-			name:   "bad ModifiedCount: 2",
-			bookID: okID,
+			name:                "bad ModifiedCount: 0",
+			bookID:              okID,
+			FindOneFunc:         findBook,
+			TrashInsertManyFunc: insertTrash,
 			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 				return &mongo.DeleteResult{DeletedCount: 0}, nil
 			},
 		},
 		{
-			name:   "happy path",
-			bookID: okID,
+			name:        "happy path",
+			bookID:      okID,
+			FindOneFunc: findBook,
+			TrashInsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				if len(documents) != 1 {
+					t.Fatalf("wanted 1 document, got %v", len(documents))
+				}
+				m, ok := documents[0].(mDeletedBook)
+				switch {
+				case !ok:
+					t.Errorf("wanted deleted book, got %T", documents[0])
+				case !reflect.DeepEqual(mongoBook(b), m.Book):
+					t.Errorf("trashed books not equal: \n wanted: %v \n got:    %v", mongoBook(b), m.Book)
+				case m.DeletedDate.IsZero():
+					t.Errorf("wanted deleted date to be set")
+				}
+				return &mongo.InsertManyResult{InsertedIDs: []interface{}{okID}}, nil
+			},
 			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 				wantFilter := bson.D(bson.E(bookIDField, objectIDHelper(t, okID)))
 				gotFilter := filter
@@ -594,16 +1382,998 @@ func TestDeleteBook(t *testing.T) {
 			},
 			wantOk: true,
 		},
+		{
+			name:                "audit log error",
+			bookID:              okID,
+			entries:             []book.AuditEntry{{ID: "e2", BookID: okID, Action: book.ActionDelete}},
+			FindOneFunc:         findBook,
+			TrashInsertManyFunc: insertTrash,
+			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+				return &mongo.DeleteResult{DeletedCount: 1}, nil
+			},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				return nil, fmt.Errorf("insert error")
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			d := Database{
 				booksCollection: mockCollection{
+					FindOneFunc:   test.FindOneFunc,
 					DeleteOneFunc: test.DeleteOneFunc,
 				},
+				trashCollection: mockCollection{
+					InsertManyFunc: test.TrashInsertManyFunc,
+				},
+				auditCollection: mockCollection{
+					InsertManyFunc: test.InsertManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.DeleteBook(ctx, test.bookID, test.entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadTrash(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.TrashedBook
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "decode error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						trashDeletedDateField: "yesterday",
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D()
+				wantOpts := options.Find().
+					SetSort(bson.D(
+						bson.E(trashDeletedDateField, -1),
+					)).
+					SetProjection(bson.D(
+						bson.E(bookIDField, 1),
+						bson.E(bookTitleField, 1),
+						bson.E(bookAuthorField, 1),
+						bson.E(bookSubjectField, 1),
+						bson.E(trashDeletedDateField, 1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mDeletedBook{Book: mBook{Header: mHeader{ID: "b2", Title: "t2"}}, DeletedDate: time1.Add(time.Hour)},
+					mDeletedBook{Book: mBook{Header: mHeader{ID: "b1", Title: "t1", Author: "a1", Subject: "s1"}}, DeletedDate: time1},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.TrashedBook{
+				{Header: book.Header{ID: "b2", Title: "t2"}, DeletedDate: time1.Add(time.Hour)},
+				{Header: book.Header{ID: "b1", Title: "t1", Author: "a1", Subject: "s1"}, DeletedDate: time1},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				trashCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadTrash(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("trash not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadDeletedBook(t *testing.T) {
+	b := book.DeletedBook{Book: book.Book{Header: book.Header{ID: "b1", Title: "t1"}, ImageBase64: "IMG"}, DeletedDate: time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)}
+	tests := []struct {
+		name        string
+		FindOneFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		wantErr     error
+		wantOk      bool
+	}{
+		{
+			name: "not in trash",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mDeletedBook{}, mongo.ErrNoDocuments, nil)
+			},
+			wantErr: book.ErrNotFound,
+		},
+		{
+			name: "find error",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(nil, fmt.Errorf("find error"), nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				wantFilter := bson.D(bson.E(bookIDField, "b1"))
+				if !reflect.DeepEqual(wantFilter, filter) {
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				}
+				return mongo.NewSingleResultFromDocument(mongoDeletedBook(b), nil, nil)
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				trashCollection: mockCollection{
+					FindOneFunc: test.FindOneFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadDeletedBook(ctx, "b1")
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+					t.Errorf("wanted error to wrap %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case got.ID != b.ID, got.ImageBase64 != b.ImageBase64, !got.DeletedDate.Equal(b.DeletedDate):
+				t.Errorf("deleted books not equal: \n wanted: %v \n got:    %v", b, *got)
+			}
+		})
+	}
+}
+
+func TestImportTrash(t *testing.T) {
+	books := []book.DeletedBook{
+		{Book: book.Book{Header: book.Header{ID: "b1", Title: "t1"}}},
+		{Book: book.Book{Header: book.Header{ID: "b2", Title: "t2"}}},
+	}
+	tests := []struct {
+		name           string
+		FindFunc       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		InsertManyFunc func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		wantOk         bool
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "insert error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return mongo.NewCursorFromDocuments(nil, nil, nil)
+			},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				return nil, fmt.Errorf("insert error")
+			},
+		},
+		{
+			name: "all imported",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					mHeader{ID: "b1"},
+					mHeader{ID: "b2"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A("b1", "b2")))))
+				if !reflect.DeepEqual(wantFilter, filter) {
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				}
+				documents := []interface{}{
+					mHeader{ID: "b1"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				want := []interface{}{mongoDeletedBook(books[1])}
+				if !reflect.DeepEqual(want, documents) {
+					t.Errorf("documents not equal: \n wanted: %#v \n got:    %#v", want, documents)
+				}
+				return &mongo.InsertManyResult{}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				trashCollection: mockCollection{
+					FindFunc:       test.FindFunc,
+					InsertManyFunc: test.InsertManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.ImportTrash(ctx, books...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRestoreBook(t *testing.T) {
+	const okID = okID1
+	b := book.Book{Header: book.Header{ID: okID, Title: "t1"}, Language: "en", Format: "print", ImageBase64: "IMG"}
+	findTrash := func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+		wantFilter := bson.D(bson.E(bookIDField, okID))
+		if !reflect.DeepEqual(wantFilter, filter) {
+			t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+		}
+		document := mDeletedBook{Book: mongoBook(b), DeletedDate: time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)}
+		return mongo.NewSingleResultFromDocument(document, nil, nil)
+	}
+	upsert := func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+		wantFilter := bson.D(bson.E(bookIDField, objectIDHelper(t, okID)))
+		wantUpdate := bson.D(bson.E("$setOnInsert", bookSets(b, true)))
+		wantOpts := options.Update().SetUpsert(true)
+		gotOpts := options.MergeUpdateOptions(opts...)
+		switch {
+		case !reflect.DeepEqual(wantFilter, filter):
+			t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+		case !reflect.DeepEqual(wantUpdate, update):
+			t.Errorf("updates not equal: \n wanted: %#v \n got:    %#v", wantUpdate, update)
+		case !reflect.DeepEqual(wantOpts, gotOpts):
+			t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+		}
+		return &mongo.UpdateResult{UpsertedCount: 1}, nil
+	}
+	deleteTrash := func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+		wantFilter := bson.D(bson.E(bookIDField, okID))
+		if !reflect.DeepEqual(wantFilter, filter) {
+			t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+		}
+		return &mongo.DeleteResult{DeletedCount: 1}, nil
+	}
+	tests := []struct {
+		name            string
+		entries         []book.AuditEntry
+		FindOneFunc     func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		UpdateOneFunc   func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+		DeleteOneFunc   func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+		InsertManyFunc  func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		wantErr         error
+		wantOk          bool
+		wantAuditAction string
+	}{
+		{
+			name: "not in trash",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mDeletedBook{}, mongo.ErrNoDocuments, nil)
+			},
+			wantErr: book.ErrNotFound,
+		},
+		{
+			name: "find error",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(nil, fmt.Errorf("find error"), nil)
+			},
+		},
+		{
+			name:        "existing id",
+			FindOneFunc: findTrash,
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return &mongo.UpdateResult{MatchedCount: 1}, nil
+			},
+			wantErr: book.ErrIDExists,
+		},
+		{
+			name:        "upsert error",
+			FindOneFunc: findTrash,
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return nil, fmt.Errorf("update error")
+			},
+		},
+		{
+			name:          "delete error",
+			FindOneFunc:   findTrash,
+			UpdateOneFunc: upsert,
+			DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+				return nil, fmt.Errorf("delete error")
+			},
+		},
+		{
+			name:          "happy path",
+			entries:       []book.AuditEntry{{ID: "e1", BookID: okID, Action: book.ActionRestore}},
+			FindOneFunc:   findTrash,
+			UpdateOneFunc: upsert,
+			DeleteOneFunc: deleteTrash,
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				if len(documents) != 1 || documents[0].(mAuditEntry).Action != book.ActionRestore {
+					t.Errorf("unwanted audit entries: %v", documents)
+				}
+				return &mongo.InsertManyResult{InsertedIDs: []interface{}{"e1"}}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection: mockCollection{
+					UpdateOneFunc: test.UpdateOneFunc,
+				},
+				trashCollection: mockCollection{
+					FindOneFunc:   test.FindOneFunc,
+					DeleteOneFunc: test.DeleteOneFunc,
+				},
+				auditCollection: mockCollection{
+					InsertManyFunc: test.InsertManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.RestoreBook(ctx, okID, test.entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+					t.Errorf("wanted error to wrap %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPurgeBooks(t *testing.T) {
+	tests := []struct {
+		name           string
+		ids            []string
+		DeleteManyFunc func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+		wantOk         bool
+	}{
+		{
+			name:   "no ids",
+			wantOk: true,
+		},
+		{
+			name: "delete error",
+			ids:  []string{"b1"},
+			DeleteManyFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+				return nil, fmt.Errorf("delete error")
+			},
+		},
+		{
+			name: "happy path",
+			ids:  []string{"b1", okID1},
+			DeleteManyFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+				wantFilter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A("b1", okID1)))))
+				wantOpts := options.Delete()
+				gotOpts := options.MergeDeleteOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				return &mongo.DeleteResult{DeletedCount: 1}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				trashCollection: mockCollection{
+					DeleteManyFunc: test.DeleteManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.PurgeBooks(ctx, test.ids...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadBookHistory(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.AuditEntry
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "decode error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						auditTimeField: "yesterday",
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D(bson.E(auditBookIDField, "b1"))
+				wantOpts := options.Find().
+					SetSort(bson.D(
+						bson.E(auditTimeField, -1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mAuditEntry{ID: "e2", BookID: "b1", Time: time1, Actor: "Bob", Action: "delete"},
+					mAuditEntry{ID: "e1", BookID: "b1", Time: time1, Actor: "Ann", Action: "update", Changes: []mFieldChange{{Field: "title", Before: "a", After: "b"}}},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.AuditEntry{
+				{ID: "e2", BookID: "b1", Time: time1, Actor: "Bob", Action: book.ActionDelete},
+				{ID: "e1", BookID: "b1", Time: time1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "a", After: "b"}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				auditCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadBookHistory(ctx, "b1")
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("history not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadAuditLog(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.AuditEntry
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantOpts := options.Find().
+					SetSort(bson.D(
+						bson.E(auditTimeField, 1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(bson.D(), filter):
+					t.Errorf("wanted empty filter, got %#v", filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mAuditEntry{ID: "e1", BookID: "b1", Time: time1, Actor: "Ann", Action: "update"},
+					mAuditEntry{ID: "e2", BookID: "b2", Time: time1, Actor: "system", Action: "restore"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.AuditEntry{
+				{ID: "e1", BookID: "b1", Time: time1, Actor: "Ann", Action: book.ActionUpdate},
+				{ID: "e2", BookID: "b2", Time: time1, Actor: "system", Action: book.ActionRestore},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				auditCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadAuditLog(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestImportAuditLog(t *testing.T) {
+	entries := []book.AuditEntry{
+		{ID: "e1", BookID: "b1", Action: book.ActionUpdate},
+		{ID: "e2", BookID: "b1", Action: book.ActionDelete},
+	}
+	tests := []struct {
+		name           string
+		FindFunc       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		InsertManyFunc func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+		wantOk         bool
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "insert error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return mongo.NewCursorFromDocuments(nil, nil, nil)
+			},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				return nil, fmt.Errorf("insert error")
+			},
+		},
+		{
+			name: "all imported",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					mAuditEntry{ID: "e1"},
+					mAuditEntry{ID: "e2"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D(bson.E(bookIDField, bson.D(bson.E("$in", bson.A("e1", "e2")))))
+				if !reflect.DeepEqual(wantFilter, filter) {
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				}
+				documents := []interface{}{
+					mAuditEntry{ID: "e1"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			InsertManyFunc: func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+				want := []interface{}{mongoAuditEntry(entries[1])}
+				if !reflect.DeepEqual(want, documents) {
+					t.Errorf("documents not equal: \n wanted: %#v \n got:    %#v", want, documents)
+				}
+				return &mongo.InsertManyResult{}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				auditCollection: mockCollection{
+					FindFunc:       test.FindFunc,
+					InsertManyFunc: test.InsertManyFunc,
+				},
+			}
+			ctx := context.Background()
+			err := d.ImportAuditLog(ctx, entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadSubjectAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		FindFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
+		wantOk   bool
+		want     []book.SubjectAlias
+	}{
+		{
+			name: "find error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				return nil, fmt.Errorf("find error")
+			},
+		},
+		{
+			name: "decode error",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				documents := []interface{}{
+					map[string]interface{}{
+						aliasNameField: 1,
+					},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+		},
+		{
+			name: "happy path",
+			FindFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
+				wantFilter := bson.D()
+				wantOpts := options.Find().
+					SetSort(bson.D(
+						bson.E(aliasNameField, 1),
+					))
+				gotOpts := options.MergeFindOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				documents := []interface{}{
+					mSubjectAlias{Key: "novels", Name: "Novels", Canonical: "Fiction"},
+				}
+				return mongo.NewCursorFromDocuments(documents, nil, nil)
+			},
+			wantOk: true,
+			want: []book.SubjectAlias{
+				{Name: "Novels", Canonical: "Fiction"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				aliasesCollection: mockCollection{
+					FindFunc: test.FindFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadSubjectAliases(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("aliases not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMergeSubjects(t *testing.T) {
+	keysIn := func(field string) interface{} {
+		key := bson.D(bson.E("$toLower", bson.D(bson.E("$trim", bson.D(bson.E("input", "$"+field))))))
+		return bson.D(bson.E("$expr", bson.D(bson.E("$in", bson.A(key, bson.A("science fiction", "sci-fi"))))))
+	}
+	okUpdate := func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+		return &mongo.UpdateResult{}, nil
+	}
+	okDelete := func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+		return &mongo.DeleteResult{}, nil
+	}
+	errUpdate := func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+		return nil, fmt.Errorf("update error")
+	}
+	tests := []struct {
+		name    string
+		books   mockCollection
+		aliases mockCollection
+		wantOk  bool
+	}{
+		{
+			name:  "books update error",
+			books: mockCollection{UpdateManyFunc: errUpdate},
+		},
+		{
+			name: "tags update error",
+			books: mockCollection{
+				UpdateManyFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+					if len(opts) != 0 && opts[0].ArrayFilters != nil {
+						return nil, fmt.Errorf("tags update error")
+					}
+					return &mongo.UpdateResult{}, nil
+				},
+			},
+		},
+		{
+			name:    "aliases update error",
+			books:   mockCollection{UpdateManyFunc: okUpdate},
+			aliases: mockCollection{UpdateManyFunc: errUpdate},
+		},
+		{
+			name:  "delete error",
+			books: mockCollection{UpdateManyFunc: okUpdate},
+			aliases: mockCollection{
+				UpdateManyFunc: okUpdate,
+				DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+					return nil, fmt.Errorf("delete error")
+				},
+			},
+		},
+		{
+			name:  "upsert error",
+			books: mockCollection{UpdateManyFunc: okUpdate},
+			aliases: mockCollection{
+				UpdateManyFunc: okUpdate,
+				DeleteOneFunc:  okDelete,
+				UpdateOneFunc:  errUpdate,
+			},
+		},
+		{
+			name: "happy path",
+			books: mockCollection{
+				UpdateManyFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+					wantFilter := keysIn(bookSubjectField)
+					wantUpdate := bson.D(bson.E("$set", bson.D(bson.E(bookSubjectField, "Science Fiction"))))
+					if gotOpts := options.MergeUpdateOptions(opts...); gotOpts.ArrayFilters != nil {
+						regexes := bson.A(primitive.MatchSubjectRegex("science fiction"), primitive.MatchSubjectRegex("sci-fi"))
+						wantFilter = bson.D(bson.E(bookTagsField, bson.D(bson.E("$in", regexes))))
+						wantUpdate = bson.D(bson.E("$set", bson.D(bson.E("tags.$[tag]", "Science Fiction"))))
+						wantArrayFilters := []interface{}{
+							bson.D(bson.E("tag", bson.D(bson.E("$in", regexes)))),
+						}
+						if !reflect.DeepEqual(wantArrayFilters, gotOpts.ArrayFilters.Filters) {
+							t.Errorf("array filters not equal: \n wanted: %v \n got:    %v", wantArrayFilters, gotOpts.ArrayFilters.Filters)
+						}
+					}
+					switch {
+					case !reflect.DeepEqual(wantFilter, filter):
+						t.Errorf("book filters not equal: \n wanted: %v \n got:    %v", wantFilter, filter)
+					case !reflect.DeepEqual(wantUpdate, update):
+						t.Errorf("book updates not equal: \n wanted: %v \n got:    %v", wantUpdate, update)
+					}
+					return &mongo.UpdateResult{ModifiedCount: 3}, nil
+				},
+			},
+			aliases: mockCollection{
+				UpdateManyFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+					if wantFilter := keysIn(aliasCanonicalField); !reflect.DeepEqual(wantFilter, filter) {
+						t.Errorf("alias filters not equal: \n wanted: %v \n got:    %v", wantFilter, filter)
+					}
+					return &mongo.UpdateResult{}, nil
+				},
+				DeleteOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+					if wantFilter := bson.D(bson.E(aliasKeyField, "science fiction")); !reflect.DeepEqual(wantFilter, filter) {
+						t.Errorf("delete filters not equal: \n wanted: %v \n got:    %v", wantFilter, filter)
+					}
+					return &mongo.DeleteResult{}, nil
+				},
+				UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+					wantFilter := bson.D(bson.E(aliasKeyField, "sci-fi"))
+					wantUpdate := bson.D(bson.E("$set", bson.D(
+						bson.E(aliasNameField, "Sci-Fi"),
+						bson.E(aliasCanonicalField, "Science Fiction"),
+					)))
+					wantOpts := options.Update().
+						SetUpsert(true)
+					gotOpts := options.MergeUpdateOptions(opts...)
+					switch {
+					case !reflect.DeepEqual(wantFilter, filter):
+						t.Errorf("upsert filters not equal: \n wanted: %v \n got:    %v", wantFilter, filter)
+					case !reflect.DeepEqual(wantUpdate, update):
+						t.Errorf("upserts not equal: \n wanted: %v \n got:    %v", wantUpdate, update)
+					case !reflect.DeepEqual(wantOpts, gotOpts):
+						t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+					}
+					return &mongo.UpdateResult{UpsertedCount: 1}, nil
+				},
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				booksCollection:   test.books,
+				aliasesCollection: test.aliases,
+			}
+			ctx := context.Background()
+			err := d.MergeSubjects(ctx, " Science Fiction", []string{"Sci-Fi"})
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadCustomFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		FindOneFunc func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+		wantOk      bool
+		want        []book.CustomField
+	}{
+		{
+			name: "find error",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				err := fmt.Errorf("find error")
+				return mongo.NewSingleResultFromDocument(nil, err, nil)
+			},
+		},
+		{
+			name: "no document",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				return mongo.NewSingleResultFromDocument(mCustomFields{}, mongo.ErrNoDocuments, nil)
+			},
+			wantOk: true,
+		},
+		{
+			name: "happy path",
+			FindOneFunc: func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+				wantFilter := bson.D(bson.E(settingIDField, customFieldsID))
+				if !reflect.DeepEqual(wantFilter, filter) {
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				}
+				document := mCustomFields{
+					Fields: []mCustomField{
+						{Name: "Donor", Type: "text"},
+						{Name: "Shelf", Type: "enum", Options: []string{"Top", "Bottom"}},
+					},
+				}
+				return mongo.NewSingleResultFromDocument(document, nil, nil)
+			},
+			wantOk: true,
+			want: []book.CustomField{
+				{Name: "Donor", Type: "text"},
+				{Name: "Shelf", Type: "enum", Options: []string{"Top", "Bottom"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				settingsCollection: mockCollection{
+					FindOneFunc: test.FindOneFunc,
+				},
+			}
+			ctx := context.Background()
+			got, err := d.ReadCustomFields(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateCustomFields(t *testing.T) {
+	fields := []book.CustomField{{Name: "Box", Type: "number"}}
+	tests := []struct {
+		name          string
+		UpdateOneFunc func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+		wantOk        bool
+	}{
+		{
+			name: "update error",
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				return nil, fmt.Errorf("update error")
+			},
+		},
+		{
+			name: "happy path",
+			UpdateOneFunc: func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				wantFilter := bson.D(bson.E(settingIDField, customFieldsID))
+				wantUpdate := bson.D(bson.E("$set", bson.D(bson.E(customFieldsField, []mCustomField{{Name: "Box", Type: "number"}}))))
+				wantOpts := options.Update().
+					SetUpsert(true)
+				gotOpts := options.MergeUpdateOptions(opts...)
+				switch {
+				case !reflect.DeepEqual(wantFilter, filter):
+					t.Errorf("filters not equal: \n wanted: %#v \n got:    %#v", wantFilter, filter)
+				case !reflect.DeepEqual(wantUpdate, update):
+					t.Errorf("updates not equal: \n wanted: %#v \n got:    %#v", wantUpdate, update)
+				case !reflect.DeepEqual(wantOpts, gotOpts):
+					t.Errorf("options not equal: \n wanted: %#v \n got:    %#v", wantOpts, gotOpts)
+				}
+				return &mongo.UpdateResult{}, nil
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Database{
+				settingsCollection: mockCollection{
+					UpdateOneFunc: test.UpdateOneFunc,
+				},
 			}
 			ctx := context.Background()
-			err := d.DeleteBook(ctx, test.bookID)
+			err := d.UpdateCustomFields(ctx, fields...)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -13,7 +13,11 @@ type mockCollection struct {
 	FindFunc       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
 	FindOneFunc    func(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
 	UpdateOneFunc  func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateManyFunc func(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
 	DeleteOneFunc  func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	DeleteManyFunc func(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	// FindOneAndUpdateFunc is named for the method.
+	FindOneAndUpdateFunc func(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult
 }
 
 func (m mockCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
@@ -36,6 +40,26 @@ func (m mockCollection) UpdateOne(ctx context.Context, filter, update interface{
 	return m.UpdateOneFunc(ctx, filter, update, opts...)
 }
 
+func (m mockCollection) UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return m.UpdateManyFunc(ctx, filter, update, opts...)
+}
+
 func (m mockCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 	return m.DeleteOneFunc(ctx, filter, opts...)
 }
+
+func (m mockCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return m.DeleteManyFunc(ctx, filter, opts...)
+}
+
+type mockIndexView struct {
+	CreateManyFunc func(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+}
+
+func (m mockIndexView) CreateMany(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return m.CreateManyFunc(ctx, models, opts...)
+}
+
+func (m mockCollection) FindOneAndUpdate(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	return m.FindOneAndUpdateFunc(ctx, filter, update, opts...)
+}
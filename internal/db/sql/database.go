@@ -4,7 +4,10 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
 	_ "github.com/lib/pq"           // register "postgres" database driver from package init() function
@@ -14,24 +17,47 @@ import (
 type (
 	Database struct {
 		*db
-		driver driverInfo
+		driver     driverInfo
+		migrations []Migration
 	}
 	driverInfo struct {
 		ILike string
+		// StringAgg is the aggregate function that joins strings with a separator, in the order of its ORDER BY clause.
+		// Sqlite supports ordered aggregates since version 3.44.
+		StringAgg string
+		// JSONObjectAgg is the aggregate function that builds a json object from keys and values.
+		JSONObjectAgg string
+		// TableCount is the query that counts the tables with the name of its argument.
+		TableCount string
 	}
 	query struct {
 		cmd                string
 		args               []interface{}
 		wantedRowsAffected []int64
+		// anyRowsAffected allows any number of rows to be affected, such as by updates of many rows.
+		anyRowsAffected bool
 	}
 )
 
 var drivers = map[string]driverInfo{
-	"postgres": {"ILIKE"},
-	"sqlite3":  {"LIKE"},
+	"postgres": {"ILIKE", "STRING_AGG", "JSON_OBJECT_AGG", "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = $1"},
+	"sqlite3":  {"LIKE", "GROUP_CONCAT", "JSON_GROUP_OBJECT", "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1"},
 }
 
+// NewDatabase opens the database and applies pending migrations.
 func NewDatabase(ctx context.Context, driverName, url string) (*Database, error) {
+	d, err := OpenDatabase(driverName, url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Migrate(ctx, false); err != nil {
+		return nil, fmt.Errorf("migrating tables: %w", err)
+	}
+	return d, nil
+}
+
+// OpenDatabase opens the database without migrating it.
+func OpenDatabase(driverName, url string) (*Database, error) {
 	driver, ok := drivers[driverName]
 	if !ok {
 		return nil, fmt.Errorf("unknown driverName: %q", driverName)
@@ -41,74 +67,207 @@ func NewDatabase(ctx context.Context, driverName, url string) (*Database, error)
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
 	d := Database{
-		db:     &db{sqlDB},
-		driver: driver,
-	}
-	if err := d.setupTables(ctx); err != nil {
-		return nil, fmt.Errorf("setting up tables: %w", err)
+		db:         &db{sqlDB},
+		driver:     driver,
+		migrations: migrations,
 	}
 	return &d, nil
 }
 
-func (d *Database) setupTables(ctx context.Context) error {
-	queries := []query{
-		{
-			cmd: "CREATE TABLE IF NOT EXISTS books" +
-				" ( id TEXT PRIMARY KEY" +
-				" , title TEXT" +
-				" , author TEXT" +
-				" , subject TEXT" +
-				" , description TEXT" +
-				" , dewey_dec_class TEXT" +
-				" , pages INT" +
-				" , publisher TEXT" +
-				" , publish_date TIMESTAMP" +
-				" , added_date TIMESTAMP" +
-				" , ean_isbn13 TEXT" +
-				" , upc_isbn10 TEXT" +
-				" , image_base64 TEXT" +
-				" )",
-			wantedRowsAffected: []int64{0},
-		},
-		{
-			cmd: "CREATE TABLE IF NOT EXISTS users" +
-				" ( username TEXT PRIMARY KEY" +
-				" , password TEXT" +
-				" )",
-			wantedRowsAffected: []int64{0},
-		},
-		{
-			cmd: "INSERT INTO users (username)" +
-				" VALUES ('admin')" +
-				" ON CONFLICT DO NOTHING",
-			wantedRowsAffected: []int64{0, 1},
-		},
-	}
-	return d.execTx(ctx, queries...)
-}
-
-func (d *Database) CreateBooks(ctx context.Context, books ...book.Book) ([]book.Book, error) {
-	queries := make([]query, len(books))
+// CreateBooks creates the books with new ids, adding an entry to the audit log for each audit of each book in the same transaction.
+func (d *Database) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
 	created := make([]book.Book, len(books))
+	var entries []book.AuditEntry
 	for i, b := range books {
 		b.ID = book.NewID()
-		queries[i].cmd = "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64)" +
-			" VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)"
-		queries[i].args = []interface{}{b.ID, b.Title, b.Author, b.Subject, b.Description, b.DeweyDecClass, b.Pages, b.Publisher, b.PublishDate, b.AddedDate, b.EanIsbn13, b.UpcIsbn10, b.ImageBase64}
-		queries[i].wantedRowsAffected = []int64{1}
 		created[i] = b
+		entries = append(entries, book.Entries(audits, book.Book{}, b)...)
+	}
+	audit, err := auditQueries(entries...)
+	if err != nil {
+		return nil, fmt.Errorf("creating books: %w", err)
 	}
+	queries := append(bookQueries(false, created...), audit...)
 	if err := d.execTx(ctx, queries...); err != nil {
 		return nil, fmt.Errorf("creating books: %w", err)
 	}
 	return created, nil
 }
 
-func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
-	cmd := "SELECT subject, COUNT(*)" +
+// ImportBooks creates the books with the ids they already have, adding the entries to the audit log in the same transaction.
+// Books with ids that already exist are replaced if upsert is true.
+// Otherwise, no books are imported if any of the ids already exist.
+func (d *Database) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if err := book.Books(books).CheckIDs(); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	if len(books) == 0 {
+		return nil
+	}
+	if !upsert {
+		existingIDs, err := existingIDs(ctx, d.db.db, books...)
+		if err != nil {
+			return fmt.Errorf("checking for existing ids: %w", err)
+		}
+		if len(existingIDs) != 0 {
+			return book.ExistingIDsError(existingIDs)
+		}
+	}
+	audit, err := auditQueries(entries...)
+	if err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	queries := append(bookQueries(upsert, books...), audit...)
+	if err := d.execTx(ctx, queries...); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	return nil
+}
+
+// bookQueries insert the books with their tags, contributors, and custom values.
+// If upsert is true, books that already exist are updated.
+func bookQueries(upsert bool, books ...book.Book) []query {
+	cmd := "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level)" +
+		" VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)"
+	if upsert {
+		cmd += " ON CONFLICT (id) DO UPDATE" +
+			" SET title = excluded.title" +
+			" , author = excluded.author" +
+			" , subject = excluded.subject" +
+			" , description = excluded.description" +
+			" , dewey_dec_class = excluded.dewey_dec_class" +
+			" , pages = excluded.pages" +
+			" , publisher = excluded.publisher" +
+			" , publish_date = excluded.publish_date" +
+			" , added_date = excluded.added_date" +
+			" , ean_isbn13 = excluded.ean_isbn13" +
+			" , upc_isbn10 = excluded.upc_isbn10" +
+			" , image_base64 = excluded.image_base64" +
+			" , series = excluded.series" +
+			" , volume = excluded.volume" +
+			" , language = excluded.language" +
+			" , edition = excluded.edition" +
+			" , format = excluded.format" +
+			" , reading_level = excluded.reading_level"
+	}
+	queries := make([]query, 0, len(books))
+	for _, b := range books {
+		q := query{
+			cmd:                cmd,
+			args:               []interface{}{b.ID, b.Title, b.Author, b.Subject, b.Description, b.DeweyDecClass, b.Pages, b.Publisher, b.PublishDate, b.AddedDate, b.EanIsbn13, b.UpcIsbn10, b.ImageBase64, b.Series, b.Volume, b.Language, b.Edition, b.Format, b.ReadingLevel},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+		queries = append(queries, tagQueries(b, upsert)...)
+		queries = append(queries, contributorQueries(b, upsert)...)
+		queries = append(queries, customQueries(b, upsert)...)
+	}
+	return queries
+}
+
+// tagQueries insert the tags of the book into the book tags table.
+// If replace is true, the tags the book already has are deleted first.
+func tagQueries(b book.Book, replace bool) []query {
+	var queries []query
+	if replace {
+		q := query{
+			cmd:             "DELETE FROM book_tags WHERE book_id = $1",
+			args:            []interface{}{b.ID},
+			anyRowsAffected: true,
+		}
+		queries = append(queries, q)
+	}
+	for _, t := range book.NormalizeTags(b.Subject, b.Tags) {
+		q := query{
+			cmd:                "INSERT INTO book_tags (book_id, tag) VALUES ($1, $2)",
+			args:               []interface{}{b.ID, t},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// contributorQueries insert the contributors of the book into the book contributors table, keeping their order.
+// If replace is true, the contributors the book already has are deleted first.
+func contributorQueries(b book.Book, replace bool) []query {
+	var queries []query
+	if replace {
+		q := query{
+			cmd:             "DELETE FROM book_contributors WHERE book_id = $1",
+			args:            []interface{}{b.ID},
+			anyRowsAffected: true,
+		}
+		queries = append(queries, q)
+	}
+	for i, c := range book.NormalizeContributors(b.Contributors) {
+		q := query{
+			cmd:                "INSERT INTO book_contributors (book_id, position, name, role, sort_name) VALUES ($1, $2, $3, $4, $5)",
+			args:               []interface{}{b.ID, i, c.Name, c.Role, c.SortName},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// customQueries insert the custom values of the book into the book custom values table, sorted by name.
+// If replace is true, the values the book already has are deleted first.
+func customQueries(b book.Book, replace bool) []query {
+	var queries []query
+	if replace {
+		q := query{
+			cmd:             "DELETE FROM book_custom_values WHERE book_id = $1",
+			args:            []interface{}{b.ID},
+			anyRowsAffected: true,
+		}
+		queries = append(queries, q)
+	}
+	custom := book.NormalizeCustom(b.Custom)
+	for _, name := range book.CustomNames(nil, custom) {
+		q := query{
+			cmd:                "INSERT INTO book_custom_values (book_id, name, value) VALUES ($1, $2, $3)",
+			args:               []interface{}{b.ID, name, custom[name]},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// existingIDs reads the ids of the books that are already in the database.
+func existingIDs(ctx context.Context, qr querier, books ...book.Book) ([]string, error) {
+	params := make([]string, len(books))
+	args := make([]interface{}, len(books))
+	for i, b := range books {
+		params[i] = fmt.Sprintf("$%v", i+1)
+		args[i] = b.ID
+	}
+	cmd := "SELECT id" +
 		" FROM books" +
-		" GROUP BY subject" +
-		" ORDER BY subject ASC" +
+		" WHERE id IN (" + strings.Join(params, ", ") + ")"
+	q := query{
+		cmd:  cmd,
+		args: args,
+	}
+	var ids []string
+	dest := func() []interface{} {
+		ids = append(ids, "")
+		return []interface{}{&ids[len(ids)-1]}
+	}
+	if err := queryRows(ctx, qr, q, dest); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ReadBookSubjects counts the books for each subject or tag.
+func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
+	cmd := "SELECT MIN(TRIM(subject)) AS name, COUNT(DISTINCT id)" +
+		" FROM (SELECT id, subject FROM books" +
+		" UNION ALL SELECT book_id, tag FROM book_tags) AS subjects" +
+		" GROUP BY LOWER(TRIM(subject))" +
+		" ORDER BY name ASC" +
 		" LIMIT $1" +
 		" OFFSET $2"
 	q := query{
@@ -132,23 +291,76 @@ func (d *Database) ReadBookSubjects(ctx context.Context, limit, offset int) ([]b
 	return subjects, nil
 }
 
+// ReadBookAuthors counts the books for each author or contributor, sorted by the sort names of the contributors.
+// Authors that are not contributors are given sort names from their names, like book.SortName.
+func (d *Database) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	sortName := "COALESCE(NULLIF(MAX(sort_name), ''), " + sortNameExpr("MIN(TRIM(name))") + ")"
+	cmd := "SELECT MIN(TRIM(name)), " + sortName + ", COUNT(DISTINCT id)" +
+		" FROM (SELECT id, author AS name, '' AS sort_name FROM books" +
+		" UNION ALL SELECT book_id, name, sort_name FROM book_contributors) AS authors" +
+		" WHERE TRIM(name) <> ''" +
+		" GROUP BY LOWER(TRIM(name))" +
+		" ORDER BY LOWER(" + sortName + ") ASC, MIN(TRIM(name)) ASC" +
+		" LIMIT $1" +
+		" OFFSET $2"
+	q := query{
+		cmd:  cmd,
+		args: []interface{}{limit, offset},
+	}
+	authors := make([]book.Author, limit)
+	n := 0
+	dest := func() []interface{} {
+		if n >= limit {
+			return nil
+		}
+		a := &authors[n]
+		n++
+		return []interface{}{&a.Name, &a.SortName, &a.Count}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading book authors: %w", err)
+	}
+	authors = authors[:n]
+	return authors, nil
+}
+
+// sortNameExpr is the sql expression of the name with the last word first, like book.SortName.
+// The part before the last word is found by trimming all of the characters of the name that are not spaces from its end.
+func sortNameExpr(name string) string {
+	first := "RTRIM(" + name + ", REPLACE(" + name + ", ' ', ''))"
+	return "CASE WHEN " + name + " LIKE '%,%' OR " + first + " = '' THEN " + name +
+		" ELSE SUBSTR(" + name + ", LENGTH(" + first + ") + 1) || ', ' || RTRIM(" + first + ") END"
+}
+
 func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
 	hasSubject := len(filter.Subject) != 0
+	hasAuthor := len(filter.Author) != 0
+	hasLanguage := len(filter.Language) != 0
+	hasFormat := len(filter.Format) != 0
+	hasReadingLevel := len(filter.ReadingLevel) != 0
 	hasHeaderPart := len(filter.HeaderPart) != 0
 	likeHeaderPart := "%" + filter.HeaderPart + "%"
 	cmd := "SELECT id, title, author, subject" +
 		" FROM books" +
-		" WHERE ($1 OR subject = $2)" +
-		" AND ($3" +
-		" OR title " + d.driver.ILike + " $4" +
-		" OR author " + d.driver.ILike + " $4" +
-		" OR subject " + d.driver.ILike + " $4)" +
+		" WHERE ($1 OR LOWER(TRIM(subject)) = $2" +
+		" OR id IN (SELECT book_id FROM book_tags WHERE LOWER(TRIM(tag)) = $2))" +
+		" AND ($3 OR LOWER(TRIM(author)) = $4" +
+		" OR id IN (SELECT book_id FROM book_contributors WHERE LOWER(TRIM(name)) = $4))" +
+		" AND ($5 OR LOWER(TRIM(language)) = $6)" +
+		" AND ($7 OR LOWER(TRIM(format)) = $8)" +
+		" AND ($9 OR LOWER(TRIM(reading_level)) = $10)" +
+		" AND ($11" +
+		" OR title " + d.driver.ILike + " $12" +
+		" OR author " + d.driver.ILike + " $12" +
+		" OR subject " + d.driver.ILike + " $12" +
+		" OR id IN (SELECT book_id FROM book_tags WHERE tag " + d.driver.ILike + " $12)" +
+		" OR id IN (SELECT book_id FROM book_contributors WHERE name " + d.driver.ILike + " $12))" +
 		" ORDER BY subject ASC, Title ASC" +
-		" LIMIT $5" +
-		" OFFSET $6"
+		" LIMIT $13" +
+		" OFFSET $14"
 	q := query{
 		cmd:  cmd,
-		args: []interface{}{!hasSubject, filter.Subject, !hasHeaderPart, likeHeaderPart, limit, offset},
+		args: []interface{}{!hasSubject, book.SubjectKey(filter.Subject), !hasAuthor, book.AuthorKey(filter.Author), !hasLanguage, book.SubjectKey(filter.Language), !hasFormat, book.SubjectKey(filter.Format), !hasReadingLevel, book.SubjectKey(filter.ReadingLevel), !hasHeaderPart, likeHeaderPart, limit, offset},
 	}
 	headers := make([]book.Header, limit)
 	n := 0
@@ -167,31 +379,200 @@ func (d *Database) ReadBookHeaders(ctx context.Context, filter book.Filter, limi
 	return headers, nil
 }
 
-func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error) {
-	cmd := "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64" +
+func (d *Database) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	cmd := "SELECT id, title, author, subject, dewey_dec_class" +
+		" FROM books"
+	q := query{
+		cmd: cmd,
+	}
+	var shelved []book.ShelvedBook
+	dest := func() []interface{} {
+		shelved = append(shelved, book.ShelvedBook{})
+		b := &shelved[len(shelved)-1]
+		return []interface{}{&b.ID, &b.Title, &b.Author, &b.Subject, &b.DeweyDecClass}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading shelved books: %w", err)
+	}
+	return shelved, nil
+}
+
+func (d *Database) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	cmd := "SELECT id, title, author, subject, series, volume" +
 		" FROM books" +
+		" WHERE TRIM(series) <> ''"
+	q := query{
+		cmd: cmd,
+	}
+	var seriesBooks []book.SeriesBook
+	dest := func() []interface{} {
+		seriesBooks = append(seriesBooks, book.SeriesBook{})
+		b := &seriesBooks[len(seriesBooks)-1]
+		return []interface{}{&b.ID, &b.Title, &b.Author, &b.Subject, &b.Series, &b.Volume}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading series books: %w", err)
+	}
+	return seriesBooks, nil
+}
+
+func (d *Database) ReadBook(ctx context.Context, id string) (*book.Book, error) {
+	return d.readBook(ctx, d.db.db, id, true)
+}
+
+// ReadBookMetadata reads the book without its image.
+func (d *Database) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	return d.readBook(ctx, d.db.db, id, false)
+}
+
+// readBook reads the book with the querier, which can be a transaction.
+// The image is only read if withImage is true.
+func (d *Database) readBook(ctx context.Context, qr querier, id string, withImage bool) (*book.Book, error) {
+	cmd := d.selectBookCmd(withImage) +
 		" WHERE id = $1"
 	q := query{
 		cmd:  cmd,
 		args: []interface{}{id},
 	}
 	var b book.Book
-	dest := []interface{}{&b.ID, &b.Title, &b.Author, &b.Subject, &b.Description, &b.DeweyDecClass, &b.Pages, &b.Publisher, &b.PublishDate, &b.AddedDate, &b.EanIsbn13, &b.UpcIsbn10, &b.ImageBase64}
-	if err := d.queryRow(ctx, q, dest...); err != nil {
+	found := false
+	dest := func() []interface{} {
+		found = true
+		return bookDest(&b)
+	}
+	if err := queryRows(ctx, qr, q, dest); err != nil {
 		return nil, fmt.Errorf("reading book: %w", err)
 	}
+	if !found {
+		return nil, book.IDNotFoundError(id)
+	}
+	return &b, nil
+}
+
+// ReadBookByISBN reads the book that has the isbn-13 or isbn-10.
+// The isbn columns are indexed by a migration.
+func (d *Database) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	if len(isbn) == 0 {
+		return nil, book.ISBNNotFoundError(isbn)
+	}
+	cmd := d.selectBookCmd(true) +
+		" WHERE ean_isbn13 = $1 OR upc_isbn10 = $1" +
+		" LIMIT 1"
+	q := query{
+		cmd:  cmd,
+		args: []interface{}{isbn},
+	}
+	var b book.Book
+	found := false
+	dest := func() []interface{} {
+		found = true
+		return bookDest(&b)
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading book by isbn: %w", err)
+	}
+	if !found {
+		return nil, book.ISBNNotFoundError(isbn)
+	}
 	return &b, nil
 }
 
-func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool) error {
+// selectBookCmd selects the columns of books, with their tags joined by the tag separator, their contributors joined as text, in order, and their custom values as a json object.
+// The image is empty unless withImage is true.
+func (d *Database) selectBookCmd(withImage bool) string {
+	contributor := "name || '" + book.ContributorFieldSeparator + "' || role || '" + book.ContributorFieldSeparator + "' || sort_name"
+	image := "image_base64"
+	if !withImage {
+		image = "'' AS image_base64"
+	}
+	return "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, " + image + ", series, volume, language, edition, format, reading_level" +
+		", (SELECT " + d.driver.StringAgg + "(tag, '" + book.TagSeparator + "' ORDER BY tag) FROM book_tags WHERE book_id = books.id) AS tags" +
+		", (SELECT " + d.driver.StringAgg + "(" + contributor + ", '" + book.ContributorSeparator + "' ORDER BY position)" +
+		" FROM book_contributors WHERE book_id = books.id) AS contributors" +
+		", (SELECT " + d.driver.JSONObjectAgg + "(name, value) FROM book_custom_values WHERE book_id = books.id) AS custom" +
+		" FROM books"
+}
+
+// bookDest is where the columns of selectBookCmd are scanned.
+func bookDest(b *book.Book) []interface{} {
+	return []interface{}{&b.ID, &b.Title, &b.Author, &b.Subject, &b.Description, &b.DeweyDecClass, &b.Pages, &b.Publisher, &b.PublishDate, &b.AddedDate, &b.EanIsbn13, &b.UpcIsbn10, &b.ImageBase64, &b.Series, &b.Volume, &b.Language, &b.Edition, &b.Format, &b.ReadingLevel, tagsDest{&b.Tags}, contributorsDest{&b.Contributors}, customDest{&b.Custom}}
+}
+
+// tagsDest scans the joined tags of a book, which are null if the book has no tags.
+type tagsDest struct {
+	tags *[]string
+}
+
+func (t tagsDest) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*t.tags = nil
+	case string:
+		*t.tags = book.NormalizeTags("", book.ParseTags(src))
+	case []byte:
+		*t.tags = book.NormalizeTags("", book.ParseTags(string(src)))
+	default:
+		return fmt.Errorf("unwanted type of tags: %T", src)
+	}
+	return nil
+}
+
+// contributorsDest scans the joined contributors of a book, which are null if the book has no contributors.
+type contributorsDest struct {
+	contributors *[]book.Contributor
+}
+
+func (c contributorsDest) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*c.contributors = nil
+	case string:
+		*c.contributors = book.ParseContributors(src)
+	case []byte:
+		*c.contributors = book.ParseContributors(string(src))
+	default:
+		return fmt.Errorf("unwanted type of contributors: %T", src)
+	}
+	return nil
+}
+
+// customDest scans the custom values of a book as a json object, which is null or empty if the book has no values.
+type customDest struct {
+	custom *map[string]string
+}
+
+func (c customDest) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*c.custom = nil
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("unwanted type of custom values: %T", src)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("decoding custom values: %w", err)
+	}
+	*c.custom = book.NormalizeCustom(m)
+	return nil
+}
+
+// UpdateBook replaces the book, adding an entry to the audit log for each audit in the same transaction.
+// The book from before the update is read in the transaction so the entries have the changes that were made, even if the book is being changed by others.
+func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
 	cmd := "UPDATE books" +
-		" SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11"
-	args := []interface{}{b.Title, b.Author, b.Subject, b.Description, b.DeweyDecClass, b.Pages, b.Publisher, b.PublishDate, b.AddedDate, b.EanIsbn13, b.UpcIsbn10}
+		" SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11, series = $12, volume = $13, language = $14, edition = $15, format = $16, reading_level = $17"
+	args := []interface{}{b.Title, b.Author, b.Subject, b.Description, b.DeweyDecClass, b.Pages, b.Publisher, b.PublishDate, b.AddedDate, b.EanIsbn13, b.UpcIsbn10, b.Series, b.Volume, b.Language, b.Edition, b.Format, b.ReadingLevel}
 	if updateImage {
-		cmd += ", image_base64 = $12 WHERE id = $13"
+		cmd += ", image_base64 = $18 WHERE id = $19"
 		args = append(args, b.ImageBase64, b.ID)
 	} else {
-		cmd += " WHERE id = $12"
+		cmd += " WHERE id = $18"
 		args = append(args, b.ID)
 	}
 	q := query{
@@ -199,21 +580,505 @@ func (d *Database) UpdateBook(ctx context.Context, b book.Book, updateImage bool
 		args:               args,
 		wantedRowsAffected: []int64{1},
 	}
-	if err := d.execTx(ctx, q); err != nil {
+	queries := append([]query{q}, tagQueries(b, true)...)
+	queries = append(queries, contributorQueries(b, true)...)
+	queries = append(queries, customQueries(b, true)...)
+	if len(audits) == 0 { // the book is only read if the update is audited
+		if err := d.execTx(ctx, queries...); err != nil {
+			return fmt.Errorf("updating book: %w", err)
+		}
+		return nil
+	}
+	err := d.withTx(ctx, func(tx *sql.Tx) error {
+		lock := query{
+			cmd:             "UPDATE books SET id = id WHERE id = $1", // locks the book so it is not changed by others before it is updated
+			args:            []interface{}{b.ID},
+			anyRowsAffected: true, // unknown books are not found when they are read
+		}
+		if err := lock.execute(ctx, tx); err != nil {
+			return err
+		}
+		before, err := d.readBook(ctx, tx, b.ID, false)
+		if err != nil {
+			return err
+		}
+		audit, err := auditQueries(book.Entries(audits, *before, b)...)
+		if err != nil {
+			return err
+		}
+		for _, q := range append(queries, audit...) {
+			if err := q.execute(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("updating book: %w", err)
 	}
 	return nil
 }
 
-func (d *Database) DeleteBook(ctx context.Context, id string) error {
-	cmd := "DELETE FROM books WHERE id = $1"
+// DeleteBook moves the book to the trash, adding the entries to the audit log in the same transaction.
+// The book is stored in the trash as json so it can be restored with its tags, contributors, custom values, and image.
+// The book is read in the transaction so changes to it while it is being deleted are not lost.
+func (d *Database) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	audit, err := auditQueries(entries...)
+	if err != nil {
+		return fmt.Errorf("deleting book: %w", err)
+	}
+	err = d.withTx(ctx, func(tx *sql.Tx) error {
+		b, err := d.readBook(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		queries, err := deleteBookQueries(*b)
+		if err != nil {
+			return err
+		}
+		for _, q := range append(queries, audit...) {
+			if err := q.execute(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("deleting book: %w", err)
+	}
+	return nil
+}
+
+// deleteBookQueries move the book to the trash.
+func deleteBookQueries(b book.Book) ([]query, error) {
+	id := b.ID
 	q := query{
-		cmd:                cmd,
+		cmd:                "DELETE FROM books WHERE id = $1",
 		args:               []interface{}{id},
 		wantedRowsAffected: []int64{1},
 	}
+	trashQuery, err := insertTrashQuery(book.DeletedBook{Book: b, DeletedDate: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	deleted := book.Book{Header: book.Header{ID: id}} // only delete the tags, contributors, and custom values
+	queries := append(tagQueries(deleted, true), contributorQueries(deleted, true)...)
+	queries = append(queries, customQueries(deleted, true)...)
+	queries = append(queries, q, trashQuery)
+	return queries, nil
+}
+
+// insertTrashQuery adds the deleted book to the trash.
+func insertTrashQuery(b book.DeletedBook) (query, error) {
+	data, err := json.Marshal(b.Book)
+	if err != nil {
+		return query{}, fmt.Errorf("encoding deleted book: %w", err)
+	}
+	q := query{
+		cmd:                "INSERT INTO book_trash (id, title, author, subject, deleted_date, book) VALUES ($1, $2, $3, $4, $5, $6)",
+		args:               []interface{}{b.ID, b.Title, b.Author, b.Subject, b.DeletedDate, string(data)},
+		wantedRowsAffected: []int64{1},
+	}
+	return q, nil
+}
+
+// ReadTrash reads the books in the trash, most recently deleted first.
+func (d *Database) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	cmd := "SELECT id, title, author, subject, deleted_date" +
+		" FROM book_trash" +
+		" ORDER BY deleted_date DESC"
+	q := query{
+		cmd: cmd,
+	}
+	var trash []book.TrashedBook
+	dest := func() []interface{} {
+		trash = append(trash, book.TrashedBook{})
+		b := &trash[len(trash)-1]
+		return []interface{}{&b.ID, &b.Title, &b.Author, &b.Subject, &b.DeletedDate}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading trash: %w", err)
+	}
+	return trash, nil
+}
+
+// ReadDeletedBook reads the book in the trash with its image.
+func (d *Database) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	q := query{
+		cmd:  "SELECT deleted_date, book FROM book_trash WHERE id = $1",
+		args: []interface{}{id},
+	}
+	var b book.DeletedBook
+	found := false
+	dest := func() []interface{} {
+		found = true
+		return []interface{}{&b.DeletedDate, deletedBookDest{&b.Book}}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading book from trash: %w", err)
+	}
+	if !found {
+		return nil, book.TrashNotFoundError(id)
+	}
+	return &b, nil
+}
+
+// ImportTrash adds the deleted books to the trash with the times they were deleted.
+// Books with ids that are already in the trash are skipped.
+func (d *Database) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	queries := make([]query, len(books))
+	for i, b := range books {
+		q, err := insertTrashQuery(b)
+		if err != nil {
+			return fmt.Errorf("importing trash: %w", err)
+		}
+		q.cmd += " ON CONFLICT (id) DO NOTHING"
+		q.wantedRowsAffected = nil
+		q.anyRowsAffected = true
+		queries[i] = q
+	}
+	if err := d.execTx(ctx, queries...); err != nil {
+		return fmt.Errorf("importing trash: %w", err)
+	}
+	return nil
+}
+
+// RestoreBook moves the book out of the trash, adding the entries to the audit log in the same transaction.
+// Books cannot be restored if another book has their id, which is checked in the transaction so a book that is created at the same time cannot take the id.
+func (d *Database) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	audit, err := auditQueries(entries...)
+	if err != nil {
+		return fmt.Errorf("restoring book: %w", err)
+	}
+	err = d.withTx(ctx, func(tx *sql.Tx) error {
+		q := query{
+			cmd:  "SELECT book FROM book_trash WHERE id = $1",
+			args: []interface{}{id},
+		}
+		var b book.Book
+		found := false
+		dest := func() []interface{} {
+			found = true
+			return []interface{}{deletedBookDest{&b}}
+		}
+		if err := queryRows(ctx, tx, q, dest); err != nil {
+			return fmt.Errorf("reading book from trash: %w", err)
+		}
+		if !found {
+			return book.TrashNotFoundError(id)
+		}
+		existingIDs, err := existingIDs(ctx, tx, b)
+		if err != nil {
+			return fmt.Errorf("checking for existing ids: %w", err)
+		}
+		if len(existingIDs) != 0 {
+			return book.ExistingIDsError(existingIDs)
+		}
+		queries := bookQueries(false, b)
+		trashQuery := query{
+			cmd:                "DELETE FROM book_trash WHERE id = $1",
+			args:               []interface{}{id},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, trashQuery)
+		for _, q := range append(queries, audit...) {
+			if err := q.execute(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("restoring book: %w", err)
+	}
+	return nil
+}
+
+// deletedBookDest scans a book in the trash, which is a json object.
+type deletedBookDest struct {
+	b *book.Book
+}
+
+func (d deletedBookDest) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("unwanted type of deleted book: %T", src)
+	}
+	if err := json.Unmarshal(data, d.b); err != nil {
+		return fmt.Errorf("decoding deleted book: %w", err)
+	}
+	return nil
+}
+
+// PurgeBooks permanently removes the books from the trash.
+// Ids of books that are not in the trash are ignored.
+func (d *Database) PurgeBooks(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	params := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		params[i] = fmt.Sprintf("$%v", i+1)
+		args[i] = id
+	}
+	q := query{
+		cmd:             "DELETE FROM book_trash WHERE id IN (" + strings.Join(params, ", ") + ")",
+		args:            args,
+		anyRowsAffected: true,
+	}
 	if err := d.execTx(ctx, q); err != nil {
-		return fmt.Errorf("deleting book: %w", err)
+		return fmt.Errorf("purging books: %w", err)
+	}
+	return nil
+}
+
+// auditQueries insert the entries into the audit log.
+// The changes of each entry are stored as a json array.
+func auditQueries(entries ...book.AuditEntry) ([]query, error) {
+	queries := make([]query, 0, len(entries))
+	for _, e := range entries {
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return nil, fmt.Errorf("encoding changes of audit entry %q: %w", e.ID, err)
+		}
+		q := query{
+			cmd:                "INSERT INTO book_audit_log (id, book_id, time, actor, action, changes) VALUES ($1, $2, $3, $4, $5, $6)",
+			args:               []interface{}{e.ID, e.BookID, e.Time, e.Actor, e.Action, string(changes)},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+// ReadBookHistory reads the audit log of the book, newest entries first.
+func (d *Database) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	cmd := "SELECT id, book_id, time, actor, action, changes" +
+		" FROM book_audit_log" +
+		" WHERE book_id = $1" +
+		" ORDER BY time DESC"
+	q := query{
+		cmd:  cmd,
+		args: []interface{}{bookID},
+	}
+	var history []book.AuditEntry
+	if err := d.query(ctx, q, auditEntriesDest(&history)); err != nil {
+		return nil, fmt.Errorf("reading book history: %w", err)
+	}
+	return history, nil
+}
+
+// ReadAuditLog reads the entries of the audit log, oldest first.
+func (d *Database) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	cmd := "SELECT id, book_id, time, actor, action, changes" +
+		" FROM book_audit_log" +
+		" ORDER BY time ASC"
+	q := query{
+		cmd: cmd,
+	}
+	var entries []book.AuditEntry
+	if err := d.query(ctx, q, auditEntriesDest(&entries)); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+func (d *Database) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	queries, err := auditQueries(entries...)
+	if err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	for i := range queries {
+		queries[i].cmd += " ON CONFLICT (id) DO NOTHING"
+		queries[i].wantedRowsAffected = nil
+		queries[i].anyRowsAffected = true
+	}
+	if err := d.execTx(ctx, queries...); err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	return nil
+}
+
+// auditEntriesDest adds an entry to the entries for each row that is scanned.
+func auditEntriesDest(entries *[]book.AuditEntry) func() []interface{} {
+	return func() []interface{} {
+		*entries = append(*entries, book.AuditEntry{})
+		e := &(*entries)[len(*entries)-1]
+		return []interface{}{&e.ID, &e.BookID, &e.Time, &e.Actor, &e.Action, changesDest{&e.Changes}}
+	}
+}
+
+// changesDest scans the changes of an audit entry, which are a json array.
+type changesDest struct {
+	changes *[]book.FieldChange
+}
+
+func (c changesDest) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("unwanted type of changes: %T", src)
+	}
+	var changes []book.FieldChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return fmt.Errorf("decoding changes: %w", err)
+	}
+	*c.changes = changes
+	return nil
+}
+
+func (d *Database) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	cmd := "SELECT name, canonical" +
+		" FROM subject_aliases" +
+		" ORDER BY name ASC"
+	q := query{
+		cmd: cmd,
+	}
+	var aliases []book.SubjectAlias
+	dest := func() []interface{} {
+		aliases = append(aliases, book.SubjectAlias{})
+		a := &aliases[len(aliases)-1]
+		return []interface{}{&a.Name, &a.Canonical}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading subject aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// MergeSubjects gives the books with any of the subjects the canonical subject in one transaction, making the subjects aliases of it.
+// The entries are added to the audit log in the same transaction.
+// Tags with any of the subjects are replaced with the canonical subject.
+// Aliases of the subjects become aliases of the canonical subject.
+func (d *Database) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	canonical = book.NormalizeSubject(canonical)
+	keys := book.SubjectKeys(append([]string{canonical}, subjects...)...)
+	params := make([]string, len(keys))
+	keyArgs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		params[i] = fmt.Sprintf("$%v", i+2)
+		keyArgs[i] = k
+	}
+	inKeys := " IN (" + strings.Join(params, ", ") + ")"
+	args := append([]interface{}{canonical}, keyArgs...)
+	queries := []query{
+		{
+			cmd:             "UPDATE books SET subject = $1 WHERE LOWER(TRIM(subject))" + inKeys,
+			args:            args,
+			anyRowsAffected: true,
+		},
+		{
+			cmd: "INSERT INTO book_tags (book_id, tag)" +
+				" SELECT DISTINCT book_id, $1 FROM book_tags WHERE LOWER(TRIM(tag))" + inKeys +
+				" ON CONFLICT DO NOTHING",
+			args:            args,
+			anyRowsAffected: true,
+		},
+		{
+			cmd:             "DELETE FROM book_tags WHERE tag <> $1 AND LOWER(TRIM(tag))" + inKeys,
+			args:            args,
+			anyRowsAffected: true,
+		},
+		{
+			cmd:             "UPDATE subject_aliases SET canonical = $1 WHERE LOWER(TRIM(canonical))" + inKeys,
+			args:            args,
+			anyRowsAffected: true,
+		},
+		{
+			cmd:                "DELETE FROM subject_aliases WHERE name_key = $1",
+			args:               []interface{}{book.SubjectKey(canonical)},
+			wantedRowsAffected: []int64{0, 1},
+		},
+	}
+	for _, a := range book.SubjectAliases(nil).Merge(canonical, subjects...) {
+		q := query{
+			cmd: "INSERT INTO subject_aliases (name_key, name, canonical)" +
+				" VALUES ($1, $2, $3)" +
+				" ON CONFLICT (name_key) DO UPDATE" +
+				" SET name = excluded.name" +
+				" , canonical = excluded.canonical",
+			args:               []interface{}{book.SubjectKey(a.Name), a.Name, a.Canonical},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	audit, err := auditQueries(entries...)
+	if err != nil {
+		return fmt.Errorf("merging subjects: %w", err)
+	}
+	queries = append(queries, audit...)
+	if err := d.execTx(ctx, queries...); err != nil {
+		return fmt.Errorf("merging subjects: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	cmd := "SELECT name, type, options" +
+		" FROM custom_fields" +
+		" ORDER BY position ASC"
+	q := query{
+		cmd: cmd,
+	}
+	var fields []book.CustomField
+	dest := func() []interface{} {
+		fields = append(fields, book.CustomField{})
+		f := &fields[len(fields)-1]
+		return []interface{}{&f.Name, &f.Type, optionsDest{&f.Options}}
+	}
+	if err := d.query(ctx, q, dest); err != nil {
+		return nil, fmt.Errorf("reading custom fields: %w", err)
+	}
+	return fields, nil
+}
+
+// optionsDest scans the options of a custom field, which are joined by the option separator.
+type optionsDest struct {
+	options *[]string
+}
+
+func (o optionsDest) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		*o.options = book.ParseOptions(src)
+	case []byte:
+		*o.options = book.ParseOptions(string(src))
+	default:
+		return fmt.Errorf("unwanted type of options: %T", src)
+	}
+	return nil
+}
+
+// UpdateCustomFields replaces the custom fields in a transaction, keeping their order.
+// The values of books are kept for fields that are removed.
+func (d *Database) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	queries := []query{
+		{
+			cmd:             "DELETE FROM custom_fields",
+			anyRowsAffected: true,
+		},
+	}
+	for i, f := range fields {
+		q := query{
+			cmd:                "INSERT INTO custom_fields (position, name, type, options) VALUES ($1, $2, $3, $4)",
+			args:               []interface{}{i, f.Name, f.Type, book.FormatOptions(f.Options)},
+			wantedRowsAffected: []int64{1},
+		}
+		queries = append(queries, q)
+	}
+	if err := d.execTx(ctx, queries...); err != nil {
+		return fmt.Errorf("updating custom fields: %w", err)
 	}
 	return nil
 }
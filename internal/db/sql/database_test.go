@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -13,9 +14,14 @@ import (
 )
 
 var testDriverInfo = driverInfo{
-	ILike: "mock_ILIKE",
+	ILike:         "mock_ILIKE",
+	StringAgg:     "mock_STRING_AGG",
+	JSONObjectAgg: "mock_JSON_OBJECT_AGG",
+	TableCount:    "mock_TABLE_COUNT $1",
 }
 
+const wantInsertAuditEntry = "INSERT INTO book_audit_log (id, book_id, time, actor, action, changes) VALUES ($1, $2, $3, $4, $5, $6)"
+
 func init() {
 	drivers[testDriverName] = testDriverInfo
 }
@@ -48,11 +54,19 @@ func TestNewDatabase(t *testing.T) {
 				return mock.Conn{}, fmt.Errorf("open error (on tx)")
 			},
 		},
+		{
+			name:       "migration error",
+			driverName: testDriverName,
+			openFunc: func(name string) (mock.Conn, error) {
+				return mock.NewTransactionConn(*mock.NewAnyQuery(0), mock.Query{Name: "unexpected query"}), nil
+			},
+		},
 		{
 			name:       "happy path (create user)",
 			driverName: testDriverName,
 			openFunc: func(name string) (mock.Conn, error) {
-				return mock.NewTransactionConn(*mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1)), nil
+				schemaVersion := mock.Query{Name: wantSchemaVersionQuery, Rows: [][]interface{}{{int64(0)}}}
+				return mock.NewTransactionConn(*mock.NewAnyQuery(0), schemaVersion, *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1)), nil
 			},
 			wantOk:     true,
 			wantDriver: testDriverInfo,
@@ -61,7 +75,18 @@ func TestNewDatabase(t *testing.T) {
 			name:       "happy path",
 			driverName: testDriverName,
 			openFunc: func(name string) (mock.Conn, error) {
-				return mock.NewTransactionConn(*mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0)), nil
+				schemaVersion := mock.Query{Name: wantSchemaVersionQuery, Rows: [][]interface{}{{int64(0)}}}
+				return mock.NewTransactionConn(*mock.NewAnyQuery(0), schemaVersion, *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1), *mock.NewAnyQuery(0), *mock.NewAnyQuery(1)), nil
+			},
+			wantOk:     true,
+			wantDriver: testDriverInfo,
+		},
+		{
+			name:       "happy path (already migrated)",
+			driverName: testDriverName,
+			openFunc: func(name string) (mock.Conn, error) {
+				schemaVersion := mock.Query{Name: wantSchemaVersionQuery, Rows: [][]interface{}{{int64(len(migrations))}}}
+				return mock.NewTransactionConn(*mock.NewAnyQuery(0), schemaVersion), nil
 			},
 			wantOk:     true,
 			wantDriver: testDriverInfo,
@@ -89,11 +114,14 @@ func TestNewDatabase(t *testing.T) {
 func TestCreateBooks(t *testing.T) {
 	d1 := time.Date(2003, 6, 9, 0, 0, 0, 0, time.UTC)
 	d2 := time.Date(2004, 12, 31, 0, 0, 0, 0, time.UTC)
-	wantInsert := "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)"
+	wantInsert := "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)"
+	wantInsertTag := "INSERT INTO book_tags (book_id, tag) VALUES ($1, $2)"
+	wantInsertCustom := "INSERT INTO book_custom_values (book_id, name, value) VALUES ($1, $2, $3)"
 	tests := []struct {
 		name   string
 		conn   mock.Conn
 		books  []book.Book
+		audits []book.Audit
 		wantOk bool
 	}{
 		{
@@ -114,16 +142,45 @@ func TestCreateBooks(t *testing.T) {
 			conn: mock.NewTransactionConn(
 				mock.Query{
 					Name:         wantInsert,
-					Args:         []interface{}{mock.AnyArg, "t1", "a1", "s1", "d1", "ddc1", 2, "p1", d1, d2, "ean", "upc", "?"},
+					Args:         []interface{}{mock.AnyArg, "t1", "a1", "s1", "d1", "ddc1", 2, "p1", d1, d2, "ean", "upc", "?", "ser", 3, "de", "2nd", "ebook", "adult"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertTag,
+					Args:         []interface{}{mock.AnyArg, "Tag1"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertTag,
+					Args:         []interface{}{mock.AnyArg, "tag2"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_contributors (book_id, position, name, role, sort_name) VALUES ($1, $2, $3, $4, $5)",
+					Args:         []interface{}{mock.AnyArg, 0, "Ann Lee", "editor", "Lee, Ann"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertCustom,
+					Args:         []interface{}{mock.AnyArg, "Box", "3"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertCustom,
+					Args:         []interface{}{mock.AnyArg, "Donor", "Ann"},
 					RowsAffected: 1,
 				},
 			),
 			books: []book.Book{
 				{
-					Header:      book.Header{ID: "", Title: "t1", Author: "a1", Subject: "s1"},
-					Description: "d1", DeweyDecClass: "ddc1", Pages: 2, Publisher: "p1",
+					Header:       book.Header{ID: "", Title: "t1", Author: "a1", Subject: "s1"},
+					Tags:         []string{"tag2", "Tag1"},
+					Contributors: []book.Contributor{{Name: "Ann Lee", Role: "editor"}},
+					Description:  "d1", DeweyDecClass: "ddc1", Pages: 2, Publisher: "p1",
 					PublishDate: d1, AddedDate: d2, EanIsbn13: "ean", UpcIsbn10: "upc",
-					ImageBase64: "?",
+					ImageBase64: "?", Series: "ser", Volume: 3,
+					Language: "de", Edition: "2nd", Format: "ebook", ReadingLevel: "adult",
+					Custom: map[string]string{"Donor": "Ann", "Box": "3"},
 				},
 			},
 			wantOk: true,
@@ -133,12 +190,12 @@ func TestCreateBooks(t *testing.T) {
 			conn: mock.NewTransactionConn(
 				mock.Query{
 					Name:         wantInsert,
-					Args:         []interface{}{mock.AnyArg, "", "", "", "", "", 14, "", time.Time{}, time.Time{}, "", "", ""},
+					Args:         []interface{}{mock.AnyArg, "", "", "", "", "", 14, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
 					RowsAffected: 1,
 				},
 				mock.Query{
 					Name:         wantInsert,
-					Args:         []interface{}{mock.AnyArg, "Title2", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", ""},
+					Args:         []interface{}{mock.AnyArg, "Title2", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
 					RowsAffected: 1,
 				},
 			),
@@ -148,6 +205,26 @@ func TestCreateBooks(t *testing.T) {
 			},
 			wantOk: true,
 		},
+		{
+			name: "happy path: audited",
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         wantInsert,
+					Args:         []interface{}{mock.AnyArg, "Title3", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertAuditEntry,
+					Args:         []interface{}{mock.AnyArg, mock.AnyArg, mock.AnyArg, "Ann", book.ActionCreate, `[{"Field":"title","Before":"","After":"Title3"}]`},
+					RowsAffected: 1,
+				},
+			),
+			books: []book.Book{
+				{Header: book.Header{Title: "Title3"}},
+			},
+			audits: []book.Audit{{Actor: "Ann", Action: book.ActionCreate}},
+			wantOk: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -155,7 +232,7 @@ func TestCreateBooks(t *testing.T) {
 			copy(want, test.books)
 			d := DatabaseHelper(t, test.conn)
 			ctx := context.Background()
-			got, err := d.CreateBooks(ctx, test.books...)
+			got, err := d.CreateBooks(ctx, test.books, test.audits...)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -178,8 +255,160 @@ func TestCreateBooks(t *testing.T) {
 	}
 }
 
+func TestImportBooks(t *testing.T) {
+	wantInsert := "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)"
+	wantUpsert := wantInsert + " ON CONFLICT (id) DO UPDATE SET title = excluded.title , author = excluded.author , subject = excluded.subject , description = excluded.description , dewey_dec_class = excluded.dewey_dec_class , pages = excluded.pages , publisher = excluded.publisher , publish_date = excluded.publish_date , added_date = excluded.added_date , ean_isbn13 = excluded.ean_isbn13 , upc_isbn10 = excluded.upc_isbn10 , image_base64 = excluded.image_base64 , series = excluded.series , volume = excluded.volume , language = excluded.language , edition = excluded.edition , format = excluded.format , reading_level = excluded.reading_level"
+	wantExistingIDs := "SELECT id FROM books WHERE id IN ($1, $2)"
+	books := []book.Book{
+		{Header: book.Header{ID: "id7", Title: "t1"}},
+		{Header: book.Header{ID: "id8", Title: "t2"}},
+	}
+	tests := []struct {
+		name    string
+		conn    mock.Conn
+		upsert  bool
+		books   []book.Book
+		entries []book.AuditEntry
+		wantOk  bool
+		wantErr error
+	}{
+		{
+			name:  "missing id",
+			books: []book.Book{{Header: book.Header{Title: "t1"}}},
+		},
+		{
+			name: "existing ids query error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			books: books,
+		},
+		{
+			name: "collision",
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name: wantExistingIDs,
+					Args: []interface{}{"id7", "id8"},
+					Rows: [][]interface{}{{"id8"}},
+				},
+			),
+			books:   books,
+			wantErr: book.ErrIDExists,
+		},
+		{
+			name:   "happy path: no books",
+			wantOk: true,
+		},
+		{
+			name: "happy path: keeps ids",
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name: wantExistingIDs,
+					Args: []interface{}{"id7", "id8"},
+				},
+				mock.Query{
+					Name:         wantInsert,
+					Args:         []interface{}{"id7", "t1", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsert,
+					Args:         []interface{}{"id8", "t2", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
+					RowsAffected: 1,
+				},
+			),
+			books:  books,
+			wantOk: true,
+		},
+		{
+			name: "happy path: upsert",
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         wantUpsert,
+					Args:         []interface{}{"id7", "t1", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_tags WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 2,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_contributors WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_custom_values WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 0,
+				},
+			),
+			upsert: true,
+			books:  books[:1],
+			wantOk: true,
+		},
+		{
+			name: "happy path: audit entries",
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         wantUpsert,
+					Args:         []interface{}{"id7", "t1", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "", "", 0, "", "", "", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_tags WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_contributors WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_custom_values WHERE book_id = $1",
+					Args:         []interface{}{"id7"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         wantInsertAuditEntry,
+					Args:         []interface{}{"e1", "id7", time.Time{}, "Ann", "import", `[{"Field":"title","Before":"t0","After":"t1"}]`},
+					RowsAffected: 1,
+				},
+			),
+			upsert: true,
+			books:  books[:1],
+			entries: []book.AuditEntry{
+				{ID: "e1", BookID: "id7", Actor: "Ann", Action: book.ActionImport, Changes: []book.FieldChange{{Field: "title", Before: "t0", After: "t1"}}},
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.ImportBooks(ctx, test.upsert, test.books, test.entries...)
+			switch {
+			case !test.wantOk:
+				switch {
+				case err == nil:
+					t.Errorf("wanted error")
+				case test.wantErr != nil && !errors.Is(err, test.wantErr):
+					t.Errorf("wanted error to be %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
 func TestReadBookSubjects(t *testing.T) {
-	wantQuery := "SELECT subject, COUNT(*) FROM books GROUP BY subject ORDER BY subject ASC LIMIT $1 OFFSET $2"
+	wantQuery := "SELECT MIN(TRIM(subject)) AS name, COUNT(DISTINCT id) FROM (SELECT id, subject FROM books UNION ALL SELECT book_id, tag FROM book_tags) AS subjects GROUP BY LOWER(TRIM(subject)) ORDER BY name ASC LIMIT $1 OFFSET $2"
 	tests := []struct {
 		name   string
 		limit  int
@@ -248,16 +477,16 @@ func TestReadBookSubjects(t *testing.T) {
 	}
 }
 
-func TestReadBookHeaders(t *testing.T) {
-	wantQuery := "SELECT id, title, author, subject FROM books WHERE ($1 OR subject = $2) AND ($3 OR title LK $4 OR author LK $4 OR subject LK $4) ORDER BY subject ASC, Title ASC LIMIT $5 OFFSET $6"
+func TestReadBookAuthors(t *testing.T) {
+	sortName := "COALESCE(NULLIF(MAX(sort_name), ''), CASE WHEN MIN(TRIM(name)) LIKE '%,%' OR RTRIM(MIN(TRIM(name)), REPLACE(MIN(TRIM(name)), ' ', '')) = '' THEN MIN(TRIM(name)) ELSE SUBSTR(MIN(TRIM(name)), LENGTH(RTRIM(MIN(TRIM(name)), REPLACE(MIN(TRIM(name)), ' ', ''))) + 1) || ', ' || RTRIM(RTRIM(MIN(TRIM(name)), REPLACE(MIN(TRIM(name)), ' ', ''))) END)"
+	wantQuery := "SELECT MIN(TRIM(name)), " + sortName + ", COUNT(DISTINCT id) FROM (SELECT id, author AS name, '' AS sort_name FROM books UNION ALL SELECT book_id, name, sort_name FROM book_contributors) AS authors WHERE TRIM(name) <> '' GROUP BY LOWER(TRIM(name)) ORDER BY LOWER(" + sortName + ") ASC, MIN(TRIM(name)) ASC LIMIT $1 OFFSET $2"
 	tests := []struct {
 		name   string
-		filter book.Filter
-		offset int
 		limit  int
+		offset int
 		conn   mock.Conn
 		wantOk bool
-		want   []book.Header
+		want   []book.Author
 	}{
 		{
 			name: "db error",
@@ -269,56 +498,41 @@ func TestReadBookHeaders(t *testing.T) {
 		},
 		{
 			name:  "more than limit",
-			limit: 1,
+			limit: 0,
 			conn: mock.NewQueryConn(
 				mock.Query{
 					Name: wantQuery,
-					Args: []interface{}{true, "", true, "%%", 1, 0},
+					Args: []interface{}{0, 0},
 				},
 				[][]interface{}{
-					{"x1", "cats", "a3", "SBJ"},
-					{"a0", "cats", "b2", "SBJ"},
+					{"Ann Lee", "Lee, Ann", 8},
 				}),
-			want: []book.Header{},
-		},
-		{
-			name: "no filter",
-			conn: mock.NewQueryConn(
-				mock.Query{
-					Name: wantQuery,
-					Args: []interface{}{true, "", true, "%%", 0, 0},
-				},
-				[][]interface{}{}),
-			wantOk: true,
-			want:   []book.Header{},
 		},
 		{
-			name:   "happy path with filter",
-			filter: book.Filter{Subject: "SBJ", HeaderPart: "cat"},
-			limit:  5,
-			offset: 100,
+			name:   "happy path",
+			limit:  2,
+			offset: 3,
 			conn: mock.NewQueryConn(
 				mock.Query{
 					Name: wantQuery,
-					Args: []interface{}{false, "SBJ", false, "%cat%", 5, 100},
+					Args: []interface{}{2, 3},
 				},
 				[][]interface{}{
-					{"x1", "cats", "a3", "SBJ"},
-					{"a0", "cats", "b2", "SBJ"},
+					{"Ann Lee", "Lee, Ann", 8},
+					{"Bob Smith", "Smith, Bob", 7},
 				}),
 			wantOk: true,
-			want: []book.Header{
-				{ID: "x1", Title: "cats", Author: "a3", Subject: "SBJ"},
-				{ID: "a0", Title: "cats", Author: "b2", Subject: "SBJ"},
+			want: []book.Author{
+				{Name: "Ann Lee", SortName: "Lee, Ann", Count: 8},
+				{Name: "Bob Smith", SortName: "Smith, Bob", Count: 7},
 			},
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			d := DatabaseHelper(t, test.conn)
-			d.driver.ILike = "LK"
 			ctx := context.Background()
-			got, err := d.ReadBookHeaders(ctx, test.filter, test.limit, test.offset)
+			got, err := d.ReadBookAuthors(ctx, test.limit, test.offset)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -327,22 +541,19 @@ func TestReadBookHeaders(t *testing.T) {
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
 			case !reflect.DeepEqual(test.want, got):
-				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+				t.Errorf("authors not equal: \n wanted: %q \n got:    %q", test.want, got)
 			}
 		})
 	}
 }
 
-func TestReadBook(t *testing.T) {
-	d0 := time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)
-	d1 := time.Date(2022, 12, 6, 0, 0, 0, 0, time.UTC)
-	wantSelect := "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64 FROM books WHERE id = $1"
+func TestReadShelvedBooks(t *testing.T) {
+	wantQuery := "SELECT id, title, author, subject, dewey_dec_class FROM books"
 	tests := []struct {
 		name   string
-		bookID string
 		conn   mock.Conn
 		wantOk bool
-		want   *book.Book
+		want   []book.ShelvedBook
 	}{
 		{
 			name: "db error",
@@ -353,33 +564,19 @@ func TestReadBook(t *testing.T) {
 			},
 		},
 		{
-			name:   "no result",
-			bookID: "b52",
-			conn: mock.NewQueryConn(
-				mock.Query{
-					Name: wantSelect,
-					Args: []interface{}{"b52"},
-				},
-				[][]interface{}{},
-			),
-		},
-		{
-			name:   "happy path",
-			bookID: "b52",
+			name: "happy path",
 			conn: mock.NewQueryConn(
 				mock.Query{
-					Name: wantSelect,
-					Args: []interface{}{"b52"},
+					Name: wantQuery,
 				},
 				[][]interface{}{
-					{"id0", "t2", "a3", "s4", "d5", "ddc6", 7, "p8", d0, d1, "EAN", "UPC", "IMG"},
-				},
-			),
+					{"id1", "title1", "author1", "subject1", "599.8 DOE"},
+					{"id2", "title2", "author2", "subject2", ""},
+				}),
 			wantOk: true,
-			want: &book.Book{
-				Header:      book.Header{ID: "id0", Title: "t2", Author: "a3", Subject: "s4"},
-				Description: "d5", DeweyDecClass: "ddc6", Pages: 7, Publisher: "p8",
-				PublishDate: d0, AddedDate: d1, EanIsbn13: "EAN", UpcIsbn10: "UPC", ImageBase64: "IMG",
+			want: []book.ShelvedBook{
+				{Header: book.Header{ID: "id1", Title: "title1", Author: "author1", Subject: "subject1"}, DeweyDecClass: "599.8 DOE"},
+				{Header: book.Header{ID: "id2", Title: "title2", Author: "author2", Subject: "subject2"}},
 			},
 		},
 	}
@@ -387,7 +584,7 @@ func TestReadBook(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			d := DatabaseHelper(t, test.conn)
 			ctx := context.Background()
-			got, err := d.ReadBook(ctx, test.bookID)
+			got, err := d.ReadShelvedBooks(ctx)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -402,68 +599,44 @@ func TestReadBook(t *testing.T) {
 	}
 }
 
-func TestUpdateBook(t *testing.T) {
-	d1 := time.Date(2001, 6, 9, 0, 0, 0, 0, time.UTC)
-	d2 := time.Date(2012, 12, 31, 0, 0, 0, 0, time.UTC)
-	const (
-		wantUpdateBasic = "UPDATE books SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11 WHERE id = $12"
-		wantUpdateImage = "UPDATE books SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11, image_base64 = $12 WHERE id = $13"
-	)
+func TestReadSeriesBooks(t *testing.T) {
+	wantQuery := "SELECT id, title, author, subject, series, volume FROM books WHERE TRIM(series) <> ''"
 	tests := []struct {
-		name        string
-		b           book.Book
-		updateImage bool
-		conn        mock.Conn
-		wantOk      bool
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.SeriesBook
 	}{
 		{
 			name: "db error",
 			conn: mock.Conn{
-				BeginFunc: func() (driver.Tx, error) {
+				PrepareFunc: func(query string) (driver.Stmt, error) {
 					return nil, fmt.Errorf("db error")
 				},
 			},
 		},
 		{
 			name: "happy path",
-			b: book.Book{
-				Header:      book.Header{ID: "b81", Title: "t1", Author: "a1", Subject: "s1"},
-				Description: "d1", DeweyDecClass: "ddc1", Pages: 9, Publisher: "p1",
-				PublishDate: d1, AddedDate: d2, EanIsbn13: "ean", UpcIsbn10: "upc",
-			},
-			conn: mock.NewTransactionConn(
+			conn: mock.NewQueryConn(
 				mock.Query{
-					Name:         wantUpdateBasic,
-					Args:         []interface{}{"t1", "a1", "s1", "d1", "ddc1", int64(9), "p1", d1, d2, "ean", "upc", "b81"},
-					RowsAffected: 1,
+					Name: wantQuery,
 				},
-			),
+				[][]interface{}{
+					{"id1", "title1", "author1", "subject1", "Dune", 2},
+					{"id2", "title2", "author2", "subject2", "Dune", 0},
+				}),
 			wantOk: true,
-		},
-		{
-			name: "happy path - updateImage",
-			b: book.Book{
-				Header:      book.Header{ID: "b82", Title: "t2", Author: "a2", Subject: "s2"},
-				Description: "d2", DeweyDecClass: "ddc2", Pages: 4, Publisher: "p2",
-				PublishDate: d2, AddedDate: d1, EanIsbn13: "ean", UpcIsbn10: "upc",
-				ImageBase64: "333",
+			want: []book.SeriesBook{
+				{Header: book.Header{ID: "id1", Title: "title1", Author: "author1", Subject: "subject1"}, Series: "Dune", Volume: 2},
+				{Header: book.Header{ID: "id2", Title: "title2", Author: "author2", Subject: "subject2"}, Series: "Dune"},
 			},
-			updateImage: true,
-			conn: mock.NewTransactionConn(
-				mock.Query{
-					Name:         wantUpdateImage,
-					Args:         []interface{}{"t2", "a2", "s2", "d2", "ddc2", int64(4), "p2", d2, d1, "ean", "upc", "333", "b82"},
-					RowsAffected: 1,
-				},
-			),
-			wantOk: true,
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			d := DatabaseHelper(t, test.conn)
 			ctx := context.Background()
-			err := d.UpdateBook(ctx, test.b, test.updateImage)
+			got, err := d.ReadSeriesBooks(ctx)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -471,44 +644,972 @@ func TestUpdateBook(t *testing.T) {
 				}
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
 			}
 		})
 	}
 }
 
-func TestDeleteBook(t *testing.T) {
+func TestReadBookHeaders(t *testing.T) {
+	wantQuery := "SELECT id, title, author, subject FROM books WHERE ($1 OR LOWER(TRIM(subject)) = $2 OR id IN (SELECT book_id FROM book_tags WHERE LOWER(TRIM(tag)) = $2)) AND ($3 OR LOWER(TRIM(author)) = $4 OR id IN (SELECT book_id FROM book_contributors WHERE LOWER(TRIM(name)) = $4)) AND ($5 OR LOWER(TRIM(language)) = $6) AND ($7 OR LOWER(TRIM(format)) = $8) AND ($9 OR LOWER(TRIM(reading_level)) = $10) AND ($11 OR title LK $12 OR author LK $12 OR subject LK $12 OR id IN (SELECT book_id FROM book_tags WHERE tag LK $12) OR id IN (SELECT book_id FROM book_contributors WHERE name LK $12)) ORDER BY subject ASC, Title ASC LIMIT $13 OFFSET $14"
 	tests := []struct {
 		name   string
-		bookID string
+		filter book.Filter
+		offset int
+		limit  int
 		conn   mock.Conn
 		wantOk bool
+		want   []book.Header
 	}{
 		{
 			name: "db error",
 			conn: mock.Conn{
-				BeginFunc: func() (driver.Tx, error) {
+				PrepareFunc: func(query string) (driver.Stmt, error) {
 					return nil, fmt.Errorf("db error")
 				},
 			},
 		},
 		{
-			name:   "happy path",
-			bookID: "113=zoom",
-			conn: mock.NewTransactionConn(
+			name:  "more than limit",
+			limit: 1,
+			conn: mock.NewQueryConn(
 				mock.Query{
-					Name:         "DELETE FROM books WHERE id = $1",
-					Args:         []interface{}{"113=zoom"},
-					RowsAffected: 1,
+					Name: wantQuery,
+					Args: []interface{}{true, "", true, "", true, "", true, "", true, "", true, "%%", 1, 0},
 				},
-			),
+				[][]interface{}{
+					{"x1", "cats", "a3", "SBJ"},
+					{"a0", "cats", "b2", "SBJ"},
+				}),
+			want: []book.Header{},
+		},
+		{
+			name: "no filter",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+					Args: []interface{}{true, "", true, "", true, "", true, "", true, "", true, "%%", 0, 0},
+				},
+				[][]interface{}{}),
+			wantOk: true,
+			want:   []book.Header{},
+		},
+		{
+			name:   "happy path with filter",
+			filter: book.Filter{Subject: "SBJ", Author: " B2", Language: "EN", Format: "dvd", HeaderPart: "cat"},
+			limit:  5,
+			offset: 100,
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+					Args: []interface{}{false, "sbj", false, "b2", false, "en", false, "dvd", true, "", false, "%cat%", 5, 100},
+				},
+				[][]interface{}{
+					{"x1", "cats", "a3", "SBJ"},
+					{"a0", "cats", "b2", "SBJ"},
+				}),
+			wantOk: true,
+			want: []book.Header{
+				{ID: "x1", Title: "cats", Author: "a3", Subject: "SBJ"},
+				{ID: "a0", Title: "cats", Author: "b2", Subject: "SBJ"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			d.driver.ILike = "LK"
+			ctx := context.Background()
+			got, err := d.ReadBookHeaders(ctx, test.filter, test.limit, test.offset)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadBook(t *testing.T) {
+	d0 := time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)
+	d1 := time.Date(2022, 12, 6, 0, 0, 0, 0, time.UTC)
+	wantSelect := "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level, (SELECT AGG(tag, ';' ORDER BY tag) FROM book_tags WHERE book_id = books.id) AS tags, (SELECT AGG(name || '|' || role || '|' || sort_name, ';' ORDER BY position) FROM book_contributors WHERE book_id = books.id) AS contributors, (SELECT OBJ(name, value) FROM book_custom_values WHERE book_id = books.id) AS custom FROM books WHERE id = $1"
+	tests := []struct {
+		name   string
+		bookID string
+		conn   mock.Conn
+		wantOk bool
+		want   *book.Book
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name:   "no result",
+			bookID: "b52",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantSelect,
+					Args: []interface{}{"b52"},
+				},
+				[][]interface{}{},
+			),
+		},
+		{
+			name:   "happy path",
+			bookID: "b52",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantSelect,
+					Args: []interface{}{"b52"},
+				},
+				[][]interface{}{
+					{"id0", "t2", "a3", "s4", "d5", "ddc6", 7, "p8", d0, d1, "EAN", "UPC", "IMG", "ser", 4, "fr", "1st", "dvd", "children", "t9;T10", "c11|editor|s11;c12|author|", `{"Box": "3", "Donor": " "}`},
+				},
+			),
+			wantOk: true,
+			want: &book.Book{
+				Header:       book.Header{ID: "id0", Title: "t2", Author: "a3", Subject: "s4"},
+				Tags:         []string{"T10", "t9"},
+				Contributors: []book.Contributor{{Name: "c11", Role: "editor", SortName: "s11"}, {Name: "c12", Role: "author", SortName: "c12"}},
+				Description:  "d5", DeweyDecClass: "ddc6", Pages: 7, Publisher: "p8",
+				PublishDate: d0, AddedDate: d1, EanIsbn13: "EAN", UpcIsbn10: "UPC", ImageBase64: "IMG",
+				Series: "ser", Volume: 4, Language: "fr", Edition: "1st", Format: "dvd", ReadingLevel: "children",
+				Custom: map[string]string{"Box": "3"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			d.driver.StringAgg = "AGG"
+			d.driver.JSONObjectAgg = "OBJ"
+			got, err := d.ReadBook(ctx, test.bookID)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadBookMetadata(t *testing.T) {
+	wantSelect := "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, '' AS image_base64, series, volume, language, edition, format, reading_level, (SELECT AGG(tag, ';' ORDER BY tag) FROM book_tags WHERE book_id = books.id) AS tags, (SELECT AGG(name || '|' || role || '|' || sort_name, ';' ORDER BY position) FROM book_contributors WHERE book_id = books.id) AS contributors, (SELECT OBJ(name, value) FROM book_custom_values WHERE book_id = books.id) AS custom FROM books WHERE id = $1"
+	conn := mock.NewQueryConn(
+		mock.Query{
+			Name: wantSelect,
+			Args: []interface{}{"b52"},
+		},
+		[][]interface{}{
+			{"b52", "t2", "a3", "s4", "", "", 7, "p8", time.Time{}, time.Time{}, "", "", "", "", 0, "en", "", "print", "", nil, nil, nil},
+		},
+	)
+	d := DatabaseHelper(t, conn)
+	d.driver.StringAgg = "AGG"
+	d.driver.JSONObjectAgg = "OBJ"
+	ctx := context.Background()
+	want := &book.Book{Header: book.Header{ID: "b52", Title: "t2", Author: "a3", Subject: "s4"}, Pages: 7, Publisher: "p8", Language: "en", Format: "print"}
+	got, err := d.ReadBookMetadata(ctx, "b52")
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case !reflect.DeepEqual(want, got):
+		t.Errorf("books not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
+func TestReadBookByISBN(t *testing.T) {
+	wantSelect := "SELECT id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level, (SELECT AGG(tag, ';' ORDER BY tag) FROM book_tags WHERE book_id = books.id) AS tags, (SELECT AGG(name || '|' || role || '|' || sort_name, ';' ORDER BY position) FROM book_contributors WHERE book_id = books.id) AS contributors, (SELECT OBJ(name, value) FROM book_custom_values WHERE book_id = books.id) AS custom FROM books WHERE ean_isbn13 = $1 OR upc_isbn10 = $1 LIMIT 1"
+	d0 := time.Date(1999, 12, 6, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		isbn         string
+		conn         mock.Conn
+		wantOk       bool
+		wantNotFound bool
+		want         *book.Book
+	}{
+		{
+			name:         "empty isbn",
+			wantNotFound: true,
+		},
+		{
+			name: "db error",
+			isbn: "9780306406157",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "not found",
+			isbn: "9780306406157",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantSelect,
+					Args: []interface{}{"9780306406157"},
+				},
+				[][]interface{}{},
+			),
+			wantNotFound: true,
+		},
+		{
+			name: "happy path",
+			isbn: "0306406152",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantSelect,
+					Args: []interface{}{"0306406152"},
+				},
+				[][]interface{}{
+					{"id0", "t2", "a3", "s4", "d5", "ddc6", 7, "p8", d0, d0, "9780306406157", "0306406152", "IMG", "", 0, "en", "", "print", "", nil, nil, nil},
+				},
+			),
+			wantOk: true,
+			want: &book.Book{
+				Header:      book.Header{ID: "id0", Title: "t2", Author: "a3", Subject: "s4"},
+				Description: "d5", DeweyDecClass: "ddc6", Pages: 7, Publisher: "p8",
+				PublishDate: d0, AddedDate: d0, EanIsbn13: "9780306406157", UpcIsbn10: "0306406152", ImageBase64: "IMG",
+				Language: "en", Format: "print",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			d.driver.StringAgg = "AGG"
+			d.driver.JSONObjectAgg = "OBJ"
+			got, err := d.ReadBookByISBN(ctx, test.isbn)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if want, got := test.wantNotFound, errors.Is(err, book.ErrNotFound); want != got {
+					t.Errorf("wanted ErrNotFound: %v, got error: %v", want, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("books not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateBook(t *testing.T) {
+	d1 := time.Date(2001, 6, 9, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2012, 12, 31, 0, 0, 0, 0, time.UTC)
+	const (
+		wantUpdateBasic        = "UPDATE books SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11, series = $12, volume = $13, language = $14, edition = $15, format = $16, reading_level = $17 WHERE id = $18"
+		wantUpdateImage        = "UPDATE books SET title = $1, author = $2, subject = $3, description = $4, dewey_dec_class = $5, pages = $6, publisher = $7, publish_date = $8, added_date = $9, ean_isbn13 = $10, upc_isbn10 = $11, series = $12, volume = $13, language = $14, edition = $15, format = $16, reading_level = $17, image_base64 = $18 WHERE id = $19"
+		wantDeleteTags         = "DELETE FROM book_tags WHERE book_id = $1"
+		wantDeleteContributors = "DELETE FROM book_contributors WHERE book_id = $1"
+		wantDeleteCustom       = "DELETE FROM book_custom_values WHERE book_id = $1"
+	)
+	selectBefore := func() mock.Query { // the book before it is updated, the same as the update except for its title
+		q := mock.NewAnyQuery(0)
+		q.Rows = [][]interface{}{
+			{"b82", "t1", "a2", "s2", "d2", "ddc2", 4, "p2", d2, d1, "ean", "upc", "", "ser", 2, "es", "3rd", "hardcover", "middle grade", nil, nil, nil},
+		}
+		return *q
+	}
+	tests := []struct {
+		name        string
+		b           book.Book
+		updateImage bool
+		audits      []book.Audit
+		conn        mock.Conn
+		wantOk      bool
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				BeginFunc: func() (driver.Tx, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			b: book.Book{
+				Header:       book.Header{ID: "b81", Title: "t1", Author: "a1", Subject: "s1"},
+				Tags:         []string{"t1", "S1"},
+				Contributors: []book.Contributor{{Name: "c1"}},
+				Custom:       map[string]string{"Donor": "Ann"},
+				Description:  "d1", DeweyDecClass: "ddc1", Pages: 9, Publisher: "p1",
+				PublishDate: d1, AddedDate: d2, EanIsbn13: "ean", UpcIsbn10: "upc",
+			},
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         wantUpdateBasic,
+					Args:         []interface{}{"t1", "a1", "s1", "d1", "ddc1", int64(9), "p1", d1, d2, "ean", "upc", "", int64(0), "", "", "", "", "b81"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantDeleteTags,
+					Args:         []interface{}{"b81"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_tags (book_id, tag) VALUES ($1, $2)",
+					Args:         []interface{}{"b81", "t1"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantDeleteContributors,
+					Args:         []interface{}{"b81"},
+					RowsAffected: 2,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_contributors (book_id, position, name, role, sort_name) VALUES ($1, $2, $3, $4, $5)",
+					Args:         []interface{}{"b81", int64(0), "c1", "author", "c1"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantDeleteCustom,
+					Args:         []interface{}{"b81"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_custom_values (book_id, name, value) VALUES ($1, $2, $3)",
+					Args:         []interface{}{"b81", "Donor", "Ann"},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+		{
+			name:   "unknown audited book",
+			b:      book.Book{Header: book.Header{ID: "b83"}},
+			audits: []book.Audit{{Actor: "Ann", Action: book.ActionUpdate}},
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name: "UPDATE books SET id = id WHERE id = $1",
+					Args: []interface{}{"b83"},
+				},
+				*mock.NewAnyQuery(0),
+			),
+		},
+		{
+			name: "happy path - updateImage",
+			b: book.Book{
+				Header:      book.Header{ID: "b82", Title: "t2", Author: "a2", Subject: "s2"},
+				Description: "d2", DeweyDecClass: "ddc2", Pages: 4, Publisher: "p2",
+				PublishDate: d2, AddedDate: d1, EanIsbn13: "ean", UpcIsbn10: "upc",
+				ImageBase64: "333", Series: "ser", Volume: 2,
+				Language: "es", Edition: "3rd", Format: "hardcover", ReadingLevel: "middle grade",
+			},
+			updateImage: true,
+			audits:      []book.Audit{{Actor: "Ann", Action: book.ActionUpdate}},
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         "UPDATE books SET id = id WHERE id = $1",
+					Args:         []interface{}{"b82"},
+					RowsAffected: 1,
+				},
+				selectBefore(),
+				mock.Query{
+					Name:         wantUpdateImage,
+					Args:         []interface{}{"t2", "a2", "s2", "d2", "ddc2", int64(4), "p2", d2, d1, "ean", "upc", "ser", int64(2), "es", "3rd", "hardcover", "middle grade", "333", "b82"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantDeleteTags,
+					Args:         []interface{}{"b82"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantDeleteContributors,
+					Args:         []interface{}{"b82"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         wantDeleteCustom,
+					Args:         []interface{}{"b82"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         wantInsertAuditEntry,
+					Args:         []interface{}{mock.AnyArg, "b82", mock.AnyArg, "Ann", "update", `[{"Field":"title","Before":"t1","After":"t2"}]`},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.UpdateBook(ctx, test.b, test.updateImage, test.audits...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteBook(t *testing.T) {
+	d0 := time.Date(2022, 12, 6, 0, 0, 0, 0, time.UTC)
+	selectBook := func() mock.Query {
+		q := mock.NewAnyQuery(0)
+		q.Rows = [][]interface{}{
+			{"113=zoom", "t2", "a3", "s4", "", "", 0, "", d0, d0, "", "", "IMG", "", 0, "en", "", "print", "", "t9", nil, nil},
+		}
+		return *q
+	}
+	wantTrashedBook := `{"ID":"113=zoom","Title":"t2","Author":"a3","Subject":"s4","Tags":["t9"],"Contributors":null,"Series":"","Volume":0,"Language":"en","Edition":"","Format":"print","ReadingLevel":"","Custom":null,"Description":"","DeweyDecClass":"","Pages":0,"Publisher":"","PublishDate":"2022-12-06T00:00:00Z","AddedDate":"2022-12-06T00:00:00Z","EanIsbn13":"","UpcIsbn10":"","ImageBase64":"IMG"}`
+	tests := []struct {
+		name    string
+		bookID  string
+		entries []book.AuditEntry
+		conn    mock.Conn
+		wantOk  bool
+	}{
+		{
+			name: "read book error",
+			conn: mock.Conn{
+				BeginFunc: func() (driver.Tx, error) {
+					return mock.Tx{
+						RollbackFunc: func() error {
+							return nil
+						},
+					}, nil
+				},
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name:   "book not found",
+			bookID: "113=zoom",
+			conn: mock.NewTransactionConn(
+				*mock.NewAnyQuery(0),
+			),
+		},
+		{
+			name:   "db error",
+			bookID: "113=zoom",
+			conn: mock.NewTransactionConn(
+				selectBook(),
+				mock.Query{
+					Name:         "DELETE FROM book_tags WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_contributors WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_custom_values WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM books WHERE id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 0,
+				},
+			),
+		},
+		{
+			name:   "happy path",
+			bookID: "113=zoom",
+			conn: mock.NewTransactionConn(
+				selectBook(),
+				mock.Query{
+					Name:         "DELETE FROM book_tags WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_contributors WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_custom_values WHERE book_id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 0,
+				},
+				mock.Query{
+					Name:         "DELETE FROM books WHERE id = $1",
+					Args:         []interface{}{"113=zoom"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_trash (id, title, author, subject, deleted_date, book) VALUES ($1, $2, $3, $4, $5, $6)",
+					Args:         []interface{}{"113=zoom", "t2", "a3", "s4", mock.AnyArg, wantTrashedBook},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+		{
+			name:    "audit log error",
+			bookID:  "113=zoom",
+			entries: []book.AuditEntry{{ID: "e2", BookID: "113=zoom", Action: book.ActionDelete}},
+			conn: mock.NewTransactionConn(
+				selectBook(),
+				*mock.NewAnyQuery(1),
+				*mock.NewAnyQuery(0),
+				*mock.NewAnyQuery(0),
+				*mock.NewAnyQuery(1),
+				*mock.NewAnyQuery(1),
+				mock.Query{
+					Name:         wantInsertAuditEntry,
+					Args:         []interface{}{"e2", "113=zoom", time.Time{}, "", "delete", "null"},
+					RowsAffected: 0,
+				},
+			),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.DeleteBook(ctx, test.bookID, test.entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadTrash(t *testing.T) {
+	wantQuery := "SELECT id, title, author, subject, deleted_date FROM book_trash ORDER BY deleted_date DESC"
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.TrashedBook
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+				},
+				[][]interface{}{
+					{"b2", "t2", "a2", "s2", t1.Add(time.Hour)},
+					{"b1", "t1", "a1", "s1", t1},
+				}),
+			wantOk: true,
+			want: []book.TrashedBook{
+				{Header: book.Header{ID: "b2", Title: "t2", Author: "a2", Subject: "s2"}, DeletedDate: t1.Add(time.Hour)},
+				{Header: book.Header{ID: "b1", Title: "t1", Author: "a1", Subject: "s1"}, DeletedDate: t1},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			got, err := d.ReadTrash(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("trash not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadDeletedBook(t *testing.T) {
+	wantSelect := mock.Query{
+		Name: "SELECT deleted_date, book FROM book_trash WHERE id = $1",
+		Args: []interface{}{"b1"},
+	}
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name    string
+		conn    mock.Conn
+		wantErr error
+		wantOk  bool
+		want    *book.DeletedBook
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name:    "not in trash",
+			conn:    mock.NewQueryConn(wantSelect, nil),
+			wantErr: book.ErrNotFound,
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(wantSelect, [][]interface{}{
+				{t1, `{"ID":"b1","Title":"t1","ImageBase64":"IMG"}`},
+			}),
+			wantOk: true,
+			want:   &book.DeletedBook{Book: book.Book{Header: book.Header{ID: "b1", Title: "t1"}, ImageBase64: "IMG"}, DeletedDate: t1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			got, err := d.ReadDeletedBook(ctx, "b1")
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+					t.Errorf("wanted error to wrap %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("deleted books not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestImportTrash(t *testing.T) {
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	books := []book.DeletedBook{
+		{Book: book.Book{Header: book.Header{ID: "b1", Title: "t1"}}, DeletedDate: t1},
+		{Book: book.Book{Header: book.Header{ID: "b2", Title: "t2"}}, DeletedDate: t1},
+	}
+	wantInsert := "INSERT INTO book_trash (id, title, author, subject, deleted_date, book) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO NOTHING"
+	conn := mock.NewTransactionConn(
+		mock.Query{
+			Name:         wantInsert,
+			Args:         []interface{}{"b1", "t1", "", "", t1, mock.AnyArg},
+			RowsAffected: 0, // already in the trash
+		},
+		mock.Query{
+			Name:         wantInsert,
+			Args:         []interface{}{"b2", "t2", "", "", t1, mock.AnyArg},
+			RowsAffected: 1,
+		},
+	)
+	d := DatabaseHelper(t, conn)
+	ctx := context.Background()
+	if err := d.ImportTrash(ctx, books...); err != nil {
+		t.Errorf("unwanted error: %v", err)
+	}
+}
+
+func TestRestoreBook(t *testing.T) {
+	wantSelect := mock.Query{
+		Name: "SELECT book FROM book_trash WHERE id = $1",
+		Args: []interface{}{"b1"},
+	}
+	wantExistingIDs := mock.Query{
+		Name: "SELECT id FROM books WHERE id IN ($1)",
+		Args: []interface{}{"b1"},
+	}
+	trashedBook := `{"ID":"b1","Title":"t1","Tags":["t9"],"Language":"en","Format":"print","ImageBase64":"IMG"}`
+	withRows := func(q mock.Query, rows ...[]interface{}) mock.Query {
+		q.Rows = rows
+		return q
+	}
+	// withRollback allows the transaction to be rolled back after an error.
+	withRollback := func(conn mock.Conn) mock.Conn {
+		conn.BeginFunc = func() (driver.Tx, error) {
+			return mock.Tx{
+				RollbackFunc: func() error {
+					return nil
+				},
+			}, nil
+		}
+		return conn
+	}
+	tests := []struct {
+		name    string
+		entries []book.AuditEntry
+		conn    mock.Conn
+		wantErr error
+		wantOk  bool
+	}{
+		{
+			name: "db error",
+			conn: withRollback(mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			}),
+		},
+		{
+			name:    "not in trash",
+			conn:    withRollback(mock.NewTransactionConn(wantSelect)),
+			wantErr: book.ErrNotFound,
+		},
+		{
+			name: "bad json",
+			conn: withRollback(mock.NewTransactionConn(
+				withRows(wantSelect, []interface{}{"{bad json"}),
+			)),
+		},
+		{
+			name: "existing id",
+			conn: withRollback(mock.NewTransactionConn(
+				withRows(wantSelect, []interface{}{trashedBook}),
+				withRows(wantExistingIDs, []interface{}{"b1"}),
+			)),
+			wantErr: book.ErrIDExists,
+		},
+		{
+			name:    "happy path",
+			entries: []book.AuditEntry{{ID: "e1", BookID: "b1", Action: book.ActionRestore}},
+			conn: mock.NewTransactionConn(
+				withRows(wantSelect, []interface{}{[]byte(trashedBook)}),
+				wantExistingIDs,
+				mock.Query{
+					Name:         "INSERT INTO books (id, title, author, subject, description, dewey_dec_class, pages, publisher, publish_date, added_date, ean_isbn13, upc_isbn10, image_base64, series, volume, language, edition, format, reading_level) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)",
+					Args:         []interface{}{"b1", "t1", "", "", "", "", 0, "", time.Time{}, time.Time{}, "", "", "IMG", "", 0, "en", "", "print", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "INSERT INTO book_tags (book_id, tag) VALUES ($1, $2)",
+					Args:         []interface{}{"b1", "t9"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "DELETE FROM book_trash WHERE id = $1",
+					Args:         []interface{}{"b1"},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         wantInsertAuditEntry,
+					Args:         []interface{}{"e1", "b1", time.Time{}, "", "restore", "null"},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.RestoreBook(ctx, "b1", test.entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+				if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+					t.Errorf("wanted error to wrap %v, got %v", test.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPurgeBooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    []string
+		conn   mock.Conn
+		wantOk bool
+	}{
+		{
+			name:   "no ids",
+			wantOk: true,
+		},
+		{
+			name: "db error",
+			ids:  []string{"b1"},
+			conn: mock.Conn{
+				BeginFunc: func() (driver.Tx, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			ids:  []string{"b1", "b2"},
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         "DELETE FROM book_trash WHERE id IN ($1, $2)",
+					Args:         []interface{}{"b1", "b2"},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.PurgeBooks(ctx, test.ids...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadBookHistory(t *testing.T) {
+	wantQuery := "SELECT id, book_id, time, actor, action, changes FROM book_audit_log WHERE book_id = $1 ORDER BY time DESC"
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	tests := []struct {
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.AuditEntry
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "bad changes",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+					Args: []interface{}{"b9"},
+				},
+				[][]interface{}{
+					{"e1", "b9", t1, "Ann", "update", "{bad json"},
+				}),
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+					Args: []interface{}{"b9"},
+				},
+				[][]interface{}{
+					{"e2", "b9", t2, "Bob", "revert", `[{"Field":"custom:Box","Before":"4","After":"3"}]`},
+					{"e1", "b9", t1, "Ann", "update", "[]"},
+				}),
+			wantOk: true,
+			want: []book.AuditEntry{
+				{ID: "e2", BookID: "b9", Time: t2, Actor: "Bob", Action: book.ActionRevert, Changes: []book.FieldChange{{Field: "custom:Box", Before: "4", After: "3"}}},
+				{ID: "e1", BookID: "b9", Time: t1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			got, err := d.ReadBookHistory(ctx, "b9")
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("history not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadAuditLog(t *testing.T) {
+	wantQuery := "SELECT id, book_id, time, actor, action, changes FROM book_audit_log ORDER BY time ASC"
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.AuditEntry
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+				},
+				[][]interface{}{
+					{"e1", "b9", t1, "Ann", "update", "[]"},
+					{"e2", "b8", t1.Add(time.Hour), "system", "restore", `[{"Field":"title","Before":"a","After":"b"}]`},
+				}),
 			wantOk: true,
+			want: []book.AuditEntry{
+				{ID: "e1", BookID: "b9", Time: t1, Actor: "Ann", Action: book.ActionUpdate, Changes: []book.FieldChange{}},
+				{ID: "e2", BookID: "b8", Time: t1.Add(time.Hour), Actor: "system", Action: book.ActionRestore, Changes: []book.FieldChange{{Field: "title", Before: "a", After: "b"}}},
+			},
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			d := DatabaseHelper(t, test.conn)
 			ctx := context.Background()
-			err := d.DeleteBook(ctx, test.bookID)
+			got, err := d.ReadAuditLog(ctx)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -516,11 +1617,39 @@ func TestDeleteBook(t *testing.T) {
 				}
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("audit logs not equal: \n wanted: %v \n got:    %v", test.want, got)
 			}
 		})
 	}
 }
 
+func TestImportAuditLog(t *testing.T) {
+	t1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	entries := []book.AuditEntry{
+		{ID: "e1", BookID: "b9", Time: t1, Actor: "Ann", Action: book.ActionUpdate},
+		{ID: "e2", BookID: "b9", Time: t1, Actor: "Bob", Action: book.ActionDelete},
+	}
+	wantInsert := wantInsertAuditEntry + " ON CONFLICT (id) DO NOTHING"
+	conn := mock.NewTransactionConn(
+		mock.Query{
+			Name:         wantInsert,
+			Args:         []interface{}{"e1", "b9", t1, "Ann", "update", "null"},
+			RowsAffected: 0, // already imported
+		},
+		mock.Query{
+			Name:         wantInsert,
+			Args:         []interface{}{"e2", "b9", t1, "Bob", "delete", "null"},
+			RowsAffected: 1,
+		},
+	)
+	d := DatabaseHelper(t, conn)
+	ctx := context.Background()
+	if err := d.ImportAuditLog(ctx, entries...); err != nil {
+		t.Errorf("unwanted error: %v", err)
+	}
+}
+
 func TestReadAdminPassword(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -585,6 +1714,252 @@ func TestReadAdminPassword(t *testing.T) {
 	}
 }
 
+func TestReadSubjectAliases(t *testing.T) {
+	wantQuery := "SELECT name, canonical FROM subject_aliases ORDER BY name ASC"
+	tests := []struct {
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.SubjectAlias
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+				},
+				[][]interface{}{
+					{"Novels", "Fiction"},
+					{"sci-fi", "Science Fiction"},
+				}),
+			wantOk: true,
+			want: []book.SubjectAlias{
+				{Name: "Novels", Canonical: "Fiction"},
+				{Name: "sci-fi", Canonical: "Science Fiction"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			got, err := d.ReadSubjectAliases(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("aliases not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReadCustomFields(t *testing.T) {
+	wantQuery := "SELECT name, type, options FROM custom_fields ORDER BY position ASC"
+	tests := []struct {
+		name   string
+		conn   mock.Conn
+		wantOk bool
+		want   []book.CustomField
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				PrepareFunc: func(query string) (driver.Stmt, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			conn: mock.NewQueryConn(
+				mock.Query{
+					Name: wantQuery,
+				},
+				[][]interface{}{
+					{"Donor", "text", ""},
+					{"Shelf", "enum", "Top; Bottom"},
+				}),
+			wantOk: true,
+			want: []book.CustomField{
+				{Name: "Donor", Type: "text"},
+				{Name: "Shelf", Type: "enum", Options: []string{"Top", "Bottom"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			got, err := d.ReadCustomFields(ctx)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("fields not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateCustomFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []book.CustomField
+		conn   mock.Conn
+		wantOk bool
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				BeginFunc: func() (driver.Tx, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "happy path",
+			fields: []book.CustomField{
+				{Name: "Donor", Type: "text"},
+				{Name: "Shelf", Type: "enum", Options: []string{"Top", "Bottom"}},
+			},
+			conn: mock.NewTransactionConn(
+				mock.Query{
+					Name:         "DELETE FROM custom_fields",
+					RowsAffected: 3,
+				},
+				mock.Query{
+					Name:         "INSERT INTO custom_fields (position, name, type, options) VALUES ($1, $2, $3, $4)",
+					Args:         []interface{}{int64(0), "Donor", "text", ""},
+					RowsAffected: 1,
+				},
+				mock.Query{
+					Name:         "INSERT INTO custom_fields (position, name, type, options) VALUES ($1, $2, $3, $4)",
+					Args:         []interface{}{int64(1), "Shelf", "enum", "Top; Bottom"},
+					RowsAffected: 1,
+				},
+			),
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.UpdateCustomFields(ctx, test.fields...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeSubjects(t *testing.T) {
+	keyArgs := []interface{}{"Science Fiction", "science fiction", "sci-fi"}
+	updateBooks := mock.Query{
+		Name:         "UPDATE books SET subject = $1 WHERE LOWER(TRIM(subject)) IN ($2, $3)",
+		Args:         keyArgs,
+		RowsAffected: 4,
+	}
+	insertTags := mock.Query{
+		Name:         "INSERT INTO book_tags (book_id, tag) SELECT DISTINCT book_id, $1 FROM book_tags WHERE LOWER(TRIM(tag)) IN ($2, $3) ON CONFLICT DO NOTHING",
+		Args:         keyArgs,
+		RowsAffected: 2,
+	}
+	deleteTags := mock.Query{
+		Name:         "DELETE FROM book_tags WHERE tag <> $1 AND LOWER(TRIM(tag)) IN ($2, $3)",
+		Args:         keyArgs,
+		RowsAffected: 2,
+	}
+	updateAliases := mock.Query{
+		Name: "UPDATE subject_aliases SET canonical = $1 WHERE LOWER(TRIM(canonical)) IN ($2, $3)",
+		Args: keyArgs,
+	}
+	deleteAlias := mock.Query{
+		Name: "DELETE FROM subject_aliases WHERE name_key = $1",
+		Args: []interface{}{"science fiction"},
+	}
+	insertAlias := func(rowsAffected int64) mock.Query {
+		return mock.Query{
+			Name:         "INSERT INTO subject_aliases (name_key, name, canonical) VALUES ($1, $2, $3) ON CONFLICT (name_key) DO UPDATE SET name = excluded.name , canonical = excluded.canonical",
+			Args:         []interface{}{"sci-fi", "Sci-Fi", "Science Fiction"},
+			RowsAffected: rowsAffected,
+		}
+	}
+	entry := book.AuditEntry{ID: "e1", BookID: "b1", Actor: "Ann", Action: book.ActionMerge, Changes: []book.FieldChange{{Field: "subject", Before: "sci-fi", After: "Science Fiction"}}}
+	insertEntry := mock.Query{
+		Name:         wantInsertAuditEntry,
+		Args:         []interface{}{"e1", "b1", time.Time{}, "Ann", "merge", `[{"Field":"subject","Before":"sci-fi","After":"Science Fiction"}]`},
+		RowsAffected: 1,
+	}
+	tests := []struct {
+		name    string
+		conn    mock.Conn
+		entries []book.AuditEntry
+		wantOk  bool
+	}{
+		{
+			name: "db error",
+			conn: mock.Conn{
+				BeginFunc: func() (driver.Tx, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+		},
+		{
+			name: "bad insert count",
+			conn: mock.NewTransactionConn(updateBooks, insertTags, deleteTags, updateAliases, deleteAlias, insertAlias(0)),
+		},
+		{
+			name:   "happy path",
+			conn:   mock.NewTransactionConn(updateBooks, insertTags, deleteTags, updateAliases, deleteAlias, insertAlias(1)),
+			wantOk: true,
+		},
+		{
+			name:    "happy path: audit entries",
+			conn:    mock.NewTransactionConn(updateBooks, insertTags, deleteTags, updateAliases, deleteAlias, insertAlias(1), insertEntry),
+			entries: []book.AuditEntry{entry},
+			wantOk:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			ctx := context.Background()
+			err := d.MergeSubjects(ctx, " Science  Fiction", []string{"sci-fi", "Sci-Fi "}, test.entries...)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			}
+		})
+	}
+}
+
 func TestUpdateAdminPassword(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -639,3 +2014,63 @@ func TestUpdateAdminPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestTagsDestScan(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    interface{}
+		wantOk bool
+		want   []string
+	}{
+		{"null", nil, true, nil},
+		{"string", "b; a", true, []string{"a", "b"}},
+		{"bytes", []byte("a;A"), true, []string{"a"}},
+		{"bad type", 7, false, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{"old"}
+			err := tagsDest{&got}.Scan(test.src)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("tags not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestContributorsDestScan(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    interface{}
+		wantOk bool
+		want   []book.Contributor
+	}{
+		{"null", nil, true, nil},
+		{"string", "b|editor|B;a|author|", true, []book.Contributor{{Name: "b", Role: "editor", SortName: "B"}, {Name: "a", Role: "author", SortName: "a"}}},
+		{"bytes", []byte("Ann Lee|author|"), true, []book.Contributor{{Name: "Ann Lee", Role: "author", SortName: "Lee, Ann"}}},
+		{"bad type", 7, false, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []book.Contributor{{Name: "old"}}
+			err := contributorsDest{&got}.Scan(test.src)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.want, got):
+				t.Errorf("contributors not equal: \n wanted: %q \n got:    %q", test.want, got)
+			}
+		})
+	}
+}
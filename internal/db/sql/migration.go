@@ -0,0 +1,335 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Migration changes the schema of the database to a newer version.
+type Migration struct {
+	Version     int
+	Description string
+	// queries are run in a transaction to apply the migration.
+	queries func(driver driverInfo) []query
+}
+
+// migrations are applied in order to create and update the tables.
+// Existing migrations should never be changed; add new ones to the end with the next version.
+// Schema changes allow any rows to be affected because sqlite reports the rows affected by the previous insert of the connection.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create books and users tables",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS books" +
+						" ( id TEXT PRIMARY KEY" +
+						" , title TEXT" +
+						" , author TEXT" +
+						" , subject TEXT" +
+						" , description TEXT" +
+						" , dewey_dec_class TEXT" +
+						" , pages INT" +
+						" , publisher TEXT" +
+						" , publish_date TIMESTAMP" +
+						" , added_date TIMESTAMP" +
+						" , ean_isbn13 TEXT" +
+						" , upc_isbn10 TEXT" +
+						" , image_base64 TEXT" +
+						" )",
+					wantedRowsAffected: []int64{0},
+				},
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS users" +
+						" ( username TEXT PRIMARY KEY" +
+						" , password TEXT" +
+						" )",
+					wantedRowsAffected: []int64{0},
+				},
+				{
+					cmd: "INSERT INTO users (username)" +
+						" VALUES ('admin')" +
+						" ON CONFLICT DO NOTHING",
+					wantedRowsAffected: []int64{0, 1},
+				},
+			}
+		},
+	},
+	{
+		Version:     2,
+		Description: "index books by isbn",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd:             "CREATE INDEX IF NOT EXISTS books_ean_isbn13 ON books (ean_isbn13)",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "CREATE INDEX IF NOT EXISTS books_upc_isbn10 ON books (upc_isbn10)",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     3,
+		Description: "create subject aliases table",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS subject_aliases" +
+						" ( name_key TEXT PRIMARY KEY" +
+						" , name TEXT" +
+						" , canonical TEXT" +
+						" )",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     4,
+		Description: "create book tags table",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS book_tags" +
+						" ( book_id TEXT" +
+						" , tag TEXT" +
+						" , PRIMARY KEY (book_id, tag)" +
+						" )",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "CREATE INDEX IF NOT EXISTS book_tags_tag_key ON book_tags ((LOWER(TRIM(tag))))",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     5,
+		Description: "create book contributors table",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS book_contributors" +
+						" ( book_id TEXT" +
+						" , position INT" +
+						" , name TEXT" +
+						" , role TEXT" +
+						" , sort_name TEXT" +
+						" , PRIMARY KEY (book_id, position)" +
+						" )",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "CREATE INDEX IF NOT EXISTS book_contributors_name_key ON book_contributors ((LOWER(TRIM(name))))",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     6,
+		Description: "add book series and volume columns",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN series TEXT NOT NULL DEFAULT ''",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN volume INT NOT NULL DEFAULT 0",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     7,
+		Description: "add book language, edition, format, and reading level columns",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN language TEXT NOT NULL DEFAULT 'en'",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN edition TEXT NOT NULL DEFAULT ''",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN format TEXT NOT NULL DEFAULT 'print'",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "ALTER TABLE books ADD COLUMN reading_level TEXT NOT NULL DEFAULT ''",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     8,
+		Description: "create custom fields and book custom values tables",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS custom_fields" +
+						" ( position INT PRIMARY KEY" +
+						" , name TEXT NOT NULL" +
+						" , type TEXT NOT NULL" +
+						" , options TEXT NOT NULL DEFAULT ''" +
+						" )",
+					anyRowsAffected: true,
+				},
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS book_custom_values" +
+						" ( book_id TEXT" +
+						" , name TEXT" +
+						" , value TEXT" +
+						" , PRIMARY KEY (book_id, name)" +
+						" )",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     9,
+		Description: "create book audit log table",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS book_audit_log" +
+						" ( id TEXT PRIMARY KEY" +
+						" , book_id TEXT NOT NULL" +
+						" , time TIMESTAMP NOT NULL" +
+						" , actor TEXT NOT NULL" +
+						" , action TEXT NOT NULL" +
+						" , changes TEXT NOT NULL" +
+						" )",
+					anyRowsAffected: true,
+				},
+				{
+					cmd:             "CREATE INDEX IF NOT EXISTS book_audit_log_book_id ON book_audit_log (book_id, time)",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+	{
+		Version:     10,
+		Description: "create book trash table",
+		queries: func(driver driverInfo) []query {
+			return []query{
+				{
+					cmd: "CREATE TABLE IF NOT EXISTS book_trash" +
+						" ( id TEXT PRIMARY KEY" +
+						" , title TEXT NOT NULL" +
+						" , author TEXT NOT NULL" +
+						" , subject TEXT NOT NULL" +
+						" , deleted_date TIMESTAMP NOT NULL" +
+						" , book TEXT NOT NULL" +
+						" )",
+					anyRowsAffected: true,
+				},
+			}
+		},
+	},
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%v: %v", m.Version, m.Description)
+}
+
+// Migrate applies the migrations that are newer than the schema version of the database.
+// Each migration is applied in its own transaction that also records the new schema version.
+// The pending migrations are returned; if dryRun is true, they are not applied and the database is not changed.
+func (d *Database) Migrate(ctx context.Context, dryRun bool) ([]Migration, error) {
+	if dryRun {
+		v, err := d.dryRunSchemaVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pendingMigrations(d.migrations, v), nil
+	}
+	if err := d.setupSchemaVersion(ctx); err != nil {
+		return nil, fmt.Errorf("setting up schema version table: %w", err)
+	}
+	version, err := d.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := pendingMigrations(d.migrations, version)
+	for _, m := range pending {
+		if err := d.applyMigration(ctx, m); err != nil {
+			return nil, fmt.Errorf("applying migration %v: %w", m, err)
+		}
+	}
+	return pending, nil
+}
+
+func (d *Database) setupSchemaVersion(ctx context.Context) error {
+	q := query{
+		cmd: "CREATE TABLE IF NOT EXISTS schema_version" +
+			" ( version INT PRIMARY KEY" +
+			" , description TEXT" +
+			" , applied_date TIMESTAMP" +
+			" )",
+		anyRowsAffected: true,
+	}
+	return d.execTx(ctx, q)
+}
+
+func (d *Database) schemaVersion(ctx context.Context) (int, error) {
+	q := query{
+		cmd: "SELECT COALESCE(MAX(version), 0) FROM schema_version",
+	}
+	var version int
+	if err := d.queryRow(ctx, q, &version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// dryRunSchemaVersion reads the schema version without creating the schema version table.
+// The version is 0 if the table does not exist.
+func (d *Database) dryRunSchemaVersion(ctx context.Context) (int, error) {
+	q := query{
+		cmd:  d.driver.TableCount,
+		args: []interface{}{"schema_version"},
+	}
+	var n int
+	if err := d.queryRow(ctx, q, &n); err != nil {
+		return 0, fmt.Errorf("checking for schema version table: %w", err)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return d.schemaVersion(ctx)
+}
+
+func pendingMigrations(migrations []Migration, version int) []Migration {
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+func (d *Database) applyMigration(ctx context.Context, m Migration) error {
+	queries := m.queries(d.driver)
+	versionQuery := query{
+		cmd:                "INSERT INTO schema_version (version, description, applied_date) VALUES ($1, $2, $3)",
+		args:               []interface{}{m.Version, m.Description, time.Now().UTC()},
+		wantedRowsAffected: []int64{1},
+	}
+	queries = append(queries, versionQuery)
+	return d.execTx(ctx, queries...)
+}
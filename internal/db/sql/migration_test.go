@@ -0,0 +1,174 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/sql/mock"
+)
+
+const (
+	wantSetupSchemaVersionQuery = "CREATE TABLE IF NOT EXISTS schema_version ( version INT PRIMARY KEY , description TEXT , applied_date TIMESTAMP )"
+	wantSchemaVersionQuery      = "SELECT COALESCE(MAX(version), 0) FROM schema_version"
+	wantInsertVersionQuery      = "INSERT INTO schema_version (version, description, applied_date) VALUES ($1, $2, $3)"
+)
+
+func TestMigrationsOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if want, got := i+1, m.Version; want != got {
+			t.Errorf("migration %v: wanted version %v, got %v", i, want, got)
+		}
+		if len(m.Description) == 0 {
+			t.Errorf("migration %v: missing description", i)
+		}
+		for driverName, driver := range drivers {
+			if len(m.queries(driver)) == 0 {
+				t.Errorf("migration %v: no queries for %v driver", i, driverName)
+			}
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	tests := []struct {
+		name    string
+		version int
+		want    []Migration
+	}{
+		{"new database", 0, all},
+		{"partially migrated", 2, all[2:]},
+		{"fully migrated", 3, nil},
+		{"newer database", 4, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := pendingMigrations(all, test.version)
+			if len(test.want) != len(got) {
+				t.Fatalf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+			}
+			for i := range got {
+				if test.want[i].Version != got[i].Version {
+					t.Errorf("not equal: \n wanted: %v \n got:    %v", test.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	testMigrations := []Migration{
+		{
+			Version:     1,
+			Description: "first",
+			queries: func(driver driverInfo) []query {
+				return []query{{cmd: "CREATE first", wantedRowsAffected: []int64{0}}}
+			},
+		},
+		{
+			Version:     2,
+			Description: "second",
+			queries: func(driver driverInfo) []query {
+				return []query{{cmd: "ALTER second " + driver.ILike, wantedRowsAffected: []int64{0}}}
+			},
+		},
+	}
+	setup := mock.Query{Name: wantSetupSchemaVersionQuery}
+	schemaVersion := func(version int64) mock.Query {
+		return mock.Query{Name: wantSchemaVersionQuery, Rows: [][]interface{}{{version}}}
+	}
+	tableCount := func(n int64) mock.Query {
+		return mock.Query{Name: "mock_TABLE_COUNT $1", Args: []interface{}{"schema_version"}, Rows: [][]interface{}{{n}}}
+	}
+	tests := []struct {
+		name        string
+		conn        mock.Conn
+		dryRun      bool
+		wantOk      bool
+		wantPending []int
+	}{
+		{
+			name: "setup error",
+			conn: mock.NewTransactionConn(mock.Query{Name: "other"}),
+		},
+		{
+			name: "schema version error",
+			conn: mock.NewTransactionConn(setup, mock.Query{Name: wantSchemaVersionQuery}),
+		},
+		{
+			name: "migration error",
+			conn: mock.NewTransactionConn(setup, schemaVersion(0),
+				mock.Query{Name: "CREATE first"},
+				mock.Query{Name: "unexpected"},
+			),
+		},
+		{
+			name:   "dry run table count error",
+			conn:   mock.NewTransactionConn(mock.Query{Name: "other"}),
+			dryRun: true,
+		},
+		{
+			name:        "dry run without schema version table",
+			conn:        mock.NewTransactionConn(tableCount(0)),
+			dryRun:      true,
+			wantOk:      true,
+			wantPending: []int{1, 2},
+		},
+		{
+			name:        "dry run",
+			conn:        mock.NewTransactionConn(tableCount(1), schemaVersion(1)),
+			dryRun:      true,
+			wantOk:      true,
+			wantPending: []int{2},
+		},
+		{
+			name: "all",
+			conn: mock.NewTransactionConn(setup, schemaVersion(0),
+				mock.Query{Name: "CREATE first"},
+				mock.Query{Name: wantInsertVersionQuery, Args: []interface{}{1, "first", mock.AnyArg}, RowsAffected: 1},
+				mock.Query{Name: "ALTER second mock_ILIKE"},
+				mock.Query{Name: wantInsertVersionQuery, Args: []interface{}{2, "second", mock.AnyArg}, RowsAffected: 1},
+			),
+			wantOk:      true,
+			wantPending: []int{1, 2},
+		},
+		{
+			name:   "up to date",
+			conn:   mock.NewTransactionConn(setup, schemaVersion(2)),
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := DatabaseHelper(t, test.conn)
+			d.driver = testDriverInfo
+			d.migrations = testMigrations
+			ctx := context.Background()
+			got, err := d.Migrate(ctx, test.dryRun)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			default:
+				var gotPending []int
+				for _, m := range got {
+					gotPending = append(gotPending, m.Version)
+				}
+				if !reflect.DeepEqual(test.wantPending, gotPending) {
+					t.Errorf("pending migrations not equal: \n wanted: %v \n got:    %v", test.wantPending, gotPending)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrationString(t *testing.T) {
+	m := Migration{Version: 7, Description: "add things"}
+	if want, got := "7: add things", m.String(); want != got {
+		t.Errorf("not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
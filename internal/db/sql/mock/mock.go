@@ -17,10 +17,12 @@ type (
 		OpenFunc func(name string) (Conn, error)
 	}
 	// Query simplifies sending arguments/constraints to custom connections.
+	// Rows are the results returned if the query is run to read rows.
 	Query struct {
 		Name         string
 		Args         []interface{}
 		RowsAffected int64
+		Rows         [][]interface{}
 	}
 	// Conn implements the sql/driver.Conn interface.
 	Conn struct {
@@ -123,29 +125,7 @@ func NewQueryConn(query Query, results [][]interface{}) Conn {
 					if err := want.checkEquals(query, args...); err != nil {
 						return nil, err
 					}
-					var rowIndex int
-					return Rows{
-						ColumnsFunc: func() []string {
-							if len(results) == 0 {
-								return nil
-							}
-							return make([]string, len(results[0]))
-						},
-						CloseFunc: func() error {
-							return nil
-						},
-						NextFunc: func(dest []driver.Value) error {
-							if rowIndex >= len(results) {
-								return io.EOF
-							}
-							row := results[rowIndex]
-							rowIndex++
-							for i, src := range row {
-								dest[i] = src
-							}
-							return nil
-						},
-					}, nil
+					return newRows(results), nil
 				},
 			}, nil
 		},
@@ -155,6 +135,34 @@ func NewQueryConn(query Query, results [][]interface{}) Conn {
 	}
 }
 
+func newRows(results [][]interface{}) Rows {
+	var rowIndex int
+	return Rows{
+		ColumnsFunc: func() []string {
+			if len(results) == 0 {
+				return nil
+			}
+			return make([]string, len(results[0]))
+		},
+		CloseFunc: func() error {
+			return nil
+		},
+		NextFunc: func(dest []driver.Value) error {
+			if rowIndex >= len(results) {
+				return io.EOF
+			}
+			row := results[rowIndex]
+			rowIndex++
+			for i, src := range row {
+				dest[i] = src
+			}
+			return nil
+		},
+	}
+}
+
+// NewTransactionConn creates a connection that expects the commands to be run in order.
+// Commands can be executed in transactions or be queried for their rows.
 func NewTransactionConn(commands ...Query) Conn {
 	var commandIndex int
 	return Conn{
@@ -192,6 +200,14 @@ func NewTransactionConn(commands ...Query) Conn {
 						},
 					}, nil
 				},
+				QueryFunc: func(args []driver.Value) (driver.Rows, error) {
+					q := commands[commandIndex].driverValue()
+					commandIndex++
+					if err := q.checkEquals(query, args...); err != nil {
+						return nil, err
+					}
+					return newRows(q.Rows), nil
+				},
 			}, nil
 		},
 	}
@@ -287,6 +287,37 @@ func TestTransactionConn(t *testing.T) {
 	}
 }
 
+func TestTransactionConnQuery(t *testing.T) {
+	conn := NewTransactionConn(
+		Query{Name: "SELECT $1", Args: []interface{}{7}, Rows: [][]interface{}{{int64(7)}}},
+		Query{Name: "c2", RowsAffected: 1},
+	)
+	testDriver.OpenFunc = func(name string) (Conn, error) {
+		return conn, nil
+	}
+	db, err := sql.Open(testDriverName, "")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	var got int
+	if err := db.QueryRow("SELECT $1", 7).Scan(&got); err != nil {
+		t.Fatalf("unwanted query error: %v", err)
+	}
+	if got != 7 {
+		t.Errorf("wanted 7, got %v", got)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unwanted begin error: %v", err)
+	}
+	if _, err := tx.Exec("c2"); err != nil {
+		t.Errorf("unwanted exec error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("unwanted commit error: %v", err)
+	}
+}
+
 func TestNotImplemented(t *testing.T) {
 	tests := []struct {
 		name    string
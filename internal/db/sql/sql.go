@@ -6,9 +6,15 @@ import (
 	"fmt"
 )
 
-type db struct {
-	db *sql.DB
-}
+type (
+	db struct {
+		db *sql.DB
+	}
+	// querier runs queries in or out of transactions.
+	querier interface {
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	}
+)
 
 func (q query) execute(ctx context.Context, tx *sql.Tx) error {
 	result, err := tx.ExecContext(ctx, q.cmd, q.args...)
@@ -26,6 +32,9 @@ func (q query) execute(ctx context.Context, tx *sql.Tx) error {
 }
 
 func (q query) allowsRowsAffected(target int64) bool {
+	if q.anyRowsAffected {
+		return true
+	}
 	for _, v := range q.wantedRowsAffected {
 		if v == target {
 			return true
@@ -35,15 +44,23 @@ func (q query) allowsRowsAffected(target int64) bool {
 }
 
 func (d *db) execTx(ctx context.Context, queries ...query) error {
+	return d.withTx(ctx, func(tx *sql.Tx) error {
+		for _, q := range queries {
+			if err := q.execute(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withTx runs the function in a transaction, which is rolled back if the function returns an error.
+func (d *db) withTx(ctx context.Context, f func(tx *sql.Tx) error) error {
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
-	for _, q := range queries {
-		if err = q.execute(ctx, tx); err != nil {
-			break
-		}
-	}
+	err = f(tx)
 	if err != nil {
 		if err2 := tx.Rollback(); err2 != nil {
 			err = fmt.Errorf("rollback error: %v, root cause: %w", err, err2)
@@ -57,7 +74,12 @@ func (d *db) execTx(ctx context.Context, queries ...query) error {
 }
 
 func (d *db) query(ctx context.Context, q query, dest func() []interface{}) error {
-	rows, err := d.db.QueryContext(ctx, q.cmd, q.args...)
+	return queryRows(ctx, d.db, q, dest)
+}
+
+// queryRows runs the query, scanning each row into the destinations of the dest function.
+func queryRows(ctx context.Context, qr querier, q query, dest func() []interface{}) error {
+	rows, err := qr.QueryContext(ctx, q.cmd, q.args...)
 	if err != nil {
 		return fmt.Errorf("running query: %w", err)
 	}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// getBookAuthors shows the authors and contributors of the books with their counts, sorted by sort name.
+func (s *Server) getBookAuthors(w http.ResponseWriter, r *http.Request) {
+	if data, ok := loadPage(w, r, s.cfg.MaxRows, "Authors", s.db.ReadBookAuthors); ok {
+		s.serveTemplate(w, "authors", data)
+	}
+}
+
+// getAuthor shows the books that the author wrote or contributed to.
+func (s *Server) getAuthor(w http.ResponseWriter, r *http.Request) {
+	var filter book.Filter
+	if !parseFormValue(w, r, "name", &filter.Author, 256) {
+		return
+	}
+	filter.Author = strings.TrimSpace(filter.Author)
+	if len(filter.Author) == 0 {
+		httpBadRequest(w, fmt.Errorf("author name required"))
+		return
+	}
+	pageLoader := func(ctx context.Context, limit, offset int) ([]book.Header, error) {
+		return s.db.ReadBookHeaders(ctx, filter, limit, offset)
+	}
+	if data, ok := loadPage(w, r, s.cfg.MaxRows, "Books", pageLoader); ok {
+		data["Author"] = filter.Author
+		s.serveTemplate(w, "author", data)
+	}
+}
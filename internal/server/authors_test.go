@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestAuthors(t *testing.T) {
+	books := []book.Book{
+		{
+			Header:       book.Header{ID: "1", Title: "The Hobbit", Author: "J. R. R. Tolkien"},
+			Contributors: []book.Contributor{{Name: "Alan Lee", Role: "illustrator", SortName: "Lee, Alan"}},
+		},
+		{
+			Header:       book.Header{ID: "2", Title: "The Silmarillion", Author: "J. R. R. Tolkien"},
+			Contributors: []book.Contributor{{Name: "Christopher Tolkien", Role: "editor", SortName: "Tolkien, Christopher"}},
+		},
+		{Header: book.Header{ID: "3", Title: "Beowulf", Author: "Unknown"}},
+	}
+	tests := []struct {
+		name       string
+		url        string
+		handler    func(s *Server) http.HandlerFunc
+		db         database
+		wantCode   int
+		wantParts  []string
+		wantAbsent []string
+	}{
+		{
+			name: "authors db error",
+			url:  "/authors",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getBookAuthors
+			},
+			db: mockDatabase{
+				readBookAuthorsFunc: func(limit, offset int) ([]book.Author, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "authors",
+			url:  "/authors",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getBookAuthors
+			},
+			wantCode: 200,
+			wantParts: []string{
+				`href="/author?name=Alan+Lee"`,
+				`>Lee, Alan</span>`,
+				`href="/author?name=Christopher+Tolkien"`,
+				"Load More authors",
+			},
+			wantAbsent: []string{"J. R. R. Tolkien", "Unknown"},
+		},
+		{
+			name: "authors next page",
+			url:  "/authors?page=2",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getBookAuthors
+			},
+			wantCode: 200,
+			wantParts: []string{
+				`href="/author?name=J.+R.+R.+Tolkien"`,
+				`>Tolkien, J. R. R.</span>`,
+				`<span title="Count" class="subject-count">2</span>`,
+				"Unknown",
+			},
+			wantAbsent: []string{"Alan Lee", "Christopher Tolkien", "Load More authors"},
+		},
+		{
+			name: "author name too long",
+			url:  "/author?name=" + strings.Repeat("a", 257),
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getAuthor
+			},
+			wantCode: 413,
+		},
+		{
+			name: "author name required",
+			url:  "/author?name=+",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getAuthor
+			},
+			wantCode: 400,
+		},
+		{
+			name: "author db error",
+			url:  "/author?name=x",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getAuthor
+			},
+			db: mockDatabase{
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "author",
+			url:  "/author?name=j.+r.+r.+tolkien",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getAuthor
+			},
+			wantCode:   200,
+			wantParts:  []string{"The Hobbit", "The Silmarillion"},
+			wantAbsent: []string{"Beowulf"},
+		},
+		{
+			name: "contributor",
+			url:  "/author?name=Christopher+Tolkien",
+			handler: func(s *Server) http.HandlerFunc {
+				return s.getAuthor
+			},
+			wantCode:   200,
+			wantParts:  []string{"<h2>Christopher Tolkien</h2>", "The Silmarillion"},
+			wantAbsent: []string{"The Hobbit"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				cfg:  Config{MaxRows: 2},
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			test.handler(&s)(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+				for _, absent := range test.wantAbsent {
+					if strings.Contains(got, absent) {
+						t.Errorf("unwanted %q in body: %v", absent, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/citation"
+)
+
+// getCitations downloads citations in the format of the book with the id, or of the books on the page of the books that match the filter if no id is set.
+// Books are read without their images, which are not cited.
+func (s *Server) getCitations(w http.ResponseWriter, r *http.Request) {
+	var formatName, id string
+	var filter book.Filter
+	var page int
+	switch {
+	case !parseFormValue(w, r, "format", &formatName, 10),
+		!parseFormValue(w, r, "id", &id, 64),
+		!parseFormValue(w, r, "q", &filter.HeaderPart, 256),
+		!parseFormValue(w, r, "s", &filter.Subject, 256),
+		!parseDetailsFilter(w, r, &filter),
+		!parsePage(w, r, &page):
+		return
+	}
+	format, ok := citation.Formats[formatName]
+	if !ok {
+		err := fmt.Errorf("unknown citation format: %q", formatName)
+		httpBadRequest(w, err)
+		return
+	}
+	ctx := r.Context()
+	ids := []string{id}
+	filename := "book"
+	if len(id) == 0 {
+		offset := (page - 1) * s.cfg.MaxRows
+		headers, err := s.db.ReadBookHeaders(ctx, filter, s.cfg.MaxRows, offset)
+		if err != nil {
+			err = fmt.Errorf("reading books: %w", err)
+			httpInternalServerError(w, err)
+			return
+		}
+		ids = make([]string, len(headers))
+		for i, h := range headers {
+			ids[i] = h.ID
+		}
+		filename = "books"
+	}
+	books := make([]book.Book, len(ids))
+	for i, id := range ids {
+		b, err := s.db.ReadBookMetadata(ctx, id)
+		if err != nil {
+			err = fmt.Errorf("reading book: %w", err)
+			if errors.Is(err, book.ErrNotFound) {
+				httpError(w, http.StatusNotFound, err)
+				return
+			}
+			httpInternalServerError(w, err)
+			return
+		}
+		books[i] = *b
+	}
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+format.Extension))
+	if err := format.Write(w, books...); err != nil {
+		fmt.Fprintf(s.out, "writing citations: %v\n", err)
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetCitations(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Subject: "Animals"}, ImageBase64: "img1"},
+		{Header: book.Header{ID: "2", Title: "Zebras", Subject: "Animals"}, Format: "dvd"},
+		{Header: book.Header{ID: "3", Title: "Volcanoes", Subject: "Geology"}},
+	}
+	tests := []struct {
+		name            string
+		url             string
+		db              database
+		wantCode        int
+		wantContentType string
+		wantFilename    string
+		wantBody        string
+	}{
+		{
+			name:     "unknown format",
+			url:      "/cite?id=1&format=mla",
+			wantCode: 400,
+		},
+		{
+			name:     "missing format",
+			url:      "/cite?id=1",
+			wantCode: 400,
+		},
+		{
+			name:     "unknown book",
+			url:      "/cite?id=4&format=ris",
+			wantCode: 404,
+		},
+		{
+			name: "book error",
+			url:  "/cite?id=1&format=ris",
+			db: mockDatabase{
+				readBookMetadataFunc: func(id string) (*book.Book, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "bad page",
+			url:      "/cite?s=Animals&page=two&format=ris",
+			wantCode: 400,
+		},
+		{
+			name: "search error",
+			url:  "/cite?format=ris",
+			db: mockDatabase{
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:            "book",
+			url:             "/cite?id=2&format=ris",
+			wantCode:        200,
+			wantContentType: "application/x-research-info-systems; charset=utf-8",
+			wantFilename:    "book.ris",
+			wantBody:        "TY  - BOOK\r\nTI  - Zebras\r\nKW  - Animals\r\nER  - \r\n",
+		},
+		{
+			name:            "search results",
+			url:             "/cite?s=Animals&q=&format=bibtex",
+			wantCode:        200,
+			wantContentType: "application/x-bibtex; charset=utf-8",
+			wantFilename:    "books.bib",
+			wantBody:        "@book{lemurs,\n  title = {Lemurs},\n  keywords = {Animals},\n}\n",
+		},
+		{
+			name:            "search results page",
+			url:             "/cite?s=Animals&page=2&format=bibtex",
+			wantCode:        200,
+			wantContentType: "application/x-bibtex; charset=utf-8",
+			wantFilename:    "books.bib",
+			wantBody:        "@book{zebras,\n  title = {Zebras},\n  keywords = {Animals},\n}\n",
+		},
+		{
+			name:            "search results by book format",
+			url:             "/cite?s=Animals&book-format=DVD&format=ris",
+			wantCode:        200,
+			wantContentType: "application/x-research-info-systems; charset=utf-8",
+			wantFilename:    "books.ris",
+			wantBody:        "TY  - BOOK\r\nTI  - Zebras\r\nKW  - Animals\r\nER  - \r\n",
+		},
+		{
+			name:            "no search results",
+			url:             "/cite?q=unknown&format=csl-json",
+			wantCode:        200,
+			wantContentType: "application/vnd.citationstyles.csl+json; charset=utf-8",
+			wantFilename:    "books.json",
+			wantBody:        "[]\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			var sb strings.Builder
+			s := Server{
+				db:  test.db,
+				out: &sb,
+				cfg: Config{
+					MaxRows: 1,
+				},
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getCitations(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode != 200:
+			case test.wantContentType != w.Header().Get("Content-Type"):
+				t.Errorf("content types not equal: wanted %q, got %q", test.wantContentType, w.Header().Get("Content-Type"))
+			case !strings.Contains(w.Header().Get("Content-Disposition"), test.wantFilename):
+				t.Errorf("wanted filename %q, got %q", test.wantFilename, w.Header().Get("Content-Disposition"))
+			case test.wantBody != w.Body.String():
+				t.Errorf("bodies not equal: \n wanted: %q \n got:    %q", test.wantBody, w.Body.String())
+			case sb.Len() != 0:
+				t.Errorf("unwanted log: %q", sb.String())
+			}
+		})
+	}
+}
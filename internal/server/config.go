@@ -2,13 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
 	"github.com/jacobpatterson1549/kuuf-library/internal/db/csv"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/sql"
 	"golang.org/x/time/rate"
 )
 
@@ -16,6 +19,11 @@ func (cfg Config) queryTimeout() time.Duration {
 	return time.Second * time.Duration(cfg.DBTimeoutSec)
 }
 
+// exportTimeout is how long exports can take to read and write all of the books.
+func (cfg Config) exportTimeout() time.Duration {
+	return time.Second * time.Duration(cfg.ExportTimeoutSec)
+}
+
 func (cfg Config) postRateLimiter() *rate.Limiter {
 	r := rate.Inf
 	if cfg.PostLimitSec != 0 {
@@ -30,6 +38,46 @@ func (cfg Config) databaseScheme() string {
 	return beforeColon
 }
 
+// sqlDriverName is the name of the sql driver for the database url, if it is a sql database.
+func (cfg Config) sqlDriverName() (driverName string, ok bool) {
+	switch s := cfg.databaseScheme(); s {
+	case "postgres":
+		return s, true
+	case "file":
+		return "sqlite3", true
+	}
+	return "", false
+}
+
+// Migrate applies pending database migrations, printing each one.
+// If MigrateDryRun is set, the pending migrations are printed, but not applied.
+func (cfg Config) Migrate(ctx context.Context, out io.Writer) error {
+	driverName, ok := cfg.sqlDriverName()
+	if !ok {
+		fmt.Fprintf(out, "Database %q does not have migrations.\n", cfg.databaseScheme())
+		return nil
+	}
+	d, err := sql.OpenDatabase(driverName, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	pending, err := d.Migrate(ctx, cfg.MigrateDryRun)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(out, "No pending migrations.")
+	}
+	action := "Applied"
+	if cfg.MigrateDryRun {
+		action = "Pending"
+	}
+	for _, m := range pending {
+		fmt.Fprintf(out, "%v migration %v\n", action, m)
+	}
+	return nil
+}
+
 func (cfg Config) setup(ctx context.Context, db database, ph passwordHandler, pv passwordValidator, out io.Writer) error {
 	if len(cfg.AdminPassword) != 0 {
 		if err := cfg.initAdminPassword(ctx, db, ph, pv); err != nil {
@@ -37,15 +85,22 @@ func (cfg Config) setup(ctx context.Context, db database, ph passwordHandler, pv
 		}
 	}
 	if cfg.BackfillCSV {
-		if err := cfg.backfillCSV(ctx, db); err != nil {
+		if err := cfg.backfillCSV(ctx, db, out); err != nil {
 			return fmt.Errorf("backfilling database from internal CSV file: %w", err)
 		}
 	}
-	if cfg.UpdateImages || cfg.DumpCSV {
+	if cfg.UpdateImages || cfg.DumpCSV || cfg.BackfillISBNs {
 		if err := cfg.updateBooks(ctx, db, out); err != nil {
-			return fmt.Errorf("updating images / dumping csv;: %w", err)
+			return fmt.Errorf("updating images / backfilling isbns / dumping csv;: %w", err)
 		}
 	}
+	n, err := cfg.purgeTrash(ctx, db, time.Now())
+	if err != nil {
+		return fmt.Errorf("purging trash: %w", err)
+	}
+	if n != 0 {
+		fmt.Fprintf(out, "Purged %v books from the trash.\n", n)
+	}
 	return nil
 }
 
@@ -63,7 +118,9 @@ func (cfg Config) initAdminPassword(ctx context.Context, db database, ph passwor
 	return nil
 }
 
-func (cfg Config) backfillCSV(ctx context.Context, db database) error {
+// backfillCSV imports the books in the embedded csv file into the database.
+// Isbns are normalized; books with invalid isbns are reported and not imported.
+func (cfg Config) backfillCSV(ctx context.Context, db database, out io.Writer) error {
 	csvD, err := embeddedCSVDatabase()
 	if err != nil {
 		return fmt.Errorf("loading csv database: %w", err)
@@ -73,20 +130,39 @@ func (cfg Config) backfillCSV(ctx context.Context, db database) error {
 	if err != nil {
 		return fmt.Errorf("reading all books to backfill: %w", err)
 	}
-	if _, err := db.CreateBooks(ctx, books...); err != nil {
-		return fmt.Errorf("creating books: %w", err)
+	books, invalidISBNs := validISBNBooks(books)
+	if err := db.ImportBooks(ctx, cfg.ImportUpsert, books); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	for _, err := range invalidISBNs {
+		fmt.Fprintln(out, err)
 	}
 	return nil
 }
 
 func (cfg Config) updateBooks(ctx context.Context, db database, out io.Writer) error {
-	d := csv.NewDump(out)
+	var customNames []string
+	if cfg.DumpCSV {
+		fields, err := db.ReadCustomFields(ctx)
+		if err != nil {
+			return fmt.Errorf("reading custom fields: %w", err)
+		}
+		customNames = book.CustomNames(fields)
+	}
+	d := csv.NewDump(out, customNames...)
+	var invalidISBNs []error
 	iter := newBookIterator(db, cfg.MaxRows)
 	for iter.HasNext(ctx) {
 		b, err := iter.Next(ctx)
 		if err != nil {
 			return err
 		}
+		if err := cfg.backfillISBNs(ctx, b, db); err != nil {
+			if !errors.Is(err, isbn.ErrInvalid) {
+				return err
+			}
+			invalidISBNs = append(invalidISBNs, err)
+		}
 		if err := cfg.updateImage(ctx, *b, db, *d); err != nil {
 			return err
 		}
@@ -97,6 +173,29 @@ func (cfg Config) updateBooks(ctx context.Context, db database, out io.Writer) e
 	if err := iter.Err(); err != nil {
 		return err
 	}
+	for _, err := range invalidISBNs { // reported after the dump so it is not interrupted
+		fmt.Fprintln(out, err)
+	}
+	return nil
+}
+
+// backfillISBNs normalizes the isbns of the book and fills in the missing isbn-13 or isbn-10, updating the book if they change.
+// Books with invalid isbns are not changed; errors for them wrap isbn.ErrInvalid.
+func (cfg Config) backfillISBNs(ctx context.Context, b *book.Book, db database) error {
+	if !cfg.BackfillISBNs {
+		return nil
+	}
+	isbn13, isbn10, err := isbn.Pair(b.EanIsbn13, b.UpcIsbn10)
+	switch {
+	case err != nil:
+		return fmt.Errorf("book %q: %w", b.ID, err)
+	case isbn13 == b.EanIsbn13 && isbn10 == b.UpcIsbn10:
+		return nil
+	}
+	b.EanIsbn13, b.UpcIsbn10 = isbn13, isbn10
+	if err := db.UpdateBook(ctx, *b, false); err != nil {
+		return fmt.Errorf("writing backfilled isbns to db for book %q: %w", b.ID, err)
+	}
 	return nil
 }
 
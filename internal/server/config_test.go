@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
 	"golang.org/x/time/rate"
 )
 
@@ -24,6 +25,18 @@ func TestQueryTimeout(t *testing.T) {
 	}
 }
 
+func TestExportTimeout(t *testing.T) {
+	cfg := Config{
+		DBTimeoutSec:     5,
+		ExportTimeoutSec: 600,
+	}
+	want := 600 * time.Second
+	got := cfg.exportTimeout()
+	if want != got {
+		t.Errorf("not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+}
+
 func TestPostRateLimiter(t *testing.T) {
 	tests := []struct {
 		name string
@@ -84,6 +97,69 @@ func TestDatabaseScheme(t *testing.T) {
 	}
 }
 
+func TestSQLDriverName(t *testing.T) {
+	tests := []struct {
+		databaseURL string
+		want        string
+		wantOk      bool
+	}{
+		{"csv://", "", false},
+		{"postgres://u:p@host:port/db", "postgres", true},
+		{"file:library.db", "sqlite3", true},
+	}
+	for _, test := range tests {
+		t.Run(test.databaseURL, func(t *testing.T) {
+			cfg := Config{DatabaseURL: test.databaseURL}
+			got, ok := cfg.sqlDriverName()
+			if test.want != got || test.wantOk != ok {
+				t.Errorf("wanted %q (%v), got %q (%v)", test.want, test.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantOk      bool
+		wantLogPart string
+	}{
+		{
+			name: "no migrations",
+			cfg: Config{
+				DatabaseURL: "csv://",
+			},
+			wantOk:      true,
+			wantLogPart: "does not have migrations",
+		},
+		{
+			name: "database error",
+			cfg: Config{
+				DatabaseURL:   "postgres://u:p@localhost:1/kuuf_library_db?connect_timeout=1",
+				MigrateDryRun: true,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			ctx := context.Background()
+			err := test.cfg.Migrate(ctx, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !strings.Contains(sb.String(), test.wantLogPart):
+				t.Errorf("wanted log to contain %q, got: %q", test.wantLogPart, sb.String())
+			}
+		})
+	}
+}
+
 func TestSetupInitAdminPassword(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -167,12 +243,13 @@ func TestSetupBackfillCSV(t *testing.T) {
 		libraryCSV string
 		db         database
 		wantOk     bool
+		wantOut    string
 	}{
 		{
 			name: "db error",
 			db: mockDatabase{
-				createBooksFunc: func(books ...book.Book) ([]book.Book, error) {
-					return nil, fmt.Errorf("db error")
+				importBooksFunc: func(upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+					return fmt.Errorf("db error")
 				},
 			},
 		},
@@ -180,14 +257,30 @@ func TestSetupBackfillCSV(t *testing.T) {
 			name:       "invalid csv db",
 			libraryCSV: "INVALID,CSV",
 		},
+		{
+			name: "invalid isbns",
+			libraryCSV: "id,title,author,contributors,description,subject,tags,series,volume,language,edition,format,reading-level,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64\n" +
+				"1,Lemurs,,,,,,,,,,,,,,,,,978-0-306-40615-7,,\n" +
+				"2,Zebras,,,,,,,,,,,,,,,,,9780306406158,,\n",
+			db: mockDatabase{
+				importBooksFunc: func(upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+					if len(books) != 1 || books[0].EanIsbn13 != "9780306406157" || books[0].UpcIsbn10 != "0306406152" {
+						return fmt.Errorf("wanted only the book with normalized isbns to be imported, got %v", books)
+					}
+					return nil
+				},
+			},
+			wantOk:  true,
+			wantOut: `book 2 ("Zebras"): invalid isbn`,
+		},
 		{
 			name: "happy path",
 			db: mockDatabase{
-				createBooksFunc: func(books ...book.Book) ([]book.Book, error) {
+				importBooksFunc: func(upsert bool, books []book.Book, entries ...book.AuditEntry) error {
 					if len(books) != 0 {
-						return nil, fmt.Errorf("the embedded csv database should be empty when testing: got %v books", len(books))
+						return fmt.Errorf("the embedded csv database should be empty when testing: got %v books", len(books))
 					}
-					return books, nil
+					return nil
 				},
 			},
 			wantOk: true,
@@ -201,9 +294,9 @@ func TestSetupBackfillCSV(t *testing.T) {
 			}
 			var ph passwordHandler
 			var pv passwordValidator
-			var w io.Writer
+			var sb strings.Builder
 			ctx := context.Background()
-			err := cfg.setup(ctx, test.db, ph, pv, w)
+			err := cfg.setup(ctx, test.db, ph, pv, &sb)
 			switch {
 			case !test.wantOk:
 				if err == nil {
@@ -211,6 +304,8 @@ func TestSetupBackfillCSV(t *testing.T) {
 				}
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
+			case !strings.Contains(sb.String(), test.wantOut):
+				t.Errorf("wanted %q in output, got %q", test.wantOut, sb.String())
 			}
 		})
 	}
@@ -218,12 +313,19 @@ func TestSetupBackfillCSV(t *testing.T) {
 
 func TestSetupDumpCSV(t *testing.T) {
 	tests := []struct {
-		name            string
-		readBookHeaders func(f book.Filter, limit, offset int) ([]book.Header, error)
-		readBook        func(id string) (*book.Book, error)
-		wantOk          bool
-		wantOut         string
+		name             string
+		readCustomFields func() ([]book.CustomField, error)
+		readBookHeaders  func(f book.Filter, limit, offset int) ([]book.Header, error)
+		readBook         func(id string) (*book.Book, error)
+		wantOk           bool
+		wantOut          string
 	}{
+		{
+			name: "readCustomFields error",
+			readCustomFields: func() ([]book.CustomField, error) {
+				return nil, fmt.Errorf("readCustomFields error")
+			},
+		},
 		{
 			name: "readBookHeaders error",
 			readBookHeaders: func(f book.Filter, limit, offset int) ([]book.Header, error) {
@@ -265,21 +367,33 @@ func TestSetupDumpCSV(t *testing.T) {
 					},
 					Description: id + "_description",
 				}
+				if id == "bk22" {
+					b.Custom = map[string]string{"Box": "22"}
+				}
 				return &b, nil
 			},
+			readCustomFields: func() ([]book.CustomField, error) {
+				return []book.CustomField{{Name: "Box", Type: book.FieldNumber}}, nil
+			},
 			wantOk: true,
-			wantOut: `id,title,author,description,subject,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64
-bk1,,,bk1_description,,,0,,01/01/0001,01/01/0001,,,
-bk22,,,bk22_description,,,0,,01/01/0001,01/01/0001,,,
-bk3,,,bk3_description,,,0,,01/01/0001,01/01/0001,,,
+			wantOut: `id,title,author,contributors,description,subject,tags,series,volume,language,edition,format,reading-level,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64,custom:Box
+bk1,,,,bk1_description,,,,,,,,,,0,,01/01/0001,01/01/0001,,,,
+bk22,,,,bk22_description,,,,,,,,,,0,,01/01/0001,01/01/0001,,,,22
+bk3,,,,bk3_description,,,,,,,,,,0,,01/01/0001,01/01/0001,,,,
 `,
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			if test.readCustomFields == nil {
+				test.readCustomFields = func() ([]book.CustomField, error) {
+					return nil, nil
+				}
+			}
 			db := mockDatabase{
-				readBookHeadersFunc: test.readBookHeaders,
-				readBookFunc:        test.readBook,
+				readCustomFieldsFunc: test.readCustomFields,
+				readBookHeadersFunc:  test.readBookHeaders,
+				readBookFunc:         test.readBook,
 			}
 			cfg := Config{
 				DumpCSV: true,
@@ -304,6 +418,67 @@ bk3,,,bk3_description,,,0,,01/01/0001,01/01/0001,,,
 	}
 }
 
+func TestSetupBackfillISBNs(t *testing.T) {
+	t.Run("db error", func(t *testing.T) {
+		db := mockDatabase{
+			readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+				return []book.Header{{ID: "1"}}, nil
+			},
+			readBookFunc: func(id string) (*book.Book, error) {
+				b := book.Book{Header: book.Header{ID: id}, UpcIsbn10: "0306406152"}
+				return &b, nil
+			},
+			updateBookFunc: func(b book.Book, updateImage bool, audits ...book.Audit) error {
+				return fmt.Errorf("db error")
+			},
+		}
+		cfg := Config{
+			BackfillISBNs: true,
+			MaxRows:       10,
+		}
+		var ph passwordHandler
+		var pv passwordValidator
+		var sb strings.Builder
+		ctx := context.Background()
+		if err := cfg.setup(ctx, db, ph, pv, &sb); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+	t.Run("happy path", func(t *testing.T) {
+		books := []book.Book{
+			{Header: book.Header{ID: "1"}, UpcIsbn10: "0-306-40615-2", ImageBase64: "img1"},
+			{Header: book.Header{ID: "2"}, EanIsbn13: "9780306406158"},
+			{Header: book.Header{ID: "3"}},
+		}
+		db := memory.NewDatabase(books...)
+		cfg := Config{
+			BackfillISBNs: true,
+			MaxRows:       10,
+		}
+		var ph passwordHandler
+		var pv passwordValidator
+		var sb strings.Builder
+		ctx := context.Background()
+		if err := cfg.setup(ctx, db, ph, pv, &sb); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		want := books
+		want[0].EanIsbn13, want[0].UpcIsbn10 = "9780306406157", "0306406152"
+		for _, w := range want {
+			got, err := db.ReadBook(ctx, w.ID)
+			switch {
+			case err != nil:
+				t.Errorf("unwanted error reading book %q: %v", w.ID, err)
+			case !reflect.DeepEqual(w, *got):
+				t.Errorf("book %q not equal: \n wanted: %v \n got:    %v", w.ID, w, *got)
+			}
+		}
+		if !strings.Contains(sb.String(), `book "2": invalid isbn`) {
+			t.Errorf("wanted invalid isbn reported, got %q", sb.String())
+		}
+	})
+}
+
 func TestValidatePassword(t *testing.T) {
 	tests := []struct {
 		p      string
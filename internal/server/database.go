@@ -11,6 +11,8 @@ type (
 	// bookIterator reads books in batches
 	bookIterator struct {
 		database     database
+		filter       book.Filter
+		withoutImage bool // read books without their images
 		batchSize    int
 		batchIndex   int
 		headerIndex  int
@@ -29,8 +31,16 @@ type (
 	// readOnlyDatabase is a database that only reads books.
 	readOnlyDatabase struct {
 		ReadBookSubjectsFunc func(ctx context.Context, limit, offset int) ([]book.Subject, error)
+		ReadBookAuthorsFunc  func(ctx context.Context, limit, offset int) ([]book.Author, error)
 		ReadBookHeadersFunc  func(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error)
+		ReadShelvedBooksFunc func(ctx context.Context) ([]book.ShelvedBook, error)
+		ReadSeriesBooksFunc  func(ctx context.Context) ([]book.SeriesBook, error)
 		ReadBookFunc         func(ctx context.Context, id string) (*book.Book, error)
+		ReadBookByISBNFunc   func(ctx context.Context, isbn string) (*book.Book, error)
+		// ReadSubjectAliasesFunc reads the subject registry.
+		ReadSubjectAliasesFunc func(ctx context.Context) ([]book.SubjectAlias, error)
+		// ReadCustomFieldsFunc reads the custom fields that books have.
+		ReadCustomFieldsFunc func(ctx context.Context) ([]book.CustomField, error)
 	}
 )
 
@@ -59,10 +69,9 @@ func (iter *bookIterator) HasNext(ctx context.Context) bool {
 		return false
 	case iter.batchIndex == 0,
 		iter.headerIndex >= len(iter.batchHeaders)-1 && iter.batchSize < len(iter.batchHeaders): // request more headers
-		var filter book.Filter
 		limit := iter.batchSize + 1
 		offset := iter.batchSize * iter.batchIndex
-		headers, err := iter.database.ReadBookHeaders(ctx, filter, limit, offset)
+		headers, err := iter.database.ReadBookHeaders(ctx, iter.filter, limit, offset)
 		if err != nil {
 			iter.closed = true
 			iter.nextErr = fmt.Errorf("requesting more headers: %w", err)
@@ -91,7 +100,11 @@ func (iter *bookIterator) Next(ctx context.Context) (*book.Book, error) {
 	}
 	header := iter.batchHeaders[iter.headerIndex]
 	iter.headerIndex++
-	b, err := iter.database.ReadBook(ctx, header.ID)
+	readBook := iter.database.ReadBook
+	if iter.withoutImage {
+		readBook = iter.database.ReadBookMetadata
+	}
+	b, err := readBook(ctx, header.ID)
 	if err != nil {
 		return nil, fmt.Errorf("reading book: %w", err)
 	}
@@ -120,38 +133,121 @@ func (iter *bookIterator) AllBooks(ctx context.Context) ([]book.Book, error) {
 	return books, nil
 }
 
-func (d readOnlyDatabase) CreateBooks(ctx context.Context, books ...book.Book) ([]book.Book, error) {
+func (d readOnlyDatabase) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
 	return nil, d.notAllowed()
 }
 
+func (d readOnlyDatabase) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	return d.notAllowed()
+}
+
 func (d readOnlyDatabase) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
 	return d.ReadBookSubjectsFunc(ctx, limit, offset)
 }
 
+func (d readOnlyDatabase) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	return d.ReadBookAuthorsFunc(ctx, limit, offset)
+}
+
 func (d readOnlyDatabase) ReadBookHeaders(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
 	return d.ReadBookHeadersFunc(ctx, filter, limit, offset)
 }
 
+func (d readOnlyDatabase) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	return d.ReadShelvedBooksFunc(ctx)
+}
+
+func (d readOnlyDatabase) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	return d.ReadSeriesBooksFunc(ctx)
+}
+
 func (d readOnlyDatabase) ReadBook(ctx context.Context, id string) (*book.Book, error) {
 	return d.ReadBookFunc(ctx, id)
 }
 
-func (d readOnlyDatabase) UpdateBook(ctx context.Context, b book.Book, updateImage bool) error {
+// ReadBookMetadata reads the book without its image.
+func (d readOnlyDatabase) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	b, err := d.ReadBookFunc(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b.ImageBase64 = ""
+	return b, nil
+}
+
+func (d readOnlyDatabase) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	return d.ReadBookByISBNFunc(ctx, isbn)
+}
+
+func (d readOnlyDatabase) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	return d.notAllowed()
+}
+
+func (d readOnlyDatabase) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
 	return d.notAllowed()
 }
 
-func (d readOnlyDatabase) DeleteBook(ctx context.Context, id string) error {
+// ReadTrash reads no books because the books cannot be deleted.
+func (d readOnlyDatabase) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	return nil, nil
+}
+
+// ReadDeletedBook reads no book because the books cannot be deleted.
+func (d readOnlyDatabase) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	return nil, book.TrashNotFoundError(id)
+}
+
+func (d readOnlyDatabase) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
 	return d.notAllowed()
 }
 
+func (d readOnlyDatabase) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	return d.notAllowed()
+}
+
+func (d readOnlyDatabase) PurgeBooks(ctx context.Context, ids ...string) error {
+	return d.notAllowed()
+}
+
+// ReadBookHistory reads no history because the books cannot be changed.
+func (d readOnlyDatabase) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	return nil, nil
+}
+
+// ReadAuditLog reads no entries because the books cannot be changed.
+func (d readOnlyDatabase) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	return nil, nil
+}
+
+func (d readOnlyDatabase) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	return d.notAllowed()
+}
+
+// ReadAdminPassword reads no password because the password cannot be set.
 func (d readOnlyDatabase) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
-	return nil, d.notAllowed()
+	return nil, nil
 }
 
 func (d readOnlyDatabase) UpdateAdminPassword(ctx context.Context, hashedPassword string) error {
 	return d.notAllowed()
 }
 
+func (d readOnlyDatabase) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	return d.ReadSubjectAliasesFunc(ctx)
+}
+
+func (d readOnlyDatabase) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	return d.notAllowed()
+}
+
+func (d readOnlyDatabase) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	return d.ReadCustomFieldsFunc(ctx)
+}
+
+func (d readOnlyDatabase) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	return d.notAllowed()
+}
+
 func (d readOnlyDatabase) notAllowed() error {
 	return fmt.Errorf("not supported")
 }
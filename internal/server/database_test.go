@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -364,6 +365,50 @@ func TestReadBookSubjects(t *testing.T) {
 	}
 }
 
+func TestReadBookAuthors(t *testing.T) {
+	wantCtx := context.Background()
+	wantLimit := 3
+	wantOffset := 4
+	wantAuthors := []book.Author{{}}
+	f := func(ctx context.Context, limit, offset int) ([]book.Author, error) {
+		wantArgs := []interface{}{wantCtx, wantLimit, wantOffset}
+		gotArgs := []interface{}{ctx, limit, offset}
+		if !reflect.DeepEqual(wantArgs, gotArgs) {
+			t.Errorf("arguments not equal: \n wanted: %#v \n got:    %#v", wantArgs, gotArgs)
+		}
+		return wantAuthors, nil
+	}
+	d := readOnlyDatabase{
+		ReadBookAuthorsFunc: f,
+	}
+	got, err := d.ReadBookAuthors(wantCtx, wantLimit, wantOffset)
+	wantResult := []interface{}{wantAuthors, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
+func TestReadSeriesBooks(t *testing.T) {
+	wantCtx := context.Background()
+	wantSeriesBooks := []book.SeriesBook{{Series: "s"}}
+	f := func(ctx context.Context) ([]book.SeriesBook, error) {
+		if wantCtx != ctx {
+			t.Errorf("contexts not equal")
+		}
+		return wantSeriesBooks, nil
+	}
+	d := readOnlyDatabase{
+		ReadSeriesBooksFunc: f,
+	}
+	got, err := d.ReadSeriesBooks(wantCtx)
+	wantResult := []interface{}{wantSeriesBooks, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
 func TestDatabaseReadBookHeaders(t *testing.T) {
 	wantCtx := context.Background()
 	wantFilter := book.Filter{Subject: "everything"}
@@ -389,6 +434,66 @@ func TestDatabaseReadBookHeaders(t *testing.T) {
 	}
 }
 
+func TestDatabaseReadShelvedBooks(t *testing.T) {
+	wantCtx := context.Background()
+	wantBooks := []book.ShelvedBook{{}, {}}
+	f := func(ctx context.Context) ([]book.ShelvedBook, error) {
+		if wantCtx != ctx {
+			t.Errorf("contexts not equal")
+		}
+		return wantBooks, nil
+	}
+	d := readOnlyDatabase{
+		ReadShelvedBooksFunc: f,
+	}
+	got, err := d.ReadShelvedBooks(wantCtx)
+	wantResult := []interface{}{wantBooks, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
+func TestDatabaseReadSubjectAliases(t *testing.T) {
+	wantCtx := context.Background()
+	wantAliases := []book.SubjectAlias{{Name: "novels", Canonical: "Fiction"}}
+	f := func(ctx context.Context) ([]book.SubjectAlias, error) {
+		if wantCtx != ctx {
+			t.Errorf("contexts not equal")
+		}
+		return wantAliases, nil
+	}
+	d := readOnlyDatabase{
+		ReadSubjectAliasesFunc: f,
+	}
+	got, err := d.ReadSubjectAliases(wantCtx)
+	wantResult := []interface{}{wantAliases, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
+func TestDatabaseReadCustomFields(t *testing.T) {
+	wantCtx := context.Background()
+	wantFields := []book.CustomField{{Name: "Box", Type: book.FieldNumber}}
+	f := func(ctx context.Context) ([]book.CustomField, error) {
+		if wantCtx != ctx {
+			t.Errorf("contexts not equal")
+		}
+		return wantFields, nil
+	}
+	d := readOnlyDatabase{
+		ReadCustomFieldsFunc: f,
+	}
+	got, err := d.ReadCustomFields(wantCtx)
+	wantResult := []interface{}{wantFields, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
 func TestDatabaseReadBook(t *testing.T) {
 	wantCtx := context.Background()
 	wantID := "3"
@@ -412,16 +517,78 @@ func TestDatabaseReadBook(t *testing.T) {
 	}
 }
 
+func TestDatabaseReadBookByISBN(t *testing.T) {
+	wantCtx := context.Background()
+	wantISBN := "9780306406157"
+	wantBook := new(book.Book)
+	f := func(ctx context.Context, isbn string) (*book.Book, error) {
+		wantArgs := []interface{}{wantCtx, wantISBN}
+		gotArgs := []interface{}{ctx, isbn}
+		if !reflect.DeepEqual(wantArgs, gotArgs) {
+			t.Errorf("arguments not equal: \n wanted: %#v \n got:    %#v", wantArgs, gotArgs)
+		}
+		return wantBook, nil
+	}
+	d := readOnlyDatabase{
+		ReadBookByISBNFunc: f,
+	}
+	got, err := d.ReadBookByISBN(wantCtx, wantISBN)
+	wantResult := []interface{}{wantBook, nil}
+	gotResult := []interface{}{got, err}
+	if !reflect.DeepEqual(wantResult, gotResult) {
+		t.Errorf("results not equal: \n wanted: %#v \n got:    %#v", wantResult, gotResult)
+	}
+}
+
+func TestDatabaseReadBookHistory(t *testing.T) {
+	var d readOnlyDatabase
+	ctx := context.Background()
+	if got, err := d.ReadBookHistory(ctx, "id"); err != nil || len(got) != 0 {
+		t.Errorf("wanted no history, got %v (error: %v)", got, err)
+	}
+}
+
+func TestDatabaseReadDeletedBook(t *testing.T) {
+	var d readOnlyDatabase
+	ctx := context.Background()
+	if _, err := d.ReadDeletedBook(ctx, "id"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("wanted not found error, got %v", err)
+	}
+}
+
+func TestDatabaseReadAuditLog(t *testing.T) {
+	var d readOnlyDatabase
+	ctx := context.Background()
+	if got, err := d.ReadAuditLog(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted no audit log, got %v (error: %v)", got, err)
+	}
+}
+
+func TestDatabaseReadAdminPassword(t *testing.T) {
+	var d readOnlyDatabase
+	ctx := context.Background()
+	if got, err := d.ReadAdminPassword(ctx); err != nil || len(got) != 0 {
+		t.Errorf("wanted no password, got %q (error: %v)", got, err)
+	}
+}
+
 func TestDatabaseNotAllowed(t *testing.T) {
 	tests := []struct {
 		name string
 		f    func(ctx context.Context, d readOnlyDatabase) error
 	}{
-		{"CreateBooks", func(ctx context.Context, d readOnlyDatabase) error { _, err := d.CreateBooks(ctx); return err }},
+		{"CreateBooks", func(ctx context.Context, d readOnlyDatabase) error { _, err := d.CreateBooks(ctx, nil); return err }},
 		{"UpdateBook", func(ctx context.Context, d readOnlyDatabase) error { return d.UpdateBook(ctx, book.Book{}, false) }},
 		{"DeleteBook", func(ctx context.Context, d readOnlyDatabase) error { return d.DeleteBook(ctx, "id") }},
-		{"ReadAdminPassword", func(ctx context.Context, d readOnlyDatabase) error { _, err := d.ReadAdminPassword(ctx); return err }},
+		{"ImportTrash", func(ctx context.Context, d readOnlyDatabase) error { return d.ImportTrash(ctx, book.DeletedBook{}) }},
+		{"ImportAuditLog", func(ctx context.Context, d readOnlyDatabase) error { return d.ImportAuditLog(ctx, book.AuditEntry{}) }},
 		{"UpdateAdminPassword", func(ctx context.Context, d readOnlyDatabase) error { return d.UpdateAdminPassword(ctx, "Bilbo123") }},
+		{"MergeSubjects", func(ctx context.Context, d readOnlyDatabase) error {
+			return d.MergeSubjects(ctx, "Fiction", []string{"novels"})
+		}},
+		{"UpdateCustomFields", func(ctx context.Context, d readOnlyDatabase) error {
+			return d.UpdateCustomFields(ctx, book.CustomField{Name: "Box"})
+		}},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
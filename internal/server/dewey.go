@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/dewey"
+)
+
+type (
+	// deweyNode is a class, division, or section on the dewey browse page.
+	deweyNode struct {
+		Digits string
+		Number string
+		Name   string
+		Count  int
+	}
+)
+
+// unclassifiedNode is the value of the node form value to browse books without call numbers.
+const unclassifiedNode = "none"
+
+// getDeweyBrowse shows the number of books in each child of the Dewey Decimal node.
+// Books in sections and books without call numbers are listed in shelf order.
+// Sections are shown by number only because their names are not embedded.
+func (s *Server) getDeweyBrowse(w http.ResponseWriter, r *http.Request) {
+	var digits string
+	if !parseFormValue(w, r, "n", &digits, len(unclassifiedNode)) {
+		return
+	}
+	unclassified := digits == unclassifiedNode
+	node, ok := dewey.ParseNode(digits)
+	if !ok && !unclassified {
+		httpBadRequest(w, fmt.Errorf("invalid dewey node: %q", digits))
+		return
+	}
+	ctx := r.Context()
+	all, err := s.db.ReadShelvedBooks(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading shelved books: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	counts := make(map[dewey.Node]int)
+	var unclassifiedCount int
+	var shelf []book.ShelvedBook
+	for _, b := range all {
+		c, ok := dewey.Parse(b.DeweyDecClass)
+		switch {
+		case !ok:
+			unclassifiedCount++
+			if unclassified {
+				shelf = append(shelf, b)
+			}
+			continue
+		case !unclassified && node.IsSection() && node.Contains(*c):
+			shelf = append(shelf, b)
+		}
+		section := c.Section()
+		counts[""]++
+		for _, n := range section.Path() {
+			counts[n]++
+		}
+	}
+	sort.SliceStable(shelf, func(i, j int) bool {
+		a, b := shelf[i], shelf[j]
+		if dewey.Less(a.DeweyDecClass, b.DeweyDecClass) {
+			return true
+		}
+		if dewey.Less(b.DeweyDecClass, a.DeweyDecClass) {
+			return false
+		}
+		return a.Title < b.Title
+	})
+	pageLoader := func(ctx context.Context, limit, offset int) ([]book.ShelvedBook, error) {
+		if offset > len(shelf) {
+			offset = len(shelf)
+		}
+		end := offset + limit
+		if end > len(shelf) {
+			end = len(shelf)
+		}
+		return shelf[offset:end], nil
+	}
+	data, ok := loadPage(w, r, s.cfg.MaxRows, "Books", pageLoader)
+	if !ok {
+		return
+	}
+	newNode := func(n dewey.Node) deweyNode {
+		return deweyNode{
+			Digits: string(n),
+			Number: n.Number(),
+			Name:   n.Name(),
+			Count:  counts[n],
+		}
+	}
+	var path []deweyNode
+	for _, n := range node.Path() {
+		path = append(path, newNode(n))
+	}
+	var children []deweyNode
+	if !unclassified {
+		for _, n := range node.Children() {
+			children = append(children, newNode(n))
+		}
+	}
+	data["Node"] = newNode(node)
+	data["Path"] = path
+	data["Children"] = children
+	data["Unclassified"] = unclassified
+	data["UnclassifiedCount"] = unclassifiedCount
+	data["ListBooks"] = unclassified || node.IsSection()
+	data["NumberedSections"] = !unclassified && len(node) == 2 // only classes and divisions have names
+	s.serveTemplate(w, "dewey", data)
+}
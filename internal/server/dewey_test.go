@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetDeweyBrowse(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs"}, DeweyDecClass: "599.8 SMI"},
+		{Header: book.Header{ID: "2", Title: "Apes"}, DeweyDecClass: "599.88 ADA"},
+		{Header: book.Header{ID: "3", Title: "Ants"}, DeweyDecClass: "595.796 WIL"},
+		{Header: book.Header{ID: "4", Title: "Poems"}, DeweyDecClass: "811 FRO"},
+		{Header: book.Header{ID: "5", Title: "A Novel"}, DeweyDecClass: "F DOE"},
+		{Header: book.Header{ID: "6", Title: "Monkeys"}, DeweyDecClass: "599.8 BAR"},
+	}
+	tests := []struct {
+		name       string
+		url        string
+		db         database
+		wantCode   int
+		wantParts  []string
+		wantAbsent []string
+	}{
+		{
+			name:     "long node",
+			url:      "/browse/dewey?n=59999",
+			wantCode: 413,
+		},
+		{
+			name:     "invalid node",
+			url:      "/browse/dewey?n=5a",
+			wantCode: 400,
+		},
+		{
+			name: "db error",
+			url:  "/browse/dewey",
+			db: mockDatabase{
+				readShelvedBooksFunc: func() ([]book.ShelvedBook, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "root",
+			url:      "/browse/dewey",
+			wantCode: 200,
+			wantParts: []string{
+				`href="/browse/dewey?n=5"`,
+				`<span title="Count">4</span>`,
+				`href="/browse/dewey?n=8"`,
+				`href="/browse/dewey?n=none"`,
+				`<span title="Count">1</span>`,
+			},
+			wantAbsent: []string{`href="/browse/dewey?n=0"`, "Books in shelf order", "Sections are shown by number only"},
+		},
+		{
+			name:     "division",
+			url:      "/browse/dewey?n=59",
+			wantCode: 200,
+			wantParts: []string{
+				`href="/browse/dewey?n=595"`,
+				`href="/browse/dewey?n=599"`,
+				`<span title="Count">3</span>`,
+				"Sections are shown by number only",
+			},
+			wantAbsent: []string{`href="/browse/dewey?n=590"`},
+		},
+		{
+			name:     "section",
+			url:      "/browse/dewey?n=599",
+			wantCode: 200,
+			wantParts: []string{
+				"Books in shelf order",
+				`599.8 BAR`,
+				`599.8 SMI`,
+			},
+			wantAbsent: []string{"Ants", "599.88 ADA"},
+		},
+		{
+			name:      "unclassified",
+			url:       "/browse/dewey?n=none",
+			wantCode:  200,
+			wantParts: []string{"A Novel"},
+		},
+		{
+			name:      "next page",
+			url:       "/browse/dewey?n=599&page=2",
+			wantCode:  200,
+			wantParts: []string{"599.88 ADA"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				cfg:  Config{MaxRows: 2},
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getDeweyBrowse(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+				for _, absent := range test.wantAbsent {
+					if strings.Contains(got, absent) {
+						t.Errorf("unwanted %q in body: %v", absent, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetDeweyBrowseShelfOrder(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "B"}, DeweyDecClass: "599.88 ADA"},
+		{Header: book.Header{ID: "2", Title: "C"}, DeweyDecClass: "599.8 SMI"},
+		{Header: book.Header{ID: "3", Title: "A"}, DeweyDecClass: "599 ZOO"},
+	}
+	s := Server{
+		db:   memory.NewDatabase(books...),
+		tmpl: parseTemplate(staticFS),
+		cfg:  Config{MaxRows: 10},
+	}
+	r := httptest.NewRequest("GET", "/browse/dewey?n=599", nil)
+	w := httptest.NewRecorder()
+	s.getDeweyBrowse(w, r)
+	got := w.Body.String()
+	a, c, b := strings.Index(got, "599 ZOO"), strings.Index(got, "599.8 SMI"), strings.Index(got, "599.88 ADA")
+	if a < 0 || !(a < c && c < b) {
+		t.Errorf("books not in shelf order: %v", got)
+	}
+}
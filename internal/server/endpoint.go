@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
 )
 
 func (s *Server) getBookSubjects(w http.ResponseWriter, r *http.Request) {
@@ -24,16 +25,30 @@ func (s *Server) getBookHeaders(w http.ResponseWriter, r *http.Request) {
 	if !parseFormValue(w, r, "s", &filter.Subject, 256) {
 		return
 	}
+	if !parseDetailsFilter(w, r, &filter) {
+		return
+	}
 	pageLoader := func(ctx context.Context, limit, offset int) ([]book.Header, error) {
 		return s.db.ReadBookHeaders(ctx, filter, limit, offset)
 	}
 	if data, ok := loadPage(w, r, s.cfg.MaxRows, "Books", pageLoader); ok {
 		data["Filter"] = filter.HeaderPart
 		data["Subject"] = filter.Subject
+		data["Language"] = filter.Language
+		data["Format"] = filter.Format
+		data["ReadingLevel"] = filter.ReadingLevel
 		s.serveTemplate(w, "list", data)
 	}
 }
 
+// parseDetailsFilter parses the language, format, and reading level of the books to list.
+// The format is "book-format" because citations are downloaded in formats.
+func parseDetailsFilter(w http.ResponseWriter, r *http.Request, filter *book.Filter) bool {
+	return parseFormValue(w, r, "language", &filter.Language, 32) &&
+		parseFormValue(w, r, "book-format", &filter.Format, 32) &&
+		parseFormValue(w, r, "reading-level", &filter.ReadingLevel, 32)
+}
+
 func (s *Server) getBook(w http.ResponseWriter, r *http.Request) {
 	var id string
 	if !parseFormValue(w, r, "id", &id, 64) {
@@ -46,21 +61,43 @@ func (s *Server) getBook(w http.ResponseWriter, r *http.Request) {
 		httpInternalServerError(w, err)
 		return
 	}
-	s.serveTemplate(w, "book", b)
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	data := bookPage{
+		Book:         *b,
+		Metadata:     newBookMetadata(*b),
+		CustomFields: fields,
+	}
+	s.serveTemplate(w, "book", data)
 }
 
 func (s *Server) getAdmin(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Book               book.Book
 		ValidPasswordRunes string
+		CanLookup          bool
+		Note               string
+		CustomFields       []book.CustomField
 	}{
 		ValidPasswordRunes: html.EscapeString(validPasswordRunes),
+		CanLookup:          s.metadata != nil,
 	}
+	ctx := r.Context()
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	data.CustomFields = fields
 	query := r.URL.Query()
 	hasID := query.Has("book-id")
 	if hasID {
 		id := query.Get("book-id")
-		ctx := r.Context()
 		b, err := s.db.ReadBook(ctx, id)
 		if err != nil {
 			err = fmt.Errorf("reading book: %w", err)
@@ -68,6 +105,16 @@ func (s *Server) getAdmin(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		data.Book = *b
+	} else if isbn13, isbn10, err := isbn.Pair(query.Get("ean-isbn-13"), query.Get("upc-isbn-10")); err == nil {
+		data.Book.EanIsbn13, data.Book.UpcIsbn10 = isbn13, isbn10 // prefill from the scan page
+		if s.metadata != nil && (len(isbn13) != 0 || len(isbn10) != 0) {
+			note, err := s.lookupMetadata(ctx, &data.Book)
+			if err != nil {
+				httpInternalServerError(w, err)
+				return
+			}
+			data.Note = note
+		}
 	}
 	s.serveTemplate(w, "admin", data)
 }
@@ -79,7 +126,29 @@ func (s *Server) postBook(w http.ResponseWriter, r *http.Request) {
 		httpBadRequest(w, err)
 		return
 	}
-	books, err := s.db.CreateBooks(ctx, *b)
+	if err := s.canonicalizeSubjects(ctx, b); err != nil {
+		httpInternalServerError(w, err)
+		return
+	}
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	if b.Custom, err = customValues(r, fields); err != nil {
+		httpBadRequest(w, err)
+		return
+	}
+	var actor string
+	if !parseActor(w, r, &actor) {
+		return
+	}
+	audit := book.Audit{
+		Actor:  actor,
+		Action: book.ActionCreate,
+	}
+	books, err := s.db.CreateBooks(ctx, []book.Book{*b}, audit)
 	if err != nil {
 		err = fmt.Errorf("creating book: %w", err)
 		httpInternalServerError(w, err)
@@ -95,8 +164,22 @@ func (s *Server) putBook(w http.ResponseWriter, r *http.Request) {
 		httpBadRequest(w, err)
 		return
 	}
-	var updateImageVal string
-	if !parseFormValue(w, r, "update-image", &updateImageVal, 10) {
+	if err := s.canonicalizeSubjects(ctx, b); err != nil {
+		httpInternalServerError(w, err)
+		return
+	}
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	if b.Custom, err = customValues(r, fields); err != nil {
+		httpBadRequest(w, err)
+		return
+	}
+	var updateImageVal, actor string
+	if !parseFormValue(w, r, "update-image", &updateImageVal, 10) || !parseActor(w, r, &actor) {
 		return
 	}
 	var updateImage bool
@@ -107,7 +190,11 @@ func (s *Server) putBook(w http.ResponseWriter, r *http.Request) {
 		updateImage = true
 		b.ImageBase64 = ""
 	}
-	err = s.db.UpdateBook(ctx, *b, updateImage)
+	audit := book.Audit{
+		Actor:  actor,
+		Action: book.ActionUpdate,
+	}
+	err = s.db.UpdateBook(ctx, *b, updateImage, audit)
 	if err != nil {
 		err = fmt.Errorf("updating book: %w", err)
 		httpInternalServerError(w, err)
@@ -117,12 +204,19 @@ func (s *Server) putBook(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) deleteBook(w http.ResponseWriter, r *http.Request) {
-	var id string
-	if !parseFormValue(w, r, "id", &id, 64) {
+	var id, actor string
+	if !parseFormValue(w, r, "id", &id, 64) || !parseActor(w, r, &actor) {
 		return
 	}
 	ctx := r.Context()
-	if err := s.db.DeleteBook(ctx, id); err != nil {
+	before, err := s.db.ReadBook(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("reading book: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	entry := book.NewAuditEntry(actor, book.ActionDelete, *before, book.Book{})
+	if err := s.db.DeleteBook(ctx, id, entry); err != nil {
 		err = fmt.Errorf("deleting book: %w", err)
 		httpInternalServerError(w, err)
 		return
@@ -198,8 +292,16 @@ func bookFrom(ctx context.Context, w http.ResponseWriter, r *http.Request) (*boo
 	case !parseFormValue(w, r, "id", &sb.ID, 256),
 		!parseFormValue(w, r, "title", &sb.Title, 256),
 		!parseFormValue(w, r, "author", &sb.Author, 256),
+		!parseFormValue(w, r, "contributors", &sb.Contributors, 2048),
 		!parseFormValue(w, r, "description", &sb.Description, 10000),
 		!parseFormValue(w, r, "subject", &sb.Subject, 256),
+		!parseFormValue(w, r, "tags", &sb.Tags, 1024),
+		!parseFormValue(w, r, "series", &sb.Series, 256),
+		!parseFormValue(w, r, "volume", &sb.Volume, 16),
+		!parseFormValue(w, r, "language", &sb.Language, 32),
+		!parseFormValue(w, r, "edition", &sb.Edition, 256),
+		!parseFormValue(w, r, "format", &sb.Format, 32),
+		!parseFormValue(w, r, "reading-level", &sb.ReadingLevel, 32),
 		!parseFormValue(w, r, "dewey-dec-class", &sb.DeweyDecClass, 256),
 		!parseFormValue(w, r, "pages", &sb.Pages, 32),
 		!parseFormValue(w, r, "publisher", &sb.Publisher, 256),
@@ -208,21 +310,18 @@ func bookFrom(ctx context.Context, w http.ResponseWriter, r *http.Request) (*boo
 		!parseFormValue(w, r, "ean-isbn-13", &sb.EanIsbn13, 32),
 		!parseFormValue(w, r, "upc-isbn-10", &sb.UpcIsbn10, 32):
 		return nil, fmt.Errorf("parse error")
-	case len(sb.Title) == 0:
-		return nil, fmt.Errorf("title required")
-	case len(sb.Author) == 0:
-		return nil, fmt.Errorf("author required")
-	case len(sb.Subject) == 0:
-		return nil, fmt.Errorf("subject required")
-	case len(sb.AddedDate) == 0:
-		return nil, fmt.Errorf("added date required")
 	}
+	isbn13, isbn10, err := isbn.Pair(sb.EanIsbn13, sb.UpcIsbn10)
+	if err != nil {
+		return nil, err
+	}
+	sb.EanIsbn13, sb.UpcIsbn10 = isbn13, isbn10
 	b, err := sb.Book(dateLayout)
-	switch {
-	case err != nil:
+	if err != nil {
 		return nil, fmt.Errorf("parsing book from text: %w", err)
-	case b.Pages <= 0:
-		return nil, fmt.Errorf("pages required")
+	}
+	if err := validateBook(*b); err != nil {
+		return nil, err
 	}
 	imageBase64, err := parseImage(ctx, r)
 	if err != nil {
@@ -232,21 +331,32 @@ func bookFrom(ctx context.Context, w http.ResponseWriter, r *http.Request) (*boo
 	return b, nil
 }
 
+// validateBook checks that the book has the fields that are required when it is saved on the admin page or imported.
+func validateBook(b book.Book) error {
+	switch {
+	case len(b.Title) == 0:
+		return fmt.Errorf("title required")
+	case len(b.Author) == 0:
+		return fmt.Errorf("author required")
+	case len(b.Subject) == 0:
+		return fmt.Errorf("subject required")
+	case b.AddedDate.IsZero():
+		return fmt.Errorf("added date required")
+	case b.Pages <= 0:
+		return fmt.Errorf("pages required")
+	case b.Volume < 0:
+		return fmt.Errorf("volume cannot be negative")
+	case b.Volume != 0 && len(b.Series) == 0:
+		return fmt.Errorf("series required for volume")
+	}
+	return nil
+}
+
 func loadPage[V interface{}](w http.ResponseWriter, r *http.Request, maxRows int, sliceName string, pageLoader func(cxt context.Context, limit, offset int) ([]V, error)) (data map[string]interface{}, ok bool) {
-	var a string
-	if !parseFormValue(w, r, "page", &a, 32) {
+	var page int
+	if !parsePage(w, r, &page) {
 		return nil, false
 	}
-	page := 1
-	if len(a) != 0 {
-		i, err := strconv.Atoi(a)
-		if err != nil {
-			err = fmt.Errorf("invalid page: %w", err)
-			httpBadRequest(w, err)
-			return nil, false
-		}
-		page = i
-	}
 	offset := (page - 1) * maxRows
 	limit := maxRows + 1
 	ctx := r.Context()
@@ -262,9 +372,29 @@ func loadPage[V interface{}](w http.ResponseWriter, r *http.Request, maxRows int
 		data["NextPage"] = page + 1
 	}
 	data[sliceName] = slice
+	data["Page"] = page
 	return data, true
 }
 
+// parsePage reads the page form value into dest, which is 1 if the page is not set.
+func parsePage(w http.ResponseWriter, r *http.Request, dest *int) (ok bool) {
+	var a string
+	if !parseFormValue(w, r, "page", &a, 32) {
+		return false
+	}
+	*dest = 1
+	if len(a) != 0 {
+		i, err := strconv.Atoi(a)
+		if err != nil {
+			err = fmt.Errorf("invalid page: %w", err)
+			httpBadRequest(w, err)
+			return false
+		}
+		*dest = i
+	}
+	return true
+}
+
 // parseFormValue reads the value the form by key into dest.
 // If the length of the value is longer than maxLength, an error will be written tot he response writer and false is returned.
 func parseFormValue(w http.ResponseWriter, r *http.Request, key string, dest *string, maxLength int) (ok bool) {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
 )
 
 func TestGetRequest(t *testing.T) {
@@ -23,6 +24,7 @@ func TestGetRequest(t *testing.T) {
 		readBook         func(id string) (*book.Book, error)
 		readBookSubjects func(limit, offset int) ([]book.Subject, error)
 		readBookHeaders  func(f book.Filter, limit, offset int) ([]book.Header, error)
+		readCustomFields func() ([]book.CustomField, error)
 		wantCode         int
 		wantData         []string
 		unwantedData     []string
@@ -73,6 +75,22 @@ func TestGetRequest(t *testing.T) {
 				"Update Book",
 			},
 		},
+		{
+			name:     "scanned isbn",
+			url:      "/admin?ean-isbn-13=9780306406157&upc-isbn-10=",
+			wantCode: 200,
+			wantData: []string{
+				"Create Book",
+				`name="ean-isbn-13" value="9780306406157"`,
+				`name="upc-isbn-10" value="0306406152"`,
+			},
+		},
+		{
+			name:     "scanned invalid isbn",
+			url:      "/admin?ean-isbn-13=9780306406158",
+			wantCode: 200,
+			wantData: []string{`name="ean-isbn-13" value=""`},
+		},
 		{
 			name: "db error",
 			url:  "/admin?book-id=BAD",
@@ -89,11 +107,22 @@ func TestGetRequest(t *testing.T) {
 					return nil, fmt.Errorf("unwanted id: %v", id)
 				}
 				b := book.Book{
-					Header:      book.Header{ID: "5618941"},
-					Description: "info397",
+					Header:       book.Header{ID: "5618941"},
+					Description:  "info397",
+					Format:       "audiobook",
+					ReadingLevel: "adult",
+					Custom:       map[string]string{"Donor": "Ann", "Shelf": "Bottom"},
 				}
 				return &b, nil
 			},
+			readCustomFields: func() ([]book.CustomField, error) {
+				fields := []book.CustomField{
+					{Name: "Donor", Type: book.FieldText},
+					{Name: "Box", Type: book.FieldNumber},
+					{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+				}
+				return fields, nil
+			},
 			wantCode: 200,
 			wantData: []string{
 				"Delete Book",
@@ -101,6 +130,11 @@ func TestGetRequest(t *testing.T) {
 				"Set Admin Password",
 				"info397",
 				"&lt;=&gt;",
+				`<option value="audiobook" selected>`,
+				`<option value="adult" selected>`,
+				`name="custom:Donor" value="Ann"`,
+				`type="number" name="custom:Box" value=""`,
+				`<option value="Bottom" selected>`,
 			},
 			unwantedData: []string{
 				"Create Book",
@@ -134,6 +168,14 @@ func TestGetRequest(t *testing.T) {
 						Author:  "a",
 						Subject: "s",
 					},
+					Tags:          []string{"tag 9"},
+					Contributors:  []book.Contributor{{Name: "c 10", Role: "translator"}},
+					Series:        "ser 11",
+					Volume:        12,
+					Language:      "es",
+					Edition:       "2nd",
+					Format:        "dvd",
+					ReadingLevel:  "young adult",
 					DeweyDecClass: "ddc",
 					Pages:         18,
 					Publisher:     "pub",
@@ -142,11 +184,21 @@ func TestGetRequest(t *testing.T) {
 					EanIsbn13:     "weird_isbn",
 					UpcIsbn10:     "isbn10",
 					ImageBase64:   "invalid_file",
+					Custom:        map[string]string{"Box": "12", "Old": "removed field"},
 				}
 				return &b, nil
 			},
-			wantCode: 200,
-			wantData: []string{"id7", "title8", "weird_isbn"},
+			readCustomFields: func() ([]book.CustomField, error) {
+				fields := []book.CustomField{
+					{Name: "Donor", Type: book.FieldText},
+					{Name: "Box", Type: book.FieldNumber},
+					{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+				}
+				return fields, nil
+			},
+			wantCode:     200,
+			wantData:     []string{"id7", "title8", "weird_isbn", `href="/list?s=tag+9"`, `href="/author?name=a"`, `href="/author?name=c+10">c 10</a> (translator)`, `href="/series?name=ser+11">ser 11</a>, volume 12`, `href="/list?language=es"`, "2nd", `href="/list?book-format=dvd"`, `href="/list?reading-level=young+adult"`, "<span>Box</span>\n\t\t<span>12</span>"},
+			unwantedData: []string{"<span>Donor</span>", "removed field"},
 		},
 		{
 			name:     "long filter",
@@ -221,9 +273,39 @@ func TestGetRequest(t *testing.T) {
 			},
 			unwantedData: []string{"MASTER_ID"},
 		},
+		{
+			name:     "language, format, and reading level",
+			url:      "/list?language=es&book-format=dvd&reading-level=children",
+			wantCode: 200,
+			maxRows:  1,
+			readBookHeaders: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+				wantFilter := book.Filter{Language: "es", Format: "dvd", ReadingLevel: "children"}
+				if !reflect.DeepEqual(wantFilter, f) {
+					return nil, fmt.Errorf("filters not equal: \n wanted: %v \n got:    %v", wantFilter, f)
+				}
+				headers := []book.Header{
+					{Title: "Dragons"},
+					{Title: "Elves"},
+				}
+				return headers, nil
+			},
+			wantData: []string{
+				`<option value="dvd" selected>`,
+				`<option value="children" selected>`,
+				`name="language" value="es"`,
+				`name="book-format" value="dvd"`, // preserve filter when loading next page
+				`name="reading-level" value="children"`,
+				`&amp;language=es&amp;book-format=dvd&amp;reading-level=children&amp;page=1&amp;format=bibtex`,
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name+" "+test.url, func(t *testing.T) {
+			if test.readCustomFields == nil {
+				test.readCustomFields = func() ([]book.CustomField, error) {
+					return nil, nil
+				}
+			}
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest("GET", test.url, nil)
 			var sb strings.Builder
@@ -235,6 +317,7 @@ func TestGetRequest(t *testing.T) {
 					readBookFunc:         test.readBook,
 					readBookSubjectsFunc: test.readBookSubjects,
 					readBookHeadersFunc:  test.readBookHeaders,
+					readCustomFieldsFunc: test.readCustomFields,
 				},
 				tmpl: parseTemplate(staticFS),
 				out:  &sb,
@@ -269,11 +352,14 @@ func TestPostRequest(t *testing.T) {
 		name                string
 		url                 string
 		form                map[string]string
-		createBooks         func(books ...book.Book) ([]book.Book, error)
-		updateBook          func(b book.Book, updateImage bool) error
+		createBooks         func(books []book.Book, audits ...book.Audit) ([]book.Book, error)
+		readBook            func(id string) (*book.Book, error)
+		updateBook          func(b book.Book, updateImage bool, audits ...book.Audit) error
 		hash                func(password []byte) (hashedPassword []byte, err error)
 		updateAdminPassword func(hashedPassword string) error
-		deleteBook          func(id string) error
+		deleteBook          func(id string, entries ...book.AuditEntry) error
+		readSubjectAliases  func() ([]book.SubjectAlias, error)
+		readCustomFields    func() ([]book.CustomField, error)
 		wantCode            int
 		wantLocation        string
 	}{
@@ -295,7 +381,7 @@ func TestPostRequest(t *testing.T) {
 				"pages":      "1",
 				"added-date": "2022-11-13",
 			},
-			createBooks: func(books ...book.Book) ([]book.Book, error) {
+			createBooks: func(books []book.Book, audits ...book.Audit) ([]book.Book, error) {
 				return nil, fmt.Errorf("db error")
 			},
 			wantCode: 500,
@@ -310,27 +396,140 @@ func TestPostRequest(t *testing.T) {
 				"pages":      "1",
 				"added-date": "2022-11-13",
 			},
-			createBooks: func(books ...book.Book) ([]book.Book, error) {
+			createBooks: func(books []book.Book, audits ...book.Audit) ([]book.Book, error) {
 				want := book.Book{
 					Header: book.Header{
 						Title:   "t",
 						Author:  "a",
 						Subject: "s",
 					},
+					Language:  "en",
+					Format:    "print",
 					Pages:     1,
 					AddedDate: time.Date(2022, 11, 13, 0, 0, 0, 0, time.UTC),
 				}
+				wantAudits := []book.Audit{{Actor: "admin", Action: book.ActionCreate}}
 				switch {
 				case len(books) != 1:
 					return nil, fmt.Errorf("wanted 1 book, got %v", len(books))
-				case want != books[0]:
+				case !reflect.DeepEqual(want, books[0]):
 					return nil, fmt.Errorf("books not equal: \n wanted: %v \n got:    %v", want, books[0])
+				case !reflect.DeepEqual(wantAudits, audits):
+					return nil, fmt.Errorf("audits not equal: \n wanted: %v \n got:    %v", wantAudits, audits)
 				}
 				return []book.Book{{Header: book.Header{ID: "fg34"}}}, nil
 			},
 			wantCode:     303,
 			wantLocation: "/book?id=fg34",
 		},
+		{
+			name: "subject aliases error",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":      "t",
+				"author":     "a",
+				"subject":    "s",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+			},
+			readSubjectAliases: func() ([]book.SubjectAlias, error) {
+				return nil, fmt.Errorf("db error")
+			},
+			wantCode: 500,
+		},
+		{
+			name: "custom fields error",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":      "t",
+				"author":     "a",
+				"subject":    "s",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+			},
+			readCustomFields: func() ([]book.CustomField, error) {
+				return nil, fmt.Errorf("db error")
+			},
+			wantCode: 500,
+		},
+		{
+			name: "bad custom value",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":      "t",
+				"author":     "a",
+				"subject":    "s",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+				"custom:Box": "twelve",
+			},
+			wantCode: 400,
+		},
+		{
+			name: "custom values",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":        "t",
+				"author":       "a",
+				"subject":      "s",
+				"pages":        "1",
+				"added-date":   "2022-11-13",
+				"custom:Box":   " 12 ",
+				"custom:Shelf": "top",
+				"custom:Other": "ignored",
+				"actor":        " Ann ",
+			},
+			createBooks: func(books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+				if want, got := "Ann", audits[0].Actor; want != got {
+					return nil, fmt.Errorf("actors not equal: wanted %q, got %q", want, got)
+				}
+				if want, got := map[string]string{"Box": "12", "Shelf": "Top"}, books[0].Custom; !reflect.DeepEqual(want, got) {
+					return nil, fmt.Errorf("custom values not equal: wanted %q, got %q", want, got)
+				}
+				return []book.Book{{Header: book.Header{ID: "fg37"}}}, nil
+			},
+			wantCode:     303,
+			wantLocation: "/book?id=fg37",
+		},
+		{
+			name: "canonical subject",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":      "t",
+				"author":     "a",
+				"subject":    " NOVELS ",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+			},
+			createBooks: func(books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+				if want, got := "Fiction", books[0].Subject; want != got {
+					return nil, fmt.Errorf("subjects not equal: wanted %q, got %q", want, got)
+				}
+				return []book.Book{{Header: book.Header{ID: "fg35"}}}, nil
+			},
+			wantCode:     303,
+			wantLocation: "/book?id=fg35",
+		},
+		{
+			name: "canonical tags",
+			url:  "/book/create",
+			form: map[string]string{
+				"title":      "t",
+				"author":     "a",
+				"subject":    "Fiction",
+				"tags":       "poetry; novels",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+			},
+			createBooks: func(books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+				if want, got := []string{"poetry"}, books[0].Tags; !reflect.DeepEqual(want, got) {
+					return nil, fmt.Errorf("tags not equal: wanted %q, got %q", want, got)
+				}
+				return []book.Book{{Header: book.Header{ID: "fg36"}}}, nil
+			},
+			wantCode:     303,
+			wantLocation: "/book?id=fg36",
+		},
 		{
 			name: "bad book",
 			url:  "/book/update",
@@ -355,7 +554,7 @@ func TestPostRequest(t *testing.T) {
 				"pages":      "1",
 				"added-date": "2022-11-13",
 			},
-			updateBook: func(b book.Book, updateImage bool) error {
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
 				return fmt.Errorf("db error")
 			},
 			wantCode: 500,
@@ -371,7 +570,7 @@ func TestPostRequest(t *testing.T) {
 				"pages":      "1",
 				"added-date": "2022-11-13",
 			},
-			updateBook: func(b book.Book, updateImage bool) error {
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
 				want := book.Book{
 					Header: book.Header{
 						ID:      "keep_me",
@@ -379,11 +578,13 @@ func TestPostRequest(t *testing.T) {
 						Author:  "a",
 						Subject: "s",
 					},
+					Language:  "en",
+					Format:    "print",
 					Pages:     1,
 					AddedDate: time.Date(2022, 11, 13, 0, 0, 0, 0, time.UTC),
 				}
 				switch {
-				case want != b:
+				case !reflect.DeepEqual(want, b):
 					return fmt.Errorf("books not equal: \n wanted: %v \n got:    %v", want, b)
 				case updateImage:
 					return fmt.Errorf("did not want to update image")
@@ -393,6 +594,47 @@ func TestPostRequest(t *testing.T) {
 			wantCode:     303,
 			wantLocation: "/book?id=keep_me",
 		},
+		{
+			name: "audit entry",
+			url:  "/book/update",
+			form: map[string]string{
+				"id":         "keep_me",
+				"title":      "t",
+				"author":     "a",
+				"subject":    "s",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+				"actor":      " Ann ",
+			},
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
+				if want := []book.Audit{{Actor: "Ann", Action: book.ActionUpdate}}; !reflect.DeepEqual(want, audits) {
+					return fmt.Errorf("audits not equal: \n wanted: %v \n got:    %v", want, audits)
+				}
+				return nil
+			},
+			wantCode:     303,
+			wantLocation: "/book?id=keep_me",
+		},
+		{
+			name: "canonical subject",
+			url:  "/book/update",
+			form: map[string]string{
+				"id":         "keep_me",
+				"title":      "t",
+				"author":     "a",
+				"subject":    "Science   Fiction",
+				"pages":      "1",
+				"added-date": "2022-11-13",
+			},
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
+				if want, got := "Science Fiction", b.Subject; want != got {
+					return fmt.Errorf("subjects not equal: wanted %q, got %q", want, got)
+				}
+				return nil
+			},
+			wantCode:     303,
+			wantLocation: "/book?id=keep_me",
+		},
 		{
 			name: "update image too long",
 			url:  "/book/update",
@@ -419,7 +661,7 @@ func TestPostRequest(t *testing.T) {
 				"added-date":   "2022-11-13",
 				"update-image": "true",
 			},
-			updateBook: func(b book.Book, updateImage bool) error {
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
 				switch {
 				case !updateImage:
 					return fmt.Errorf("did not want to update image")
@@ -441,7 +683,7 @@ func TestPostRequest(t *testing.T) {
 				"added-date":   "2022-11-13",
 				"update-image": "clear",
 			},
-			updateBook: func(b book.Book, updateImage bool) error {
+			updateBook: func(b book.Book, updateImage bool, audits ...book.Audit) error {
 				switch {
 				case len(b.ImageBase64) != 0:
 					return fmt.Errorf("wanted image to be zeroed")
@@ -459,18 +701,29 @@ func TestPostRequest(t *testing.T) {
 			form: map[string]string{
 				"id": "long+abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890",
 			},
-			deleteBook: func(id string) error {
+			deleteBook: func(id string, entries ...book.AuditEntry) error {
 				return fmt.Errorf("db error)")
 			},
 			wantCode: 413,
 		},
+		{
+			name: "read error",
+			url:  "/book/delete",
+			form: map[string]string{
+				"id": "x123",
+			},
+			readBook: func(id string) (*book.Book, error) {
+				return nil, fmt.Errorf("db error")
+			},
+			wantCode: 500,
+		},
 		{
 			name: "db error",
 			url:  "/book/delete",
 			form: map[string]string{
 				"id": "x123",
 			},
-			deleteBook: func(id string) error {
+			deleteBook: func(id string, entries ...book.AuditEntry) error {
 				return fmt.Errorf("db error)")
 			},
 			wantCode: 500,
@@ -481,9 +734,14 @@ func TestPostRequest(t *testing.T) {
 			form: map[string]string{
 				"id": "x123",
 			},
-			deleteBook: func(id string) error {
-				if id != "x123" {
+			deleteBook: func(id string, entries ...book.AuditEntry) error {
+				switch {
+				case id != "x123":
 					return fmt.Errorf("unwanted id: %q", id)
+				case len(entries) != 1:
+					return fmt.Errorf("wanted 1 audit entry, got %v", len(entries))
+				case entries[0].Actor != "admin", entries[0].Action != book.ActionDelete:
+					return fmt.Errorf("unwanted audit entry: %v", entries[0])
 				}
 				return nil
 			},
@@ -569,12 +827,34 @@ func TestPostRequest(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name+" "+test.url, func(t *testing.T) {
 			test.form["p"] = "v4lid_P"
+			if test.readBook == nil {
+				test.readBook = func(id string) (*book.Book, error) {
+					return &book.Book{Header: book.Header{ID: id, Title: "old"}}, nil
+				}
+			}
+			if test.readSubjectAliases == nil {
+				test.readSubjectAliases = func() ([]book.SubjectAlias, error) {
+					return []book.SubjectAlias{{Name: "novels", Canonical: "Fiction"}}, nil
+				}
+			}
+			if test.readCustomFields == nil {
+				test.readCustomFields = func() ([]book.CustomField, error) {
+					fields := []book.CustomField{
+						{Name: "Box", Type: book.FieldNumber},
+						{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+					}
+					return fields, nil
+				}
+			}
 			s := Server{
 				db: mockDatabase{
 					createBooksFunc:         test.createBooks,
+					readBookFunc:            test.readBook,
 					updateBookFunc:          test.updateBook,
 					deleteBookFunc:          test.deleteBook,
 					updateAdminPasswordFunc: test.updateAdminPassword,
+					readSubjectAliasesFunc:  test.readSubjectAliases,
+					readCustomFieldsFunc:    test.readCustomFields,
 					readAdminPasswordFunc: func() (hashedPassword []byte, err error) {
 						return []byte("H#shed+P"), nil
 					},
@@ -735,6 +1015,16 @@ func TestBookFrom(t *testing.T) {
 		{"no added Date", map[string]string{"title": "a", "author": "b", "subject": "c"}, nil, false},
 		{"bad parse", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "eight"}, nil, false},
 		{"bad pages", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "-1"}, nil, false},
+		{"negative volume", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8", "series": "d", "volume": "-2"}, nil, false},
+		{"volume without series", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8", "volume": "2"}, nil, false},
+		{"unknown format", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8", "format": "scroll"}, nil, false},
+		{"bad isbn", map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8", "ean-isbn-13": "9780306406158"}, nil, false},
+		{
+			name:   "isbn-13 filled from isbn-10",
+			form:   map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8", "upc-isbn-10": "0-8044-2957-x"},
+			want:   &book.Book{Header: book.Header{Title: "a", Author: "b", Subject: "c"}, Language: "en", Format: "print", AddedDate: dateA, Pages: 8, EanIsbn13: "9780804429573", UpcIsbn10: "080442957X"},
+			wantOk: true,
+		},
 		{
 			name:   "long id (300 chars)",
 			form:   map[string]string{"id": "012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789"},
@@ -743,7 +1033,7 @@ func TestBookFrom(t *testing.T) {
 		{
 			name:   "minimal",
 			form:   map[string]string{"title": "a", "author": "b", "subject": "c", "added-date": textAD, "pages": "8"},
-			want:   &book.Book{Header: book.Header{Title: "a", Author: "b", Subject: "c"}, AddedDate: dateA, Pages: 8},
+			want:   &book.Book{Header: book.Header{Title: "a", Author: "b", Subject: "c"}, Language: "en", Format: "print", AddedDate: dateA, Pages: 8},
 			wantOk: true,
 		},
 		{
@@ -752,6 +1042,14 @@ func TestBookFrom(t *testing.T) {
 				"title":           "a",
 				"author":          "b",
 				"subject":         "c",
+				"tags":            "h; C;  i ",
+				"contributors":    "j k | Editor",
+				"series":          " l  m ",
+				"volume":          "3",
+				"language":        "ES",
+				"edition":         "2nd",
+				"format":          "audiobook",
+				"reading-level":   "young adult",
 				"added-date":      textAD,
 				"pages":           "8",
 				"id":              "d",
@@ -759,8 +1057,8 @@ func TestBookFrom(t *testing.T) {
 				"dewey-dec-class": "f",
 				"publisher":       "g",
 				"publish-date":    textPD,
-				"ean-isbn-13":     "h",
-				"upc-isbn-10":     "i",
+				"ean-isbn-13":     "978-0-306-40615-7",
+				"upc-isbn-10":     "0-306-40615-2",
 			},
 			want: &book.Book{
 				Header: book.Header{
@@ -769,14 +1067,22 @@ func TestBookFrom(t *testing.T) {
 					Author:  "b",
 					Subject: "c",
 				},
+				Tags:          []string{"h", "i"},
+				Contributors:  []book.Contributor{{Name: "j k", Role: "editor", SortName: "k, j"}},
+				Series:        "l m",
+				Volume:        3,
+				Language:      "es",
+				Edition:       "2nd",
+				Format:        "audiobook",
+				ReadingLevel:  "young adult",
 				Description:   "e",
 				DeweyDecClass: "f",
 				Publisher:     "g",
 				PublishDate:   dateP,
 				AddedDate:     dateA,
 				Pages:         8,
-				EanIsbn13:     "h",
-				UpcIsbn10:     "i",
+				EanIsbn13:     "9780306406157",
+				UpcIsbn10:     "0306406152",
 			},
 			wantOk: true,
 		},
@@ -794,7 +1100,7 @@ func TestBookFrom(t *testing.T) {
 				}
 			case err != nil:
 				t.Errorf("unwanted error: %v", err)
-			case *test.want != *got:
+			case !reflect.DeepEqual(*test.want, *got):
 				t.Errorf("not equal: \n wanted: %+v \n got:    %+v", *test.want, *got)
 			}
 		})
@@ -847,3 +1153,72 @@ func TestParseFormValue(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryDatabaseFlow(t *testing.T) {
+	s := Server{
+		db: memory.NewDatabase(),
+		ph: mockPasswordHandler{
+			hashFunc: func(password []byte) (hashedPassword []byte, err error) {
+				return append([]byte("hashed:"), password...), nil
+			},
+			isCorrectPasswordFunc: func(hashedPassword, password []byte) (ok bool, err error) {
+				return string(hashedPassword) == "hashed:"+string(password), nil
+			},
+		},
+		tmpl: parseTemplate(staticFS),
+		cfg: Config{
+			MaxRows: 10,
+		},
+	}
+	ctx := context.Background()
+	if err := s.db.UpdateAdminPassword(ctx, "hashed:v4lid_P"); err != nil {
+		t.Fatalf("setting admin password: %v", err)
+	}
+	lim := countRateLimiter{max: 10}
+	h := s.mux(&lim)
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		var sb strings.Builder
+		s.out = &sb
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if sb.Len() != 0 {
+			t.Errorf("unwanted log: %q", sb.String())
+		}
+		return w
+	}
+	form := map[string]string{
+		"p":          "v4lid_P",
+		"title":      "Memory Book",
+		"author":     "a",
+		"subject":    "s",
+		"pages":      "1",
+		"added-date": "2022-11-13",
+	}
+	w := serve(multipartFormHelper(t, "/book/create", form))
+	if w.Code != 303 {
+		t.Fatalf("creating book: wanted 303, got %v: %v", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	id := strings.TrimPrefix(location, "/book?id=")
+	w = serve(httptest.NewRequest("GET", location, nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Memory Book") {
+		t.Errorf("reading created book: wanted 200 with title, got %v: %v", w.Code, w.Body.String())
+	}
+	form["id"] = id
+	form["title"] = "Updated Memory Book"
+	w = serve(multipartFormHelper(t, "/book/update", form))
+	if w.Code != 303 {
+		t.Fatalf("updating book: wanted 303, got %v: %v", w.Code, w.Body.String())
+	}
+	w = serve(httptest.NewRequest("GET", "/list?q=updated", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Updated Memory Book") {
+		t.Errorf("listing updated book: wanted 200 with title, got %v: %v", w.Code, w.Body.String())
+	}
+	w = serve(multipartFormHelper(t, "/book/delete", map[string]string{"p": "v4lid_P", "id": id}))
+	if w.Code != 303 {
+		t.Fatalf("deleting book: wanted 303, got %v: %v", w.Code, w.Body.String())
+	}
+	if _, err := s.db.ReadBook(ctx, id); err == nil {
+		t.Errorf("wanted book to be deleted")
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/marc"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/csv"
+)
+
+type (
+	// bookEncoder writes books to a file.
+	bookEncoder interface {
+		Encode(b book.Book) error
+		Close() error
+	}
+	// exportFormat is a kind of file that books can be downloaded as.
+	exportFormat struct {
+		contentType string
+		filename    string
+		// newEncoder creates an encoder that writes books with values for the custom fields, if the format has them.
+		// Formats with image columns leave them out if images are excluded.
+		newEncoder func(w io.Writer, fields []book.CustomField, excludeImages bool) bookEncoder
+	}
+	// csvEncoder writes books in the same format as the csv dump.
+	csvEncoder struct {
+		d *csv.Dump
+	}
+)
+
+var (
+	csvExport = exportFormat{
+		contentType: "text/csv; charset=utf-8",
+		filename:    "library.csv",
+		newEncoder: func(w io.Writer, fields []book.CustomField, excludeImages bool) bookEncoder {
+			if excludeImages {
+				return csvEncoder{csv.NewDumpWithoutImages(w, book.CustomNames(fields)...)}
+			}
+			return csvEncoder{csv.NewDump(w, book.CustomNames(fields)...)}
+		},
+	}
+	marcExport = exportFormat{
+		contentType: "application/marc",
+		filename:    "library.mrc",
+		newEncoder: func(w io.Writer, fields []book.CustomField, excludeImages bool) bookEncoder {
+			return marc.NewEncoder(w)
+		},
+	}
+	marcXMLExport = exportFormat{
+		contentType: "application/marcxml+xml",
+		filename:    "library.marc.xml",
+		newEncoder: func(w io.Writer, fields []book.CustomField, excludeImages bool) bookEncoder {
+			return marc.NewXMLEncoder(w)
+		},
+	}
+)
+
+func (e csvEncoder) Encode(b book.Book) error {
+	e.d.Write(b)
+	return nil
+}
+
+func (e csvEncoder) Close() error {
+	e.d.Write() // flush the header if no books were written
+	return nil
+}
+
+// postExport downloads the books that match the filter as a csv file in the same format as the csv dump.
+func (s *Server) postExport(w http.ResponseWriter, r *http.Request) {
+	s.exportBooks(w, r, csvExport)
+}
+
+// postExportMARC downloads the books that match the filter as MARC 21 records.
+func (s *Server) postExportMARC(w http.ResponseWriter, r *http.Request) {
+	s.exportBooks(w, r, marcExport)
+}
+
+// postExportMARCXML downloads the books that match the filter as a MARCXML collection.
+func (s *Server) postExportMARCXML(w http.ResponseWriter, r *http.Request) {
+	s.exportBooks(w, r, marcXMLExport)
+}
+
+// exportBooks downloads the books that match the filter in the format.
+// The filter is the same as the filter of the books list, with the author of the author page.
+// Images are left out if the exclude-images form value is "true", which makes the file much smaller and the books are read without them.
+// Exports can take longer than other requests, up to the export timeout.
+// Csv files have a column for each custom field, and no image column if images are left out.
+func (s *Server) exportBooks(w http.ResponseWriter, r *http.Request, format exportFormat) {
+	var filter book.Filter
+	var excludeImages string
+	switch {
+	case !parseFormValue(w, r, "q", &filter.HeaderPart, 256),
+		!parseFormValue(w, r, "s", &filter.Subject, 256),
+		!parseFormValue(w, r, "author", &filter.Author, 256),
+		!parseDetailsFilter(w, r, &filter),
+		!parseFormValue(w, r, "exclude-images", &excludeImages, 10):
+		return
+	}
+	filter.Author = strings.TrimSpace(filter.Author)
+	ctx := r.Context()
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		s.exportError(w, false, fmt.Errorf("reading custom fields: %w", err))
+		return
+	}
+	exclude := excludeImages == "true"
+	iter := newBookIterator(s.db, s.cfg.MaxRows)
+	iter.filter = filter
+	iter.withoutImage = exclude
+	var e bookEncoder
+	for iter.HasNext(ctx) {
+		b, err := iter.Next(ctx)
+		if err != nil {
+			s.exportError(w, e != nil, err)
+			return
+		}
+		if e == nil { // wait for the first book so errors reading it can still be sent as an error response
+			e = format.start(w, fields, exclude)
+		}
+		if err := e.Encode(*b); err != nil {
+			s.exportError(w, true, err)
+			return
+		}
+	}
+	if err := iter.Err(); err != nil {
+		s.exportError(w, e != nil, err)
+		return
+	}
+	if e == nil {
+		e = format.start(w, fields, exclude)
+	}
+	if err := e.Close(); err != nil {
+		s.exportError(w, true, err)
+	}
+}
+
+// start sets the headers to download the file and creates the encoder to write it.
+func (format exportFormat) start(w http.ResponseWriter, fields []book.CustomField, excludeImages bool) bookEncoder {
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.filename))
+	return format.newEncoder(w, fields, excludeImages)
+}
+
+// exportError writes an error response if the file has not started to be sent.
+// Otherwise, the error is logged and the file is cut short.
+func (s *Server) exportError(w http.ResponseWriter, started bool, err error) {
+	err = fmt.Errorf("exporting books: %w", err)
+	if !started {
+		httpInternalServerError(w, err)
+		return
+	}
+	fmt.Fprintln(s.out, err)
+}
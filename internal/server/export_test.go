@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/marc"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestPostExport(t *testing.T) {
+	addedDate := time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Subject: "Animals"}, AddedDate: addedDate, ImageBase64: "img1"},
+		{Header: book.Header{ID: "2", Title: "Zebras", Subject: "Animals"}, AddedDate: addedDate},
+		{Header: book.Header{ID: "3", Title: "Volcanoes", Subject: "Geology"}, AddedDate: addedDate, Custom: map[string]string{"Box": "7", "Old": "x"}},
+	}
+	header := "id,title,author,contributors,description,subject,tags,series,volume,language,edition,format,reading-level,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64\n"
+	tests := []struct {
+		name     string
+		form     map[string]string
+		db       database
+		fields   []book.CustomField
+		wantCode int
+		wantBody string
+		wantLog  bool
+	}{
+		{
+			name: "custom fields error",
+			db: mockDatabase{
+				readCustomFieldsFunc: func() ([]book.CustomField, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "read error",
+			db: mockDatabase{
+				readCustomFieldsFunc: func() ([]book.CustomField, error) {
+					return nil, nil
+				},
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "error after first book",
+			db: mockDatabase{
+				readCustomFieldsFunc: func() ([]book.CustomField, error) {
+					return nil, nil
+				},
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return []book.Header{{ID: "1"}, {ID: "2"}}, nil
+				},
+				readBookFunc: func(id string) (*book.Book, error) {
+					if id == "2" {
+						return nil, fmt.Errorf("db error")
+					}
+					return &books[1], nil
+				},
+			},
+			wantCode: 200,
+			wantBody: header + "2,Zebras,,,,Animals,,,,,,,,,0,,01/01/0001,01/02/2006,,,\n",
+			wantLog:  true,
+		},
+		{
+			name:     "no books",
+			form:     map[string]string{"q": "unknown"},
+			wantCode: 200,
+			wantBody: header,
+		},
+		{
+			name:     "all books",
+			wantCode: 200,
+			wantBody: header +
+				"1,Lemurs,,,,Animals,,,,,,,,,0,,01/01/0001,01/02/2006,,,img1\n" +
+				"2,Zebras,,,,Animals,,,,,,,,,0,,01/01/0001,01/02/2006,,,\n" +
+				"3,Volcanoes,,,,Geology,,,,,,,,,0,,01/01/0001,01/02/2006,,,\n",
+		},
+		{
+			name:     "filtered without images",
+			form:     map[string]string{"s": "Animals", "q": "lemur", "exclude-images": "true"},
+			wantCode: 200,
+			wantBody: strings.Replace(header, ",image-base64", "", 1) + "1,Lemurs,,,,Animals,,,,,,,,,0,,01/01/0001,01/02/2006,,\n",
+		},
+		{
+			name: "without images reads book metadata",
+			form: map[string]string{"exclude-images": "true"},
+			db: mockDatabase{
+				readCustomFieldsFunc: func() ([]book.CustomField, error) {
+					return nil, nil
+				},
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return []book.Header{{ID: "2"}}, nil
+				},
+				readBookFunc: func(id string) (*book.Book, error) {
+					return nil, fmt.Errorf("book read with image")
+				},
+				readBookMetadataFunc: func(id string) (*book.Book, error) {
+					return &books[1], nil
+				},
+			},
+			wantCode: 200,
+			wantBody: strings.Replace(header, ",image-base64", "", 1) + "2,Zebras,,,,Animals,,,,,,,,,0,,01/01/0001,01/02/2006,,\n",
+		},
+		{
+			name: "author and details filter",
+			form: map[string]string{"author": " Ann ", "language": "fr", "book-format": "ebook", "reading-level": "adult"},
+			db: memory.NewDatabase(
+				book.Book{Header: book.Header{ID: "4", Title: "Coral", Author: "Ann", Subject: "Animals"}, AddedDate: addedDate, Language: "fr", Format: "ebook", ReadingLevel: "adult"},
+				book.Book{Header: book.Header{ID: "5", Title: "Kelp", Author: "Ann", Subject: "Animals"}, AddedDate: addedDate, Language: "fr", Format: "print", ReadingLevel: "adult"},
+				book.Book{Header: book.Header{ID: "6", Title: "Reefs", Author: "Bo", Subject: "Animals"}, AddedDate: addedDate, Language: "fr", Format: "ebook", ReadingLevel: "adult"},
+			),
+			wantCode: 200,
+			wantBody: header + "4,Coral,Ann,,,Animals,,,,fr,,ebook,adult,,0,,01/01/0001,01/02/2006,,,\n",
+		},
+		{
+			name:     "custom fields",
+			form:     map[string]string{"s": "Geology"},
+			fields:   []book.CustomField{{Name: "Donor", Type: book.FieldText}, {Name: "Box", Type: book.FieldNumber}},
+			wantCode: 200,
+			wantBody: strings.TrimSuffix(header, "\n") + ",custom:Donor,custom:Box\n" +
+				"3,Volcanoes,,,,Geology,,,,,,,,,0,,01/01/0001,01/02/2006,,,,,7\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				db := memory.NewDatabase(books...)
+				ctx := context.Background()
+				if err := db.UpdateCustomFields(ctx, test.fields...); err != nil {
+					t.Fatalf("setting up custom fields: %v", err)
+				}
+				test.db = db
+			}
+			var sb strings.Builder
+			s := Server{
+				db:  test.db,
+				out: &sb,
+				cfg: Config{
+					MaxRows: 2,
+				},
+			}
+			form := make(map[string]string)
+			for k, v := range test.form {
+				form[k] = v
+			}
+			r := multipartFormHelper(t, "/export.csv", form)
+			w := httptest.NewRecorder()
+			s.postExport(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode != 200:
+			case test.wantBody != w.Body.String():
+				t.Errorf("bodies not equal: \n wanted: %q \n got:    %q", test.wantBody, w.Body.String())
+			case w.Header().Get("Content-Type") != "text/csv; charset=utf-8":
+				t.Errorf("unwanted content type: %q", w.Header().Get("Content-Type"))
+			case test.wantLog != (sb.Len() != 0):
+				t.Errorf("wanted log: %v, got %q", test.wantLog, sb.String())
+			}
+		})
+	}
+}
+
+func TestPostExportMARC(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Subject: "Animals"}, ImageBase64: "img1"},
+		{Header: book.Header{ID: "2", Title: "Zebras", Subject: "Animals"}},
+	}
+	tests := []struct {
+		name            string
+		handler         func(s *Server) http.HandlerFunc
+		wantContentType string
+		newDecoder      func(r io.Reader) bookDecoder
+	}{
+		{
+			name:            "marc",
+			handler:         func(s *Server) http.HandlerFunc { return s.postExportMARC },
+			wantContentType: "application/marc",
+			newDecoder:      func(r io.Reader) bookDecoder { return marc.NewDecoder(r) },
+		},
+		{
+			name:            "marcxml",
+			handler:         func(s *Server) http.HandlerFunc { return s.postExportMARCXML },
+			wantContentType: "application/marcxml+xml",
+			newDecoder:      func(r io.Reader) bookDecoder { return marc.NewXMLDecoder(r) },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				db: memory.NewDatabase(books...),
+				cfg: Config{
+					MaxRows: 10,
+				},
+			}
+			r := multipartFormHelper(t, "/export", map[string]string{})
+			w := httptest.NewRecorder()
+			test.handler(&s)(w, r)
+			if want, got := test.wantContentType, w.Header().Get("Content-Type"); want != got {
+				t.Errorf("content types not equal: wanted %q, got %q", want, got)
+			}
+			got, err := readMARCBooks(test.newDecoder(w.Body))
+			switch {
+			case err != nil:
+				t.Errorf("unwanted error reading exported books: %v", err)
+			case len(got) != 2 || got[0].Title != "Lemurs" || got[1].ID != "2":
+				t.Errorf("unwanted exported books: %v", got)
+			}
+		})
+	}
+}
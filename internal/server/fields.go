@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// maxCustomFields is the most custom fields that books can have.
+const maxCustomFields = 50
+
+// getAdminCustomFields shows the custom fields so they can be added, changed, or removed.
+func (s *Server) getAdminCustomFields(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	data := struct {
+		CustomFields []book.CustomField
+	}{
+		CustomFields: fields,
+	}
+	s.serveTemplate(w, "admin-fields", data)
+}
+
+// postUpdateCustomFields replaces the custom fields with the rows of the form.
+// Rows without names are removed.
+func (s *Server) postUpdateCustomFields(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		httpBadRequest(w, err)
+		return
+	}
+	names, types, options := r.Form["name"], r.Form["type"], r.Form["options"]
+	if len(names) != len(types) || len(names) != len(options) {
+		httpBadRequest(w, fmt.Errorf("custom field rows incomplete"))
+		return
+	}
+	var fields []book.CustomField
+	for i, name := range names {
+		switch {
+		case len(name) > 256, len(types[i]) > 32, len(options[i]) > 2048:
+			httpError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("custom field %v too long", i+1))
+			return
+		case len(book.NormalizeSubject(name)) == 0:
+			continue
+		}
+		f, err := book.ParseCustomField(name, types[i], options[i])
+		if err != nil {
+			httpBadRequest(w, err)
+			return
+		}
+		fields = append(fields, *f)
+	}
+	if len(fields) > maxCustomFields {
+		err := fmt.Errorf("too many custom fields: books can have at most %v custom fields", maxCustomFields)
+		httpError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if err := book.CheckCustomFields(fields); err != nil {
+		httpBadRequest(w, err)
+		return
+	}
+	ctx := r.Context()
+	if err := s.db.UpdateCustomFields(ctx, fields...); err != nil {
+		err = fmt.Errorf("updating custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	httpRedirect(w, r, "/admin/fields")
+}
+
+// customValues parses the values of the custom fields from the book form.
+// Each value has the name of its field after a "custom:" prefix.
+func customValues(r *http.Request, fields []book.CustomField) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		value := r.FormValue(customFormPrefix + f.Name)
+		if len(value) > 256 {
+			return nil, fmt.Errorf("custom field %q too long", f.Name)
+		}
+		values[f.Name] = value
+	}
+	return book.ParseCustomValues(fields, values)
+}
+
+// customFormPrefix is the prefix of the names of the custom field inputs of the book form.
+const customFormPrefix = "custom:"
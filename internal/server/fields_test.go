@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestGetAdminCustomFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []book.CustomField
+		readErr   error
+		wantCode  int
+		wantParts []string
+	}{
+		{
+			name:     "db error",
+			readErr:  fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name: "happy path",
+			fields: []book.CustomField{
+				{Name: "Donor", Type: book.FieldText},
+				{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+			},
+			wantCode: 200,
+			wantParts: []string{
+				`name="name" value="Donor"`,
+				`name="name" value="Shelf"`,
+				`<option value="enum" selected>`,
+				`name="options" value="Top; Bottom"`,
+				`id="cf-name-new"`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				db: mockDatabase{
+					readCustomFieldsFunc: func() ([]book.CustomField, error) {
+						return test.fields, test.readErr
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+			}
+			r := httptest.NewRequest("GET", "/admin/fields", nil)
+			w := httptest.NewRecorder()
+			s.getAdminCustomFields(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostUpdateCustomFields(t *testing.T) {
+	tooMany := url.Values{}
+	for i := 0; i <= maxCustomFields; i++ {
+		tooMany.Add("name", fmt.Sprint("f", i))
+		tooMany.Add("type", "text")
+		tooMany.Add("options", "")
+	}
+	tests := []struct {
+		name       string
+		form       url.Values
+		updateErr  error
+		wantCode   int
+		wantFields []book.CustomField
+	}{
+		{
+			name:     "rows incomplete",
+			form:     url.Values{"name": {"Box"}, "type": {"number"}},
+			wantCode: 400,
+		},
+		{
+			name:     "name too long",
+			form:     url.Values{"name": {strings.Repeat("n", 257)}, "type": {"text"}, "options": {""}},
+			wantCode: 413,
+		},
+		{
+			name:     "bad type",
+			form:     url.Values{"name": {"Box"}, "type": {"color"}, "options": {""}},
+			wantCode: 400,
+		},
+		{
+			name:     "duplicate names",
+			form:     url.Values{"name": {"Box", "box"}, "type": {"text", "number"}, "options": {"", ""}},
+			wantCode: 400,
+		},
+		{
+			name:     "too many fields",
+			form:     tooMany,
+			wantCode: 413,
+		},
+		{
+			name:      "db error",
+			form:      url.Values{"name": {"Box"}, "type": {"number"}, "options": {""}},
+			updateErr: fmt.Errorf("db error"),
+			wantCode:  500,
+		},
+		{
+			name:     "remove all",
+			form:     url.Values{"name": {" "}, "type": {"text"}, "options": {""}},
+			wantCode: 303,
+		},
+		{
+			name: "happy path",
+			form: url.Values{
+				"name":    {" Donor  Name ", "", "Shelf"},
+				"type":    {"text", "number", "enum"},
+				"options": {"", "", "Top; Bottom"},
+			},
+			wantCode: 303,
+			wantFields: []book.CustomField{
+				{Name: "Donor Name", Type: book.FieldText},
+				{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotFields []book.CustomField
+			s := Server{
+				db: mockDatabase{
+					updateCustomFieldsFunc: func(fields ...book.CustomField) error {
+						gotFields = fields
+						return test.updateErr
+					},
+				},
+			}
+			body := strings.NewReader(test.form.Encode())
+			r := httptest.NewRequest("POST", "/admin/fields/update", body)
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.postUpdateCustomFields(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode == 303:
+				if want, got := "/admin/fields", w.Header().Get("Location"); want != got {
+					t.Errorf("locations not equal: wanted %q, got %q", want, got)
+				}
+				if want, got := test.wantFields, gotFields; !reflect.DeepEqual(want, got) {
+					t.Errorf("fields not equal: \n wanted: %v \n got:    %v", want, got)
+				}
+			}
+		})
+	}
+}
@@ -11,10 +11,16 @@ import (
 	"time"
 )
 
-func withContextTimeout(h http.Handler, maxDuration time.Duration) http.HandlerFunc {
+// withContextTimeout limits how long requests can take.
+// Requests to the paths of the path durations, such as exports that read all books, have their own durations.
+func withContextTimeout(h http.Handler, maxDuration time.Duration, pathDurations map[string]time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		d, ok := pathDurations[r.URL.Path]
+		if !ok {
+			d = maxDuration
+		}
 		ctx := r.Context()
-		ctx, cancelFunc := context.WithTimeout(ctx, maxDuration)
+		ctx, cancelFunc := context.WithTimeout(ctx, d)
 		defer cancelFunc()
 		r = r.WithContext(ctx)
 		h.ServeHTTP(w, r)
@@ -35,7 +41,12 @@ func withCacheControl(h http.Handler, d time.Duration) http.HandlerFunc {
 func shouldCache(r *http.Request) bool {
 	switch {
 	case r.Method != http.MethodGet,
-		r.URL.Path == "/admin" && r.URL.Query().Has("book-id"): // do not cache book edit read requests
+		r.URL.Path == "/admin",                             // book edit forms and the add book form show the current custom fields
+		r.URL.Path == "/scan" && r.URL.Query().Has("isbn"), // scanned books might be added later
+		r.URL.Path == "/admin/subjects",                    // merged subjects should be shown immediately
+		r.URL.Path == "/admin/trash",                       // deleted books should be shown immediately
+		r.URL.Path == "/admin/history",                     // reverted changes should be shown immediately
+		r.URL.Path == "/admin/fields":                      // updated custom fields should be shown immediately
 		return false
 	}
 	return true
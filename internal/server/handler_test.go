@@ -11,18 +11,33 @@ import (
 
 func TestWithContextTimeout(t *testing.T) {
 	tests := []struct {
-		name        string
-		maxDuration time.Duration
-		wantTimeout bool
+		name          string
+		path          string
+		maxDuration   time.Duration
+		pathDurations map[string]time.Duration
+		wantTimeout   bool
 	}{
 		{
 			name:        "timeout",
+			path:        "/",
 			wantTimeout: true,
 		},
 		{
 			name:        "long maxDuration",
+			path:        "/",
 			maxDuration: 2 * time.Hour,
 		},
+		{
+			name:          "long path duration",
+			path:          "/export.csv",
+			pathDurations: map[string]time.Duration{"/export.csv": 2 * time.Hour},
+		},
+		{
+			name:          "other path duration",
+			path:          "/",
+			maxDuration:   2 * time.Hour,
+			pathDurations: map[string]time.Duration{"/export.csv": 0},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -36,9 +51,9 @@ func TestWithContextTimeout(t *testing.T) {
 					gotTimeout = false
 				}
 			}
-			h2 := withContextTimeout(http.HandlerFunc(h1), test.maxDuration)
+			h2 := withContextTimeout(http.HandlerFunc(h1), test.maxDuration, test.pathDurations)
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "/", nil)
+			r := httptest.NewRequest("GET", test.path, nil)
 			h2.ServeHTTP(w, r)
 			if test.wantTimeout != gotTimeout {
 				t.Error()
@@ -59,11 +74,16 @@ func TestWithCacheControl(t *testing.T) {
 	}{
 		{"subjects get", true, httptest.NewRequest("GET", "/", nil)},
 		{"book get", true, httptest.NewRequest("GET", "/book?id=existing", nil)},
-		{"add book get", true, httptest.NewRequest("GET", "/admin", nil)},
+		{"add book get", false, httptest.NewRequest("GET", "/admin", nil)},
 		{"edit book get", false, httptest.NewRequest("GET", "/admin?book-id=existing", nil)},
 		{"add book post", false, httptest.NewRequest("POST", "/admin", nil)},
 		{"list", true, httptest.NewRequest("GET", "/list", nil)},
 		{"list  search", true, httptest.NewRequest("GET", "/list?q=search", nil)},
+		{"scan page", true, httptest.NewRequest("GET", "/scan", nil)},
+		{"scan isbn", false, httptest.NewRequest("GET", "/scan?isbn=9780306406157", nil)},
+		{"admin subjects", false, httptest.NewRequest("GET", "/admin/subjects", nil)},
+		{"admin fields", false, httptest.NewRequest("GET", "/admin/fields", nil)},
+		{"admin history", false, httptest.NewRequest("GET", "/admin/history?book-id=existing", nil)},
 		{"book update", false, httptest.NewRequest("POST", "/book?id=existing", nil)},
 	}
 	for _, test := range tests {
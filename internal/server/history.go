@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// defaultActor is who changes books when admins do not give their names.
+const defaultActor = "admin"
+
+// historyPage is the audit log of a book, which is only shown after the admin password is checked.
+type historyPage struct {
+	Authenticated bool
+	BookID        string
+	History       []book.AuditEntry
+}
+
+// getBookHistory shows a form to enter the admin password to see the audit log of a book, which is not shown to visitors.
+func (s *Server) getBookHistory(w http.ResponseWriter, r *http.Request) {
+	var id string
+	if !parseFormValue(w, r, "book-id", &id, 64) {
+		return
+	}
+	data := historyPage{
+		BookID: id,
+	}
+	s.serveTemplate(w, "admin-history", data)
+}
+
+// postBookHistory shows the audit log of a book so changes to it can be reverted.
+// It is a post so the history, which has the values of deleted books and who changed them, is only shown after the admin password is checked.
+// Books that were deleted still have history, so the book is not read.
+func (s *Server) postBookHistory(w http.ResponseWriter, r *http.Request) {
+	var id string
+	if !parseFormValue(w, r, "book-id", &id, 64) {
+		return
+	}
+	ctx := r.Context()
+	history, err := s.db.ReadBookHistory(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("reading book history: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	data := historyPage{
+		Authenticated: true,
+		BookID:        id,
+		History:       history,
+	}
+	s.serveTemplate(w, "admin-history", data)
+}
+
+// postRevertBook sets the fields that an entry of the audit log of a book changed back to what they were before it.
+// The revert is added to the audit log, so it can also be reverted.
+// Deletes cannot be reverted because the book is in the trash, where it can be restored.
+// Creates cannot be reverted because the book would have no fields; it can be deleted instead.
+func (s *Server) postRevertBook(w http.ResponseWriter, r *http.Request) {
+	var id, entryID, actor string
+	if !parseFormValue(w, r, "book-id", &id, 64) ||
+		!parseFormValue(w, r, "entry-id", &entryID, 64) ||
+		!parseActor(w, r, &actor) {
+		return
+	}
+	ctx := r.Context()
+	history, err := s.db.ReadBookHistory(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("reading book history: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	var entry *book.AuditEntry
+	for i, e := range history {
+		if e.ID == entryID {
+			entry = &history[i]
+			break
+		}
+	}
+	switch {
+	case entry == nil:
+		httpError(w, http.StatusNotFound, fmt.Errorf("audit entry %q not found", entryID))
+		return
+	case entry.Action == book.ActionDelete:
+		httpBadRequest(w, fmt.Errorf("deleted books cannot be reverted, restore them from the trash"))
+		return
+	case entry.Action == book.ActionCreate:
+		httpBadRequest(w, fmt.Errorf("creating books cannot be reverted, delete them instead"))
+		return
+	}
+	b, err := s.db.ReadBook(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("reading book: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	reverted, err := entry.Revert(*b)
+	if err != nil {
+		httpBadRequest(w, err)
+		return
+	}
+	if len(book.Diff(*b, *reverted)) == 0 {
+		httpBadRequest(w, fmt.Errorf("book already has the values from before the change"))
+		return
+	}
+	audit := book.Audit{
+		Actor:  actor,
+		Action: book.ActionRevert,
+	}
+	if err := s.db.UpdateBook(ctx, *reverted, false, audit); err != nil {
+		err = fmt.Errorf("reverting book: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	httpRedirect(w, r, "/admin/history?book-id="+id)
+}
+
+// parseActor reads who is changing books from the form, which is the default actor if it is empty.
+func parseActor(w http.ResponseWriter, r *http.Request, actor *string) bool {
+	if !parseFormValue(w, r, "actor", actor, 64) {
+		return false
+	}
+	if *actor = strings.TrimSpace(*actor); len(*actor) == 0 {
+		*actor = defaultActor
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestGetBookHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{"long id", "/admin/history?book-id=" + strings.Repeat("x", 65), 413},
+		{"happy path", "/admin/history?book-id=b1", 200},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				db: mockDatabase{
+					readBookHistoryFunc: func(bookID string) ([]book.AuditEntry, error) {
+						t.Errorf("history read before the admin password is checked")
+						return []book.AuditEntry{{ID: "e1", BookID: "b1", Actor: "Ann", Action: book.ActionDelete}}, nil
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getBookHistory(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			case w.Code != 200:
+			case !strings.Contains(got, `action="/admin/history"`),
+				!strings.Contains(got, `name="book-id" value="b1"`):
+				t.Errorf("wanted password form for book: %v", got)
+			case strings.Contains(got, "Ann"),
+				strings.Contains(got, "The book has not been changed."):
+				t.Errorf("wanted history to be hidden: %v", got)
+			}
+		})
+	}
+}
+
+func TestPostBookHistory(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	tests := []struct {
+		name        string
+		url         string
+		history     []book.AuditEntry
+		readErr     error
+		wantCode    int
+		wantParts   []string
+		unwantParts []string
+	}{
+		{
+			name:     "long id",
+			url:      "/admin/history?book-id=" + strings.Repeat("x", 65),
+			wantCode: 413,
+		},
+		{
+			name:     "db error",
+			url:      "/admin/history?book-id=b1",
+			readErr:  fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:      "no history",
+			url:       "/admin/history?book-id=b1",
+			wantCode:  200,
+			wantParts: []string{"The book has not been changed."},
+		},
+		{
+			name: "happy path",
+			url:  "/admin/history?book-id=b1",
+			history: []book.AuditEntry{
+				{ID: "e2", BookID: "b1", Time: time1.Add(time.Hour), Actor: "Bob", Action: book.ActionDelete, Changes: []book.FieldChange{{Field: "title", Before: "New", After: ""}}},
+				{ID: "e1", BookID: "b1", Time: time1, Actor: "<Ann>", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "Old", After: "New"}}},
+			},
+			wantCode: 200,
+			wantParts: []string{
+				"2022-11-13 01:02:03 UTC: update by &lt;Ann&gt;",
+				"<td>Old</td>",
+				`name="entry-id" value="e1"`,
+			},
+			unwantParts: []string{
+				`name="entry-id" value="e2"`,
+				"<Ann>",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				db: mockDatabase{
+					readBookHistoryFunc: func(bookID string) ([]book.AuditEntry, error) {
+						if bookID != "b1" {
+							return nil, fmt.Errorf("unwanted book id: %q", bookID)
+						}
+						return test.history, test.readErr
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+			}
+			r := httptest.NewRequest("POST", test.url, nil)
+			w := httptest.NewRecorder()
+			s.postBookHistory(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+				for _, unwanted := range test.unwantParts {
+					if strings.Contains(got, unwanted) {
+						t.Errorf("unwanted %q in body: %v", unwanted, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostRevertBook(t *testing.T) {
+	current := book.Book{Header: book.Header{ID: "b1", Title: "New", Author: "b"}, Language: "en", Format: "print", Pages: 10}
+	history := []book.AuditEntry{
+		{ID: "e3", BookID: "b1", Action: book.ActionDelete},
+		{ID: "e2", BookID: "b1", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "author", Before: "b", After: "b"}}},
+		{ID: "e1", BookID: "b1", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "title", Before: "Old", After: "New"}}},
+		{ID: "e0", BookID: "b1", Action: book.ActionUpdate, Changes: []book.FieldChange{{Field: "pages", Before: "many"}}},
+		{ID: "e-1", BookID: "b1", Action: book.ActionCreate, Changes: []book.FieldChange{{Field: "title", After: "Old"}}},
+	}
+	tests := []struct {
+		name         string
+		form         url.Values
+		readErr      error
+		readBookErr  error
+		updateErr    error
+		wantCode     int
+		wantReverted *book.Book
+	}{
+		{
+			name:     "long entry id",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {strings.Repeat("x", 65)}},
+			wantCode: 413,
+		},
+		{
+			name:     "history error",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e1"}},
+			readErr:  fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:     "unknown entry",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e9"}},
+			wantCode: 404,
+		},
+		{
+			name:     "deleted book",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e3"}},
+			wantCode: 400,
+		},
+		{
+			name:     "created book",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e-1"}},
+			wantCode: 400,
+		},
+		{
+			name:        "read book error",
+			form:        url.Values{"book-id": {"b1"}, "entry-id": {"e1"}},
+			readBookErr: fmt.Errorf("db error"),
+			wantCode:    500,
+		},
+		{
+			name:     "bad change",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e0"}},
+			wantCode: 400,
+		},
+		{
+			name:     "nothing to revert",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e2"}},
+			wantCode: 400,
+		},
+		{
+			name:      "update error",
+			form:      url.Values{"book-id": {"b1"}, "entry-id": {"e1"}},
+			updateErr: fmt.Errorf("db error"),
+			wantCode:  500,
+		},
+		{
+			name:     "happy path",
+			form:     url.Values{"book-id": {"b1"}, "entry-id": {"e1"}, "actor": {"Cy"}},
+			wantCode: 303,
+			wantReverted: &book.Book{
+				Header:   book.Header{ID: "b1", Title: "Old", Author: "b"},
+				Language: "en",
+				Format:   "print",
+				Pages:    10,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var reverted *book.Book
+			var audits []book.Audit
+			s := Server{
+				db: mockDatabase{
+					readBookHistoryFunc: func(bookID string) ([]book.AuditEntry, error) {
+						return history, test.readErr
+					},
+					readBookFunc: func(id string) (*book.Book, error) {
+						if test.readBookErr != nil {
+							return nil, test.readBookErr
+						}
+						b := current
+						return &b, nil
+					},
+					updateBookFunc: func(b book.Book, updateImage bool, a ...book.Audit) error {
+						if updateImage {
+							return fmt.Errorf("did not want to update image")
+						}
+						reverted, audits = &b, a
+						return test.updateErr
+					},
+				},
+			}
+			r := httptest.NewRequest("POST", "/admin/history/revert", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.postRevertBook(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode == 303:
+				want := []book.Audit{{Actor: "Cy", Action: book.ActionRevert}}
+				switch {
+				case w.Header().Get("Location") != "/admin/history?book-id=b1":
+					t.Errorf("unwanted redirect location: %q", w.Header().Get("Location"))
+				case !reflect.DeepEqual(test.wantReverted, reverted):
+					t.Errorf("reverted book not equal: \n wanted: %v \n got:    %v", test.wantReverted, reverted)
+				case !reflect.DeepEqual(want, audits):
+					t.Errorf("audits not equal: \n wanted: %v \n got:    %v", want, audits)
+				}
+			}
+		})
+	}
+}
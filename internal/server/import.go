@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/csv"
+)
+
+type (
+	// importRow describes what importing a row of a csv file does.
+	importRow struct {
+		Line   int
+		Status importStatus
+		Book   book.Book
+		Error  string
+		Note   string
+		// existing is the book in the database that the row changes.
+		existing *book.Book
+	}
+	importStatus string
+	// importReport is the result of previewing or committing a csv import.
+	importReport struct {
+		Rows      []importRow
+		CSV       string
+		New       int
+		Changed   int
+		Unchanged int
+		Invalid   int
+		Committed bool
+	}
+)
+
+const (
+	importNew       importStatus = "new"
+	importChanged   importStatus = "changed"
+	importUnchanged importStatus = "unchanged"
+	importInvalid   importStatus = "invalid"
+	maxImportSize                = 50_000_000 // 50mb
+)
+
+// postImport previews the books in the uploaded csv file, or imports them if the commit form value is "true".
+// The books are imported in one transaction, keeping their ids, so nothing is imported if any row is invalid.
+// Books without ids are given new ids.
+// Rows without images keep the images of the books they update, so files exported without images do not remove them.
+// The changes to existing books are added to the audit log, so they can be reverted.
+func (s *Server) postImport(w http.ResponseWriter, r *http.Request) {
+	var text, commit, actor string
+	if !parseImportCSV(w, r, &text) {
+		return
+	}
+	if !parseFormValue(w, r, "commit", &commit, 10) ||
+		!parseActor(w, r, &actor) {
+		return
+	}
+	rows, err := csv.ReadRows(strings.NewReader(text))
+	if err != nil {
+		err = fmt.Errorf("reading csv: %w", err)
+		httpBadRequest(w, err)
+		return
+	}
+	ctx := r.Context()
+	aliases, err := s.db.ReadSubjectAliases(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading subject aliases: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	fields, err := s.db.ReadCustomFields(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading custom fields: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	report, err := s.newImportReport(ctx, rows, aliases, fields)
+	if err != nil {
+		err = fmt.Errorf("comparing rows to books: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	report.CSV = text
+	if commit != "true" {
+		s.serveTemplate(w, "import", report)
+		return
+	}
+	if report.Invalid != 0 {
+		err := fmt.Errorf("%v invalid rows must be fixed before importing", report.Invalid)
+		httpBadRequest(w, err)
+		return
+	}
+	books, entries := report.changedBooks(actor)
+	if err := s.db.ImportBooks(ctx, true, books, entries...); err != nil {
+		err = fmt.Errorf("importing books: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	report.Committed = true
+	s.serveTemplate(w, "import", report)
+}
+
+// parseImportCSV reads the text of the uploaded csv file into dest.
+// The preview page sends the text it previewed as a form value rather than as a file.
+// If the text cannot be read, an error is written to the response writer and false is returned.
+func parseImportCSV(w http.ResponseWriter, r *http.Request, dest *string) (ok bool) {
+	f, fh, err := r.FormFile("csv")
+	switch {
+	case err == http.ErrMissingFile:
+		return parseFormValue(w, r, "csv-text", dest, maxImportSize)
+	case err != nil:
+		err = fmt.Errorf("reading csv file: %w", err)
+		httpBadRequest(w, err)
+		return false
+	case fh.Size > maxImportSize:
+		err := fmt.Errorf("file to large (%v), max size the server will import is %v bytes", fh.Size, maxImportSize)
+		httpError(w, http.StatusRequestEntityTooLarge, err)
+		return false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		err = fmt.Errorf("reading csv file: %w", err)
+		httpBadRequest(w, err)
+		return false
+	}
+	*dest = string(data)
+	return true
+}
+
+// newImportReport compares the rows to the books in the database.
+// Rows that are missing fields that are required on the admin page, or that have the id of an earlier row, invalid isbns, or invalid values for the custom fields are invalid.
+// Isbns of other rows are normalized and their subjects are replaced by the canonical subjects of the aliases.
+func (s *Server) newImportReport(ctx context.Context, rows []csv.Row, aliases book.SubjectAliases, fields []book.CustomField) (importReport, error) {
+	var report importReport
+	lines := make(map[string]int, len(rows))
+	for _, row := range rows {
+		ir := importRow{
+			Line: row.Line,
+		}
+		switch {
+		case row.Err != nil:
+			ir.Status = importInvalid
+			ir.Error = row.Err.Error()
+		default:
+			ir.Book = *row.Book
+			if err := validateBook(ir.Book); err != nil {
+				ir.Status = importInvalid
+				ir.Error = err.Error()
+				break
+			}
+			note, err := normalizeISBNs(&ir.Book)
+			if err != nil {
+				ir.Status = importInvalid
+				ir.Error = err.Error()
+				break
+			}
+			ir.Note = note
+			if ir.Book.Custom, err = book.ParseCustomValues(fields, ir.Book.Custom); err != nil {
+				ir.Status = importInvalid
+				ir.Error = err.Error()
+				break
+			}
+			if subject := aliases.Canonical(ir.Book.Subject); subject != ir.Book.Subject {
+				if len(ir.Note) != 0 {
+					ir.Note += "; "
+				}
+				ir.Note += fmt.Sprintf("subject changed from %q to %q", ir.Book.Subject, subject)
+				ir.Book.Subject = subject
+			}
+			ir.Book.Tags = aliases.CanonicalTags(ir.Book.Subject, ir.Book.Tags)
+			status, existing, err := s.importStatus(ctx, &ir.Book)
+			if err != nil {
+				return report, fmt.Errorf("line %v: %w", row.Line, err)
+			}
+			ir.Status, ir.existing = status, existing
+			if len(ir.Book.ID) == 0 {
+				break
+			}
+			if line, ok := lines[ir.Book.ID]; ok {
+				ir.Status = importInvalid
+				ir.Error = fmt.Sprintf("id is also used on line %v", line)
+				break
+			}
+			lines[ir.Book.ID] = row.Line
+		}
+		report.add(ir)
+	}
+	return report, nil
+}
+
+// normalizeISBNs normalizes the isbns of the book, describing how they were changed.
+func normalizeISBNs(b *book.Book) (note string, err error) {
+	isbn13, isbn10, err := isbn.Pair(b.EanIsbn13, b.UpcIsbn10)
+	if err != nil {
+		return "", err
+	}
+	if isbn13 != b.EanIsbn13 || isbn10 != b.UpcIsbn10 {
+		note = fmt.Sprintf("isbns changed from %q, %q to %q, %q", b.EanIsbn13, b.UpcIsbn10, isbn13, isbn10)
+	}
+	b.EanIsbn13, b.UpcIsbn10 = isbn13, isbn10
+	return note, nil
+}
+
+// validISBNBooks normalizes the isbns of the books, returning the books with valid isbns and errors describing the others.
+func validISBNBooks(books []book.Book) (valid []book.Book, invalid []error) {
+	valid = make([]book.Book, 0, len(books))
+	for i, b := range books {
+		if _, err := normalizeISBNs(&b); err != nil {
+			err = fmt.Errorf("book %v (%q): %w", i+1, b.Title, err)
+			invalid = append(invalid, err)
+			continue
+		}
+		valid = append(valid, b)
+	}
+	return valid, invalid
+}
+
+// importStatus compares the book to the book in the database with its id, which is returned if it exists.
+// Books without images are given the image of the book in the database.
+func (s *Server) importStatus(ctx context.Context, b *book.Book) (importStatus, *book.Book, error) {
+	if len(b.ID) == 0 {
+		return importNew, nil, nil
+	}
+	existing, err := s.db.ReadBook(ctx, b.ID)
+	switch {
+	case errors.Is(err, book.ErrNotFound):
+		return importNew, nil, nil
+	case err != nil:
+		return "", nil, fmt.Errorf("reading book: %w", err)
+	}
+	if len(b.ImageBase64) == 0 {
+		b.ImageBase64 = existing.ImageBase64
+	}
+	if csv.Equal(*b, *existing) {
+		return importUnchanged, existing, nil
+	}
+	return importChanged, existing, nil
+}
+
+func (report *importReport) add(ir importRow) {
+	switch ir.Status {
+	case importNew:
+		report.New++
+	case importChanged:
+		report.Changed++
+	case importUnchanged:
+		report.Unchanged++
+	case importInvalid:
+		report.Invalid++
+	}
+	report.Rows = append(report.Rows, ir)
+}
+
+// changedBooks are the new and changed books to import and audit entries for them.
+// New books without ids are given ids.  Like books that are created on the admin page, new books have create entries.
+func (report importReport) changedBooks(actor string) ([]book.Book, []book.AuditEntry) {
+	books := make([]book.Book, 0, report.New+report.Changed)
+	entries := make([]book.AuditEntry, 0, report.New+report.Changed)
+	for _, ir := range report.Rows {
+		b := ir.Book
+		switch {
+		case ir.Status == importNew:
+			if len(b.ID) == 0 {
+				b.ID = book.NewID()
+			}
+			books = append(books, b)
+			entries = append(entries, book.NewAuditEntry(actor, book.ActionCreate, book.Book{}, b))
+		case ir.Status == importChanged:
+			books = append(books, b)
+			if ir.existing == nil {
+				break
+			}
+			if e := book.NewAuditEntry(actor, book.ActionImport, *ir.existing, b); len(e.Changes) != 0 {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return books, entries
+}
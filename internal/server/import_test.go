@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+const importHeader = "id,title,author,description,subject,tags,dewey-dec-class,pages,publisher,publish-date,added-date,ean-isbn13,upc-isbn10,image-base64"
+
+func TestPostImport(t *testing.T) {
+	existing := book.Book{
+		Header:      book.Header{ID: "old", Title: "Old", Author: "a", Subject: "s"},
+		Language:    "en",
+		Format:      "print",
+		Pages:       1,
+		AddedDate:   time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC),
+		ImageBase64: "IMG",
+	}
+	unchangedRow := "old,Old,a,,s,,,1,,,01/02/2006,,,"
+	changedRow := "old,Old 2nd Edition,a,,s,,,1,,,01/02/2006,,,"
+	newRow := "new,New,a,,s,,,2,,,01/02/2006,,,"
+	noIDRow := ",No ID,a,,s,,,3,,,01/02/2006,,,"
+	invalidRow := "bad,Bad,a,,s,,,NaN,,,01/02/2006,,,"
+	isbnRow := "isbn,ISBN,a,,s,,,4,,,01/02/2006,,0-306-40615-2,"
+	invalidISBNRow := "isbn,ISBN,a,,s,,,4,,,01/02/2006,9780306406158,,"
+	noTitleRow := "untitled,,a,,s,,,4,,,01/02/2006,,,"
+	noAuthorRow := "anonymous,Anonymous,,,s,,,4,,,01/02/2006,,,"
+	noPagesRow := "pageless,Pageless,a,,s,,,0,,,01/02/2006,,,"
+	aliasRow := "alias,Alias,a,,Novels ,novels; Poetry,,5,,,01/02/2006,,,"
+	tests := []struct {
+		name        string
+		csv         string
+		upload      bool
+		commit      bool
+		importErr   error
+		readErr     error
+		wantCode    int
+		wantBody    []string
+		wantBooks   int
+		wantEntries []book.AuditEntry
+	}{
+		{
+			name:      "bad header",
+			csv:       "id,title",
+			wantCode:  400,
+			wantBooks: 1,
+		},
+		{
+			name:      "preview upload",
+			csv:       strings.Join([]string{importHeader, unchangedRow, newRow, noIDRow, invalidRow}, "\n"),
+			upload:    true,
+			wantCode:  200,
+			wantBody:  []string{"2 new", "1 unchanged", "1 invalid", "Fix the invalid rows", "pages: "},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview duplicate id",
+			csv:       strings.Join([]string{importHeader, newRow, newRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"id is also used on line 2"},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview normalized isbns",
+			csv:       strings.Join([]string{importHeader, isbnRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"1 new", "0 invalid", `isbns changed from &#34;&#34;, &#34;0-306-40615-2&#34; to &#34;9780306406157&#34;, &#34;0306406152&#34;`},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview missing required fields",
+			csv:       strings.Join([]string{importHeader, noTitleRow, noAuthorRow, noPagesRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"0 new", "3 invalid", "title required", "author required", "pages required"},
+			wantBooks: 1,
+		},
+		{
+			name:      "commit missing required fields",
+			csv:       strings.Join([]string{importHeader, newRow, noTitleRow}, "\n"),
+			commit:    true,
+			wantCode:  400,
+			wantBooks: 1,
+		},
+		{
+			name:      "preview invalid isbn",
+			csv:       strings.Join([]string{importHeader, invalidISBNRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"1 invalid", "invalid isbn"},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview subject alias",
+			csv:       strings.Join([]string{importHeader, aliasRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"1 new", `subject changed from &#34;Novels &#34; to &#34;Fiction&#34;`},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview custom values",
+			csv:       strings.Join([]string{importHeader + ",custom:Box", newRow + ",12", noIDRow + ",twelve"}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"1 new", "1 invalid", "Box: not a number"},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview changed",
+			csv:       strings.Join([]string{importHeader, changedRow}, "\n"),
+			wantCode:  200,
+			wantBody:  []string{"1 changed", `name="csv-text"`, "Old 2nd Edition"},
+			wantBooks: 1,
+		},
+		{
+			name:      "preview read error",
+			csv:       strings.Join([]string{importHeader, changedRow}, "\n"),
+			readErr:   fmt.Errorf("db error"),
+			wantCode:  500,
+			wantBooks: 1,
+		},
+		{
+			name:      "commit invalid",
+			csv:       strings.Join([]string{importHeader, newRow, invalidRow}, "\n"),
+			commit:    true,
+			wantCode:  400,
+			wantBooks: 1,
+		},
+		{
+			name:      "commit error",
+			csv:       strings.Join([]string{importHeader, newRow}, "\n"),
+			commit:    true,
+			importErr: fmt.Errorf("db error"),
+			wantCode:  500,
+			wantBooks: 1,
+		},
+		{
+			name:      "commit",
+			csv:       strings.Join([]string{importHeader, changedRow, newRow, noIDRow}, "\n"),
+			commit:    true,
+			wantCode:  200,
+			wantBody:  []string{"Imported 2 new and 1 changed books"},
+			wantBooks: 3,
+			wantEntries: []book.AuditEntry{
+				{
+					BookID:  "old",
+					Actor:   "admin",
+					Action:  book.ActionImport,
+					Changes: []book.FieldChange{{Field: "title", Before: "Old", After: "Old 2nd Edition"}},
+				},
+				{
+					BookID:  "new",
+					Actor:   "admin",
+					Action:  book.ActionCreate,
+					Changes: []book.FieldChange{{Field: "title", After: "New"}, {Field: "author", After: "a"}, {Field: "subject", After: "s"}, {Field: "language", After: "en"}, {Field: "format", After: "print"}, {Field: "pages", After: "2"}, {Field: "added-date", After: "2006-01-02"}},
+				},
+				{
+					BookID:  "", // new books without ids are given random ids
+					Actor:   "admin",
+					Action:  book.ActionCreate,
+					Changes: []book.FieldChange{{Field: "title", After: "No ID"}, {Field: "author", After: "a"}, {Field: "subject", After: "s"}, {Field: "language", After: "en"}, {Field: "format", After: "print"}, {Field: "pages", After: "3"}, {Field: "added-date", After: "2006-01-02"}},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mem := memory.NewDatabase(existing)
+			db := importDatabase{
+				Database:  mem,
+				importErr: test.importErr,
+				readErr:   test.readErr,
+			}
+			s := Server{
+				db: db,
+				ph: mockPasswordHandler{
+					isCorrectPasswordFunc: func(hashedPassword, password []byte) (ok bool, err error) {
+						return true, nil
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+			}
+			ctx := context.Background()
+			if err := s.db.UpdateAdminPassword(ctx, "H#shed+P"); err != nil {
+				t.Fatalf("setting admin password: %v", err)
+			}
+			if err := s.db.MergeSubjects(ctx, "Fiction", []string{"novels"}); err != nil {
+				t.Fatalf("adding subject alias: %v", err)
+			}
+			if err := s.db.UpdateCustomFields(ctx, book.CustomField{Name: "Box", Type: book.FieldNumber}); err != nil {
+				t.Fatalf("adding custom field: %v", err)
+			}
+			form := map[string]string{
+				"p": "v4lid_P",
+			}
+			if test.commit {
+				form["commit"] = "true"
+			}
+			var r *http.Request
+			switch {
+			case test.upload:
+				r = importUploadHelper(t, test.csv, form)
+			default:
+				form["csv-text"] = test.csv
+				r = multipartFormHelper(t, "/admin/import", form)
+			}
+			w := httptest.NewRecorder()
+			lim := countRateLimiter{max: 1}
+			h := s.mux(&lim)
+			h.ServeHTTP(w, r)
+			if want, got := test.wantCode, w.Code; want != got {
+				t.Fatalf("codes not equal: wanted %v, got %v: %v", want, got, w.Body.String())
+			}
+			for _, want := range test.wantBody {
+				if got := w.Body.String(); !strings.Contains(got, want) {
+					t.Errorf("wanted body to contain %q, got: %v", want, got)
+				}
+			}
+			headers, err := mem.ReadBookHeaders(ctx, book.Filter{}, 10, 0)
+			switch {
+			case err != nil:
+				t.Errorf("reading books: %v", err)
+			case test.wantBooks != len(headers):
+				t.Errorf("wanted %v books in database, got %v", test.wantBooks, len(headers))
+			}
+			if b, err := mem.ReadBook(ctx, existing.ID); err != nil || b.ImageBase64 != existing.ImageBase64 {
+				t.Errorf("wanted image of existing book to be kept, got %v, %v", b, err)
+			}
+			log, err := mem.ReadAuditLog(ctx)
+			if err != nil {
+				t.Fatalf("reading audit log: %v", err)
+			}
+			for i := range log {
+				log[i].ID, log[i].Time = "", time.Time{}
+				if log[i].Action == book.ActionCreate && len(test.wantEntries) > i && len(test.wantEntries[i].BookID) == 0 {
+					log[i].BookID = ""
+				}
+			}
+			if want, got := test.wantEntries, log; len(want) != len(got) || len(want) != 0 && !reflect.DeepEqual(want, got) {
+				t.Errorf("audit entries not equal: \n wanted: %v \n got:    %v", want, got)
+			}
+		})
+	}
+}
+
+// importDatabase is a memory database that can fail to import books.
+type importDatabase struct {
+	*memory.Database
+	importErr error
+	readErr   error
+}
+
+func (d importDatabase) ReadBook(ctx context.Context, id string) (*book.Book, error) {
+	if d.readErr != nil {
+		return nil, d.readErr
+	}
+	return d.Database.ReadBook(ctx, id)
+}
+
+func (d importDatabase) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	if d.importErr != nil {
+		return d.importErr
+	}
+	return d.Database.ImportBooks(ctx, upsert, books, entries...)
+}
+
+func importUploadHelper(t *testing.T, csv string, form map[string]string) *http.Request {
+	t.Helper()
+	var sb strings.Builder
+	mpw := multipart.NewWriter(&sb)
+	for k, v := range form {
+		if err := mpw.WriteField(k, v); err != nil {
+			t.Fatalf("writing form field: %v", err)
+		}
+	}
+	fw, err := mpw.CreateFormFile("csv", "library.csv")
+	if err != nil {
+		t.Fatalf("creating form file: %v", err)
+	}
+	if _, err := fw.Write([]byte(csv)); err != nil {
+		t.Fatalf("writing form file: %v", err)
+	}
+	mpw.Close()
+	r := httptest.NewRequest("POST", "/admin/import", strings.NewReader(sb.String()))
+	r.Header.Set("Content-Type", mpw.FormDataContentType())
+	return r
+}
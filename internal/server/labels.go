@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/label"
+)
+
+type (
+	// labelFormat is a kind of file that labels can be downloaded as.
+	labelFormat struct {
+		contentType string
+		filename    string
+		write       func(w io.Writer, layout label.Layout, skip int, labels ...label.Label) error
+	}
+	// labelLayout is a layout option on the labels page.
+	labelLayout struct {
+		Key  string
+		Name string
+	}
+)
+
+const (
+	// maxLabels is the most labels that can be printed at once.
+	maxLabels = 1000
+	// customLayout is the layout option to print on a sheet with the sizes in the form.
+	customLayout = "custom"
+)
+
+var (
+	pdfLabels = labelFormat{
+		contentType: "application/pdf",
+		filename:    "labels.pdf",
+		write:       label.WritePDF,
+	}
+	svgLabels = labelFormat{
+		contentType: "image/svg+xml",
+		filename:    "labels.svg",
+		write:       label.WriteSVG,
+	}
+	// customLayoutFields are the form values of custom layouts, in inches.
+	customLayoutFields = []string{
+		"page-width", "page-height", "label-width", "label-height",
+		"top-margin", "left-margin", "column-pitch", "row-pitch",
+	}
+)
+
+// getLabels shows the books that match the filter so labels can be printed for the selected ones.
+func (s *Server) getLabels(w http.ResponseWriter, r *http.Request) {
+	var filter book.Filter
+	if !parseFormValue(w, r, "q", &filter.HeaderPart, 256) {
+		return
+	}
+	if !parseFormValue(w, r, "s", &filter.Subject, 256) {
+		return
+	}
+	pageLoader := func(ctx context.Context, limit, offset int) ([]book.Header, error) {
+		return s.db.ReadBookHeaders(ctx, filter, limit, offset)
+	}
+	if data, ok := loadPage(w, r, s.cfg.MaxRows, "Books", pageLoader); ok {
+		data["Filter"] = filter.HeaderPart
+		data["Subject"] = filter.Subject
+		var layouts []labelLayout
+		for _, key := range label.LayoutNames() {
+			layouts = append(layouts, labelLayout{key, label.Layouts[key].Name})
+		}
+		data["Layouts"] = layouts
+		s.serveTemplate(w, "labels", data)
+	}
+}
+
+// postLabelsPDF downloads labels for the selected books as a pdf to print.
+func (s *Server) postLabelsPDF(w http.ResponseWriter, r *http.Request) {
+	s.writeLabels(w, r, pdfLabels)
+}
+
+// postLabelsSVG downloads labels for the selected books as a svg image to preview.
+func (s *Server) postLabelsSVG(w http.ResponseWriter, r *http.Request) {
+	s.writeLabels(w, r, svgLabels)
+}
+
+// writeLabels draws labels for the books with the ids in the form onto the sheets of the layout in the format.
+func (s *Server) writeLabels(w http.ResponseWriter, r *http.Request, format labelFormat) {
+	var barcode string
+	if !parseFormValue(w, r, "barcode", &barcode, 10) {
+		return
+	}
+	layout, skip, ok := labelSheetFrom(w, r)
+	if !ok {
+		return
+	}
+	ids := r.Form["id"]
+	switch {
+	case len(ids) == 0:
+		httpBadRequest(w, fmt.Errorf("no books selected"))
+		return
+	case len(ids) > maxLabels:
+		err := fmt.Errorf("too many books selected: at most %v labels can be printed at once", maxLabels)
+		httpError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	ctx := r.Context()
+	labels := make([]label.Label, 0, len(ids))
+	for _, id := range ids {
+		if len(id) > 64 {
+			httpBadRequest(w, fmt.Errorf("book id too long"))
+			return
+		}
+		b, err := s.db.ReadBookMetadata(ctx, id)
+		if err != nil {
+			err = fmt.Errorf("reading book: %w", err)
+			httpInternalServerError(w, err)
+			return
+		}
+		l, err := label.NewLabel(*b, label.BarcodeSource(barcode))
+		if err != nil {
+			httpBadRequest(w, err)
+			return
+		}
+		labels = append(labels, *l)
+	}
+	serveLabels(w, format, *layout, skip, labels)
+}
+
+// labelSheetFrom reads the layout and the number of used labels to skip from the form.
+// If the form is invalid, an error is written to the response writer and false is returned.
+func labelSheetFrom(w http.ResponseWriter, r *http.Request) (layout *label.Layout, skip int, ok bool) {
+	var skipValue string
+	if !parseFormValue(w, r, "skip", &skipValue, 10) {
+		return nil, 0, false
+	}
+	layout, err := labelLayoutFrom(r)
+	if err != nil {
+		httpBadRequest(w, err)
+		return nil, 0, false
+	}
+	if len(skipValue) != 0 {
+		if skip, err = strconv.Atoi(skipValue); err != nil || skip < 0 {
+			err = fmt.Errorf("invalid skip: %q", skipValue)
+			httpBadRequest(w, err)
+			return nil, 0, false
+		}
+	}
+	return layout, skip, true
+}
+
+// serveLabels sends the labels in the format.
+// The labels are drawn before anything is sent so errors can be sent as error responses.
+func serveLabels(w http.ResponseWriter, format labelFormat, layout label.Layout, skip int, labels []label.Label) {
+	var buf bytes.Buffer
+	if err := format.write(&buf, layout, skip, labels...); err != nil {
+		err = fmt.Errorf("writing labels: %w", err)
+		httpBadRequest(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.filename))
+	buf.WriteTo(w)
+}
+
+// labelLayoutFrom reads the layout from the form, which is either a known sheet or the custom sizes in the form.
+func labelLayoutFrom(r *http.Request) (*label.Layout, error) {
+	key := r.FormValue("layout")
+	if key != customLayout {
+		layout, ok := label.Layouts[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown label layout: %q", key)
+		}
+		return &layout, nil
+	}
+	var lengths [8]float64
+	for i, name := range customLayoutFields {
+		f, err := strconv.ParseFloat(r.FormValue(name), 64)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("invalid %v: %w", name, err)
+		case math.IsNaN(f), math.IsInf(f, 0):
+			return nil, fmt.Errorf("invalid %v: %v", name, f)
+		}
+		lengths[i] = f * 72 // points
+	}
+	columns, err := strconv.Atoi(r.FormValue("columns"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid columns: %w", err)
+	}
+	rows, err := strconv.Atoi(r.FormValue("rows"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rows: %w", err)
+	}
+	if columns > maxLabels || rows > maxLabels || columns*rows > maxLabels {
+		return nil, fmt.Errorf("too many labels on each sheet")
+	}
+	layout := label.Layout{
+		Name:        "Custom",
+		PageWidth:   lengths[0],
+		PageHeight:  lengths[1],
+		Columns:     columns,
+		Rows:        rows,
+		LabelWidth:  lengths[2],
+		LabelHeight: lengths[3],
+		TopMargin:   lengths[4],
+		LeftMargin:  lengths[5],
+		ColumnPitch: lengths[6],
+		RowPitch:    lengths[7],
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid custom layout: %w", err)
+	}
+	return &layout, nil
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetLabels(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Subject: "Animals"}},
+		{Header: book.Header{ID: "2", Title: "Zebras", Subject: "Animals"}},
+		{Header: book.Header{ID: "3", Title: "Volcanoes", Subject: "Geology"}},
+	}
+	tests := []struct {
+		name         string
+		url          string
+		db           database
+		wantCode     int
+		wantData     []string
+		unwantedData []string
+	}{
+		{
+			name: "db error",
+			url:  "/labels",
+			db: mockDatabase{
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "long filter",
+			url:      "/labels?q=" + strings.Repeat("x", 257),
+			wantCode: 413,
+		},
+		{
+			name:         "no books",
+			url:          "/labels?q=unknown",
+			wantCode:     200,
+			wantData:     []string{"No books found."},
+			unwantedData: []string{`action="/labels.pdf"`},
+		},
+		{
+			name:     "first page",
+			url:      "/labels",
+			wantCode: 200,
+			wantData: []string{
+				`name="id" value="1" checked`,
+				`name="id" value="2" checked`,
+				`<option value="avery-5160">Avery 5160`,
+				`<option value="custom">`,
+				`formaction="/labels.svg"`,
+				"page=2",
+			},
+			unwantedData: []string{`name="id" value="3"`},
+		},
+		{
+			name:         "filtered",
+			url:          "/labels?s=Geology",
+			wantCode:     200,
+			wantData:     []string{`name="id" value="3" checked`, `name="s" value="Geology"`},
+			unwantedData: []string{`name="id" value="1"`, "page=2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				cfg: Config{
+					MaxRows: 2,
+				},
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getLabels(w, r)
+			if test.wantCode != w.Code {
+				t.Fatalf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			}
+			got := w.Body.String()
+			for _, want := range test.wantData {
+				if !strings.Contains(got, want) {
+					t.Errorf("wanted %q in body, got: \n %v", want, got)
+				}
+			}
+			for _, unwanted := range test.unwantedData {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("unwanted %q in body", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestPostLabels(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Author: "Jane Doe"}, DeweyDecClass: "599.8", EanIsbn13: "9780306406157"},
+		{Header: book.Header{ID: "2", Title: "Zebras", Author: "Ann Smith"}, DeweyDecClass: "599.6"},
+	}
+	custom := url.Values{
+		"layout":       {"custom"},
+		"page-width":   {"4"},
+		"page-height":  {"6"},
+		"columns":      {"1"},
+		"rows":         {"3"},
+		"label-width":  {"4"},
+		"label-height": {"2"},
+		"top-margin":   {"0"},
+		"left-margin":  {"0"},
+		"column-pitch": {"4"},
+		"row-pitch":    {"2"},
+	}
+	withValues := func(form url.Values, keyValues ...string) url.Values {
+		f := make(url.Values)
+		for k, v := range form {
+			f[k] = v
+		}
+		for i := 0; i < len(keyValues); i += 2 {
+			f.Set(keyValues[i], keyValues[i+1])
+		}
+		return f
+	}
+	standard := url.Values{
+		"layout":  {"avery-5160"},
+		"barcode": {"isbn"},
+		"id":      {"1", "2"},
+	}
+	tooMany := withValues(standard)
+	tooMany["id"] = make([]string, maxLabels+1)
+	tests := []struct {
+		name     string
+		svg      bool
+		form     url.Values
+		db       database
+		wantCode int
+		wantType string
+		wantData []string
+	}{
+		{
+			name:     "no books",
+			form:     url.Values{"layout": {"avery-5160"}, "barcode": {"isbn"}},
+			wantCode: 400,
+		},
+		{
+			name:     "too many books",
+			form:     tooMany,
+			wantCode: 413,
+		},
+		{
+			name:     "unknown layout",
+			form:     withValues(standard, "layout", "avery-1"),
+			wantCode: 400,
+		},
+		{
+			name:     "unknown barcode",
+			form:     withValues(standard, "barcode", "qr"),
+			wantCode: 400,
+		},
+		{
+			name:     "bad skip",
+			form:     withValues(standard, "skip", "-1"),
+			wantCode: 400,
+		},
+		{
+			name:     "bad custom layout",
+			form:     withValues(custom, "barcode", "id", "id", "1", "rows", "4"),
+			wantCode: 400,
+		},
+		{
+			name:     "NaN custom layout",
+			form:     withValues(custom, "barcode", "id", "id", "1", "page-width", "NaN"),
+			wantCode: 400,
+		},
+		{
+			name: "db error",
+			form: standard,
+			db: mockDatabase{
+				readBookMetadataFunc: func(id string) (*book.Book, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "pdf",
+			form:     withValues(standard, "skip", "29"),
+			wantCode: 200,
+			wantType: "application/pdf",
+			wantData: []string{"%PDF-1.4", "/Count 2", "(599.8) Tj", "(DOE Lemurs) Tj", "(9780306406157) Tj", "(SMI Zebras) Tj", "(2) Tj"},
+		},
+		{
+			name:     "custom svg",
+			svg:      true,
+			form:     withValues(custom, "barcode", "id", "id", "1"),
+			wantCode: 200,
+			wantType: "image/svg+xml",
+			wantData: []string{`viewBox="0 0 288 432"`, ">599.8</text>", ">1</text>"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db: test.db,
+			}
+			r := httptest.NewRequest("POST", "/labels.pdf", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			if test.svg {
+				s.postLabelsSVG(w, r)
+			} else {
+				s.postLabelsPDF(w, r)
+			}
+			if test.wantCode != w.Code {
+				t.Fatalf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			}
+			if test.wantCode != 200 {
+				return
+			}
+			if want, got := test.wantType, w.Header().Get("Content-Type"); want != got {
+				t.Errorf("content types not equal: wanted %q, got %q", want, got)
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment; filename=") {
+				t.Errorf("wanted labels to be downloaded, got %q", w.Header().Get("Content-Disposition"))
+			}
+			got := w.Body.String()
+			for _, want := range test.wantData {
+				if !strings.Contains(got, want) {
+					t.Errorf("wanted %q in body, got: \n %v", want, got)
+				}
+			}
+		})
+	}
+}
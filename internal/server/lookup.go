@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/openlibrary"
+)
+
+// ImportMetadata imports the metadata dump into its index, replacing the index if it exists.
+// Full dumps take a long time to import, so they are imported by this command rather than when the server starts.
+func (cfg Config) ImportMetadata(out io.Writer) error {
+	if len(cfg.MetadataDumpFile) == 0 {
+		return fmt.Errorf("metadata dump file required")
+	}
+	indexPath := cfg.metadataIndexFile()
+	if err := openlibrary.ImportDumpFile(cfg.MetadataDumpFile, indexPath); err != nil {
+		return err
+	}
+	d, _, err := openlibrary.OpenDump(cfg.MetadataDumpFile, indexPath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	fmt.Fprintf(out, "Imported metadata for %v isbns into %q.\n", d.Len(), indexPath)
+	return nil
+}
+
+// metadataIndexFile is the file the metadata dump is imported into, which is next to the dump unless it is configured.
+func (cfg Config) metadataIndexFile() string {
+	if len(cfg.MetadataIndexFile) != 0 {
+		return cfg.MetadataIndexFile
+	}
+	return cfg.MetadataDumpFile + ".db"
+}
+
+// lookupMetadata fills in the new book from the metadata of the first of its isbns that the provider has.
+// The note tells the admin what was found.
+func (s *Server) lookupMetadata(ctx context.Context, b *book.Book) (note string, err error) {
+	for _, isbn := range []string{b.EanIsbn13, b.UpcIsbn10} {
+		if len(isbn) == 0 {
+			continue
+		}
+		m, err := s.metadata.Lookup(ctx, isbn)
+		switch {
+		case errors.Is(err, book.ErrNotFound):
+			continue
+		case err != nil:
+			return "", fmt.Errorf("looking up metadata: %w", err)
+		}
+		b.Title = m.Title
+		b.Author = m.Author
+		b.Subject = m.Subject
+		b.Description = m.Description
+		b.DeweyDecClass = m.DeweyDecClass
+		b.Pages = m.Pages
+		b.Publisher = m.Publisher
+		b.PublishDate = m.PublishDate
+		return "The book was filled in from the metadata of ISBN " + isbn + ". Check it before creating the book.", nil
+	}
+	return "No metadata was found for the ISBN.", nil
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestGetAdminLookup(t *testing.T) {
+	lemurs := book.Book{
+		Header:      book.Header{ID: "ignored", Title: "Lemurs", Author: "Jane Doe", Subject: "Primates"},
+		Pages:       312,
+		Publisher:   "Plenum Press",
+		PublishDate: time.Date(1999, 3, 3, 0, 0, 0, 0, time.UTC),
+		EanIsbn13:   "9780306406157",
+	}
+	lookup := func(isbn string) (*book.Book, error) {
+		switch isbn {
+		case "0306406152":
+			b := lemurs
+			return &b, nil
+		case "9780804429573":
+			return nil, fmt.Errorf("db error")
+		}
+		return nil, book.ISBNNotFoundError(isbn)
+	}
+	tests := []struct {
+		name         string
+		url          string
+		metadata     metadataProvider
+		wantCode     int
+		wantData     []string
+		unwantedData []string
+	}{
+		{
+			name:         "no provider",
+			url:          "/admin?ean-isbn-13=9780306406157",
+			wantCode:     200,
+			wantData:     []string{`name="ean-isbn-13" value="9780306406157"`},
+			unwantedData: []string{"Look Up Book", "metadata"},
+		},
+		{
+			name:         "lookup form",
+			url:          "/admin",
+			metadata:     mockMetadataProvider{lookupFunc: lookup},
+			wantCode:     200,
+			wantData:     []string{"Look Up Book"},
+			unwantedData: []string{"metadata"},
+		},
+		{
+			name:     "found by isbn-10",
+			url:      "/admin?ean-isbn-13=0-306-40615-2",
+			metadata: mockMetadataProvider{lookupFunc: lookup},
+			wantCode: 200,
+			wantData: []string{
+				"metadata of ISBN 0306406152",
+				`name="id" value=""`,
+				`name="title" value="Lemurs"`,
+				`name="author" value="Jane Doe"`,
+				`name="pages" value="312"`,
+				`name="publish-date" value="1999-03-03"`,
+				`name="ean-isbn-13" value="9780306406157"`,
+				`name="upc-isbn-10" value="0306406152"`,
+				"Create Book",
+			},
+		},
+		{
+			name:     "not found",
+			url:      "/admin?ean-isbn-13=9791234567896",
+			metadata: mockMetadataProvider{lookupFunc: lookup},
+			wantCode: 200,
+			wantData: []string{
+				"No metadata was found",
+				`name="ean-isbn-13" value="9791234567896"`,
+			},
+		},
+		{
+			name:     "provider error",
+			url:      "/admin?ean-isbn-13=9780804429573",
+			metadata: mockMetadataProvider{lookupFunc: lookup},
+			wantCode: 500,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			s := Server{
+				db: mockDatabase{
+					readCustomFieldsFunc: func() ([]book.CustomField, error) {
+						return nil, nil
+					},
+				},
+				metadata: test.metadata,
+				tmpl:     parseTemplate(staticFS),
+				out:      &sb,
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getAdmin(w, r)
+			switch {
+			case sb.Len() != 0:
+				t.Errorf("unwanted log: %q", sb.String())
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v", test.wantCode, w.Code)
+			case w.Code == 200:
+				got := w.Body.String()
+				for _, want := range test.wantData {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body, got: \n %v", want, got)
+					}
+				}
+				for _, exclude := range test.unwantedData {
+					if strings.Contains(got, exclude) {
+						t.Errorf("unwanted %q in body, got: \n %v", exclude, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestImportMetadata(t *testing.T) {
+	metadataDump := filepath.Join(t.TempDir(), "editions.txt")
+	edition := `{"type": {"key": "/type/edition"}, "title": "Lemurs", "isbn_10": ["0306406152"]}`
+	if err := os.WriteFile(metadataDump, []byte(edition), 0o600); err != nil {
+		t.Fatalf("writing metadata dump: %v", err)
+	}
+	indexPath := filepath.Join(t.TempDir(), "editions.db")
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantOk  bool
+		wantOut string
+	}{
+		{
+			name: "no dump",
+		},
+		{
+			name: "missing dump",
+			cfg: Config{
+				MetadataDumpFile: filepath.Join(t.TempDir(), "missing.txt"),
+			},
+		},
+		{
+			name: "default index",
+			cfg: Config{
+				MetadataDumpFile: metadataDump,
+			},
+			wantOk:  true,
+			wantOut: fmt.Sprintf("Imported metadata for 1 isbns into %q.\n", metadataDump+".db"),
+		},
+		{
+			name: "index",
+			cfg: Config{
+				MetadataDumpFile:  metadataDump,
+				MetadataIndexFile: indexPath,
+			},
+			wantOk:  true,
+			wantOut: fmt.Sprintf("Imported metadata for 1 isbns into %q.\n", indexPath),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			err := test.cfg.ImportMetadata(&sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.wantOut != sb.String():
+				t.Errorf("output not equal: \n wanted: %q \n got:    %q", test.wantOut, sb.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/marc"
+)
+
+// bookDecoder reads books from a file until it returns io.EOF.
+type bookDecoder interface {
+	Decode() (*book.Book, error)
+}
+
+// ImportMARC imports the books in the MARC file into the database.
+// Files that end in ".xml" are read as MARCXML; other files are read as MARC 21 records in the ISO 2709 exchange format.
+// Books keep the ids from the 001 control numbers of their records, and books without control numbers are given new ids.
+// Importing fails if any of the ids are already used unless ImportUpsert is set, which replaces those books.
+// Isbns are normalized; books with invalid isbns are reported and not imported.
+func (cfg Config) ImportMARC(ctx context.Context, out io.Writer) error {
+	f, err := os.Open(cfg.ImportMARCFile)
+	if err != nil {
+		return fmt.Errorf("opening marc file: %w", err)
+	}
+	defer f.Close()
+	books, err := readMARCBooks(marcDecoder(cfg.ImportMARCFile, f))
+	if err != nil {
+		return err
+	}
+	books, invalidISBNs := validISBNBooks(books)
+	db, err := cfg.createDatabase(ctx)
+	if err != nil {
+		return fmt.Errorf("creating database: %w", err)
+	}
+	if c, ok := db.(io.Closer); ok {
+		defer c.Close() // release file locks
+	}
+	if err := db.ImportBooks(ctx, cfg.ImportUpsert, books); err != nil {
+		return fmt.Errorf("importing books: %w", err)
+	}
+	for _, err := range invalidISBNs {
+		fmt.Fprintln(out, err)
+	}
+	fmt.Fprintf(out, "Imported %v books.\n", len(books))
+	if n := len(invalidISBNs); n != 0 {
+		fmt.Fprintf(out, "Skipped %v books with invalid isbns.\n", n)
+	}
+	return nil
+}
+
+func marcDecoder(name string, r io.Reader) bookDecoder {
+	if strings.EqualFold(filepath.Ext(name), ".xml") {
+		return marc.NewXMLDecoder(r)
+	}
+	return marc.NewDecoder(r)
+}
+
+func readMARCBooks(d bookDecoder) ([]book.Book, error) {
+	var books []book.Book
+	for {
+		b, err := d.Decode()
+		switch {
+		case err == io.EOF:
+			return books, nil
+		case err != nil:
+			return nil, fmt.Errorf("reading record %v: %w", len(books)+1, err)
+		}
+		if len(b.ID) == 0 {
+			b.ID = book.NewID()
+		}
+		books = append(books, *b)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/marc"
+)
+
+func TestImportMARC(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("writing %v: %v", name, err)
+		}
+		return path
+	}
+	var mrc bytes.Buffer
+	e := marc.NewEncoder(&mrc)
+	for _, b := range []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs"}},
+		{Header: book.Header{Title: "Zebras"}, EanIsbn13: "9780306406157"},
+		{Header: book.Header{Title: "Volcanoes"}, EanIsbn13: "9780306406158"},
+	} {
+		if err := e.Encode(b); err != nil {
+			t.Fatalf("encoding book: %v", err)
+		}
+	}
+	mrcFile := writeFile("books.mrc", mrc.Bytes())
+	xmlFile := writeFile("books.XML", []byte(`<collection><record><controlfield tag="001">1</controlfield></record></collection>`))
+	badFile := writeFile("bad.mrc", []byte("bad record"))
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantOk      bool
+		wantBooks   int
+		wantSkipped int
+	}{
+		{
+			name: "missing file",
+			cfg: Config{
+				DatabaseURL:    "memory://",
+				ImportMARCFile: filepath.Join(dir, "missing.mrc"),
+			},
+		},
+		{
+			name: "bad file",
+			cfg: Config{
+				DatabaseURL:    "memory://",
+				ImportMARCFile: badFile,
+			},
+		},
+		{
+			name: "bad database",
+			cfg: Config{
+				DatabaseURL:    "oracle://",
+				ImportMARCFile: mrcFile,
+			},
+		},
+		{
+			name: "happy path",
+			cfg: Config{
+				DatabaseURL:    "csvfile:" + filepath.Join(dir, "library.csv"),
+				ImportMARCFile: mrcFile,
+			},
+			wantOk:      true,
+			wantBooks:   2,
+			wantSkipped: 1,
+		},
+		{
+			name: "existing id",
+			cfg: Config{
+				DatabaseURL:    "csvfile:" + filepath.Join(dir, "library.csv"),
+				ImportMARCFile: xmlFile,
+			},
+		},
+		{
+			name: "existing id upsert",
+			cfg: Config{
+				DatabaseURL:    "csvfile:" + filepath.Join(dir, "library.csv"),
+				ImportMARCFile: xmlFile,
+				ImportUpsert:   true,
+			},
+			wantOk:    true,
+			wantBooks: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			ctx := context.Background()
+			err := test.cfg.ImportMARC(ctx, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !strings.Contains(sb.String(), fmt.Sprintf("Imported %v books.", test.wantBooks)):
+				t.Errorf("unwanted log: %q", sb.String())
+			case test.wantSkipped != 0 && !strings.Contains(sb.String(), fmt.Sprintf("Skipped %v books with invalid isbns.", test.wantSkipped)):
+				t.Errorf("wanted skipped books to be reported: %q", sb.String())
+			}
+		})
+	}
+}
+
+func TestReadMARCBooksNewIDs(t *testing.T) {
+	var buf bytes.Buffer
+	e := marc.NewEncoder(&buf)
+	if err := e.Encode(book.Book{Header: book.Header{Title: "Zebras"}}); err != nil {
+		t.Fatalf("encoding book: %v", err)
+	}
+	books, err := readMARCBooks(marcDecoder("books.mrc", &buf))
+	switch {
+	case err != nil:
+		t.Errorf("unwanted error: %v", err)
+	case len(books) != 1:
+		t.Errorf("wanted 1 book, got %v", len(books))
+	case len(books[0].ID) == 0:
+		t.Errorf("wanted book to be given an id")
+	}
+}
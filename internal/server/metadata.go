@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+type (
+	// bookPage is the data of the book template.
+	bookPage struct {
+		book.Book
+		Metadata bookMetadata
+		// CustomFields are shown with the values the book has for them.
+		CustomFields []book.CustomField
+	}
+	// bookMetadata is structured data about a book in the head of its page, so search engines and reference managers can detect it.
+	bookMetadata struct {
+		// JSONLD is a schema.org Book as JSON-LD.
+		JSONLD string
+		// DublinCore are the Dublin Core meta tags.
+		DublinCore []metaTag
+	}
+	metaTag struct {
+		Name    string
+		Content string
+	}
+	// schemaBook is a schema.org Book.
+	schemaBook struct {
+		Context       string       `json:"@context"`
+		Type          string       `json:"@type"`
+		Name          string       `json:"name,omitempty"`
+		Author        *schemaThing `json:"author,omitempty"`
+		ISBN          string       `json:"isbn,omitempty"`
+		Publisher     *schemaThing `json:"publisher,omitempty"`
+		DatePublished string       `json:"datePublished,omitempty"`
+		About         string       `json:"about,omitempty"`
+		NumberOfPages int          `json:"numberOfPages,omitempty"`
+		InLanguage    string       `json:"inLanguage,omitempty"`
+		BookEdition   string       `json:"bookEdition,omitempty"`
+		Description   string       `json:"description,omitempty"`
+	}
+	schemaThing struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+	}
+)
+
+func newBookMetadata(b book.Book) bookMetadata {
+	var isbns []string
+	for _, isbn := range []string{b.EanIsbn13, b.UpcIsbn10} {
+		if len(isbn) == 13 || len(isbn) == 10 { // UPC-A codes are not isbns
+			isbns = append(isbns, isbn)
+		}
+	}
+	var datePublished string
+	if !b.PublishDate.IsZero() {
+		datePublished = dateInputValue(b.PublishDate)
+	}
+	sb := schemaBook{
+		Context:       "https://schema.org",
+		Type:          "Book",
+		Name:          b.Title,
+		ISBN:          b.EanIsbn13,
+		DatePublished: datePublished,
+		About:         b.Subject,
+		NumberOfPages: b.Pages,
+		InLanguage:    b.Language,
+		BookEdition:   b.Edition,
+		Description:   b.Description,
+	}
+	if len(sb.ISBN) == 0 && len(isbns) != 0 {
+		sb.ISBN = isbns[0]
+	}
+	if len(b.Author) != 0 {
+		sb.Author = &schemaThing{"Person", b.Author}
+	}
+	if len(b.Publisher) != 0 {
+		sb.Publisher = &schemaThing{"Organization", b.Publisher}
+	}
+	jsonLD, _ := json.Marshal(sb) // cannot fail; "<" is escaped so the json cannot end the script element
+	var tags []metaTag
+	addTag := func(name, content string) {
+		if len(content) != 0 {
+			tags = append(tags, metaTag{"DC." + name, content})
+		}
+	}
+	addTag("title", b.Title)
+	addTag("creator", b.Author)
+	for _, isbn := range isbns {
+		addTag("identifier", "urn:isbn:"+isbn)
+	}
+	addTag("publisher", b.Publisher)
+	addTag("date", datePublished)
+	addTag("subject", b.Subject)
+	addTag("language", b.Language)
+	addTag("type", "Text")
+	return bookMetadata{
+		JSONLD:     string(jsonLD),
+		DublinCore: tags,
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestBookMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		b            book.Book
+		wantData     []string
+		unwantedData []string
+	}{
+		{
+			name: "all fields",
+			b: book.Book{
+				Header: book.Header{
+					ID:      "id7",
+					Title:   "Lemurs & <Friends>",
+					Author:  `Jane "J" Doe`,
+					Subject: "Animals",
+				},
+				Description: "About lemurs.",
+				Pages:       18,
+				Language:    "en",
+				Edition:     "2nd",
+				Publisher:   "Lemur Press",
+				PublishDate: time.Date(2022, 11, 25, 0, 0, 0, 0, time.UTC),
+				EanIsbn13:   "9780306406157",
+				UpcIsbn10:   "0306406152",
+			},
+			wantData: []string{
+				`<link rel="schema.DC" href="http://purl.org/dc/elements/1.1/">`,
+				`<meta name="DC.title" content="Lemurs &amp; &lt;Friends&gt;">`,
+				`<meta name="DC.creator" content="Jane &#34;J&#34; Doe">`,
+				`<meta name="DC.identifier" content="urn:isbn:9780306406157">`,
+				`<meta name="DC.identifier" content="urn:isbn:0306406152">`,
+				`<meta name="DC.publisher" content="Lemur Press">`,
+				`<meta name="DC.date" content="2022-11-25">`,
+				`<meta name="DC.subject" content="Animals">`,
+				`<meta name="DC.language" content="en">`,
+				`<meta name="DC.type" content="Text">`,
+				`<script type="application/ld+json">{"@context":"https://schema.org","@type":"Book",` +
+					`"name":"Lemurs \u0026 \u003cFriends\u003e",` + // escaped so the script cannot be ended early
+					`"author":{"@type":"Person","name":"Jane \"J\" Doe"},` +
+					`"isbn":"9780306406157",` +
+					`"publisher":{"@type":"Organization","name":"Lemur Press"},` +
+					`"datePublished":"2022-11-25",` +
+					`"about":"Animals",` +
+					`"numberOfPages":18,` +
+					`"inLanguage":"en",` +
+					`"bookEdition":"2nd",` +
+					`"description":"About lemurs."}</script>`,
+			},
+		},
+		{
+			name: "minimal",
+			b: book.Book{
+				Header: book.Header{
+					ID:    "id8",
+					Title: "Zebras",
+				},
+				UpcIsbn10: "0306406152",
+			},
+			wantData: []string{
+				`<meta name="DC.title" content="Zebras">`,
+				`<meta name="DC.identifier" content="urn:isbn:0306406152">`,
+				`<script type="application/ld+json">{"@context":"https://schema.org","@type":"Book","name":"Zebras","isbn":"0306406152"}</script>`,
+			},
+			unwantedData: []string{
+				"DC.creator",
+				"DC.publisher",
+				"DC.date",
+				"DC.subject",
+				"DC.language",
+			},
+		},
+		{
+			name: "upc-a is not an isbn",
+			b: book.Book{
+				Header: book.Header{
+					ID:    "id9",
+					Title: "Zebras",
+				},
+				UpcIsbn10: "036000291452",
+			},
+			wantData: []string{
+				`<script type="application/ld+json">{"@context":"https://schema.org","@type":"Book","name":"Zebras"}</script>`,
+			},
+			unwantedData: []string{
+				"DC.identifier",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			s := Server{
+				db: mockDatabase{
+					readBookFunc: func(id string) (*book.Book, error) {
+						return &test.b, nil
+					},
+					readCustomFieldsFunc: func() ([]book.CustomField, error) {
+						return nil, nil
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+				out:  &sb,
+			}
+			r := httptest.NewRequest("GET", "/book?id="+test.b.ID, nil)
+			w := httptest.NewRecorder()
+			s.getBook(w, r)
+			got := w.Body.String()
+			switch {
+			case sb.Len() != 0:
+				t.Errorf("unwanted log: %q", sb.String())
+			case w.Code != 200:
+				t.Errorf("unwanted code: %v", w.Code)
+			}
+			for _, want := range test.wantData {
+				if !strings.Contains(got, want) {
+					t.Errorf("wanted %q in body, got: \n %v", want, got)
+				}
+			}
+			for _, exclude := range test.unwantedData {
+				if strings.Contains(got, exclude) {
+					t.Errorf("unwanted %q in body, got: \n %v", exclude, got)
+				}
+			}
+		})
+	}
+}
@@ -29,38 +29,112 @@ func (m mockPasswordHandler) IsCorrectPassword(hashedPassword, password []byte)
 }
 
 type mockDatabase struct {
-	createBooksFunc         func(books ...book.Book) ([]book.Book, error)
+	createBooksFunc         func(books []book.Book, audits ...book.Audit) ([]book.Book, error)
+	importBooksFunc         func(upsert bool, books []book.Book, entries ...book.AuditEntry) error
 	readBookSubjectsFunc    func(limit, offset int) ([]book.Subject, error)
+	readBookAuthorsFunc     func(limit, offset int) ([]book.Author, error)
 	readBookHeadersFunc     func(f book.Filter, limit, offset int) ([]book.Header, error)
+	readShelvedBooksFunc    func() ([]book.ShelvedBook, error)
+	readSeriesBooksFunc     func() ([]book.SeriesBook, error)
 	readBookFunc            func(id string) (*book.Book, error)
-	updateBookFunc          func(b book.Book, updateImage bool) error
-	deleteBookFunc          func(id string) error
+	readBookMetadataFunc    func(id string) (*book.Book, error)
+	readBookByISBNFunc      func(isbn string) (*book.Book, error)
+	updateBookFunc          func(b book.Book, updateImage bool, audits ...book.Audit) error
+	deleteBookFunc          func(id string, entries ...book.AuditEntry) error
+	readTrashFunc           func() ([]book.TrashedBook, error)
+	readDeletedBookFunc     func(id string) (*book.DeletedBook, error)
+	importTrashFunc         func(books ...book.DeletedBook) error
+	restoreBookFunc         func(id string, entries ...book.AuditEntry) error
+	purgeBooksFunc          func(ids ...string) error
+	readBookHistoryFunc     func(bookID string) ([]book.AuditEntry, error)
+	readAuditLogFunc        func() ([]book.AuditEntry, error)
+	importAuditLogFunc      func(entries ...book.AuditEntry) error
 	readAdminPasswordFunc   func() (hashedPassword []byte, err error)
 	updateAdminPasswordFunc func(hashedPassword string) error
+	readSubjectAliasesFunc  func() ([]book.SubjectAlias, error)
+	mergeSubjectsFunc       func(canonical string, subjects []string, entries ...book.AuditEntry) error
+	readCustomFieldsFunc    func() ([]book.CustomField, error)
+	updateCustomFieldsFunc  func(fields ...book.CustomField) error
 }
 
-func (m mockDatabase) CreateBooks(ctx context.Context, books ...book.Book) ([]book.Book, error) {
-	return m.createBooksFunc(books...)
+func (m mockDatabase) CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error) {
+	return m.createBooksFunc(books, audits...)
+}
+
+func (m mockDatabase) ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+	return m.importBooksFunc(upsert, books, entries...)
 }
 
 func (m mockDatabase) ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error) {
 	return m.readBookSubjectsFunc(limit, offset)
 }
 
+func (m mockDatabase) ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error) {
+	return m.readBookAuthorsFunc(limit, offset)
+}
+
 func (m mockDatabase) ReadBookHeaders(ctx context.Context, f book.Filter, limit, offset int) ([]book.Header, error) {
 	return m.readBookHeadersFunc(f, limit, offset)
 }
 
+func (m mockDatabase) ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error) {
+	return m.readShelvedBooksFunc()
+}
+
+func (m mockDatabase) ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error) {
+	return m.readSeriesBooksFunc()
+}
+
 func (m mockDatabase) ReadBook(ctx context.Context, id string) (*book.Book, error) {
 	return m.readBookFunc(id)
 }
 
-func (m mockDatabase) UpdateBook(ctx context.Context, b book.Book, updateImage bool) error {
-	return m.updateBookFunc(b, updateImage)
+func (m mockDatabase) ReadBookMetadata(ctx context.Context, id string) (*book.Book, error) {
+	return m.readBookMetadataFunc(id)
+}
+
+func (m mockDatabase) ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error) {
+	return m.readBookByISBNFunc(isbn)
+}
+
+func (m mockDatabase) UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error {
+	return m.updateBookFunc(b, updateImage, audits...)
+}
+
+func (m mockDatabase) DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	return m.deleteBookFunc(id, entries...)
+}
+
+func (m mockDatabase) ReadTrash(ctx context.Context) ([]book.TrashedBook, error) {
+	return m.readTrashFunc()
+}
+
+func (m mockDatabase) ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error) {
+	return m.readDeletedBookFunc(id)
+}
+
+func (m mockDatabase) ImportTrash(ctx context.Context, books ...book.DeletedBook) error {
+	return m.importTrashFunc(books...)
 }
 
-func (m mockDatabase) DeleteBook(ctx context.Context, id string) error {
-	return m.deleteBookFunc(id)
+func (m mockDatabase) RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error {
+	return m.restoreBookFunc(id, entries...)
+}
+
+func (m mockDatabase) PurgeBooks(ctx context.Context, ids ...string) error {
+	return m.purgeBooksFunc(ids...)
+}
+
+func (m mockDatabase) ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error) {
+	return m.readBookHistoryFunc(bookID)
+}
+
+func (m mockDatabase) ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error) {
+	return m.readAuditLogFunc()
+}
+
+func (m mockDatabase) ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error {
+	return m.importAuditLogFunc(entries...)
 }
 
 func (m mockDatabase) ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error) {
@@ -70,3 +144,27 @@ func (m mockDatabase) ReadAdminPassword(ctx context.Context) (hashedPassword []b
 func (m mockDatabase) UpdateAdminPassword(ctx context.Context, hashedPassword string) error {
 	return m.updateAdminPasswordFunc(hashedPassword)
 }
+
+func (m mockDatabase) ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error) {
+	return m.readSubjectAliasesFunc()
+}
+
+func (m mockDatabase) MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error {
+	return m.mergeSubjectsFunc(canonical, subjects, entries...)
+}
+
+func (m mockDatabase) ReadCustomFields(ctx context.Context) ([]book.CustomField, error) {
+	return m.readCustomFieldsFunc()
+}
+
+func (m mockDatabase) UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error {
+	return m.updateCustomFieldsFunc(fields...)
+}
+
+type mockMetadataProvider struct {
+	lookupFunc func(isbn string) (*book.Book, error)
+}
+
+func (m mockMetadataProvider) Lookup(ctx context.Context, isbn string) (*book.Book, error) {
+	return m.lookupFunc(isbn)
+}
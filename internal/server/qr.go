@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/label"
+)
+
+// qrScale is the number of pixels on each side of the modules of qr code pngs.
+const qrScale = 8
+
+var (
+	qrSheetPDF = labelFormat{
+		contentType: "application/pdf",
+		filename:    "qr-codes.pdf",
+		write:       label.WritePDF,
+	}
+	qrSheetSVG = labelFormat{
+		contentType: "image/svg+xml",
+		filename:    "qr-codes.svg",
+		write:       label.WriteSVG,
+	}
+)
+
+// getBookQR sends a qr code of the absolute url of the page of the book as a png, or as a svg if the format is "svg".
+func (s *Server) getBookQR(w http.ResponseWriter, r *http.Request) {
+	var id, format string
+	switch {
+	case !parseFormValue(w, r, "id", &id, 64),
+		!parseFormValue(w, r, "format", &format, 10):
+		return
+	}
+	ctx := r.Context()
+	b, err := s.db.ReadBookMetadata(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("reading book: %w", err)
+		if errors.Is(err, book.ErrNotFound) {
+			httpError(w, http.StatusNotFound, err)
+			return
+		}
+		httpInternalServerError(w, err)
+		return
+	}
+	qr, err := label.QR(s.bookURL(r, b.ID))
+	if err != nil {
+		httpInternalServerError(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "", "png":
+		err = qr.WritePNG(&buf, qrScale)
+		w.Header().Set("Content-Type", "image/png")
+	case "svg":
+		err = qr.WriteSVG(&buf)
+		w.Header().Set("Content-Type", "image/svg+xml")
+	default:
+		err = fmt.Errorf("unknown qr code format: %q", format)
+		httpBadRequest(w, err)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("writing qr code: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	buf.WriteTo(w)
+}
+
+// postQRSheetPDF downloads labels with qr codes for the books of the subject as a pdf to print.
+func (s *Server) postQRSheetPDF(w http.ResponseWriter, r *http.Request) {
+	s.writeQRSheet(w, r, qrSheetPDF)
+}
+
+// postQRSheetSVG downloads labels with qr codes for the books of the subject as a svg image to preview.
+func (s *Server) postQRSheetSVG(w http.ResponseWriter, r *http.Request) {
+	s.writeQRSheet(w, r, qrSheetSVG)
+}
+
+// writeQRSheet draws labels with qr codes linking to the pages of the books of the subject onto the sheets of the layout.
+func (s *Server) writeQRSheet(w http.ResponseWriter, r *http.Request, format labelFormat) {
+	var subject string
+	if !parseFormValue(w, r, "s", &subject, 256) {
+		return
+	}
+	if len(subject) == 0 {
+		httpBadRequest(w, fmt.Errorf("subject required"))
+		return
+	}
+	layout, skip, ok := labelSheetFrom(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	iter := newBookIterator(s.db, s.cfg.MaxRows)
+	iter.filter.Subject = subject
+	iter.withoutImage = true
+	var labels []label.Label
+	for iter.HasNext(ctx) {
+		if len(labels) == maxLabels {
+			err := fmt.Errorf("too many books: at most %v labels can be printed at once", maxLabels)
+			httpError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		b, err := iter.Next(ctx)
+		if err != nil {
+			err = fmt.Errorf("reading book: %w", err)
+			httpInternalServerError(w, err)
+			return
+		}
+		l, err := label.NewQRLabel(*b, s.bookURL(r, b.ID))
+		if err != nil {
+			httpInternalServerError(w, err)
+			return
+		}
+		labels = append(labels, *l)
+	}
+	if err := iter.Err(); err != nil {
+		err = fmt.Errorf("reading books: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	if len(labels) == 0 {
+		httpBadRequest(w, fmt.Errorf("no books with subject %q", subject))
+		return
+	}
+	serveLabels(w, format, *layout, skip, labels)
+}
+
+// bookURL is the absolute url of the page of the book.
+// The base url of the server is used if it is configured; otherwise, it is guessed from the host of the request.
+func (s *Server) bookURL(r *http.Request, id string) string {
+	baseURL := strings.TrimSuffix(s.cfg.BaseURL, "/")
+	if len(baseURL) == 0 {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	return baseURL + "/book?id=" + url.QueryEscape(id)
+}
+
+// validateBaseURL checks that the base url is an absolute http or https url, if it is set.
+func (cfg Config) validateBaseURL() error {
+	if len(cfg.BaseURL) == 0 {
+		return nil
+	}
+	u, err := url.Parse(cfg.BaseURL)
+	switch {
+	case err != nil:
+		return fmt.Errorf("parsing base url: %w", err)
+	case u.Scheme != "http" && u.Scheme != "https", len(u.Host) == 0:
+		return fmt.Errorf("base url must be an absolute http or https url: %q", cfg.BaseURL)
+	case len(u.RawQuery) != 0, len(u.Fragment) != 0:
+		return fmt.Errorf("base url must not have a query or fragment: %q", cfg.BaseURL)
+	}
+	return nil
+}
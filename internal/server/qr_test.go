@@ -0,0 +1,262 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"image/png"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetBookQR(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs"}},
+	}
+	tests := []struct {
+		name     string
+		url      string
+		db       database
+		wantCode int
+		wantType string
+	}{
+		{
+			name:     "long id",
+			url:      "/book/qr?id=" + strings.Repeat("1", 65),
+			wantCode: 413,
+		},
+		{
+			name: "db error",
+			url:  "/book/qr?id=1",
+			db: mockDatabase{
+				readBookMetadataFunc: func(id string) (*book.Book, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "unknown book",
+			url:      "/book/qr?id=2",
+			wantCode: 404,
+		},
+		{
+			name:     "unknown format",
+			url:      "/book/qr?id=1&format=gif",
+			wantCode: 400,
+		},
+		{
+			name:     "png",
+			url:      "/book/qr?id=1",
+			wantCode: 200,
+			wantType: "image/png",
+		},
+		{
+			name:     "svg",
+			url:      "/book/qr?id=1&format=svg",
+			wantCode: 200,
+			wantType: "image/svg+xml",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db: test.db,
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getBookQR(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode != 200:
+			case test.wantType != w.Header().Get("Content-Type"):
+				t.Errorf("content types not equal: wanted %q, got %q", test.wantType, w.Header().Get("Content-Type"))
+			case test.wantType == "image/png":
+				img, err := png.Decode(w.Body)
+				if err != nil {
+					t.Fatalf("decoding png: %v", err)
+				}
+				if want, got := (29+8)*qrScale, img.Bounds().Dx(); want != got { // version 3 for http://example.com/book?id=1
+					t.Errorf("wanted png width of %v, got %v", want, got)
+				}
+			case !strings.HasPrefix(w.Body.String(), "<svg"):
+				t.Errorf("wanted svg, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPostQRSheet(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs", Author: "Jane Doe", Subject: "Animals"}, DeweyDecClass: "599.8"},
+		{Header: book.Header{ID: "2", Title: "Zebras", Author: "Ann Smith", Subject: "Animals"}, DeweyDecClass: "599.6"},
+		{Header: book.Header{ID: "3", Title: "Volcanoes", Subject: "Geology"}},
+	}
+	tests := []struct {
+		name     string
+		svg      bool
+		form     url.Values
+		db       database
+		wantCode int
+		wantData []string
+	}{
+		{
+			name:     "no subject",
+			form:     url.Values{"layout": {"avery-5160"}},
+			wantCode: 400,
+		},
+		{
+			name:     "unknown layout",
+			form:     url.Values{"s": {"Animals"}, "layout": {"avery-1"}},
+			wantCode: 400,
+		},
+		{
+			name:     "no books",
+			form:     url.Values{"s": {"Plants"}, "layout": {"avery-5160"}},
+			wantCode: 400,
+		},
+		{
+			name: "db error",
+			form: url.Values{"s": {"Animals"}, "layout": {"avery-5160"}},
+			db: mockDatabase{
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "too many books",
+			form: url.Values{"s": {"Animals"}, "layout": {"avery-5160"}},
+			db: mockDatabase{
+				readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+					headers := make([]book.Header, limit)
+					for i := range headers {
+						headers[i].ID = fmt.Sprint(offset + i)
+					}
+					return headers, nil
+				},
+				readBookMetadataFunc: func(id string) (*book.Book, error) {
+					return &book.Book{Header: book.Header{ID: id}}, nil
+				},
+			},
+			wantCode: 413,
+		},
+		{
+			name:     "pdf",
+			form:     url.Values{"s": {"Animals"}, "layout": {"avery-5160"}, "skip": {"1"}},
+			wantCode: 200,
+			wantData: []string{"%PDF-1.4", "/Count 1", "(599.8) Tj", "(DOE) Tj", "(Zebras) Tj"},
+		},
+		{
+			name:     "svg",
+			svg:      true,
+			form:     url.Values{"s": {"Geology"}, "layout": {"avery-l7651"}},
+			wantCode: 200,
+			wantData: []string{"<svg", ">Volcanoes</text>"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db: test.db,
+				cfg: Config{
+					MaxRows: 100,
+					BaseURL: "https://library.example.com",
+				},
+			}
+			r := httptest.NewRequest("POST", "/qr-sheet.pdf", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			if test.svg {
+				s.postQRSheetSVG(w, r)
+			} else {
+				s.postQRSheetPDF(w, r)
+			}
+			if test.wantCode != w.Code {
+				t.Fatalf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			}
+			got := w.Body.String()
+			for _, want := range test.wantData {
+				if !strings.Contains(got, want) {
+					t.Errorf("wanted %q in body, got: \n %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBookURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		https   bool
+		header  string
+		want    string
+	}{
+		{"request host", "", false, "", "http://example.com/book?id=a+b"},
+		{"tls", "", true, "", "https://example.com/book?id=a+b"},
+		{"forwarded https", "", false, "https", "https://example.com/book?id=a+b"},
+		{"base url", "https://library.example.com", false, "", "https://library.example.com/book?id=a+b"},
+		{"base url with path", "https://example.com/library/", false, "", "https://example.com/library/book?id=a+b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				cfg: Config{
+					BaseURL: test.baseURL,
+				},
+			}
+			r := httptest.NewRequest("GET", "/book/qr?id=a+b", nil)
+			if test.https {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if len(test.header) != 0 {
+				r.Header.Set("X-Forwarded-Proto", test.header)
+			}
+			if got := s.bookURL(r, "a b"); test.want != got {
+				t.Errorf("wanted %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		wantOk  bool
+	}{
+		{"", true},
+		{"https://library.example.com", true},
+		{"http://localhost:8000/library/", true},
+		{"library.example.com", false},
+		{"ftp://library.example.com", false},
+		{"https://", false},
+		{"https://library.example.com/?a=b", false},
+		{"https://library.example.com/#a", false},
+		{"%", false},
+	}
+	for _, test := range tests {
+		cfg := Config{BaseURL: test.baseURL}
+		err := cfg.validateBaseURL()
+		switch {
+		case !test.wantOk:
+			if err == nil {
+				t.Errorf("%q: wanted error", test.baseURL)
+			}
+		case err != nil:
+			t.Errorf("%q: unwanted error: %v", test.baseURL, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/isbn"
+)
+
+// scanPage is the data of the scan template.
+type scanPage struct {
+	ISBN  string
+	Error string
+}
+
+// getScan looks up the book with the scanned isbn.
+// Barcode scanners type the isbn and press enter, which submits the form on the page back to this handler.
+// Books that are found are shown.  Otherwise, the create form on the admin page is filled with the isbn.
+func (s *Server) getScan(w http.ResponseWriter, r *http.Request) {
+	var value string
+	if !parseFormValue(w, r, "isbn", &value, 32) {
+		return
+	}
+	if len(value) == 0 {
+		s.serveTemplate(w, "scan", scanPage{})
+		return
+	}
+	isbn13, isbn10, err := isbn.Pair(value, "")
+	if err != nil {
+		data := scanPage{
+			ISBN:  value,
+			Error: err.Error(),
+		}
+		s.serveTemplate(w, "scan", data)
+		return
+	}
+	ctx := r.Context()
+	for _, v := range []string{isbn13, isbn10} {
+		if len(v) == 0 {
+			continue
+		}
+		b, err := s.db.ReadBookByISBN(ctx, v)
+		switch {
+		case err == nil:
+			httpRedirect(w, r, "/book?id="+url.QueryEscape(b.ID))
+			return
+		case !errors.Is(err, book.ErrNotFound):
+			err = fmt.Errorf("reading book by isbn: %w", err)
+			httpInternalServerError(w, err)
+			return
+		}
+	}
+	query := url.Values{
+		"ean-isbn-13": {isbn13},
+		"upc-isbn-10": {isbn10},
+	}
+	httpRedirect(w, r, "/admin?"+query.Encode())
+}
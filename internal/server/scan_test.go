@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetScan(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Lemurs"}, EanIsbn13: "9780306406157", UpcIsbn10: "0306406152"},
+		{Header: book.Header{ID: "2", Title: "Zebras"}, UpcIsbn10: "080442957X"},
+	}
+	tests := []struct {
+		name         string
+		url          string
+		db           database
+		wantCode     int
+		wantLocation string
+		wantData     string
+	}{
+		{
+			name:     "scan page",
+			url:      "/scan",
+			wantCode: 200,
+			wantData: `name="isbn"`,
+		},
+		{
+			name:     "invalid isbn",
+			url:      "/scan?isbn=9780306406158",
+			wantCode: 200,
+			wantData: "Could not scan 9780306406158",
+		},
+		{
+			name:     "long isbn",
+			url:      "/scan?isbn=" + strings.Repeat("9", 33),
+			wantCode: 413,
+		},
+		{
+			name: "db error",
+			url:  "/scan?isbn=9780306406157",
+			db: mockDatabase{
+				readBookByISBNFunc: func(isbn string) (*book.Book, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:         "found by isbn-13",
+			url:          "/scan?isbn=9780306406157",
+			wantCode:     303,
+			wantLocation: "/book?id=1",
+		},
+		{
+			name:         "found by isbn-10 of scanned isbn-13",
+			url:          "/scan?isbn=978-0-8044-2957-3",
+			wantCode:     303,
+			wantLocation: "/book?id=2",
+		},
+		{
+			name:         "not found",
+			url:          "/scan?isbn=9791234567896",
+			wantCode:     303,
+			wantLocation: "/admin?ean-isbn-13=9791234567896&upc-isbn-10=",
+		},
+		{
+			name:         "not found isbn-10",
+			url:          "/scan?isbn=0-8044-2957-x",
+			db:           memory.NewDatabase(),
+			wantCode:     303,
+			wantLocation: "/admin?ean-isbn-13=9780804429573&upc-isbn-10=080442957X",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			var sb strings.Builder
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				out:  &sb,
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getScan(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantLocation != w.Header().Get("Location"):
+				t.Errorf("locations not equal: wanted %q, got %q", test.wantLocation, w.Header().Get("Location"))
+			case !strings.Contains(w.Body.String(), test.wantData):
+				t.Errorf("wanted %q in body, got: \n %v", test.wantData, w.Body.String())
+			case sb.Len() != 0:
+				t.Errorf("unwanted log: %q", sb.String())
+			}
+		})
+	}
+}
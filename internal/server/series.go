@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// getSeries shows the series of the books with their volumes in order and the volumes that are missing.
+// If the name is set, only the series with the name is shown.
+func (s *Server) getSeries(w http.ResponseWriter, r *http.Request) {
+	var name string
+	if !parseFormValue(w, r, "name", &name, 256) {
+		return
+	}
+	name = strings.TrimSpace(name)
+	ctx := r.Context()
+	seriesBooks, err := s.db.ReadSeriesBooks(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading series books: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	all := book.GroupSeries(seriesBooks)
+	if len(name) != 0 {
+		k := book.SubjectKey(name)
+		var named []book.Series
+		for _, series := range all {
+			if book.SubjectKey(series.Name) == k {
+				named = append(named, series)
+				name = series.Name
+			}
+		}
+		all = named
+	}
+	pageLoader := func(ctx context.Context, limit, offset int) ([]book.Series, error) {
+		if offset > len(all) {
+			offset = len(all)
+		}
+		end := offset + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		return all[offset:end], nil
+	}
+	if data, ok := loadPage(w, r, s.cfg.MaxRows, "Series", pageLoader); ok {
+		data["Name"] = name
+		s.serveTemplate(w, "series", data)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetSeries(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune"}, Series: "Dune", Volume: 1},
+		{Header: book.Header{ID: "2", Title: "Children of Dune"}, Series: "Dune", Volume: 3},
+		{Header: book.Header{ID: "3", Title: "Chapterhouse"}, Series: "Dune", Volume: 6},
+		{Header: book.Header{ID: "4", Title: "Mossflower"}, Series: "Redwall", Volume: 2},
+		{Header: book.Header{ID: "5", Title: "Lemurs"}},
+		{Header: book.Header{ID: "6", Title: "Atlas"}, Series: "Atlases"},
+	}
+	tests := []struct {
+		name       string
+		url        string
+		db         database
+		wantCode   int
+		wantParts  []string
+		wantAbsent []string
+	}{
+		{
+			name:     "long name",
+			url:      "/series?name=" + strings.Repeat("a", 257),
+			wantCode: 413,
+		},
+		{
+			name: "db error",
+			url:  "/series",
+			db: mockDatabase{
+				readSeriesBooksFunc: func() ([]book.SeriesBook, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "all series",
+			url:      "/series",
+			wantCode: 200,
+			wantParts: []string{
+				`href="/series?name=Atlases"`,
+				`Unnumbered: <a href="/book?id=6">Atlas</a>`,
+				`Volume 1: <a href="/book?id=1">Dune</a>`,
+				`<li class="missing">Missing volume 2</li>`,
+				`Volume 3: <a href="/book?id=2">Children of Dune</a>`,
+				`<li class="missing">Missing volumes 4-5</li>`,
+				"Load More series",
+			},
+			wantAbsent: []string{"Lemurs", "Redwall"},
+		},
+		{
+			name:       "next page",
+			url:        "/series?page=2",
+			wantCode:   200,
+			wantParts:  []string{"Mossflower", `<li class="missing">Missing volume 1</li>`},
+			wantAbsent: []string{"Chapterhouse", "Load More series"},
+		},
+		{
+			name:       "named series",
+			url:        "/series?name=+redwall",
+			wantCode:   200,
+			wantParts:  []string{"<h2>Redwall</h2>", "Mossflower", `href="/series">All series</a>`},
+			wantAbsent: []string{"Dune"},
+		},
+		{
+			name:      "unknown series",
+			url:       "/series?name=Discworld",
+			wantCode:  200,
+			wantParts: []string{"No series."},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				test.db = memory.NewDatabase(books...)
+			}
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				cfg:  Config{MaxRows: 2},
+			}
+			r := httptest.NewRequest("GET", test.url, nil)
+			w := httptest.NewRecorder()
+			s.getSeries(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+				for _, absent := range test.wantAbsent {
+					if strings.Contains(got, absent) {
+						t.Errorf("unwanted %q in body: %v", absent, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/openlibrary"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/bolt"
 	"github.com/jacobpatterson1549/kuuf-library/internal/db/csv"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
 	"github.com/jacobpatterson1549/kuuf-library/internal/db/mongo"
 	"github.com/jacobpatterson1549/kuuf-library/internal/db/sql"
 	"github.com/jacobpatterson1549/kuuf-library/internal/server/bcrypt"
@@ -36,16 +40,28 @@ const (
 
 type (
 	Config struct {
-		Port          string
-		DatabaseURL   string
-		BackfillCSV   bool
-		UpdateImages  bool
-		DumpCSV       bool
-		AdminPassword string
-		MaxRows       int
-		DBTimeoutSec  int
-		PostLimitSec  int
-		PostMaxBurst  int
+		Port               string
+		DatabaseURL        string
+		BackfillCSV        bool
+		UpdateImages       bool
+		DumpCSV            bool
+		AdminPassword      string
+		MaxRows            int
+		DBTimeoutSec       int
+		ExportTimeoutSec   int
+		PostLimitSec       int
+		PostMaxBurst       int
+		MigrateOnly        bool
+		MigrateDryRun      bool
+		TargetDatabaseURL  string
+		ImportUpsert       bool
+		ImportMARCFile     string
+		BackfillISBNs      bool
+		MetadataDumpFile   string
+		MetadataIndexFile  string
+		MetadataImport     bool
+		BaseURL            string
+		TrashRetentionDays int
 	}
 	Server struct {
 		cfg      Config
@@ -53,6 +69,7 @@ type (
 		tmpl     *template.Template
 		staticFS fs.FS
 		db       database
+		metadata metadataProvider
 		ph       passwordHandler
 		pv       passwordValidator
 		out      io.Writer
@@ -62,14 +79,48 @@ type (
 		IsCorrectPassword(hashedPassword, password []byte) (ok bool, err error)
 	}
 	database interface {
-		CreateBooks(ctx context.Context, books ...book.Book) ([]book.Book, error)
+		// CreateBooks creates the books with new ids, adding an entry to the audit log for each audit of each book.
+		CreateBooks(ctx context.Context, books []book.Book, audits ...book.Audit) ([]book.Book, error)
+		ImportBooks(ctx context.Context, upsert bool, books []book.Book, entries ...book.AuditEntry) error
 		ReadBookSubjects(ctx context.Context, limit, offset int) ([]book.Subject, error)
+		ReadBookAuthors(ctx context.Context, limit, offset int) ([]book.Author, error)
 		ReadBookHeaders(ctx context.Context, f book.Filter, limit, offset int) ([]book.Header, error)
+		ReadShelvedBooks(ctx context.Context) ([]book.ShelvedBook, error)
+		ReadSeriesBooks(ctx context.Context) ([]book.SeriesBook, error)
 		ReadBook(ctx context.Context, id string) (*book.Book, error)
-		UpdateBook(ctx context.Context, b book.Book, updateImage bool) error
-		DeleteBook(ctx context.Context, id string) error
+		// ReadBookMetadata reads the book without its image.
+		ReadBookMetadata(ctx context.Context, id string) (*book.Book, error)
+		ReadBookByISBN(ctx context.Context, isbn string) (*book.Book, error)
+		// UpdateBook replaces the book, adding an entry to the audit log for each audit with the changes from the book before the update.
+		UpdateBook(ctx context.Context, b book.Book, updateImage bool, audits ...book.Audit) error
+		// DeleteBook moves the book to the trash, adding the entries to the audit log.
+		DeleteBook(ctx context.Context, id string, entries ...book.AuditEntry) error
+		// ReadTrash reads the books in the trash, most recently deleted first.
+		ReadTrash(ctx context.Context) ([]book.TrashedBook, error)
+		// ReadDeletedBook reads the book in the trash with its image.
+		ReadDeletedBook(ctx context.Context, id string) (*book.DeletedBook, error)
+		// ImportTrash adds the deleted books to the trash with the times they were deleted, skipping books that are already in it.
+		ImportTrash(ctx context.Context, books ...book.DeletedBook) error
+		// RestoreBook moves the book out of the trash, adding the entries to the audit log.
+		RestoreBook(ctx context.Context, id string, entries ...book.AuditEntry) error
+		// PurgeBooks permanently removes the books from the trash.
+		PurgeBooks(ctx context.Context, ids ...string) error
+		// ReadBookHistory reads the audit log of the book, newest entries first.
+		ReadBookHistory(ctx context.Context, bookID string) ([]book.AuditEntry, error)
+		// ReadAuditLog reads the entries of the audit log, oldest first.
+		ReadAuditLog(ctx context.Context) ([]book.AuditEntry, error)
+		// ImportAuditLog adds the entries to the audit log, skipping entries with ids that are already in it.
+		ImportAuditLog(ctx context.Context, entries ...book.AuditEntry) error
 		ReadAdminPassword(ctx context.Context) (hashedPassword []byte, err error)
 		UpdateAdminPassword(ctx context.Context, hashedPassword string) error
+		ReadSubjectAliases(ctx context.Context) ([]book.SubjectAlias, error)
+		MergeSubjects(ctx context.Context, canonical string, subjects []string, entries ...book.AuditEntry) error
+		ReadCustomFields(ctx context.Context) ([]book.CustomField, error)
+		UpdateCustomFields(ctx context.Context, fields ...book.CustomField) error
+	}
+	// metadataProvider looks up the metadata of books that are not in the library to fill in the create form.
+	metadataProvider interface {
+		Lookup(ctx context.Context, isbn string) (*book.Book, error)
 	}
 	// page is sent to templates
 	page struct {
@@ -90,6 +141,9 @@ type (
 // NewServer creates and initializes a new server.
 // Initialization reads the config to set the admin password and backfill books from the csv database if desired.
 func (cfg Config) NewServer(ctx context.Context, out io.Writer) (*Server, error) {
+	if err := cfg.validateBaseURL(); err != nil {
+		return nil, err
+	}
 	db, err := cfg.createDatabase(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("creating database: %w", err)
@@ -116,6 +170,17 @@ func (cfg Config) NewServer(ctx context.Context, out io.Writer) (*Server, error)
 		pv:       pv,
 		out:      out,
 	}
+	if len(cfg.MetadataDumpFile) != 0 {
+		indexPath := cfg.metadataIndexFile()
+		d, stale, err := openlibrary.OpenDump(cfg.MetadataDumpFile, indexPath)
+		if err != nil {
+			return nil, fmt.Errorf("opening metadata dump (import it with -metadata-import): %w", err)
+		}
+		if stale {
+			fmt.Fprintf(out, "Metadata index %q is older than the dump; import it again with -metadata-import.\n", indexPath)
+		}
+		s.metadata = d
+	}
 	return &s, nil
 }
 
@@ -127,6 +192,11 @@ func (s *Server) RunSync() error {
 	lim := s.cfg.postRateLimiter()
 	addr := ":" + s.cfg.Port
 	handler := s.mux(lim)
+	if s.cfg.TrashRetentionDays > 0 {
+		ticker := time.NewTicker(trashPurgePeriod)
+		defer ticker.Stop()
+		go s.purgeTrashPeriodically(ticker.C)
+	}
 	return http.ListenAndServe(addr, handler) // BLOCKING
 }
 
@@ -134,12 +204,27 @@ func (cfg Config) createDatabase(ctx context.Context) (database, error) {
 	switch s := cfg.databaseScheme(); s {
 	case "csv":
 		return embeddedCSVDatabase()
+	case "csvfile":
+		return csv.NewFileDatabase(cfg.DatabaseURL)
+	case "bolt":
+		return bolt.NewDatabase(cfg.DatabaseURL)
+	case "memory":
+		return cfg.memoryDatabase(ctx)
 	case "mongodb+srv":
-		return mongo.NewDatabase(ctx, cfg.DatabaseURL)
-	case "postgres":
-		return sql.NewDatabase(ctx, s, cfg.DatabaseURL)
-	case "file":
-		return sql.NewDatabase(ctx, "sqlite3", cfg.DatabaseURL)
+		d, err := mongo.NewDatabase(ctx, cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.CreateIndexes(ctx); err != nil {
+			return nil, err
+		}
+		if err := d.SetDefaults(ctx); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case "postgres", "file":
+		driverName, _ := cfg.sqlDriverName()
+		return sql.NewDatabase(ctx, driverName, cfg.DatabaseURL)
 	default:
 		return nil, fmt.Errorf("unknown database: %q", s)
 	}
@@ -155,12 +240,30 @@ func embeddedCSVDatabase() (database, error) {
 		ReadBookSubjectsFunc: func(ctx context.Context, limit, offset int) ([]book.Subject, error) {
 			return d.ReadBookSubjects(limit, offset)
 		},
+		ReadBookAuthorsFunc: func(ctx context.Context, limit, offset int) ([]book.Author, error) {
+			return d.ReadBookAuthors(limit, offset)
+		},
 		ReadBookHeadersFunc: func(ctx context.Context, filter book.Filter, limit, offset int) ([]book.Header, error) {
 			return d.ReadBookHeaders(filter, limit, offset)
 		},
+		ReadShelvedBooksFunc: func(ctx context.Context) ([]book.ShelvedBook, error) {
+			return d.ReadShelvedBooks()
+		},
+		ReadSeriesBooksFunc: func(ctx context.Context) ([]book.SeriesBook, error) {
+			return d.ReadSeriesBooks()
+		},
 		ReadBookFunc: func(ctx context.Context, id string) (*book.Book, error) {
 			return d.ReadBook(id)
 		},
+		ReadBookByISBNFunc: func(ctx context.Context, isbn string) (*book.Book, error) {
+			return d.ReadBookByISBN(isbn)
+		},
+		ReadSubjectAliasesFunc: func(ctx context.Context) ([]book.SubjectAlias, error) {
+			return d.ReadSubjectAliases()
+		},
+		ReadCustomFieldsFunc: func(ctx context.Context) ([]book.CustomField, error) {
+			return d.ReadCustomFields()
+		},
 	}
 	d3 := allBooksDatabase{
 		database: d2,
@@ -171,11 +274,42 @@ func embeddedCSVDatabase() (database, error) {
 	return d3, nil
 }
 
+// memoryDatabase creates a database that only stores books in memory.
+// Urls are like "memory://" or "memory://?seed=csv" to start with the books from the embedded csv file.
+func (cfg Config) memoryDatabase(ctx context.Context) (database, error) {
+	u, err := url.Parse(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database url: %w", err)
+	}
+	var books []book.Book
+	switch seed := u.Query().Get("seed"); seed {
+	case "":
+	case "csv":
+		csvD, err := embeddedCSVDatabase()
+		if err != nil {
+			return nil, fmt.Errorf("loading csv database: %w", err)
+		}
+		iter := newBookIterator(csvD, cfg.MaxRows)
+		if books, err = iter.AllBooks(ctx); err != nil {
+			return nil, fmt.Errorf("reading books to seed: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown memory database seed: %q", seed)
+	}
+	return memory.NewDatabase(books...), nil
+}
+
 func parseTemplate(fsys fs.FS) *template.Template {
 	funcs := template.FuncMap{
-		"pretty":         prettyInputValue,
-		"newDate":        time.Now,
-		"dateInputValue": dateInputValue,
+		"pretty":             prettyInputValue,
+		"newDate":            time.Now,
+		"dateInputValue":     dateInputValue,
+		"formatTags":         book.FormatTags,
+		"formatContributors": book.FormatContributors,
+		"formats":            func() []string { return book.Formats },
+		"readingLevels":      func() []string { return book.ReadingLevels },
+		"fieldTypes":         func() []string { return book.FieldTypes },
+		"formatOptions":      book.FormatOptions,
 	}
 	return template.Must(template.New("index.html").
 		Funcs(funcs).
@@ -186,17 +320,44 @@ func (s *Server) mux(postRateLimiter rateLimiter) http.Handler {
 	static := http.FileServer(http.FS(s.staticFS))
 	m := mux{
 		http.MethodGet: map[string]http.HandlerFunc{
-			"/":           s.getBookSubjects,
-			"/list":       s.getBookHeaders,
-			"/book":       s.getBook,
-			"/admin":      s.getAdmin,
-			"/robots.txt": static.ServeHTTP,
+			"/":               s.getBookSubjects,
+			"/list":           s.getBookHeaders,
+			"/book":           s.getBook,
+			"/admin":          s.getAdmin,
+			"/cite":           s.getCitations,
+			"/scan":           s.getScan,
+			"/labels":         s.getLabels,
+			"/book/qr":        s.getBookQR,
+			"/browse/dewey":   s.getDeweyBrowse,
+			"/admin/subjects": s.getAdminSubjects,
+			"/admin/fields":   s.getAdminCustomFields,
+			"/admin/history":  s.getBookHistory,
+			"/admin/trash":    s.getTrash,
+			"/authors":        s.getBookAuthors,
+			"/author":         s.getAuthor,
+			"/series":         s.getSeries,
+			"/robots.txt":     static.ServeHTTP,
 		},
 		http.MethodPost: map[string]http.HandlerFunc{
-			"/book/create":  s.postBook,
-			"/book/delete":  s.deleteBook,
-			"/book/update":  s.putBook,
-			"/admin/update": s.putAdminPassword,
+			"/book/create":          s.postBook,
+			"/book/delete":          s.deleteBook,
+			"/book/update":          s.putBook,
+			"/admin/update":         s.putAdminPassword,
+			"/admin/import":         s.postImport,
+			"/admin/subjects/merge": s.postMergeSubjects,
+			"/admin/fields/update":  s.postUpdateCustomFields,
+			"/admin/history":        s.postBookHistory,
+			"/admin/history/revert": s.postRevertBook,
+			"/admin/trash":          s.postTrash,
+			"/admin/trash/restore":  s.postRestoreBook,
+			"/admin/trash/purge":    s.postPurgeBooks,
+			"/export.csv":           s.postExport,
+			"/export.mrc":           s.postExportMARC,
+			"/export.marc.xml":      s.postExportMARCXML,
+			"/labels.pdf":           s.postLabelsPDF,
+			"/labels.svg":           s.postLabelsSVG,
+			"/qr-sheet.pdf":         s.postQRSheetPDF,
+			"/qr-sheet.svg":         s.postQRSheetSVG,
 		},
 	}
 	authenticatedMethods := []string{
@@ -211,9 +372,15 @@ func (s *Server) mux(postRateLimiter rateLimiter) http.Handler {
 	}
 	duration := time.Hour * 24 // update message in admin.html when updating cache age
 	queryTimeout := s.cfg.queryTimeout()
+	exportTimeout := s.cfg.exportTimeout()
+	exportDurations := map[string]time.Duration{
+		"/export.csv":      exportTimeout,
+		"/export.mrc":      exportTimeout,
+		"/export.marc.xml": exportTimeout,
+	}
 	h := withContentEncoding(m)
 	h = withCacheControl(h, duration)
-	h = withContextTimeout(h, queryTimeout)
+	h = withContextTimeout(h, queryTimeout, exportDurations)
 	return h
 }
 
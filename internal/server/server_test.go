@@ -3,15 +3,26 @@ package server
 import (
 	"context"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"text/template"
 	"time"
 
 	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/book/openlibrary"
 )
 
 func TestNewServer(t *testing.T) {
+	metadataDump := filepath.Join(t.TempDir(), "editions.txt")
+	edition := `{"type": {"key": "/type/edition"}, "title": "Lemurs", "isbn_10": ["0306406152"]}`
+	if err := os.WriteFile(metadataDump, []byte(edition), 0o600); err != nil {
+		t.Fatalf("writing metadata dump: %v", err)
+	}
+	if err := openlibrary.ImportDumpFile(metadataDump, metadataDump+".db"); err != nil {
+		t.Fatalf("importing metadata dump: %v", err)
+	}
 	tests := []struct {
 		name   string
 		cfg    Config
@@ -40,6 +51,87 @@ func TestNewServer(t *testing.T) {
 			},
 			wantOk: true,
 		},
+		{
+			name: "csvfile",
+			cfg: Config{
+				DatabaseURL: "csvfile://" + filepath.Join(t.TempDir(), "library.csv"),
+			},
+			wantOk: true,
+		},
+		{
+			name: "bolt",
+			cfg: Config{
+				DatabaseURL: "bolt://" + filepath.Join(t.TempDir(), "library.db"),
+			},
+			wantOk: true,
+		},
+		{
+			name: "memory",
+			cfg: Config{
+				DatabaseURL: "memory://",
+			},
+			wantOk: true,
+		},
+		{
+			name: "memory seeded from csv",
+			cfg: Config{
+				DatabaseURL: "memory://?seed=csv",
+				MaxRows:     10,
+			},
+			wantOk: true,
+		},
+		{
+			name: "memory unknown seed",
+			cfg: Config{
+				DatabaseURL: "memory://?seed=mongo",
+			},
+		},
+		{
+			name: "memory with admin password",
+			cfg: Config{
+				DatabaseURL:   "memory://",
+				AdminPassword: "Backfill-M3",
+			},
+			wantOk: true,
+		},
+		{
+			name: "metadata dump",
+			cfg: Config{
+				DatabaseURL:      "memory://",
+				MetadataDumpFile: metadataDump,
+			},
+			wantOk: true,
+		},
+		{
+			name: "metadata index not imported",
+			cfg: Config{
+				DatabaseURL:       "memory://",
+				MetadataDumpFile:  metadataDump,
+				MetadataIndexFile: filepath.Join(t.TempDir(), "editions.db"),
+			},
+		},
+		{
+			name: "missing metadata dump",
+			cfg: Config{
+				DatabaseURL:      "memory://",
+				MetadataDumpFile: filepath.Join(t.TempDir(), "missing.txt"),
+			},
+		},
+		{
+			name: "base url",
+			cfg: Config{
+				DatabaseURL: "memory://",
+				BaseURL:     "https://library.example.com/",
+			},
+			wantOk: true,
+		},
+		{
+			name: "invalid base url",
+			cfg: Config{
+				DatabaseURL: "memory://",
+				BaseURL:     "library.example.com",
+			},
+		},
 		{
 			name: "setup failure",
 			cfg: Config{
@@ -68,6 +160,8 @@ func TestNewServer(t *testing.T) {
 				t.Errorf("staticFS not set")
 			case got.db == nil:
 				t.Errorf("database not set")
+			case len(test.cfg.MetadataDumpFile) != 0 && got.metadata == nil:
+				t.Errorf("metadata provider not set")
 			case got.ph == nil:
 				t.Errorf("password handler not set")
 			case got.out != &sb:
@@ -140,6 +234,9 @@ func TestEmbeddedCSVDatabase(t *testing.T) {
 	if _, err := db.ReadBook(ctx, "unknown-id"); err == nil {
 		t.Errorf("wanted error reading book with unknown id")
 	}
+	if fields, err := db.ReadCustomFields(ctx); err != nil || len(fields) != 0 {
+		t.Errorf("wanted no custom fields and no error, got: %v, %v", fields, err)
+	}
 	if _, ok := db.(AllBooksDatabase); !ok {
 		t.Fatalf("source is not an allBookIterator")
 	}
@@ -157,6 +254,24 @@ func TestMux(t *testing.T) {
 			readBookFunc: func(id string) (*book.Book, error) {
 				return new(book.Book), nil
 			},
+			readBookMetadataFunc: func(id string) (*book.Book, error) {
+				return new(book.Book), nil
+			},
+			readShelvedBooksFunc: func() ([]book.ShelvedBook, error) {
+				return nil, nil
+			},
+			readSubjectAliasesFunc: func() ([]book.SubjectAlias, error) {
+				return nil, nil
+			},
+			readCustomFieldsFunc: func() ([]book.CustomField, error) {
+				return nil, nil
+			},
+			readBookHistoryFunc: func(bookID string) ([]book.AuditEntry, error) {
+				return nil, nil
+			},
+			readTrashFunc: func() ([]book.TrashedBook, error) {
+				return nil, nil
+			},
 		},
 		tmpl:     parseTemplate(staticFS),
 		staticFS: staticFS, // used by robots.txt
@@ -174,6 +289,15 @@ func TestMux(t *testing.T) {
 		{"list", "GET", "/list", 200},
 		{"book", "GET", "/book", 200},
 		{"admin", "GET", "/admin", 200},
+		{"cite", "GET", "/cite?id=1&format=ris", 200},
+		{"scan", "GET", "/scan", 200},
+		{"labels", "GET", "/labels", 200},
+		{"book qr", "GET", "/book/qr?id=1", 200},
+		{"dewey", "GET", "/browse/dewey", 200},
+		{"admin subjects", "GET", "/admin/subjects", 200},
+		{"admin fields", "GET", "/admin/fields", 200},
+		{"admin history", "GET", "/admin/history?book-id=1", 200},
+		{"admin trash", "GET", "/admin/trash", 200},
 		{"robots.txt", "GET", "/robots.txt", 200},
 		{"not found", "GET", "/bad.html", 404},
 	}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// maxMergeSubjects is the most subjects that can be merged at once.
+const maxMergeSubjects = 100
+
+// getAdminSubjects shows the subjects with their counts so they can be merged, and the aliases in the subject registry.
+func (s *Server) getAdminSubjects(w http.ResponseWriter, r *http.Request) {
+	data, ok := loadPage(w, r, s.cfg.MaxRows, "Subjects", s.db.ReadBookSubjects)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	aliases, err := s.db.ReadSubjectAliases(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading subject aliases: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	data["Aliases"] = aliases
+	s.serveTemplate(w, "admin-subjects", data)
+}
+
+// postMergeSubjects gives all books with the selected subjects the canonical subject.
+// The selected subjects become aliases of the canonical subject, so books that are saved with them later get the canonical subject.
+// The change to each book is added to the audit log, so it can be reverted.
+func (s *Server) postMergeSubjects(w http.ResponseWriter, r *http.Request) {
+	var canonical, actor string
+	if !parseFormValue(w, r, "canonical", &canonical, 256) ||
+		!parseActor(w, r, &actor) {
+		return
+	}
+	canonical = book.NormalizeSubject(canonical)
+	var subjects []string
+	for _, subject := range r.Form["s"] {
+		switch {
+		case len(subject) > 256:
+			httpError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("subject too long"))
+			return
+		case len(book.NormalizeSubject(subject)) != 0:
+			subjects = append(subjects, subject)
+		}
+	}
+	switch {
+	case len(canonical) == 0:
+		httpBadRequest(w, fmt.Errorf("canonical subject required"))
+		return
+	case len(subjects) == 0:
+		httpBadRequest(w, fmt.Errorf("no subjects selected"))
+		return
+	case len(subjects) > maxMergeSubjects:
+		err := fmt.Errorf("too many subjects selected: at most %v subjects can be merged at once", maxMergeSubjects)
+		httpError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	ctx := r.Context()
+	entries, err := s.mergeAuditEntries(ctx, actor, canonical, subjects...)
+	if err != nil {
+		err = fmt.Errorf("reading books to merge: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	if err := s.db.MergeSubjects(ctx, canonical, subjects, entries...); err != nil {
+		err = fmt.Errorf("merging subjects: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	httpRedirect(w, r, "/admin/subjects")
+}
+
+// mergeAuditEntries describes how merging the subjects changes the books that have them or a variant of the canonical subject as their subject or a tag.
+// Books are read without their images, which merging does not change.
+func (s *Server) mergeAuditEntries(ctx context.Context, actor, canonical string, subjects ...string) ([]book.AuditEntry, error) {
+	ids := make(map[string]struct{})
+	var before book.Books
+	for _, subject := range append([]string{canonical}, subjects...) {
+		iter := newBookIterator(s.db, s.cfg.MaxRows)
+		iter.filter = book.Filter{Subject: subject}
+		iter.withoutImage = true
+		for iter.HasNext(ctx) {
+			b, err := iter.Next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := ids[b.ID]; ok {
+				continue
+			}
+			ids[b.ID] = struct{}{}
+			before = append(before, *b)
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+	after := make(book.Books, len(before))
+	copy(after, before) // merging replaces the tags of books rather than changing them
+	after.MergeSubjects(canonical, subjects...)
+	var entries []book.AuditEntry
+	for i := range before {
+		if e := book.NewAuditEntry(actor, book.ActionMerge, before[i], after[i]); len(e.Changes) != 0 {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// canonicalizeSubjects replaces the subject and tags of the book that are aliases in the subject registry with their canonical subjects.
+func (s *Server) canonicalizeSubjects(ctx context.Context, b *book.Book) error {
+	aliases, err := s.db.ReadSubjectAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("reading subject aliases: %w", err)
+	}
+	b.Subject = book.SubjectAliases(aliases).Canonical(b.Subject)
+	b.Tags = book.SubjectAliases(aliases).CanonicalTags(b.Subject, b.Tags)
+	return nil
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestGetAdminSubjects(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Subject: "Fiction"}},
+		{Header: book.Header{ID: "2", Subject: "fiction "}},
+		{Header: book.Header{ID: "3", Subject: "Poetry"}},
+	}
+	tests := []struct {
+		name      string
+		db        database
+		wantCode  int
+		wantParts []string
+	}{
+		{
+			name: "subjects error",
+			db: mockDatabase{
+				readBookSubjectsFunc: func(limit, offset int) ([]book.Subject, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name: "aliases error",
+			db: mockDatabase{
+				readBookSubjectsFunc: func(limit, offset int) ([]book.Subject, error) {
+					return nil, nil
+				},
+				readSubjectAliasesFunc: func() ([]book.SubjectAlias, error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantCode: 500,
+		},
+		{
+			name:     "happy path",
+			wantCode: 200,
+			wantParts: []string{
+				`value="Fiction"`,
+				"Fiction (2)",
+				"Poetry (1)",
+				"<td>novels</td>",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.db == nil {
+				db := memory.NewDatabase(books...)
+				ctx := context.Background()
+				if err := db.MergeSubjects(ctx, "Fiction", []string{"novels"}); err != nil {
+					t.Fatalf("setting up aliases: %v", err)
+				}
+				test.db = db
+			}
+			s := Server{
+				db:   test.db,
+				tmpl: parseTemplate(staticFS),
+				cfg:  Config{MaxRows: 10},
+			}
+			r := httptest.NewRequest("GET", "/admin/subjects", nil)
+			w := httptest.NewRecorder()
+			s.getAdminSubjects(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostMergeSubjects(t *testing.T) {
+	tooMany := make([]string, maxMergeSubjects+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprint("s", i)
+	}
+	tests := []struct {
+		name         string
+		form         url.Values
+		readErr      error
+		mergeErr     error
+		wantCode     int
+		wantSubjects []string
+	}{
+		{
+			name:     "canonical too long",
+			form:     url.Values{"canonical": {strings.Repeat("c", 257)}, "s": {"a"}},
+			wantCode: 413,
+		},
+		{
+			name:     "subject too long",
+			form:     url.Values{"canonical": {"c"}, "s": {strings.Repeat("s", 257)}},
+			wantCode: 413,
+		},
+		{
+			name:     "no canonical",
+			form:     url.Values{"canonical": {"  "}, "s": {"a"}},
+			wantCode: 400,
+		},
+		{
+			name:     "no subjects",
+			form:     url.Values{"canonical": {"c"}, "s": {"", " "}},
+			wantCode: 400,
+		},
+		{
+			name:     "too many subjects",
+			form:     url.Values{"canonical": {"c"}, "s": tooMany},
+			wantCode: 413,
+		},
+		{
+			name:     "read error",
+			form:     url.Values{"canonical": {"c"}, "s": {"a"}},
+			readErr:  fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:     "db error",
+			form:     url.Values{"canonical": {"c"}, "s": {"a"}},
+			mergeErr: fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:         "happy path",
+			form:         url.Values{"canonical": {" Science  Fiction "}, "s": {"sci-fi", "", "SF"}},
+			wantCode:     303,
+			wantSubjects: []string{"Science Fiction", "sci-fi", "SF"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotSubjects []string
+			s := Server{
+				db: mockDatabase{
+					readBookHeadersFunc: func(f book.Filter, limit, offset int) ([]book.Header, error) {
+						return nil, test.readErr
+					},
+					mergeSubjectsFunc: func(canonical string, subjects []string, entries ...book.AuditEntry) error {
+						gotSubjects = append([]string{canonical}, subjects...)
+						return test.mergeErr
+					},
+				},
+			}
+			body := strings.NewReader(test.form.Encode())
+			r := httptest.NewRequest("POST", "/admin/subjects/merge", body)
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.postMergeSubjects(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode == 303:
+				if want, got := "/admin/subjects", w.Header().Get("Location"); want != got {
+					t.Errorf("locations not equal: wanted %q, got %q", want, got)
+				}
+				if want, got := test.wantSubjects, gotSubjects; !reflect.DeepEqual(want, got) {
+					t.Errorf("merged subjects not equal: \n wanted: %q \n got:    %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPostMergeSubjectsAuditLog(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "1", Title: "Dune", Subject: "sci-fi"}, ImageBase64: "img1"},
+		{Header: book.Header{ID: "2", Title: "Lemurs", Subject: "Animals"}, Tags: []string{"SF"}},
+		{Header: book.Header{ID: "3", Title: "Foundation", Subject: "Science Fiction"}},
+		{Header: book.Header{ID: "4", Title: "Zebras", Subject: "Animals"}},
+	}
+	db := memory.NewDatabase(books...)
+	s := Server{
+		db: db,
+		cfg: Config{
+			MaxRows: 1,
+		},
+	}
+	form := url.Values{"canonical": {"Science Fiction"}, "s": {"sci-fi", "SF"}, "actor": {"Ann"}}
+	r := httptest.NewRequest("POST", "/admin/subjects/merge", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.postMergeSubjects(w, r)
+	if want, got := 303, w.Code; want != got {
+		t.Fatalf("codes not equal: wanted %v, got %v: %v", want, got, w.Body.String())
+	}
+	ctx := context.Background()
+	log, err := db.ReadAuditLog(ctx)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	want := map[string][]book.FieldChange{
+		"1": {{Field: "subject", Before: "sci-fi", After: "Science Fiction"}},
+		"2": {{Field: "tags", Before: "SF", After: "Science Fiction"}},
+	}
+	got := make(map[string][]book.FieldChange, len(log))
+	for _, e := range log {
+		if e.Actor != "Ann" || e.Action != book.ActionMerge {
+			t.Errorf("wanted merge by Ann, got %v by %v", e.Action, e.Actor)
+		}
+		got[e.BookID] = e.Changes
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("audit entries not equal: \n wanted: %v \n got:    %v", want, got)
+	}
+	b, err := db.ReadBook(ctx, "1")
+	if err != nil || b.ImageBase64 != "img1" {
+		t.Errorf("merging should keep the image, got %v (error: %v)", b, err)
+	}
+}
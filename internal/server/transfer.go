@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// transferStep copies a part of the source database to the target database.
+type transferStep struct {
+	name     string
+	transfer func(ctx context.Context, src, dst database, out io.Writer) error
+}
+
+// TransferDatabase copies everything from the database to the target database.
+// Books keep their ids.
+// Books that are already in the target database are skipped, so a transfer that is interrupted can be resumed by running it again.
+// If ImportUpsert is set, books that are already in the target database are replaced instead.
+func (cfg Config) TransferDatabase(ctx context.Context, out io.Writer) error {
+	src, err := cfg.createDatabase(ctx)
+	if err != nil {
+		return fmt.Errorf("creating source database: %w", err)
+	}
+	if c, ok := src.(io.Closer); ok {
+		defer c.Close() // release file locks
+	}
+	targetCfg := cfg
+	targetCfg.DatabaseURL = cfg.TargetDatabaseURL
+	dst, err := targetCfg.createDatabase(ctx)
+	if err != nil {
+		return fmt.Errorf("creating target database: %w", err)
+	}
+	if c, ok := dst.(io.Closer); ok {
+		defer c.Close() // release file locks
+	}
+	for _, step := range cfg.transferSteps() {
+		fmt.Fprintf(out, "Transferring %v.\n", step.name)
+		if err := step.transfer(ctx, src, dst, out); err != nil {
+			return fmt.Errorf("transferring %v: %w", step.name, err)
+		}
+	}
+	fmt.Fprintln(out, "Transfer complete.")
+	return nil
+}
+
+// transferSteps are run in order. Add a step for each new kind of data that databases store.
+func (cfg Config) transferSteps() []transferStep {
+	return []transferStep{
+		{"custom fields", transferCustomFields},
+		{"books", cfg.transferBooks},
+		{"trash", transferTrash},
+		{"audit log", transferAuditLog},
+		{"subject aliases", transferSubjectAliases},
+		{"admin password", transferAdminPassword},
+	}
+}
+
+// transferBooks copies books in batches, including their images.
+func (cfg Config) transferBooks(ctx context.Context, src, dst database, out io.Writer) error {
+	var transferred, skipped int
+	batch := make([]book.Book, 0, cfg.MaxRows)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := dst.ImportBooks(ctx, cfg.ImportUpsert, batch); err != nil {
+			return fmt.Errorf("writing books: %w", err)
+		}
+		transferred += len(batch)
+		batch = batch[:0]
+		fmt.Fprintf(out, "Transferred %v books, skipped %v books already in the target database.\n", transferred, skipped)
+		return nil
+	}
+	iter := newBookIterator(src, cfg.MaxRows)
+	for iter.HasNext(ctx) {
+		b, err := iter.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !cfg.ImportUpsert {
+			if _, err := dst.ReadBook(ctx, b.ID); err == nil {
+				skipped++
+				continue
+			}
+		}
+		batch = append(batch, *b)
+		if len(batch) >= cfg.MaxRows {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Finished transferring books: %v transferred, %v skipped.\n", transferred, skipped)
+	return nil
+}
+
+// transferTrash copies the deleted books with their images and the times they were deleted.
+// Books that are already in the trash of the target database are skipped.
+func transferTrash(ctx context.Context, src, dst database, out io.Writer) error {
+	trash, err := src.ReadTrash(ctx)
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+	books := make([]book.DeletedBook, len(trash))
+	for i, b := range trash {
+		deleted, err := src.ReadDeletedBook(ctx, b.ID)
+		if err != nil {
+			return fmt.Errorf("reading deleted book %q: %w", b.ID, err)
+		}
+		books[i] = *deleted
+	}
+	if err := dst.ImportTrash(ctx, books...); err != nil {
+		return fmt.Errorf("importing trash: %w", err)
+	}
+	fmt.Fprintf(out, "Transferred %v books in the trash.\n", len(books))
+	return nil
+}
+
+// transferAuditLog copies the entries of the audit log that are not already in the target database.
+func transferAuditLog(ctx context.Context, src, dst database, out io.Writer) error {
+	entries, err := src.ReadAuditLog(ctx)
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	if err := dst.ImportAuditLog(ctx, entries...); err != nil {
+		return fmt.Errorf("importing audit log: %w", err)
+	}
+	fmt.Fprintf(out, "Transferred %v audit log entries.\n", len(entries))
+	return nil
+}
+
+// transferAdminPassword copies the hashed admin password if the source database has one.
+func transferAdminPassword(ctx context.Context, src, dst database, out io.Writer) error {
+	hashedPassword, err := src.ReadAdminPassword(ctx)
+	if err != nil {
+		return fmt.Errorf("reading admin password: %w", err)
+	}
+	if len(hashedPassword) == 0 {
+		fmt.Fprintln(out, "The source database does not have an admin password.")
+		return nil
+	}
+	if err := dst.UpdateAdminPassword(ctx, string(hashedPassword)); err != nil {
+		return fmt.Errorf("updating admin password: %w", err)
+	}
+	return nil
+}
+
+// transferSubjectAliases merges the aliases of each canonical subject in the target database.
+func transferSubjectAliases(ctx context.Context, src, dst database, out io.Writer) error {
+	aliases, err := src.ReadSubjectAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("reading subject aliases: %w", err)
+	}
+	var canonicals []string
+	names := make(map[string][]string)
+	for _, a := range aliases {
+		if _, ok := names[a.Canonical]; !ok {
+			canonicals = append(canonicals, a.Canonical)
+		}
+		names[a.Canonical] = append(names[a.Canonical], a.Name)
+	}
+	for _, canonical := range canonicals {
+		if err := dst.MergeSubjects(ctx, canonical, names[canonical]); err != nil {
+			return fmt.Errorf("merging subjects into %q: %w", canonical, err)
+		}
+	}
+	fmt.Fprintf(out, "Transferred %v subject aliases.\n", len(aliases))
+	return nil
+}
+
+// transferCustomFields replaces the custom fields of the target database if the source database has any.
+func transferCustomFields(ctx context.Context, src, dst database, out io.Writer) error {
+	fields, err := src.ReadCustomFields(ctx)
+	if err != nil {
+		return fmt.Errorf("reading custom fields: %w", err)
+	}
+	if len(fields) == 0 {
+		fmt.Fprintln(out, "The source database does not have custom fields.")
+		return nil
+	}
+	if err := dst.UpdateCustomFields(ctx, fields...); err != nil {
+		return fmt.Errorf("updating custom fields: %w", err)
+	}
+	fmt.Fprintf(out, "Transferred %v custom fields.\n", len(fields))
+	return nil
+}
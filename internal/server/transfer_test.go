@@ -0,0 +1,502 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/bolt"
+	"github.com/jacobpatterson1549/kuuf-library/internal/db/memory"
+)
+
+func TestTransferDatabase(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		cfg    Config
+		wantOk bool
+	}{
+		{
+			name: "bad source",
+			cfg: Config{
+				DatabaseURL:       "oracle://",
+				TargetDatabaseURL: "memory://",
+			},
+		},
+		{
+			name: "bad target",
+			cfg: Config{
+				DatabaseURL:       "memory://",
+				TargetDatabaseURL: "oracle://",
+			},
+		},
+		{
+			name: "happy path",
+			cfg: Config{
+				DatabaseURL:       "csv://",
+				TargetDatabaseURL: "csvfile:" + filepath.Join(dir, "library.csv"),
+				MaxRows:           10,
+			},
+			wantOk: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			ctx := context.Background()
+			err := test.cfg.TransferDatabase(ctx, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !strings.Contains(sb.String(), "Transfer complete."):
+				t.Errorf("wanted transfer to complete, got log: %q", sb.String())
+			}
+		})
+	}
+}
+
+func TestTransferDatabaseClosesDatabases(t *testing.T) {
+	dir := t.TempDir()
+	src := "bolt://" + filepath.Join(dir, "source.db")
+	dst := "bolt://" + filepath.Join(dir, "target.db")
+	cfg := Config{
+		DatabaseURL:       src,
+		TargetDatabaseURL: dst,
+		MaxRows:           10,
+	}
+	var sb strings.Builder
+	ctx := context.Background()
+	if err := cfg.TransferDatabase(ctx, &sb); err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	for _, url := range []string{src, dst} {
+		d, err := bolt.NewDatabase(url)
+		if err != nil {
+			t.Errorf("wanted database to be closed after transfer so it can be opened again: %v", err)
+			continue
+		}
+		d.Close()
+	}
+}
+
+func TestTransferBooks(t *testing.T) {
+	books := []book.Book{
+		{Header: book.Header{ID: "a", Title: "A", Subject: "s"}, ImageBase64: "img"},
+		{Header: book.Header{ID: "b", Title: "B", Subject: "s"}},
+		{Header: book.Header{ID: "c", Title: "C", Subject: "s"}},
+	}
+	cfg := Config{
+		MaxRows: 2,
+	}
+	ctx := context.Background()
+	t.Run("keep ids and resume", func(t *testing.T) {
+		src := memory.NewDatabase(books...)
+		dst := memory.NewDatabase(books[1]) // already transferred
+		var sb strings.Builder
+		if err := cfg.transferBooks(ctx, src, dst, &sb); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		for _, want := range books {
+			got, err := dst.ReadBook(ctx, want.ID)
+			switch {
+			case err != nil:
+				t.Errorf("reading transferred book: %v", err)
+			case !reflect.DeepEqual(want, *got):
+				t.Errorf("not equal: \n wanted: %v \n got:    %v", want, *got)
+			}
+		}
+		if want, got := "2 transferred, 1 skipped", sb.String(); !strings.Contains(got, want) {
+			t.Errorf("wanted log to contain %q, got: %q", want, got)
+		}
+	})
+	t.Run("upsert", func(t *testing.T) {
+		src := memory.NewDatabase(books...)
+		old := books[1]
+		old.Title = "old title"
+		dst := memory.NewDatabase(old)
+		upsertCfg := cfg
+		upsertCfg.ImportUpsert = true
+		var sb strings.Builder
+		if err := upsertCfg.transferBooks(ctx, src, dst, &sb); err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got, err := dst.ReadBook(ctx, old.ID)
+		switch {
+		case err != nil:
+			t.Errorf("reading replaced book: %v", err)
+		case !reflect.DeepEqual(books[1], *got):
+			t.Errorf("not equal: \n wanted: %v \n got:    %v", books[1], *got)
+		}
+		if want, got := "3 transferred, 0 skipped", sb.String(); !strings.Contains(got, want) {
+			t.Errorf("wanted log to contain %q, got: %q", want, got)
+		}
+	})
+	t.Run("write error", func(t *testing.T) {
+		src := memory.NewDatabase(books...)
+		dst := mockDatabase{
+			readBookFunc: func(id string) (*book.Book, error) {
+				return nil, fmt.Errorf("not found")
+			},
+			importBooksFunc: func(upsert bool, books []book.Book, entries ...book.AuditEntry) error {
+				return fmt.Errorf("db error")
+			},
+		}
+		var sb strings.Builder
+		if err := cfg.transferBooks(ctx, src, dst, &sb); err == nil {
+			t.Errorf("wanted error")
+		}
+	})
+}
+
+func TestTransferTrash(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	deleted := []book.DeletedBook{
+		{Book: book.Book{Header: book.Header{ID: "b2", Title: "Two"}, ImageBase64: "IMG"}, DeletedDate: time1.Add(time.Hour)},
+		{Book: book.Book{Header: book.Header{ID: "b1", Title: "One"}}, DeletedDate: time1},
+	}
+	trash := []book.TrashedBook{deleted[0].TrashedBook(), deleted[1].TrashedBook()}
+	tests := []struct {
+		name      string
+		readErr   error
+		bookErr   error
+		importErr error
+		wantOk    bool
+		wantBooks []book.DeletedBook
+	}{
+		{
+			name:    "read trash error",
+			readErr: fmt.Errorf("db error"),
+		},
+		{
+			name:    "read deleted book error",
+			bookErr: fmt.Errorf("db error"),
+		},
+		{
+			name:      "import error",
+			importErr: fmt.Errorf("db error"),
+		},
+		{
+			name:      "happy path",
+			wantOk:    true,
+			wantBooks: deleted,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := mockDatabase{
+				readTrashFunc: func() ([]book.TrashedBook, error) {
+					return trash, test.readErr
+				},
+				readDeletedBookFunc: func(id string) (*book.DeletedBook, error) {
+					for _, b := range deleted {
+						if b.ID == id {
+							return &b, test.bookErr
+						}
+					}
+					return nil, book.TrashNotFoundError(id)
+				},
+			}
+			var gotBooks []book.DeletedBook
+			dst := mockDatabase{
+				importTrashFunc: func(books ...book.DeletedBook) error {
+					gotBooks = books
+					return test.importErr
+				},
+			}
+			var sb strings.Builder
+			ctx := context.Background()
+			err := transferTrash(ctx, src, dst, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantBooks, gotBooks):
+				t.Errorf("books not equal: \n wanted: %v \n got:    %v", test.wantBooks, gotBooks)
+			case !strings.Contains(sb.String(), "Transferred 2 books in the trash"):
+				t.Errorf("wanted log to contain book count, got %q", sb.String())
+			}
+		})
+	}
+}
+
+func TestTransferAuditLog(t *testing.T) {
+	entries := []book.AuditEntry{
+		{ID: "e1", BookID: "a", Actor: "Ann", Action: book.ActionUpdate},
+		{ID: "e2", BookID: "a", Actor: "Bob", Action: book.ActionDelete},
+	}
+	tests := []struct {
+		name        string
+		readErr     error
+		importErr   error
+		wantOk      bool
+		wantEntries []book.AuditEntry
+	}{
+		{
+			name:    "read error",
+			readErr: fmt.Errorf("db error"),
+		},
+		{
+			name:      "import error",
+			importErr: fmt.Errorf("db error"),
+		},
+		{
+			name:        "happy path",
+			wantOk:      true,
+			wantEntries: entries,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := mockDatabase{
+				readAuditLogFunc: func() ([]book.AuditEntry, error) {
+					return entries, test.readErr
+				},
+			}
+			var gotEntries []book.AuditEntry
+			dst := mockDatabase{
+				importAuditLogFunc: func(entries ...book.AuditEntry) error {
+					gotEntries = entries
+					return test.importErr
+				},
+			}
+			var sb strings.Builder
+			ctx := context.Background()
+			err := transferAuditLog(ctx, src, dst, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantEntries, gotEntries):
+				t.Errorf("entries not equal: \n wanted: %v \n got:    %v", test.wantEntries, gotEntries)
+			case !strings.Contains(sb.String(), "Transferred 2 audit log entries"):
+				t.Errorf("wanted log to contain entry count, got %q", sb.String())
+			}
+		})
+	}
+}
+
+func TestTransferSubjectAliases(t *testing.T) {
+	aliases := []book.SubjectAlias{
+		{Name: "novels", Canonical: "Fiction"},
+		{Name: "poems", Canonical: "Poetry"},
+		{Name: "stories", Canonical: "Fiction"},
+	}
+	tests := []struct {
+		name       string
+		readErr    error
+		mergeErr   error
+		wantOk     bool
+		wantMerges [][]string
+	}{
+		{
+			name:    "read error",
+			readErr: fmt.Errorf("db error"),
+		},
+		{
+			name:     "merge error",
+			mergeErr: fmt.Errorf("db error"),
+		},
+		{
+			name:   "happy path",
+			wantOk: true,
+			wantMerges: [][]string{
+				{"Fiction", "novels", "stories"},
+				{"Poetry", "poems"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := mockDatabase{
+				readSubjectAliasesFunc: func() ([]book.SubjectAlias, error) {
+					return aliases, test.readErr
+				},
+			}
+			var gotMerges [][]string
+			dst := mockDatabase{
+				mergeSubjectsFunc: func(canonical string, subjects []string, entries ...book.AuditEntry) error {
+					gotMerges = append(gotMerges, append([]string{canonical}, subjects...))
+					return test.mergeErr
+				},
+			}
+			var sb strings.Builder
+			ctx := context.Background()
+			err := transferSubjectAliases(ctx, src, dst, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantMerges, gotMerges):
+				t.Errorf("merges not equal: \n wanted: %q \n got:    %q", test.wantMerges, gotMerges)
+			}
+		})
+	}
+}
+
+func TestTransferCustomFields(t *testing.T) {
+	fields := []book.CustomField{
+		{Name: "Donor", Type: book.FieldText},
+		{Name: "Shelf", Type: book.FieldEnum, Options: []string{"Top", "Bottom"}},
+	}
+	tests := []struct {
+		name        string
+		fields      []book.CustomField
+		readErr     error
+		updateErr   error
+		wantOk      bool
+		wantFields  []book.CustomField
+		wantLogPart string
+	}{
+		{
+			name:    "read error",
+			readErr: fmt.Errorf("db error"),
+		},
+		{
+			name:      "update error",
+			fields:    fields,
+			updateErr: fmt.Errorf("db error"),
+		},
+		{
+			name:        "no fields",
+			wantOk:      true,
+			wantLogPart: "does not have custom fields",
+		},
+		{
+			name:        "happy path",
+			fields:      fields,
+			wantOk:      true,
+			wantFields:  fields,
+			wantLogPart: "Transferred 2 custom fields",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := mockDatabase{
+				readCustomFieldsFunc: func() ([]book.CustomField, error) {
+					return test.fields, test.readErr
+				},
+			}
+			var gotFields []book.CustomField
+			dst := mockDatabase{
+				updateCustomFieldsFunc: func(fields ...book.CustomField) error {
+					gotFields = fields
+					return test.updateErr
+				},
+			}
+			var sb strings.Builder
+			ctx := context.Background()
+			err := transferCustomFields(ctx, src, dst, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case !reflect.DeepEqual(test.wantFields, gotFields):
+				t.Errorf("fields not equal: \n wanted: %v \n got:    %v", test.wantFields, gotFields)
+			case !strings.Contains(sb.String(), test.wantLogPart):
+				t.Errorf("wanted log to contain %q, got %q", test.wantLogPart, sb.String())
+			}
+		})
+	}
+}
+
+func TestTransferAdminPassword(t *testing.T) {
+	tests := []struct {
+		name          string
+		src           database
+		updateErr     error
+		wantOk        bool
+		wantPassword  string
+		wantLogPart   string
+		wantNoUpdates bool
+	}{
+		{
+			name: "read error",
+			src: mockDatabase{
+				readAdminPasswordFunc: func() (hashedPassword []byte, err error) {
+					return nil, fmt.Errorf("db error")
+				},
+			},
+			wantNoUpdates: true,
+		},
+		{
+			name: "no password",
+			src: mockDatabase{
+				readAdminPasswordFunc: func() (hashedPassword []byte, err error) {
+					return nil, nil
+				},
+			},
+			wantOk:        true,
+			wantLogPart:   "does not have an admin password",
+			wantNoUpdates: true,
+		},
+		{
+			name: "update error",
+			src: mockDatabase{
+				readAdminPasswordFunc: func() (hashedPassword []byte, err error) {
+					return []byte("hash48"), nil
+				},
+			},
+			updateErr: fmt.Errorf("db error"),
+		},
+		{
+			name: "happy path",
+			src: mockDatabase{
+				readAdminPasswordFunc: func() (hashedPassword []byte, err error) {
+					return []byte("hash48"), nil
+				},
+			},
+			wantOk:       true,
+			wantPassword: "hash48",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotPassword string
+			dst := mockDatabase{
+				updateAdminPasswordFunc: func(hashedPassword string) error {
+					if test.wantNoUpdates {
+						t.Errorf("unwanted password update")
+					}
+					gotPassword = hashedPassword
+					return test.updateErr
+				},
+			}
+			var sb strings.Builder
+			ctx := context.Background()
+			err := transferAdminPassword(ctx, test.src, dst, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.wantPassword != gotPassword:
+				t.Errorf("passwords not equal: wanted %q, got %q", test.wantPassword, gotPassword)
+			case !strings.Contains(sb.String(), test.wantLogPart):
+				t.Errorf("wanted log to contain %q, got: %q", test.wantLogPart, sb.String())
+			}
+		})
+	}
+}
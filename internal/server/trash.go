@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+// trashPurgePeriod is how often books that have been in the trash longer than the retention period are purged.
+const trashPurgePeriod = time.Hour * 24
+
+// trashItem is a book in the trash with when it will be purged, which is zero if it is kept until it is purged by an admin.
+type trashItem struct {
+	book.TrashedBook
+	PurgeDate time.Time
+}
+
+// trashPage is the trash page, which only lists the books after the admin password is checked.
+type trashPage struct {
+	Authenticated bool
+	Trash         []trashItem
+	RetentionDays int
+}
+
+// getTrash shows a form to enter the admin password to see the trash, which is not shown to visitors.
+func (s *Server) getTrash(w http.ResponseWriter, r *http.Request) {
+	data := trashPage{
+		RetentionDays: s.cfg.TrashRetentionDays,
+	}
+	s.serveTemplate(w, "admin-trash", data)
+}
+
+// postTrash shows the deleted books so they can be restored or purged.
+// It is a post so the books are only shown after the admin password is checked.
+func (s *Server) postTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	trash, err := s.db.ReadTrash(ctx)
+	if err != nil {
+		err = fmt.Errorf("reading trash: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	items := make([]trashItem, len(trash))
+	for i, b := range trash {
+		items[i].TrashedBook = b
+		if s.cfg.TrashRetentionDays > 0 {
+			items[i].PurgeDate = b.DeletedDate.AddDate(0, 0, s.cfg.TrashRetentionDays)
+		}
+	}
+	data := trashPage{
+		Authenticated: true,
+		Trash:         items,
+		RetentionDays: s.cfg.TrashRetentionDays,
+	}
+	s.serveTemplate(w, "admin-trash", data)
+}
+
+// postRestoreBook moves a book out of the trash, adding the restore to its audit log.
+func (s *Server) postRestoreBook(w http.ResponseWriter, r *http.Request) {
+	var id, actor string
+	if !parseFormValue(w, r, "book-id", &id, 64) || !parseActor(w, r, &actor) {
+		return
+	}
+	ctx := r.Context()
+	b := book.Book{Header: book.Header{ID: id}}
+	entry := book.NewAuditEntry(actor, book.ActionRestore, b, b)
+	if err := s.db.RestoreBook(ctx, id, entry); err != nil {
+		err = fmt.Errorf("restoring book: %w", err)
+		switch {
+		case errors.Is(err, book.ErrNotFound):
+			httpError(w, http.StatusNotFound, err)
+		case errors.Is(err, book.ErrIDExists):
+			httpError(w, http.StatusConflict, err)
+		default:
+			httpInternalServerError(w, err)
+		}
+		return
+	}
+	httpRedirect(w, r, "/book?id="+id)
+}
+
+// postPurgeBooks permanently removes a book from the trash.
+func (s *Server) postPurgeBooks(w http.ResponseWriter, r *http.Request) {
+	var id string
+	if !parseFormValue(w, r, "book-id", &id, 64) {
+		return
+	}
+	if len(id) == 0 {
+		httpBadRequest(w, fmt.Errorf("book id required"))
+		return
+	}
+	ctx := r.Context()
+	if err := s.db.PurgeBooks(ctx, id); err != nil {
+		err = fmt.Errorf("purging book: %w", err)
+		httpInternalServerError(w, err)
+		return
+	}
+	httpRedirect(w, r, "/admin/trash")
+}
+
+// purgeTrash permanently removes the books that have been in the trash longer than the retention period, returning how many were purged.
+// Nothing is purged if the retention period is not positive.
+func (cfg Config) purgeTrash(ctx context.Context, db database, now time.Time) (int, error) {
+	if cfg.TrashRetentionDays <= 0 {
+		return 0, nil
+	}
+	trash, err := db.ReadTrash(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("reading trash: %w", err)
+	}
+	ids := book.ExpiredIDs(trash, now.AddDate(0, 0, -cfg.TrashRetentionDays))
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := db.PurgeBooks(ctx, ids...); err != nil {
+		return 0, fmt.Errorf("purging books: %w", err)
+	}
+	return len(ids), nil
+}
+
+// purgeTrashPeriodically purges expired books from the trash each time the ticks channel receives, printing the results.
+func (s *Server) purgeTrashPeriodically(ticks <-chan time.Time) {
+	for now := range ticks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.queryTimeout())
+		n, err := s.cfg.purgeTrash(ctx, s.db, now)
+		cancel()
+		switch {
+		case err != nil:
+			fmt.Fprintf(s.out, "purging trash: %v\n", err)
+		case n != 0:
+			fmt.Fprintf(s.out, "Purged %v books from the trash.\n", n)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobpatterson1549/kuuf-library/internal/book"
+)
+
+func TestGetTrash(t *testing.T) {
+	s := Server{
+		cfg: Config{
+			TrashRetentionDays: 7,
+		},
+		db: mockDatabase{
+			readTrashFunc: func() ([]book.TrashedBook, error) {
+				t.Errorf("trash read before the admin password is checked")
+				return nil, nil
+			},
+		},
+		tmpl: parseTemplate(staticFS),
+	}
+	r := httptest.NewRequest("GET", "/admin/trash", nil)
+	w := httptest.NewRecorder()
+	s.getTrash(w, r)
+	got := w.Body.String()
+	switch {
+	case w.Code != 200:
+		t.Errorf("wanted ok, got %v: %v", w.Code, got)
+	case !strings.Contains(got, `action="/admin/trash"`):
+		t.Errorf("wanted password form: %v", got)
+	case strings.Contains(got, "The trash is empty."):
+		t.Errorf("wanted trash to be hidden: %v", got)
+	}
+}
+
+func TestPostTrash(t *testing.T) {
+	time1 := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	trash := []book.TrashedBook{
+		{Header: book.Header{ID: "b2", Title: "<Two>", Author: "a2", Subject: "s2"}, DeletedDate: time1.Add(time.Hour)},
+		{Header: book.Header{ID: "b1", Title: "One", Author: "a1", Subject: "s1"}, DeletedDate: time1},
+	}
+	tests := []struct {
+		name          string
+		retentionDays int
+		trash         []book.TrashedBook
+		readErr       error
+		wantCode      int
+		wantParts     []string
+		unwantParts   []string
+	}{
+		{
+			name:     "db error",
+			readErr:  fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:      "empty",
+			wantCode:  200,
+			wantParts: []string{"The trash is empty."},
+		},
+		{
+			name:     "kept forever",
+			trash:    trash,
+			wantCode: 200,
+			wantParts: []string{
+				"Books are kept in the trash until they are purged.",
+				"Deleted 2022-11-13 01:02:03 UTC",
+				`name="book-id" value="b1"`,
+			},
+			unwantParts: []string{
+				"purged after",
+			},
+		},
+		{
+			name:          "happy path",
+			retentionDays: 7,
+			trash:         trash,
+			wantCode:      200,
+			wantParts: []string{
+				"Books are purged automatically 7 days after they are deleted.",
+				"&lt;Two&gt;",
+				"Deleted 2022-11-13 02:02:03 UTC",
+				"purged after 2022-11-20 02:02:03 UTC",
+				`name="book-id" value="b2"`,
+			},
+			unwantParts: []string{
+				"<Two>",
+				"The trash is empty.",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				cfg: Config{
+					TrashRetentionDays: test.retentionDays,
+				},
+				db: mockDatabase{
+					readTrashFunc: func() ([]book.TrashedBook, error) {
+						return test.trash, test.readErr
+					},
+				},
+				tmpl: parseTemplate(staticFS),
+			}
+			r := httptest.NewRequest("POST", "/admin/trash", nil)
+			w := httptest.NewRecorder()
+			s.postTrash(w, r)
+			got := w.Body.String()
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, got)
+			default:
+				for _, want := range test.wantParts {
+					if !strings.Contains(got, want) {
+						t.Errorf("wanted %q in body: %v", want, got)
+					}
+				}
+				for _, unwanted := range test.unwantParts {
+					if strings.Contains(got, unwanted) {
+						t.Errorf("unwanted %q in body: %v", unwanted, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostRestoreBook(t *testing.T) {
+	tests := []struct {
+		name       string
+		form       url.Values
+		restoreErr error
+		wantCode   int
+		wantActor  string
+	}{
+		{
+			name:     "long id",
+			form:     url.Values{"book-id": {strings.Repeat("x", 65)}},
+			wantCode: 413,
+		},
+		{
+			name:       "not in trash",
+			form:       url.Values{"book-id": {"b1"}},
+			restoreErr: book.TrashNotFoundError("b1"),
+			wantCode:   404,
+		},
+		{
+			name:       "id exists",
+			form:       url.Values{"book-id": {"b1"}},
+			restoreErr: book.ExistingIDsError([]string{"b1"}),
+			wantCode:   409,
+		},
+		{
+			name:       "db error",
+			form:       url.Values{"book-id": {"b1"}},
+			restoreErr: fmt.Errorf("db error"),
+			wantCode:   500,
+		},
+		{
+			name:      "default actor",
+			form:      url.Values{"book-id": {"b1"}},
+			wantCode:  303,
+			wantActor: defaultActor,
+		},
+		{
+			name:      "happy path",
+			form:      url.Values{"book-id": {"b1"}, "actor": {" Cy "}},
+			wantCode:  303,
+			wantActor: "Cy",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var entries []book.AuditEntry
+			s := Server{
+				db: mockDatabase{
+					restoreBookFunc: func(id string, e ...book.AuditEntry) error {
+						if id != "b1" {
+							return fmt.Errorf("unwanted id: %q", id)
+						}
+						entries = e
+						return test.restoreErr
+					},
+				},
+			}
+			r := httptest.NewRequest("POST", "/admin/trash/restore", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.postRestoreBook(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode != 303:
+			case w.Header().Get("Location") != "/book?id=b1":
+				t.Errorf("unwanted redirect: %q", w.Header().Get("Location"))
+			case len(entries) != 1:
+				t.Errorf("wanted 1 audit entry, got %v", entries)
+			default:
+				e := entries[0]
+				if e.BookID != "b1" || e.Action != book.ActionRestore || e.Actor != test.wantActor || len(e.Changes) != 0 {
+					t.Errorf("unwanted audit entry: %#v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestPostPurgeBooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     url.Values
+		purgeErr error
+		wantCode int
+	}{
+		{
+			name:     "long id",
+			form:     url.Values{"book-id": {strings.Repeat("x", 65)}},
+			wantCode: 413,
+		},
+		{
+			name:     "no id",
+			wantCode: 400,
+		},
+		{
+			name:     "db error",
+			form:     url.Values{"book-id": {"b1"}},
+			purgeErr: fmt.Errorf("db error"),
+			wantCode: 500,
+		},
+		{
+			name:     "happy path",
+			form:     url.Values{"book-id": {"b1"}},
+			wantCode: 303,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := Server{
+				db: mockDatabase{
+					purgeBooksFunc: func(ids ...string) error {
+						if want := []string{"b1"}; !reflect.DeepEqual(want, ids) {
+							return fmt.Errorf("ids not equal: wanted %q, got %q", want, ids)
+						}
+						return test.purgeErr
+					},
+				},
+			}
+			r := httptest.NewRequest("POST", "/admin/trash/purge", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.postPurgeBooks(w, r)
+			switch {
+			case test.wantCode != w.Code:
+				t.Errorf("codes not equal: wanted %v, got %v: %v", test.wantCode, w.Code, w.Body.String())
+			case test.wantCode == 303 && w.Header().Get("Location") != "/admin/trash":
+				t.Errorf("unwanted redirect: %q", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	now := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	trash := []book.TrashedBook{
+		{Header: book.Header{ID: "new"}, DeletedDate: now.AddDate(0, 0, -1)},
+		{Header: book.Header{ID: "old"}, DeletedDate: now.AddDate(0, 0, -8)},
+	}
+	tests := []struct {
+		name          string
+		retentionDays int
+		readErr       error
+		purgeErr      error
+		wantOk        bool
+		want          int
+		wantPurged    []string
+	}{
+		{
+			name:   "kept forever",
+			wantOk: true,
+		},
+		{
+			name:          "read error",
+			retentionDays: 7,
+			readErr:       fmt.Errorf("db error"),
+		},
+		{
+			name:          "purge error",
+			retentionDays: 7,
+			purgeErr:      fmt.Errorf("db error"),
+		},
+		{
+			name:          "nothing expired",
+			retentionDays: 30,
+			wantOk:        true,
+		},
+		{
+			name:          "happy path",
+			retentionDays: 7,
+			wantOk:        true,
+			want:          1,
+			wantPurged:    []string{"old"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var purged []string
+			db := mockDatabase{
+				readTrashFunc: func() ([]book.TrashedBook, error) {
+					return trash, test.readErr
+				},
+				purgeBooksFunc: func(ids ...string) error {
+					purged = ids
+					return test.purgeErr
+				},
+			}
+			cfg := Config{
+				TrashRetentionDays: test.retentionDays,
+			}
+			ctx := context.Background()
+			got, err := cfg.purgeTrash(ctx, db, now)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.want != got:
+				t.Errorf("purged counts not equal: wanted %v, got %v", test.want, got)
+			case !reflect.DeepEqual(test.wantPurged, purged):
+				t.Errorf("purged ids not equal: wanted %q, got %q", test.wantPurged, purged)
+			}
+		})
+	}
+}
+
+func TestSetupPurgeTrash(t *testing.T) {
+	tests := []struct {
+		name    string
+		purge   func(ids ...string) error
+		wantOk  bool
+		wantOut string
+	}{
+		{
+			name: "purge error",
+			purge: func(ids ...string) error {
+				return fmt.Errorf("db error")
+			},
+		},
+		{
+			name: "happy path",
+			purge: func(ids ...string) error {
+				return nil
+			},
+			wantOk:  true,
+			wantOut: "Purged 1 books from the trash.\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := mockDatabase{
+				readTrashFunc: func() ([]book.TrashedBook, error) {
+					return []book.TrashedBook{{Header: book.Header{ID: "b1"}}}, nil
+				},
+				purgeBooksFunc: test.purge,
+			}
+			cfg := Config{
+				TrashRetentionDays: 1,
+			}
+			var ph passwordHandler
+			var pv passwordValidator
+			var sb strings.Builder
+			ctx := context.Background()
+			err := cfg.setup(ctx, db, ph, pv, &sb)
+			switch {
+			case !test.wantOk:
+				if err == nil {
+					t.Errorf("wanted error")
+				}
+			case err != nil:
+				t.Errorf("unwanted error: %v", err)
+			case test.wantOut != sb.String():
+				t.Errorf("outputs not equal: wanted %q, got %q", test.wantOut, sb.String())
+			}
+		})
+	}
+}
+
+func TestPurgeTrashPeriodically(t *testing.T) {
+	now := time.Date(2022, 11, 13, 1, 2, 3, 0, time.UTC)
+	purgeErrs := []error{nil, fmt.Errorf("db error")}
+	var sb strings.Builder
+	s := Server{
+		cfg: Config{
+			TrashRetentionDays: 1,
+		},
+		db: mockDatabase{
+			readTrashFunc: func() ([]book.TrashedBook, error) {
+				return []book.TrashedBook{{Header: book.Header{ID: "b1"}}}, nil
+			},
+			purgeBooksFunc: func(ids ...string) error {
+				err := purgeErrs[0]
+				purgeErrs = purgeErrs[1:]
+				return err
+			},
+		},
+		out: &sb,
+	}
+	ticks := make(chan time.Time, 2)
+	ticks <- now
+	ticks <- now
+	close(ticks)
+	s.purgeTrashPeriodically(ticks)
+	want := "Purged 1 books from the trash.\npurging trash: purging books: db error\n"
+	if got := sb.String(); want != got {
+		t.Errorf("outputs not equal: \n wanted: %q \n got:    %q", want, got)
+	}
+}
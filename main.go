@@ -25,6 +25,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("parsing server config: %v", err)
 	}
+	if cfg.MigrateOnly || cfg.MigrateDryRun {
+		if err := cfg.Migrate(ctx, out); err != nil {
+			log.Fatalf("migrating database: %v", err)
+		}
+		return
+	}
+	if len(cfg.TargetDatabaseURL) != 0 {
+		if err := cfg.TransferDatabase(ctx, out); err != nil {
+			log.Fatalf("transferring database: %v", err)
+		}
+		return
+	}
+	if len(cfg.ImportMARCFile) != 0 {
+		if err := cfg.ImportMARC(ctx, out); err != nil {
+			log.Fatalf("importing marc file: %v", err)
+		}
+		return
+	}
+	if cfg.MetadataImport {
+		if err := cfg.ImportMetadata(out); err != nil {
+			log.Fatalf("importing metadata dump: %v", err)
+		}
+		return
+	}
 	s, err := cfg.NewServer(ctx, out)
 	if err != nil {
 		log.Fatalf("creating server: %v", err)
@@ -47,15 +71,27 @@ func newServerConfig(out io.Writer, programName string, programArgs ...string) (
 	fs.Usage = usage(fs, programName+" runs a library web server")
 	var cfg server.Config
 	fs.StringVar(&cfg.Port, "port", "8000", "the port to run the server on, required")
-	fs.StringVar(&cfg.DatabaseURL, "database-url", "csv://", "the url of the database to use, defaults to the readonly internal library.csv file")
+	fs.StringVar(&cfg.DatabaseURL, "database-url", "csv://", "the url of the database to use, defaults to the readonly internal library.csv file, use csvfile:library.csv for a writable csv file or bolt:library.db for an embedded database")
 	fs.StringVar(&cfg.AdminPassword, "admin-password", "", "password to set for the administrator, if supplied")
 	fs.BoolVar(&cfg.BackfillCSV, "csv-backfill", false, "backfill the database from the internal library.csv file")
 	fs.BoolVar(&cfg.DumpCSV, "csv-dump", false, "dump all books from the database to the console as CSV before starting the server")
 	fs.BoolVar(&cfg.UpdateImages, "update-images", false, "processes all images in the database to webp")
+	fs.BoolVar(&cfg.BackfillISBNs, "isbn-backfill", false, "normalize the isbns of all books in the database and fill in missing isbn-13s and isbn-10s, reporting invalid isbns")
 	fs.IntVar(&cfg.MaxRows, "max-rows", 100, "the maximum number of books to display as rows on the filter page")
 	fs.IntVar(&cfg.DBTimeoutSec, "db-timeout-sec", 5, "the number of seconds each database operation can take")
+	fs.IntVar(&cfg.ExportTimeoutSec, "export-timeout-sec", 600, "the number of seconds exporting books can take, which reads all of the books")
 	fs.IntVar(&cfg.PostLimitSec, "post-rate-sec", 5, "the limit on number of seconds that must pas between posts")
 	fs.IntVar(&cfg.PostMaxBurst, "post-max-burst", 2, "the maximum number of posts that can take place in a post-rate-sec period")
+	fs.BoolVar(&cfg.MigrateOnly, "migrate-only", false, "apply pending database migrations and exit without starting the server")
+	fs.BoolVar(&cfg.MigrateDryRun, "migrate-dry-run", false, "print pending database migrations and exit without applying them")
+	fs.StringVar(&cfg.TargetDatabaseURL, "target-database-url", "", "copy all data from the database to the target database url and exit without starting the server, rerun to resume")
+	fs.BoolVar(&cfg.ImportUpsert, "import-upsert", false, "replace books that have the same ids when backfilling, transferring, or importing books instead of failing or skipping them")
+	fs.StringVar(&cfg.MetadataDumpFile, "metadata-dump", "", "an Open Library editions and authors dump file (optionally .gz) to look up isbns in to fill in new books on the admin page")
+	fs.StringVar(&cfg.MetadataIndexFile, "metadata-index", "", "the file the metadata dump is imported into, defaults to the metadata-dump file with a .db extension added")
+	fs.BoolVar(&cfg.MetadataImport, "metadata-import", false, "import the metadata-dump file into the metadata-index file and exit without starting the server")
+	fs.StringVar(&cfg.BaseURL, "base-url", "", "the absolute url the library is served at, such as https://library.example.com, for qr codes that link to books, defaults to the host of each request")
+	fs.IntVar(&cfg.TrashRetentionDays, "trash-retention-days", 30, "the number of days deleted books stay in the trash before they are purged, 0 keeps deleted books until they are purged on the trash page")
+	fs.StringVar(&cfg.ImportMARCFile, "import-marc", "", "import the books in the MARC 21 file (.mrc) or MARCXML file (.xml) and exit without starting the server")
 	if err := ParseFlags(fs, programArgs); err != nil {
 		return nil, err
 	}
@@ -37,12 +37,14 @@ func TestServerConfig(t *testing.T) {
 			name:   "default args",
 			wantOk: true,
 			want: &server.Config{
-				Port:         "8000",
-				DatabaseURL:  "csv://",
-				MaxRows:      100,
-				DBTimeoutSec: 5,
-				PostLimitSec: 5,
-				PostMaxBurst: 2,
+				Port:               "8000",
+				DatabaseURL:        "csv://",
+				MaxRows:            100,
+				DBTimeoutSec:       5,
+				ExportTimeoutSec:   600,
+				PostLimitSec:       5,
+				PostMaxBurst:       2,
+				TrashRetentionDays: 30,
 			},
 		},
 		{
@@ -57,20 +59,44 @@ func TestServerConfig(t *testing.T) {
 				"-update-images=true",
 				"-max-rows=30",
 				"-db-timeout-sec=4",
+				"-export-timeout-sec=60",
 				"-post-rate-sec=6",
 				"-post-max-burst=3",
+				"-migrate-only=true",
+				"-migrate-dry-run=true",
+				"-target-database-url=file:library.db",
+				"-import-upsert=true",
+				"-import-marc=library.mrc",
+				"-isbn-backfill=true",
+				"-metadata-dump=editions.txt",
+				"-metadata-index=editions.db",
+				"-metadata-import=true",
+				"-base-url=https://library.example.com",
+				"-trash-retention-days=7",
 			},
 			want: &server.Config{
-				Port:          "8001",
-				DatabaseURL:   "postgres://u:p@localhost/kuuf_library_db1",
-				AdminPassword: "new-password1",
-				BackfillCSV:   true,
-				DumpCSV:       true,
-				UpdateImages:  true,
-				MaxRows:       30,
-				DBTimeoutSec:  4,
-				PostLimitSec:  6,
-				PostMaxBurst:  3,
+				Port:               "8001",
+				DatabaseURL:        "postgres://u:p@localhost/kuuf_library_db1",
+				AdminPassword:      "new-password1",
+				BackfillCSV:        true,
+				DumpCSV:            true,
+				UpdateImages:       true,
+				MaxRows:            30,
+				DBTimeoutSec:       4,
+				ExportTimeoutSec:   60,
+				PostLimitSec:       6,
+				PostMaxBurst:       3,
+				MigrateOnly:        true,
+				MigrateDryRun:      true,
+				TargetDatabaseURL:  "file:library.db",
+				ImportUpsert:       true,
+				ImportMARCFile:     "library.mrc",
+				BackfillISBNs:      true,
+				MetadataDumpFile:   "editions.txt",
+				MetadataIndexFile:  "editions.db",
+				MetadataImport:     true,
+				BaseURL:            "https://library.example.com",
+				TrashRetentionDays: 7,
 			},
 		},
 		{
@@ -85,20 +111,43 @@ func TestServerConfig(t *testing.T) {
 				{"UPDATE_IMAGES", "true"},
 				{"MAX_ROWS", "55"},
 				{"DB_TIMEOUT_SEC", "3"},
+				{"EXPORT_TIMEOUT_SEC", "120"},
 				{"POST_RATE_SEC", "7"},
 				{"POST_MAX_BURST", "4"},
+				{"MIGRATE_ONLY", "true"},
+				{"MIGRATE_DRY_RUN", "true"},
+				{"TARGET_DATABASE_URL", "bolt:library.db"},
+				{"IMPORT_UPSERT", "true"},
+				{"IMPORT_MARC", "library.marc.xml"},
+				{"ISBN_BACKFILL", "true"},
+				{"METADATA_DUMP", "editions.txt.gz"},
+				{"METADATA_INDEX", "/var/lib/kuuf/editions.db"},
+				{"METADATA_IMPORT", "true"},
+				{"BASE_URL", "http://localhost:8002/"},
+				{"TRASH_RETENTION_DAYS", "0"},
 			},
 			want: &server.Config{
-				Port:          "8002",
-				DatabaseURL:   "postgres://u:p@localhost/kuuf_library_db2",
-				AdminPassword: "new-password2",
-				BackfillCSV:   true,
-				DumpCSV:       true,
-				UpdateImages:  true,
-				MaxRows:       55,
-				DBTimeoutSec:  3,
-				PostLimitSec:  7,
-				PostMaxBurst:  4,
+				Port:              "8002",
+				DatabaseURL:       "postgres://u:p@localhost/kuuf_library_db2",
+				AdminPassword:     "new-password2",
+				BackfillCSV:       true,
+				DumpCSV:           true,
+				UpdateImages:      true,
+				MaxRows:           55,
+				DBTimeoutSec:      3,
+				ExportTimeoutSec:  120,
+				PostLimitSec:      7,
+				PostMaxBurst:      4,
+				MigrateOnly:       true,
+				MigrateDryRun:     true,
+				TargetDatabaseURL: "bolt:library.db",
+				ImportUpsert:      true,
+				ImportMARCFile:    "library.marc.xml",
+				BackfillISBNs:     true,
+				MetadataDumpFile:  "editions.txt.gz",
+				MetadataIndexFile: "/var/lib/kuuf/editions.db",
+				MetadataImport:    true,
+				BaseURL:           "http://localhost:8002/",
 			},
 		},
 	}